@@ -0,0 +1,38 @@
+// Command lambda serves the analyze endpoint on AWS Lambda as a custom
+// runtime. Build it as "bootstrap" for the provided.al2023 runtime:
+//
+//	GOOS=linux GOARCH=arm64 CGO_ENABLED=0 go build -o bootstrap ./cmd/lambda
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/serverless"
+)
+
+// version is reported in the API description. Release builds set it with
+// -ldflags "-X main.version=...".
+var version = "1.0.0"
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+	logger := serverless.NewLogger(cfg)
+
+	// Lambda sends SIGTERM before shutting an instance down
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+
+	if err := serverless.StartLambda(ctx, serverless.NewHandler(cfg, version, logger), logger); err != nil {
+		logger.Error("Lambda runtime failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/notify"
+	"github.com/anjula-paulus/web-analyzer/internal/render"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+const analyzeUsage = `usage: web-analyzer analyze [-format json|table|markdown|xml|junit] [-sections s,...] [-extract selector]... [-device desktop|mobile|tablet] [-fail-on-broken] [-fail-on error|warning] url
+       web-analyzer analyze -watch [-interval d] [-format json|table] [-sections s,...] url
+       web-analyzer analyze -site [-format json|table] [-sections s,...] [-fail-on-broken] [-fail-on error|warning] domain
+
+Analyzes a single URL without starting the server and prints the result.
+Exits with 0 on success, 1 when the analysis fails, 2 on usage errors,
+with -fail-on-broken 3 when the page has inaccessible links and, with
+-fail-on, 5 when a finding is of that severity or worse.
+
+With -watch, the URL is analyzed again every interval and the differences
+between runs are printed. Watching ends with 4 as soon as a run finds new
+broken links or a missing title, and with 0 on Ctrl-C.
+
+With -site, the argument is a domain. Its https, https www., http and
+http www. variants are probed, and the report lists which respond, where
+they redirect and the origin they agree on before the analysis of the page
+the first responding variant leads to.
+
+Each -extract adds a CSS selector whose match count and text are reported
+in the result's extractions. -device fetches the page with a desktop,
+mobile or tablet browser's User-Agent.`
+
+// formatTable selects the human-readable table output of the analyze
+// subcommand
+const formatTable = "table"
+
+// Exit codes of the analyze subcommand besides 0, 1 and 2
+const (
+	exitBrokenLinks   = 3
+	exitRegression    = 4
+	exitFailedVerdict = 5
+)
+
+// runAnalyze runs the analyze subcommand and returns the exit code
+func runAnalyze(cfg *config.Config, args []string, logger *slog.Logger) int {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, analyzeUsage) }
+	format := fs.String("format", render.FormatJSON, "output format: json, table, markdown, xml or junit")
+	sectionList := fs.String("sections", "", "comma-separated optional sections: seo, security, accessibility, performance, web_vitals, outline, third_party, phishing")
+	failOnBroken := fs.Bool("fail-on-broken", false, "exit with 3 when the page has inaccessible links")
+	failOnFlag := fs.String("fail-on", "", "exit with 5 when a finding is of this severity or worse: error or warning")
+	watch := fs.Bool("watch", false, "analyze the URL repeatedly and print the differences")
+	interval := fs.Duration("interval", 5*time.Minute, "time between runs with -watch")
+	site := fs.Bool("site", false, "probe the http/https and www variants of a domain and analyze the canonical page")
+	deviceFlag := fs.String("device", "", "analyze the page as a device preset: desktop, mobile or tablet")
+	var selectors []string
+	fs.Func("extract", "report the matches of a CSS selector; may be repeated", func(selector string) error {
+		if err := analyzer.ValidateSelector(selector); err != nil {
+			return err
+		}
+		selectors = append(selectors, selector)
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 || *interval <= 0 {
+		fs.Usage()
+		return 2
+	}
+	if *watch && *format != render.FormatJSON && *format != formatTable {
+		fmt.Fprintln(os.Stderr, "-watch supports the json and table formats only")
+		return 2
+	}
+	if *site && (*watch || *format != render.FormatJSON && *format != formatTable) {
+		fmt.Fprintln(os.Stderr, "-site supports the json and table formats only and cannot be combined with -watch")
+		return 2
+	}
+	if (len(selectors) > 0 || *deviceFlag != "") && (*watch || *site) {
+		fmt.Fprintln(os.Stderr, "-extract and -device cannot be combined with -watch or -site")
+		return 2
+	}
+
+	sections, err := parseSections(*sectionList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	failOn, err := parseFailOn(*failOnFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	var device analyzer.Device
+	if *deviceFlag != "" {
+		if device, err = analyzer.ParseDevice(*deviceFlag); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	var renderer render.Renderer
+	if *format != formatTable {
+		if renderer, err = render.ForFormat(*format); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	// Stop checking links on Ctrl-C and print what was found so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	service := analyzer.NewWithOptions(analyzer.WithConfig(cfg.Analyzer), analyzer.WithLogger(logger))
+	if *watch {
+		notifier, err := notify.New(cfg.Notify, logger)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		code := watchURL(ctx, service, fs.Arg(0), sections, *interval, *format, notifier, logger)
+		if notifier != nil {
+			// Let the last notification, e.g. for the regression that ended
+			// the watch, go out before exiting
+			notifier.Shutdown(context.Background())
+		}
+		return code
+	}
+
+	if *site {
+		return analyzeSite(ctx, service, fs.Arg(0), sections, renderer, *failOnBroken, failOn, logger)
+	}
+
+	result, err := service.AnalyzeURL(ctx, fs.Arg(0), analyzer.IncludeSections(sections...), analyzer.Extract(selectors...), analyzer.AsDevice(device))
+	if err != nil {
+		logger.Error("Analysis failed", "url", fs.Arg(0), "error", err)
+		return 1
+	}
+	if failOn != "" {
+		result.Verdict = analyzer.Judge(result, failOn)
+	}
+
+	if renderer != nil {
+		err = renderer.Render(os.Stdout, result)
+	} else {
+		err = writeTable(os.Stdout, result)
+	}
+	if err != nil {
+		logger.Error("Failed to write result", "error", err)
+		return 1
+	}
+
+	return gateExitCode(result, *failOnBroken)
+}
+
+// gateExitCode returns the exit code for a successful analysis: 3 for
+// inaccessible links with -fail-on-broken, 5 for a failed verdict and
+// otherwise 0
+func gateExitCode(result *analyzer.Result, failOnBroken bool) int {
+	switch {
+	case failOnBroken && result.InaccessibleLinks > 0:
+		return exitBrokenLinks
+	case result.Verdict != nil && !result.Verdict.Passed:
+		return exitFailedVerdict
+	}
+	return 0
+}
+
+// analyzeSite runs analyze -site and returns the exit code. A report whose
+// analysis failed, or that found no responding variant, is printed and
+// exits with 1.
+func analyzeSite(ctx context.Context, service *analyzer.Analyzer, domain string, sections []analyzer.Section, renderer render.Renderer, failOnBroken bool, failOn analyzer.Severity, logger *slog.Logger) int {
+	report, err := service.AnalyzeSite(ctx, domain, analyzer.IncludeSections(sections...))
+	if err != nil {
+		logger.Error("Site analysis failed", "domain", domain, "error", err)
+		return 1
+	}
+	if report.Result != nil && failOn != "" {
+		report.Result.Verdict = analyzer.Judge(report.Result, failOn)
+	}
+
+	if renderer != nil {
+		err = json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		err = writeSiteTable(os.Stdout, report)
+	}
+	if err != nil {
+		logger.Error("Failed to write result", "error", err)
+		return 1
+	}
+
+	result := report.Result
+	switch {
+	case result == nil:
+		logger.Error("No variant of the domain responds", "domain", report.Domain)
+		return 1
+	case result.Error != "":
+		logger.Error("Analysis failed", "url", result.URL, "error", result.Error)
+		return 1
+	}
+	return gateExitCode(result, failOnBroken)
+}
+
+// writeSiteTable writes a row per variant, the canonical origin and then
+// the result's table
+func writeSiteTable(w io.Writer, report *analyzer.SiteReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, variant := range report.Variants {
+		outcome := "no response"
+		if variant.Responds {
+			outcome = strconv.Itoa(variant.Status)
+			if len(variant.Redirects) > 0 {
+				outcome += fmt.Sprintf(" -> %s (%d)", variant.FinalURL, variant.FinalStatus)
+			}
+		}
+		if variant.Error != "" {
+			outcome += ": " + variant.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", variant.URL, outcome)
+	}
+	fmt.Fprintf(tw, "Canonical origin\t%s\n", cmp.Or(report.CanonicalOrigin, "none"))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if report.Result == nil || report.Result.Error != "" {
+		return nil
+	}
+	fmt.Fprintln(w)
+	return writeTable(w, report.Result)
+}
+
+// parseSections parses a comma-separated list of section names
+func parseSections(list string) ([]analyzer.Section, error) {
+	var sections []analyzer.Section
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		section := analyzer.Section(name)
+		if !slices.Contains(analyzer.Sections, section) {
+			return nil, fmt.Errorf("unknown section %q", name)
+		}
+		sections = append(sections, section)
+	}
+	return sections, nil
+}
+
+// parseFailOn parses the -fail-on severity, which is empty when no verdict
+// is wanted
+func parseFailOn(value string) (analyzer.Severity, error) {
+	switch severity := analyzer.Severity(value); severity {
+	case "", analyzer.SeverityError, analyzer.SeverityWarning:
+		return severity, nil
+	}
+	return "", fmt.Errorf("-fail-on must be error or warning, got %q", value)
+}
+
+// writeTable writes the result as aligned name/value rows
+func writeTable(w io.Writer, result *analyzer.Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "URL\t%s\n", result.URL)
+	fmt.Fprintf(tw, "Title\t%s\n", result.Title)
+	if result.TitleCount > 1 {
+		fmt.Fprintf(tw, "Title elements\t%d\n", result.TitleCount)
+	}
+	fmt.Fprintf(tw, "HTML version\t%s\n", result.HTMLVersion)
+	if result.Device != "" {
+		fmt.Fprintf(tw, "Device\t%s\n", result.Device)
+	}
+
+	levels := make([]string, 0, len(result.Headings))
+	for level := range result.Headings {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+	for _, level := range levels {
+		fmt.Fprintf(tw, "Headings %s\t%d\n", level, result.Headings[level])
+	}
+
+	fmt.Fprintf(tw, "Internal links\t%d\n", result.InternalLinks)
+	fmt.Fprintf(tw, "External links\t%d\n", result.ExternalLinks)
+	schemes := make([]string, 0, len(result.LinkSchemes))
+	for scheme := range result.LinkSchemes {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	for _, scheme := range schemes {
+		fmt.Fprintf(tw, "Links %s\t%d\n", scheme, result.LinkSchemes[scheme])
+	}
+	if depth := result.LinkDepth; depth != nil {
+		fmt.Fprintf(tw, "Link depth\tmax %d, mean %.2f, %d deep\n", depth.Max, depth.Mean, depth.Deep)
+	}
+	fmt.Fprintf(tw, "Inaccessible links\t%d\n", result.InaccessibleLinks)
+	for _, download := range result.Downloads {
+		fmt.Fprintf(tw, "Download\t%s %s\n", download.Kind, download.URL)
+	}
+	fmt.Fprintf(tw, "Login form\t%t\n", result.HasLoginForm)
+	if result.Partial {
+		fmt.Fprintf(tw, "Partial\t%t\n", result.Partial)
+	}
+	if result.ScriptDependency != nil {
+		fmt.Fprintf(tw, "Needs JavaScript\t%s\n", result.ScriptDependency.Advice)
+	}
+	if parking := result.Parking; parking != nil {
+		fmt.Fprintf(tw, "Placeholder\t%s (%s)\n", parking.Kind, strings.Join(parking.Signals, ", "))
+	}
+	if idn := result.IDN; idn != nil {
+		if idn.Host != nil {
+			fmt.Fprintf(tw, "IDN host\t%s\n", idnHostLine(*idn.Host))
+		}
+		for _, host := range idn.Links {
+			fmt.Fprintf(tw, "IDN link\t%s\n", idnHostLine(host))
+		}
+	}
+	if refresh := result.MetaRefresh; refresh != nil {
+		fmt.Fprintf(tw, "Meta refresh\t%s after %ds\n", refresh.URL, refresh.DelaySeconds)
+		if len(refresh.Followed) > 0 {
+			fmt.Fprintf(tw, "Analyzed page\t%s\n", refresh.Followed[len(refresh.Followed)-1])
+		}
+	}
+	if frames := result.Frames; frames != nil {
+		for _, src := range frames.Sources {
+			fmt.Fprintf(tw, "Frame\t%s\n", src)
+		}
+		for _, frame := range frames.Documents {
+			if frame.Error != "" {
+				fmt.Fprintf(tw, "Frame analysis\t%s: %s\n", frame.URL, frame.Error)
+				continue
+			}
+			fmt.Fprintf(tw, "Frame analysis\t%s: %q, %d inaccessible links\n", frame.URL, frame.Title, frame.InaccessibleLinks)
+		}
+	}
+
+	if seo := result.SEO; seo != nil {
+		fmt.Fprintf(tw, "Language\t%s\n", seo.Lang)
+		fmt.Fprintf(tw, "Meta description\t%s\n", seo.MetaDescription)
+		fmt.Fprintf(tw, "Canonical\t%s\n", seo.Canonical)
+		if crumbs := seo.Breadcrumbs; crumbs != nil {
+			names := make([]string, len(crumbs.Items))
+			for i, item := range crumbs.Items {
+				names[i] = item.Name
+			}
+			fmt.Fprintf(tw, "Breadcrumbs\t%s (%s)\n", strings.Join(names, " > "), crumbs.Source)
+		}
+		if languages := seo.Languages; languages != nil {
+			if len(languages.ContentLanguage) > 0 {
+				fmt.Fprintf(tw, "Content-Language\t%s\n", strings.Join(languages.ContentLanguage, ", "))
+			}
+			if languages.Hreflang != "" {
+				fmt.Fprintf(tw, "Hreflang\t%s\n", languages.Hreflang)
+			}
+			if languages.Detected != "" {
+				fmt.Fprintf(tw, "Detected language\t%s\n", languages.Detected)
+			}
+			fmt.Fprintf(tw, "Language mismatch\t%t\n", languages.Mismatch)
+		}
+	}
+	if security := result.Security; security != nil {
+		fmt.Fprintf(tw, "HTTPS\t%t\n", security.HTTPS)
+		fmt.Fprintf(tw, "Missing headers\t%s\n", strings.Join(security.MissingHeaders, ", "))
+		if policy := security.ReferrerPolicy; policy != nil {
+			fmt.Fprintf(tw, "Referrer policy\t%s\n", policy.Policy)
+			for _, issue := range policy.Issues {
+				fmt.Fprintf(tw, "Referrer policy issue\t%s\n", issue)
+			}
+		}
+		if policy := security.PermissionsPolicy; policy != nil {
+			fmt.Fprintf(tw, "Permissions policy\t%d features\n", len(policy.Features))
+			for _, issue := range policy.Issues {
+				fmt.Fprintf(tw, "Permissions policy issue\t%s\n", issue)
+			}
+		}
+		for _, candidate := range security.OpenRedirectCandidates {
+			fmt.Fprintf(tw, "Open redirect candidate\t%s (%s)\n", candidate.URL, candidate.Parameter)
+		}
+	}
+	if a11y := result.Accessibility; a11y != nil {
+		fmt.Fprintf(tw, "Images\t%d\n", a11y.Images)
+		fmt.Fprintf(tw, "Images missing alt\t%d\n", a11y.ImagesMissingAlt)
+		for _, table := range a11y.Tables {
+			kind := "data"
+			if table.Layout {
+				kind = "layout"
+			}
+			fmt.Fprintf(tw, "Table %d\t%s, %d rows, %d header cells (%d scoped), caption %t\n",
+				table.Position, kind, table.Rows, table.HeaderCells, table.ScopedHeaders, table.Caption)
+		}
+	}
+	if perf := result.Performance; perf != nil {
+		fmt.Fprintf(tw, "Response time\t%d ms\n", perf.ResponseMS)
+		fmt.Fprintf(tw, "Page size\t%d bytes\n", perf.PageBytes)
+		fmt.Fprintf(tw, "Link check time\t%d ms\n", perf.LinkCheckMS)
+		fmt.Fprintf(tw, "Inline SVGs\t%d\n", perf.InlineSVGs)
+		if len(perf.IconFonts) > 0 {
+			fmt.Fprintf(tw, "Icon fonts\t%s (%d icons)\n", strings.Join(perf.IconFonts, ", "), perf.IconElements)
+		}
+		if caching := perf.Caching; caching != nil {
+			fmt.Fprintf(tw, "Cacheability\t%s\n", caching.Cacheability)
+			if caching.CacheControl != "" {
+				fmt.Fprintf(tw, "Cache-Control\t%s\n", caching.CacheControl)
+			}
+			for _, issue := range caching.Issues {
+				fmt.Fprintf(tw, "Caching issue\t%s\n", issue)
+			}
+		}
+		if cdn := perf.CDN; cdn != nil {
+			line := cdn.Provider
+			if cdn.Cache != "" {
+				line += " (" + cdn.Cache + ")"
+			}
+			fmt.Fprintf(tw, "CDN\t%s\n", line)
+		}
+	}
+	if vitals := result.WebVitals; vitals != nil {
+		if vitals.Error != "" {
+			fmt.Fprintf(tw, "Web vitals\t%s\n", vitals.Error)
+		} else {
+			if vitals.PerformanceScore != nil {
+				fmt.Fprintf(tw, "Performance score\t%d (%s)\n", *vitals.PerformanceScore, vitals.Strategy)
+			}
+			fmt.Fprintf(tw, "Largest contentful paint\t%.0f ms\n", vitals.LCPMS)
+			fmt.Fprintf(tw, "Total blocking time\t%.0f ms\n", vitals.TBTMS)
+			fmt.Fprintf(tw, "Cumulative layout shift\t%.3f\n", vitals.CLS)
+			if field := vitals.Field; field != nil {
+				fmt.Fprintf(tw, "Field data\t%s (LCP %d ms, INP %d ms, CLS %.2f)\n", field.Category, field.LCPMS, field.INPMS, field.CLS)
+			}
+		}
+	}
+	if outline := result.Outline; outline != nil {
+		for _, heading := range outline.Headings {
+			fmt.Fprintf(tw, "Outline\t%sh%d %s\n", strings.Repeat("  ", heading.Level-1), heading.Level, heading.Text)
+		}
+		if outline.Truncated {
+			fmt.Fprintf(tw, "Outline\t...\n")
+		}
+	}
+	if thirdParty := result.ThirdParty; thirdParty != nil {
+		for _, origin := range thirdParty.Origins {
+			fmt.Fprintf(tw, "Third party\t%s (%d resources)\n", origin.Origin, origin.Resources)
+		}
+	}
+	if phishing := result.Phishing; phishing != nil {
+		fmt.Fprintf(tw, "Phishing risk\t%s (%d)\n", phishing.Risk, phishing.Score)
+		for _, signal := range phishing.Signals {
+			fmt.Fprintf(tw, "\t  %s\n", signal.Detail)
+		}
+	}
+	for _, extraction := range result.Extractions {
+		fmt.Fprintf(tw, "Extract %s\t%d matched\n", extraction.Selector, extraction.Count)
+		for _, match := range extraction.Matches {
+			fmt.Fprintf(tw, "\t  %s\n", match)
+		}
+		if extraction.Truncated {
+			fmt.Fprintf(tw, "\t  ...\n")
+		}
+	}
+	for _, finding := range result.Findings {
+		line := fmt.Sprintf("%s %s: %s", finding.Severity, finding.ID, finding.Message)
+		if len(finding.Evidence) > 0 {
+			line += " (" + strings.Join(finding.Evidence, ", ") + ")"
+		}
+		fmt.Fprintf(tw, "Finding\t%s\n", line)
+	}
+	if verdict := result.Verdict; verdict != nil {
+		outcome := "passed"
+		if !verdict.Passed {
+			outcome = fmt.Sprintf("failed, %d findings at %s or worse", verdict.Failures, verdict.FailOn)
+		}
+		fmt.Fprintf(tw, "Verdict\t%s\n", outcome)
+	}
+
+	return tw.Flush()
+}
+
+// idnHostLine formats an internationalized host for the table
+func idnHostLine(host analyzer.IDNHost) string {
+	line := fmt.Sprintf("%s (%s)", host.Unicode, host.ASCII)
+	if host.Homograph {
+		line += " mixes " + strings.Join(host.Scripts, " and ")
+	}
+	return line
+}
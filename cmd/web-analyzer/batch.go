@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+const batchUsage = `usage: web-analyzer batch [-format ndjson|csv] [-o file] [-sections s,...] [-concurrency n] [-progress] [-fail-on-broken] [-fail-on error|warning] [file]
+
+Analyzes the URLs listed in file, one per line, or on standard input when no
+file or "-" is given. Blank lines and lines starting with "#" are skipped.
+Results are written as they finish, to standard output unless -o is given.
+Exits with 0 when every analysis succeeds, 1 when any fails, 2 on usage
+errors, with -fail-on-broken 3 when a page has inaccessible links and,
+with -fail-on, 5 when a page has a finding of that severity or worse.`
+
+// Batch output formats
+const (
+	formatNDJSON = "ndjson"
+	formatCSV    = "csv"
+)
+
+// csvHeader names the columns written by csvResultWriter
+var csvHeader = []string{
+	"url", "error", "html_version", "title",
+	"h1", "h2", "h3", "h4", "h5", "h6",
+	"internal_links", "external_links", "inaccessible_links", "has_login_form", "partial",
+}
+
+// resultWriter writes batch results one at a time
+type resultWriter interface {
+	Write(result *analyzer.Result) error
+	Flush() error
+}
+
+// runBatch runs the batch subcommand and returns the exit code
+func runBatch(cfg *config.Config, args []string, logger *slog.Logger) int {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, batchUsage) }
+	format := fs.String("format", formatNDJSON, "output format: ndjson or csv")
+	output := fs.String("o", "", "output file (default standard output)")
+	sectionList := fs.String("sections", "", "comma-separated optional sections: seo, security, accessibility, performance, web_vitals, outline, third_party, phishing")
+	concurrency := fs.Int("concurrency", cfg.Analyzer.BatchConcurrency, "pages analyzed at once")
+	progress := fs.Bool("progress", isTerminal(os.Stderr), "draw a progress bar on standard error")
+	failOnBroken := fs.Bool("fail-on-broken", false, "exit with 3 when a page has inaccessible links")
+	failOnFlag := fs.String("fail-on", "", "exit with 5 when a page has a finding of this severity or worse: error or warning")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 || *concurrency < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	sections, err := parseSections(*sectionList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	failOn, err := parseFailOn(*failOnFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *format != formatNDJSON && *format != formatCSV {
+		fmt.Fprintf(os.Stderr, "unsupported format %q\n", *format)
+		return 2
+	}
+
+	var in io.Reader = os.Stdin
+	if file := fs.Arg(0); file != "" && file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			logger.Error("Failed to open URL list", "file", file, "error", err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	urls, err := readURLs(in)
+	if err != nil {
+		logger.Error("Failed to read URL list", "error", err)
+		return 1
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			logger.Error("Failed to create output file", "file", *output, "error", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	var results resultWriter = &ndjsonResultWriter{w: w, enc: json.NewEncoder(w)}
+	if *format == formatCSV {
+		results = newCSVResultWriter(w)
+	}
+
+	// Stop on Ctrl-C; unfinished pages are written with the cancellation
+	// as their error
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var bar *progressBar
+	if *progress {
+		bar = &progressBar{w: os.Stderr, total: len(urls)}
+		bar.draw()
+	}
+
+	limits := cfg.Analyzer
+	limits.BatchConcurrency = *concurrency
+	service := analyzer.NewWithOptions(analyzer.WithConfig(limits), analyzer.WithLogger(logger))
+
+	var failed, broken, rejected int
+	var writeErr error
+	service.AnalyzeMany(ctx, urls, analyzer.IncludeSections(sections...), analyzer.OnResult(func(_ int, result *analyzer.Result) {
+		if result.Error != "" {
+			failed++
+		} else if result.InaccessibleLinks > 0 {
+			broken++
+		}
+		if failOn != "" {
+			result.Verdict = analyzer.Judge(result, failOn)
+			if result.Error == "" && !result.Verdict.Passed {
+				rejected++
+			}
+		}
+		if writeErr == nil {
+			writeErr = results.Write(result)
+		}
+		if bar != nil {
+			bar.add(result.Error != "")
+		}
+	}))
+	if bar != nil {
+		bar.finish()
+	}
+
+	if writeErr == nil {
+		writeErr = results.Flush()
+	}
+	if writeErr != nil {
+		logger.Error("Failed to write results", "error", writeErr)
+		return 1
+	}
+
+	logger.Info("Batch completed", "urls", len(urls), "failed", failed, "with_broken_links", broken, "failed_verdicts", rejected)
+
+	switch {
+	case failed > 0:
+		return 1
+	case *failOnBroken && broken > 0:
+		return exitBrokenLinks
+	case rejected > 0:
+		return exitFailedVerdict
+	}
+	return 0
+}
+
+// readURLs reads one URL per line, skipping blank lines and comments
+func readURLs(r io.Reader) ([]string, error) {
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	return urls, scanner.Err()
+}
+
+// ndjsonResultWriter writes one JSON result per line
+type ndjsonResultWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (n *ndjsonResultWriter) Write(result *analyzer.Result) error {
+	return n.enc.Encode(result)
+}
+
+func (n *ndjsonResultWriter) Flush() error {
+	return n.w.Flush()
+}
+
+// csvResultWriter writes one row per result with the core fields; the
+// optional sections are only available as NDJSON
+type csvResultWriter struct {
+	w      *bufio.Writer
+	csv    *csv.Writer
+	header bool
+}
+
+func newCSVResultWriter(w *bufio.Writer) *csvResultWriter {
+	return &csvResultWriter{w: w, csv: csv.NewWriter(w)}
+}
+
+func (c *csvResultWriter) Write(result *analyzer.Result) error {
+	if !c.header {
+		c.header = true
+		if err := c.csv.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	row := []string{result.URL, result.Error, result.HTMLVersion, result.Title}
+	for level := 1; level <= 6; level++ {
+		row = append(row, strconv.Itoa(result.Headings["h"+strconv.Itoa(level)]))
+	}
+	row = append(row,
+		strconv.Itoa(result.InternalLinks),
+		strconv.Itoa(result.ExternalLinks),
+		strconv.Itoa(result.InaccessibleLinks),
+		strconv.FormatBool(result.HasLoginForm),
+		strconv.FormatBool(result.Partial),
+	)
+	return c.csv.Write(row)
+}
+
+func (c *csvResultWriter) Flush() error {
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+// progressBarWidth is the number of cells in the progress bar
+const progressBarWidth = 30
+
+// progressBar redraws a single status line as analyses finish
+type progressBar struct {
+	w      io.Writer
+	total  int
+	done   int
+	failed int
+}
+
+// add records a finished analysis and redraws the bar
+func (p *progressBar) add(failed bool) {
+	p.done++
+	if failed {
+		p.failed++
+	}
+	p.draw()
+}
+
+func (p *progressBar) draw() {
+	filled := progressBarWidth
+	if p.total > 0 {
+		filled = p.done * progressBarWidth / p.total
+	}
+	fmt.Fprintf(p.w, "\r[%s%s] %d/%d, %d failed",
+		strings.Repeat("#", filled), strings.Repeat(" ", progressBarWidth-filled),
+		p.done, p.total, p.failed)
+}
+
+// finish ends the bar's line so later output starts on a new one
+func (p *progressBar) finish() {
+	fmt.Fprintln(p.w)
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// version is reported by --version and the API description. Release builds
+// set it with -ldflags "-X main.version=...".
+var version = "1.0.0"
+
+const usageHeader = `usage: web-analyzer [flags]
+       web-analyzer [flags] history export|import ...
+       web-analyzer [flags] analyze [-format f] url
+       web-analyzer [flags] batch [-format ndjson|csv] [file]
+
+Flags take precedence over environment variables, which take precedence over
+the configuration file.
+
+Flags:`
+
+// flags holds the command-line flags that were set. Only flags given on the
+// command line override the configuration.
+type flags struct {
+	configPath string
+	port       string
+	logLevel   string
+	maxWorkers int
+	version    bool
+
+	set  map[string]bool
+	args []string
+}
+
+// parseFlags parses the global flags in args, which excludes the program
+// name. Arguments after the flags, such as a subcommand, are kept in args.
+func parseFlags(args []string, output io.Writer) (*flags, error) {
+	f := &flags{set: make(map[string]bool)}
+
+	fs := flag.NewFlagSet("web-analyzer", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprintln(output, usageHeader)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&f.configPath, "config", "", "configuration file (overrides CONFIG_PATH)")
+	fs.StringVar(&f.port, "port", "", `listen address, e.g. ":8080" or "unix:///run/web-analyzer.sock"`)
+	fs.StringVar(&f.logLevel, "log-level", "", "log level: debug, info, warn or error")
+	fs.IntVar(&f.maxWorkers, "max-workers", 0, "concurrent link checkers per analysis")
+	fs.BoolVar(&f.version, "version", false, "print the version and exit")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	fs.Visit(func(fl *flag.Flag) { f.set[fl.Name] = true })
+	f.args = fs.Args()
+
+	if f.set["config"] {
+		if _, err := os.Stat(f.configPath); err != nil {
+			return nil, fmt.Errorf("configuration file: %w", err)
+		}
+		// Reloads read the same file
+		os.Setenv("CONFIG_PATH", f.configPath)
+	}
+
+	return f, nil
+}
+
+// apply overrides cfg with the flags that were set
+func (f *flags) apply(cfg *config.Config) {
+	if f.set["port"] {
+		cfg.Port = f.port
+	}
+	if f.set["log-level"] {
+		cfg.LogLevel = f.logLevel
+	}
+	if f.set["max-workers"] {
+		cfg.Analyzer.MaxWorkers = f.maxWorkers
+	}
+}
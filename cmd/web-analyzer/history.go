@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/internal/tenant"
+)
+
+const historyUsage = `usage: web-analyzer history export [-format ndjson|json] [-driver d] [-path p] [-tenant t] [file]
+       web-analyzer history import [-driver d] [-path p] [-tenant t] [file]
+
+Exports the stored history to file, or imports a dump from it, using the
+configured store unless -driver and -path select another one. -tenant selects
+a tenant's history instead of the default namespace. Without a file,
+standard output or input is used.`
+
+// runHistory runs the history subcommand and returns the exit code
+func runHistory(cfg *config.Config, args []string, logger *slog.Logger) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, historyUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("history "+args[0], flag.ContinueOnError)
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, historyUsage) }
+	format := fs.String("format", storage.DumpNDJSON, "export format, ndjson or json")
+	storageCfg := cfg.Storage
+	fs.StringVar(&storageCfg.Driver, "driver", storageCfg.Driver, "storage driver")
+	fs.StringVar(&storageCfg.Path, "path", storageCfg.Path, "storage path")
+	tenantName := fs.String("tenant", "", "tenant whose history to use")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	var tenants []string
+	if *tenantName != "" {
+		tenants = []string{*tenantName}
+	}
+	store, err := storage.OpenTenants(storageCfg, tenants)
+	if err != nil {
+		logger.Error("Failed to open result store", "driver", storageCfg.Driver, "error", err)
+		return 1
+	}
+	defer store.Close()
+
+	ctx := tenant.WithName(context.Background(), *tenantName)
+	file := fs.Arg(0)
+
+	switch args[0] {
+	case "export":
+		var w io.Writer = os.Stdout
+		if file != "" {
+			f, err := os.Create(file)
+			if err != nil {
+				logger.Error("Failed to create export file", "file", file, "error", err)
+				return 1
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if err := storage.Dump(ctx, store, w, *format); err != nil {
+			logger.Error("History export failed", "error", err)
+			return 1
+		}
+		logger.Info("History exported", "driver", storageCfg.Driver, "format", *format)
+
+	case "import":
+		var r io.Reader = os.Stdin
+		if file != "" {
+			f, err := os.Open(file)
+			if err != nil {
+				logger.Error("Failed to open import file", "file", file, "error", err)
+				return 1
+			}
+			defer f.Close()
+			r = f
+		}
+
+		stats, err := storage.Load(ctx, store, r)
+		if err != nil {
+			logger.Error("History import failed", "imported", stats.Imported, "error", err)
+			return 1
+		}
+		logger.Info("History imported",
+			"driver", storageCfg.Driver,
+			"imported", stats.Imported,
+			"skipped", stats.Skipped,
+		)
+
+	default:
+		fmt.Fprintln(os.Stderr, historyUsage)
+		return 2
+	}
+
+	return 0
+}
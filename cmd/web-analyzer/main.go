@@ -2,58 +2,194 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
-	_ "net/http/pprof"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
-	"web-analyzer/internal/config"
-	"web-analyzer/internal/handlers"
-	"web-analyzer/internal/server"
-	"web-analyzer/pkg/analyzer"
+	"github.com/anjula-paulus/web-analyzer/internal/cache"
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/export"
+	"github.com/anjula-paulus/web-analyzer/internal/handlers"
+	"github.com/anjula-paulus/web-analyzer/internal/logging"
+	"github.com/anjula-paulus/web-analyzer/internal/notify"
+	"github.com/anjula-paulus/web-analyzer/internal/openapi"
+	"github.com/anjula-paulus/web-analyzer/internal/render"
+	"github.com/anjula-paulus/web-analyzer/internal/server"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/internal/tenant"
+	"github.com/anjula-paulus/web-analyzer/internal/tracing"
+	"github.com/anjula-paulus/web-analyzer/internal/wayback"
+	"github.com/anjula-paulus/web-analyzer/internal/webhook"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func main() {
+	cliFlags, err := parseFlags(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if cliFlags.version {
+		fmt.Println("web-analyzer", version)
+		return
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		slog.Error("Failed to load config", "error", err)
 		os.Exit(1)
 	}
+	cliFlags.apply(cfg)
+
+	// Subcommands log to stderr so their output can be piped
+	if len(cliFlags.args) > 0 {
+		switch cliFlags.args[0] {
+		case "history":
+			os.Exit(runHistory(cfg, cliFlags.args[1:], setupLogger(cfg, os.Stderr, nil)))
+		case "analyze", "batch":
+			// Only problems are worth reporting next to the results
+			if !cliFlags.set["log-level"] && os.Getenv("LOG_LEVEL") == "" {
+				cfg.LogLevel = "warn"
+			}
+			logger := setupLogger(cfg, os.Stderr, nil)
+			if cliFlags.args[0] == "batch" {
+				os.Exit(runBatch(cfg, cliFlags.args[1:], logger))
+			}
+			os.Exit(runAnalyze(cfg, cliFlags.args[1:], logger))
+		default:
+			fmt.Fprintf(os.Stderr, "unknown command %q\n", cliFlags.args[0])
+			os.Exit(2)
+		}
+	}
 
 	// Setup structured logging
-	logger := setupLogger(cfg.LogLevel, cfg.LogFormat)
+	appLog, accessLog, closeLogs, err := openLogs(cfg.Logging)
+	if err != nil {
+		slog.Error("Failed to open log files", "error", err)
+		os.Exit(1)
+	}
+	logSink, err := logging.OpenSink(cfg.Logging.Sink)
+	if err != nil {
+		slog.Error("Failed to open log sink", "type", cfg.Logging.Sink.Type, "error", err)
+		os.Exit(1)
+	}
+	logger := setupLogger(cfg, appLog, logSink)
 	slog.SetDefault(logger)
+	accessLogger := logger
+	if accessLog != appLog {
+		accessLogger = setupLogger(cfg, accessLog, logSink)
+	}
 
 	logger.Info("Starting web analyzer",
+		"version", version,
 		"port", cfg.Port,
 		"pprof_enabled", cfg.PprofEnabled,
 		"log_level", cfg.LogLevel,
 		"max_workers", cfg.Analyzer.MaxWorkers,
 	)
 
+	// Install the tracer provider before anything starts spans
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, logger)
+	if err != nil {
+		logger.Error("Failed to set up tracing", "endpoint", cfg.Tracing.Endpoint, "error", err)
+		os.Exit(1)
+	}
+
+	// Open the shared cache, if configured, before the analyzer so link
+	// checks can use it
+	sharedCache, err := cache.Open(context.Background(), cfg.Cache)
+	if err != nil {
+		logger.Error("Failed to open cache", "driver", cfg.Cache.Driver, "error", err)
+		os.Exit(1)
+	}
+
 	// Create analyzer service
-	analyzerService := analyzer.New(cfg.Analyzer, logger)
+	analyzerOptions := []analyzer.Option{analyzer.WithConfig(cfg.Analyzer), analyzer.WithLogger(logger), analyzer.WithRegisterer(prometheus.DefaultRegisterer)}
+	var resultCache *cache.Results
+	if sharedCache != nil {
+		analyzerOptions = append(analyzerOptions, analyzer.WithLinkCache(cache.NewLinks(sharedCache, cfg.Cache.LinkTTL, logger)))
+		resultCache = cache.NewResults(sharedCache, cfg.Cache.ResultTTL, logger)
+	}
+	analyzerService := analyzer.NewWithOptions(analyzerOptions...)
+
+	// Build the API description used for docs and request validation
+	apiDoc := openapi.NewDocument(version)
+
+	// Create result store for analysis history, partitioned by tenant, and
+	// prune it in the background
+	resultStore, err := storage.OpenTenants(cfg.Storage, tenant.Names(cfg))
+	if err != nil {
+		logger.Error("Failed to open result store", "driver", cfg.Storage.Driver, "error", err)
+		os.Exit(1)
+	}
+
+	if cfg.Storage.TrendGaugeURLs > 0 {
+		prometheus.MustRegister(storage.NewTrendCollector(resultStore, cfg.Storage.TrendGaugeURLs, logger))
+	}
+
+	retentionCtx, stopRetention := context.WithCancel(context.Background())
+	go storage.RunRetention(retentionCtx, resultStore, cfg.Storage, logger)
+
+	// Create the report exporter, if configured, for uploading reports
+	// after each analysis
+	reportRenderer := render.NewHTMLReport()
+	reportExporter, err := export.New(cfg.Export, reportRenderer, logger)
+	if err != nil {
+		logger.Error("Failed to create report exporter", "provider", cfg.Export.Provider, "error", err)
+		os.Exit(1)
+	}
+
+	// Create the chat notifier, if configured, for posting analysis
+	// summaries to Slack or Teams
+	notifier, err := notify.New(cfg.Notify, logger)
+	if err != nil {
+		logger.Error("Failed to create notifier", "provider", cfg.Notify.Provider, "error", err)
+		os.Exit(1)
+	}
+
+	// Create webhook dispatcher for completion callbacks
+	webhookDispatcher := webhook.New(cfg.Webhook, logger)
 
 	// Create handlers with logger
-	analyzerHandler := handlers.NewAnalyzer(analyzerService, logger)
-	healthHandler := handlers.NewHealth(logger)
+	validator := openapi.NewValidator(apiDoc)
+	analyzerHandler := handlers.NewAnalyzer(analyzerService, validator, webhookDispatcher, resultStore, resultCache, reportExporter, notifier, wayback.New(cfg.Wayback, cfg.Analyzer.MaxPageSize), logger)
+	healthHandler := handlers.NewHealth(cfg.Health, resultStore, analyzerService, sharedCache, logger)
+	graphQLHandler := handlers.NewGraphQL(analyzerHandler, cfg.MaxBatchURLs, logger)
+	openAPIHandler := handlers.NewOpenAPI(apiDoc, logger)
+	resultsHandler := handlers.NewResults(resultStore, reportRenderer, logger)
+	adminHandler := handlers.NewAdmin(cfg, analyzerService, resultStore, webhookDispatcher, validator, logger)
 
 	// Start pprof server if enabled
-	if cfg.PprofEnabled {
+	if cfg.PprofEnabled && !cfg.PprofMainMux {
 		go func() {
-			logger.Info("Starting pprof server", "port", cfg.PprofPort)
-			if err := http.ListenAndServe(cfg.PprofPort, nil); err != nil {
+			if err := server.StartPprof(cfg, logger); err != nil {
 				logger.Error("pprof server failed", "error", err)
 			}
 		}()
 	}
 
 	// Create and start server
-	srv := server.New(cfg, analyzerHandler, healthHandler, logger)
+	srv := server.New(cfg, server.Handlers{
+		Analyzer: analyzerHandler,
+		Health:   healthHandler,
+		GraphQL:  graphQLHandler,
+		OpenAPI:  openAPIHandler,
+		Results:  resultsHandler,
+		Admin:    adminHandler,
+	}, logger, accessLogger)
 
 	// Start server in goroutine
 	go func() {
@@ -64,51 +200,151 @@ func main() {
 		}
 	}()
 
+	// Reload the configuration on SIGHUP
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	configReloader := &reloader{
+		current:  cfg,
+		flags:    cliFlags,
+		analyzer: analyzerService,
+		server:   srv,
+		admin:    adminHandler,
+		logger:   logger,
+	}
+	go func() {
+		for range hangup {
+			configReloader.reload()
+		}
+	}()
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-quit
+	signal.Stop(hangup)
 
 	logger.Info("Received shutdown signal", "signal", sig.String())
 
-	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	stopRetention()
+
+	// Graceful shutdown: let in-flight analyses finish, then the background
+	// exports and webhook deliveries they started, all within one deadline
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
+	logger.Info("Waiting for in-flight work",
+		"active_analyses", analyzerService.Stats().ActiveAnalyses,
+		"pending_webhooks", webhookDispatcher.Pending(),
+		"timeout", cfg.ShutdownTimeout,
+	)
+
+	shutdownFailed := false
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("Server shutdown failed", "error", err)
+		shutdownFailed = true
+	}
+
+	if reportExporter != nil {
+		if err := reportExporter.Shutdown(ctx); err != nil {
+			logger.Error("Report exports cut short", "error", err)
+		}
+	}
+
+	if notifier != nil {
+		if err := notifier.Shutdown(ctx); err != nil {
+			logger.Error("Notifications cut short", "error", err)
+		}
+	}
+
+	if err := webhookDispatcher.Shutdown(ctx); err != nil {
+		logger.Error("Webhook deliveries cut short", "error", err)
+	}
+
+	if err := resultStore.Close(); err != nil {
+		logger.Error("Result store close failed", "error", err)
+	}
+
+	if sharedCache != nil {
+		if err := sharedCache.Close(); err != nil {
+			logger.Error("Cache close failed", "error", err)
+		}
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("Tracing shutdown failed", "error", err)
+	}
+
+	if shutdownFailed {
 		os.Exit(1)
 	}
 
 	logger.Info("Server shutdown completed successfully")
+	if logSink != nil {
+		logSink.Close()
+	}
+	closeLogs()
 }
 
-// setupLogger configures structured logging based on configuration
-func setupLogger(level, format string) *slog.Logger {
-	var logLevel slog.Level
-	switch level {
-	case "debug":
-		logLevel = slog.LevelDebug
-	case "info":
-		logLevel = slog.LevelInfo
-	case "warn":
-		logLevel = slog.LevelWarn
-	case "error":
-		logLevel = slog.LevelError
-	default:
-		logLevel = slog.LevelInfo
+// openLogs opens the configured log files and returns the writers of the
+// application and access logs, which are the same when the access log has
+// no file of its own, and a func that closes the files. Without files, both
+// logs go to standard output.
+func openLogs(cfg config.LoggingConfig) (app, access io.Writer, closeLogs func(), err error) {
+	var files []*logging.RotatingFile
+	closeLogs = func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+
+	app = os.Stdout
+	if cfg.File != "" {
+		f, err := logging.OpenFile(cfg.File, cfg.Rotation)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		files = append(files, f)
+		app = f
+	}
+
+	access = app
+	if cfg.AccessFile != "" && cfg.AccessFile != cfg.File {
+		f, err := logging.OpenFile(cfg.AccessFile, cfg.Rotation)
+		if err != nil {
+			closeLogs()
+			return nil, nil, nil, err
+		}
+		files = append(files, f)
+		access = f
 	}
+	return app, access, closeLogs, nil
+}
+
+// logLevel is the level of the logger created by setupLogger. Reloading
+// the configuration changes it.
+var logLevel = new(slog.LevelVar)
+
+// setupLogger configures structured logging based on configuration. Secrets
+// are redacted from every record. Records are also sent to sink, if any.
+func setupLogger(cfg *config.Config, w io.Writer, sink logging.Sink) *slog.Logger {
+	logLevel.Set(parseLogLevel(cfg.LogLevel))
 
 	opts := &slog.HandlerOptions{
-		Level:     logLevel,
-		AddSource: logLevel == slog.LevelDebug,
+		Level:       logLevel,
+		AddSource:   logLevel.Level() == slog.LevelDebug,
+		ReplaceAttr: logging.NewRedactor(cfg.Logging).ReplaceAttr,
 	}
 
 	var handler slog.Handler
-	if format == "text" {
-		handler = slog.NewTextHandler(os.Stdout, opts)
+	if cfg.LogFormat == "text" {
+		handler = slog.NewTextHandler(w, opts)
 	} else {
-		handler = slog.NewJSONHandler(os.Stdout, opts)
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	// Admins can ask for debug logs of a single request
+	handler = logging.NewDebugHandler(handler)
+	if sink != nil {
+		handler = logging.Fanout(handler, logging.NewDebugHandler(logging.NewSinkHandler(sink, opts, cfg.LogFormat == "text")))
 	}
 
 	return slog.New(handler)
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/handlers"
+	"github.com/anjula-paulus/web-analyzer/internal/server"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// reloader applies configuration changes to the running service
+type reloader struct {
+	current  *config.Config
+	flags    *flags
+	analyzer *analyzer.Analyzer
+	server   *server.Server
+	admin    *handlers.Admin
+	logger   *slog.Logger
+}
+
+// reload reads the configuration again and applies the settings that can
+// change at runtime: the log level, the analyzer limits except the request
+// timeout and the concurrency limit, and the rate limits. Other changes,
+// including turning rate limiting on or off, wait for a restart. In-flight
+// requests are not interrupted.
+func (r *reloader) reload() {
+	loaded, err := config.Reload()
+	if err != nil {
+		r.logger.Error("Configuration reload failed, keeping the current settings", "error", err)
+		return
+	}
+	r.flags.apply(loaded)
+
+	next := *r.current
+	next.LogLevel = loaded.LogLevel
+	next.Analyzer = loaded.Analyzer
+	next.Analyzer.RequestTimeout = r.current.Analyzer.RequestTimeout
+	next.Analyzer.MaxConcurrentAnalyses = r.current.Analyzer.MaxConcurrentAnalyses
+	next.Analyzer.QueueTimeout = r.current.Analyzer.QueueTimeout
+	next.RateLimit = loaded.RateLimit
+	next.RateLimit.Enabled = r.current.RateLimit.Enabled
+
+	logLevel.Set(parseLogLevel(next.LogLevel))
+	r.analyzer.Reconfigure(next.Analyzer)
+	r.server.Reload(&next)
+	r.admin.SetConfig(&next)
+	r.current = &next
+
+	r.logger.Info("Configuration reloaded",
+		"log_level", next.LogLevel,
+		"max_workers", next.Analyzer.MaxWorkers,
+	)
+}
+
+// parseLogLevel maps a configured level name to a slog level, defaulting to
+// info
+func parseLogLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/notify"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// watchEvent is printed for every run after the first with -format json
+type watchEvent struct {
+	Time        time.Time      `json:"time"`
+	URL         string         `json:"url"`
+	Diff        *analyzer.Diff `json:"diff"`
+	Regressions []string       `json:"regressions,omitempty"`
+}
+
+// watchURL analyzes targetURL every interval until ctx ends or a run finds a
+// regression, printing the first result and then the differences from the
+// previous successful run. Failed runs are logged and retried at the next
+// interval, so a brief outage does not end the watch. Runs after the first
+// are passed to notifier, which may be nil, with their diff attached.
+func watchURL(ctx context.Context, service *analyzer.Analyzer, targetURL string, sections []analyzer.Section, interval time.Duration, format string, notifier *notify.Notifier, logger *slog.Logger) int {
+	enc := json.NewEncoder(os.Stdout)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var previous *analyzer.Result
+	for {
+		result, err := service.AnalyzeURL(ctx, targetURL, analyzer.IncludeSections(sections...))
+		if ctx.Err() != nil {
+			return 0
+		}
+
+		switch {
+		case err != nil:
+			logger.Error("Analysis failed, retrying at the next interval", "url", targetURL, "error", err)
+
+		case previous == nil:
+			if format == formatTable {
+				err = writeTable(os.Stdout, result)
+			} else {
+				err = enc.Encode(result)
+			}
+			previous = result
+
+		default:
+			diff := analyzer.DiffResults(previous, result)
+			regressions := diff.Regressions()
+			if notifier != nil {
+				result.PreviousDiff = diff
+				notifier.Notify(result)
+			}
+			now := time.Now().UTC().Truncate(time.Second)
+
+			if format == formatTable {
+				summary := "no changes"
+				if !diff.Identical {
+					summary = strings.Join(diff.Summary, "; ")
+				}
+				_, err = fmt.Fprintf(os.Stdout, "%s  %s\n", now.Format(time.RFC3339), summary)
+			} else {
+				err = enc.Encode(watchEvent{Time: now, URL: targetURL, Diff: diff, Regressions: regressions})
+			}
+
+			if len(regressions) > 0 {
+				logger.Warn("Regression detected", "url", targetURL, "regressions", regressions)
+				return exitRegression
+			}
+			previous = result
+		}
+		if err != nil {
+			logger.Error("Failed to write result", "error", err)
+			return 1
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Package webanalyzer exposes the analyze endpoint as a Google Cloud
+// Function. Deploy it from the repository root with:
+//
+//	gcloud functions deploy analyze --gen2 --runtime go124 --trigger-http --entry-point Analyze
+package webanalyzer
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/serverless"
+)
+
+// functionVersion is reported in the API description
+const functionVersion = "1.0.0"
+
+var (
+	handlerOnce sync.Once
+	handler     http.Handler
+)
+
+// Analyze answers like POST /api/v2/analyze. The handler is built on the
+// first request and reused while the instance stays warm.
+func Analyze(w http.ResponseWriter, r *http.Request) {
+	handlerOnce.Do(func() {
+		cfg, _ := config.Load()
+		handler = serverless.NewHandler(cfg, functionVersion, serverless.NewLogger(cfg))
+	})
+	handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,65 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/anjula-paulus/web-analyzer/internal/openapi"
+	"github.com/anjula-paulus/web-analyzer/internal/requestid"
+)
+
+// Machine-readable error codes
+const (
+	CodeInvalidRequest    = "invalid_request"
+	CodeInvalidURL        = "invalid_url"
+	CodeUnsupportedFormat = "unsupported_format"
+	CodeMethodNotAllowed  = "method_not_allowed"
+	CodeNotFound          = "not_found"
+	CodeUnauthorized      = "unauthorized"
+	CodeInvalidToken      = "invalid_token"
+	CodeInvalidAPIKey     = "invalid_api_key"
+	CodeForbidden         = "forbidden"
+	CodeInvalidCSRFToken  = "invalid_csrf_token"
+	CodeRateLimited       = "rate_limited"
+	CodeQuotaExceeded     = "quota_exceeded"
+	CodeRequestTooLarge   = "request_too_large"
+
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+	CodeIdempotencyMismatch   = "idempotency_key_reused"
+	CodeFetchTimeout          = "fetch_timeout"
+	CodeFetchFailed           = "fetch_failed"
+	CodeUpstreamStatus        = "upstream_http_error"
+	CodeNotHTML               = "not_html"
+	CodePageTooLarge          = "page_too_large"
+	CodeDocumentTooComplex    = "document_too_complex"
+	CodeCanceled              = "request_canceled"
+	CodeServerBusy            = "server_busy"
+	CodeInternal              = "internal_error"
+)
+
+// Error is the body of every API error response, wrapped as {"error": ...}
+type Error struct {
+	Code      string               `json:"code"`
+	Message   string               `json:"message"`
+	Details   []openapi.FieldError `json:"details,omitempty"`
+	RequestID string               `json:"request_id,omitempty"`
+}
+
+// Write writes an error envelope for the request
+func Write(w http.ResponseWriter, r *http.Request, statusCode int, code, message string) {
+	WriteDetails(w, r, statusCode, code, message, nil)
+}
+
+// WriteDetails writes an error envelope listing per-field problems
+func WriteDetails(w http.ResponseWriter, r *http.Request, statusCode int, code, message string, details []openapi.FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]Error{
+		"error": {
+			Code:      code,
+			Message:   message,
+			Details:   details,
+			RequestID: requestid.FromContext(r.Context()),
+		},
+	})
+}
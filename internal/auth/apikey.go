@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// ErrInvalidAPIKey is returned for unknown API keys
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeys authenticates clients presenting a static API key
+type APIKeys struct {
+	keys []apiKey
+}
+
+// apiKey holds a configured key's name, tenant and digest. Digests have a
+// fixed length, so comparing them does not leak the key length.
+type apiKey struct {
+	name   string
+	tenant string
+	digest [sha256.Size]byte
+}
+
+// NewAPIKeys func creates a new API key set from configuration. Entries
+// without a name or key are ignored.
+func NewAPIKeys(cfg []config.APIKeyConfig) *APIKeys {
+	keys := make([]apiKey, 0, len(cfg))
+	for _, k := range cfg {
+		if k.Name == "" || k.Key == "" {
+			continue
+		}
+		keys = append(keys, apiKey{name: k.Name, tenant: k.Tenant, digest: sha256.Sum256([]byte(k.Key))})
+	}
+	return &APIKeys{keys: keys}
+}
+
+// Len returns the number of configured keys
+func (a *APIKeys) Len() int {
+	return len(a.keys)
+}
+
+// Authenticate returns the principal for key, or ErrInvalidAPIKey when the
+// key is unknown
+func (a *APIKeys) Authenticate(key string) (*Principal, error) {
+	digest := sha256.Sum256([]byte(key))
+
+	var name, tenant string
+	found := 0
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(digest[:], k.digest[:]) == 1 {
+			name = k.name
+			tenant = k.tenant
+			found = 1
+		}
+	}
+	if found == 0 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	return &Principal{
+		Subject: name,
+		Method:  MethodAPIKey,
+		Tenant:  tenant,
+	}, nil
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval limits how often an unknown key ID can force a refetch
+const minRefreshInterval = 30 * time.Second
+
+// jwk is a single JSON Web Key
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keySet caches the issuer's signing keys and refreshes them periodically or
+// when a token references an unknown key ID
+type keySet struct {
+	client          *http.Client
+	issuer          string
+	jwksURL         string
+	refreshInterval time.Duration
+	logger          *slog.Logger
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	lastRefresh time.Time
+}
+
+// newKeySet creates a key set. When jwksURL is empty it is discovered from
+// the issuer's OpenID configuration.
+func newKeySet(issuer, jwksURL string, refreshInterval time.Duration, logger *slog.Logger) *keySet {
+	return &keySet{
+		client:          &http.Client{Timeout: 10 * time.Second},
+		issuer:          issuer,
+		jwksURL:         jwksURL,
+		refreshInterval: refreshInterval,
+		logger:          logger,
+	}
+}
+
+// key returns the public key for a key ID
+func (k *keySet) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	stale := time.Since(k.lastRefresh) > k.refreshInterval
+	key, known := k.keys[kid]
+
+	if stale || (!known && time.Since(k.lastRefresh) > minRefreshInterval) {
+		if err := k.refreshLocked(ctx); err != nil {
+			// Keep serving cached keys if the issuer is temporarily unreachable
+			k.logger.Error("JWKS refresh failed", "jwks_url", k.jwksURL, "error", err)
+			if !known {
+				return nil, err
+			}
+			return key, nil
+		}
+		key, known = k.keys[kid]
+	}
+
+	if !known {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+// refreshLocked refetches the key set. The caller must hold k.mu.
+func (k *keySet) refreshLocked(ctx context.Context) error {
+	k.lastRefresh = time.Now()
+
+	if k.jwksURL == "" {
+		jwksURL, err := k.discover(ctx)
+		if err != nil {
+			return err
+		}
+		k.jwksURL = jwksURL
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := k.getJSON(ctx, k.jwksURL, &doc); err != nil {
+		return fmt.Errorf("fetching JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, raw := range doc.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := raw.publicKey()
+		if err != nil {
+			k.logger.Warn("Skipping unusable JWK", "kid", raw.Kid, "error", err)
+			continue
+		}
+		keys[raw.Kid] = key
+	}
+
+	k.keys = keys
+	k.logger.Debug("JWKS refreshed", "jwks_url", k.jwksURL, "keys", len(keys))
+	return nil
+}
+
+// discover looks up the JWKS URL from the issuer's OpenID configuration
+func (k *keySet) discover(ctx context.Context) (string, error) {
+	var config struct {
+		JWKSURI string `json:"jwks_uri"`
+	}
+
+	wellKnown := strings.TrimSuffix(k.issuer, "/") + "/.well-known/openid-configuration"
+	if err := k.getJSON(ctx, wellKnown, &config); err != nil {
+		return "", fmt.Errorf("OIDC discovery: %w", err)
+	}
+	if config.JWKSURI == "" {
+		return "", fmt.Errorf("OIDC discovery: no jwks_uri in %s", wellKnown)
+	}
+	return config.JWKSURI, nil
+}
+
+// getJSON fetches and decodes a JSON document
+func (k *keySet) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// publicKey converts the JWK into an RSA or ECDSA public key
+func (j jwk) publicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+}
+
+// decodeBigInt decodes a base64url-encoded unsigned integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
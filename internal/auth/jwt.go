@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// ErrInvalidToken is returned for tokens that fail validation
+var ErrInvalidToken = errors.New("invalid token")
+
+// JWTValidator validates bearer JWTs issued by a configured OIDC provider
+type JWTValidator struct {
+	keys   *keySet
+	parser *jwt.Parser
+	logger *slog.Logger
+}
+
+// NewJWTValidator func creates a new JWT validator singleton instance
+func NewJWTValidator(cfg config.JWTConfig, logger *slog.Logger) *JWTValidator {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(cfg.Issuer),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &JWTValidator{
+		keys:   newKeySet(cfg.Issuer, cfg.JWKSURL, cfg.RefreshInterval, logger),
+		parser: jwt.NewParser(opts...),
+		logger: logger,
+	}
+}
+
+// Validate verifies the token's signature and claims and returns its principal
+func (v *JWTValidator) Validate(ctx context.Context, token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	return &Principal{
+		Subject: subject,
+		Method:  MethodJWT,
+		Claims:  claims,
+	}, nil
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "web-analyzer"
+)
+
+// testKeys are the RSA keys of the test issuer, generated once because
+// generating them is slow
+var testKeys = sync.OnceValue(func() map[string]*rsa.PrivateKey {
+	keys := make(map[string]*rsa.PrivateKey)
+	for _, kid := range []string{"k1", "k2"} {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+		keys[kid] = key
+	}
+	return keys
+})
+
+// jwksServer serves the public keys of kids as a JWKS and counts the
+// fetches. The served kids can be changed while it runs.
+type jwksServer struct {
+	*httptest.Server
+	fetches atomic.Int32
+	mu      sync.Mutex
+	kids    []string
+}
+
+func newJWKSServer(t *testing.T, kids ...string) *jwksServer {
+	t.Helper()
+	s := &jwksServer{kids: kids}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		var doc struct {
+			Keys []jwk `json:"keys"`
+		}
+		for _, kid := range s.kids {
+			public := testKeys()[kid].PublicKey
+			doc.Keys = append(doc.Keys, jwk{
+				Kid: kid,
+				Kty: "RSA",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) serve(kids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kids = kids
+}
+
+func newTestValidator(jwksURL string) *JWTValidator {
+	return NewJWTValidator(config.JWTConfig{
+		Issuer:          testIssuer,
+		Audience:        testAudience,
+		JWKSURL:         jwksURL,
+		RefreshInterval: time.Hour,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// validClaims returns the claims of a token the test validator accepts
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "user-1",
+		"iss": testIssuer,
+		"aud": testAudience,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+// signRS256 signs claims with the test key kid
+func signRS256(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(testKeys()[kid])
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func TestJWTValidator_Validate(t *testing.T) {
+	server := newJWKSServer(t, "k1")
+	v := newTestValidator(server.URL)
+
+	principal, err := v.Validate(context.Background(), signRS256(t, "k1", validClaims()))
+	if err != nil {
+		t.Fatalf("Expected a valid token, got %v", err)
+	}
+	if principal.Subject != "user-1" || principal.Method != MethodJWT {
+		t.Errorf("Unexpected principal %+v", principal)
+	}
+}
+
+func TestJWTValidator_Rejects(t *testing.T) {
+	server := newJWKSServer(t, "k1")
+	v := newTestValidator(server.URL)
+
+	with := func(key string, value interface{}) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims())
+	unsigned.Header["kid"] = "k1"
+	none, err := unsigned.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// HS256 keyed with the RSA public key, which a validator that trusts
+	// the token's alg header would verify with the key it looked up
+	der, err := x509.MarshalPKIXPublicKey(&testKeys()["k1"].PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	hmacToken.Header["kid"] = "k1"
+	hs256, err := hmacToken.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"alg none", none},
+		{"HS256 with the RSA key", hs256},
+		{"wrong issuer", signRS256(t, "k1", with("iss", "https://evil.example.com"))},
+		{"wrong audience", signRS256(t, "k1", with("aud", "another-service"))},
+		{"expired", signRS256(t, "k1", with("exp", time.Now().Add(-time.Minute).Unix()))},
+		{"no expiry", signRS256(t, "k1", with("exp", nil))},
+		{"no subject", signRS256(t, "k1", with("sub", nil))},
+		{"signed by another key", signRS256(t, "k2", validClaims())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if principal, err := v.Validate(context.Background(), tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Expected ErrInvalidToken, got %+v, %v", principal, err)
+			}
+		})
+	}
+}
+
+func TestJWTValidator_RefetchesUnknownKeys(t *testing.T) {
+	server := newJWKSServer(t, "k1")
+	v := newTestValidator(server.URL)
+	ctx := context.Background()
+
+	if _, err := v.Validate(ctx, signRS256(t, "k1", validClaims())); err != nil {
+		t.Fatalf("Expected a valid token, got %v", err)
+	}
+	if _, err := v.Validate(ctx, signRS256(t, "k1", validClaims())); err != nil || server.fetches.Load() != 1 {
+		t.Fatalf("Expected the cached key set to be used, got %d fetches, %v", server.fetches.Load(), err)
+	}
+
+	// The issuer rotates to a new key. Tokens naming it do not refetch the
+	// key set until minRefreshInterval has passed since the last fetch, so
+	// tokens with made up key IDs cannot hammer the issuer.
+	server.serve("k1", "k2")
+	rotated := signRS256(t, "k2", validClaims())
+	for range 3 {
+		if _, err := v.Validate(ctx, rotated); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("Expected the unknown key to be rejected, got %v", err)
+		}
+	}
+	if fetches := server.fetches.Load(); fetches != 1 {
+		t.Errorf("Expected no refetch within minRefreshInterval, got %d fetches", fetches)
+	}
+
+	v.keys.mu.Lock()
+	v.keys.lastRefresh = time.Now().Add(-minRefreshInterval - time.Second)
+	v.keys.mu.Unlock()
+
+	if _, err := v.Validate(ctx, rotated); err != nil {
+		t.Fatalf("Expected the refetched key to validate the token, got %v", err)
+	}
+	if fetches := server.fetches.Load(); fetches != 2 {
+		t.Errorf("Expected one refetch, got %d fetches", fetches)
+	}
+}
@@ -0,0 +1,38 @@
+package auth
+
+import "context"
+
+// Authentication methods recorded on a Principal
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "api_key"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Subject string
+	Method  string
+	Claims  map[string]interface{}
+	// Tenant is the tenant the principal acts for, or "" for the shared
+	// default namespace
+	Tenant string
+}
+
+type principalKey struct{}
+
+// ClientID identifies the principal across authentication methods, e.g. for
+// rate limiting
+func (p *Principal) ClientID() string {
+	return p.Method + ":" + p.Subject
+}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the request's principal, or nil for anonymous requests
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// ErrMiss is returned when a key is not cached
+var ErrMiss = errors.New("cache miss")
+
+// Cache stores values with an expiry
+type Cache interface {
+	// Get returns the value stored under key, or ErrMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Close releases the cache's resources
+	Close() error
+}
+
+// Cache drivers
+const (
+	DriverMemory = "memory"
+	DriverRedis  = "redis"
+)
+
+// Open creates the cache selected by the configuration. It returns nil when
+// caching is disabled.
+func Open(ctx context.Context, cfg config.CacheConfig) (Cache, error) {
+	switch cfg.Driver {
+	case "":
+		return nil, nil
+	case DriverMemory:
+		return NewMemory(cfg.MaxEntries), nil
+	case DriverRedis:
+		return NewRedis(ctx, cfg.Redis, cfg.KeyPrefix)
+	default:
+		return nil, fmt.Errorf("unknown cache driver %q", cfg.Driver)
+	}
+}
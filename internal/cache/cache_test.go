@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/tenant"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// mapCache is an in-process Cache for tests
+type mapCache struct {
+	values map[string][]byte
+	err    error
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{values: make(map[string][]byte)}
+}
+
+func (m *mapCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	value, ok := m.values[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	return value, nil
+}
+
+func (m *mapCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.values[key] = value
+	return nil
+}
+
+func (m *mapCache) Close() error { return nil }
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
+func TestResults_RoundTrip(t *testing.T) {
+	results := NewResults(newMapCache(), time.Minute, testLogger())
+	ctx := context.Background()
+
+	if _, ok := results.Get(ctx, "https://example.com", nil); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+
+	result := &analyzer.Result{ID: "abc", URL: "https://example.com", Title: "Example", Headings: map[string]int{"h1": 1}}
+	sections := []analyzer.Section{analyzer.SectionSEO, analyzer.SectionSecurity}
+	results.Set(ctx, "https://example.com", sections, result)
+
+	got, ok := results.Get(ctx, "https://example.com", []analyzer.Section{analyzer.SectionSecurity, analyzer.SectionSEO})
+	if !ok {
+		t.Fatal("expected a hit regardless of section order")
+	}
+	if got.ID != "abc" || got.Title != "Example" || got.Headings["h1"] != 1 {
+		t.Errorf("cached result = %+v, want %+v", got, result)
+	}
+	if !got.Cached || got.AnalyzedAt == nil {
+		t.Errorf("Expected the cached result to be flagged, got cached=%v analyzed_at=%v", got.Cached, got.AnalyzedAt)
+	}
+	if result.Cached {
+		t.Error("Set must not flag the caller's result")
+	}
+
+	if _, ok := results.Get(ctx, "https://example.com", nil); ok {
+		t.Error("expected a miss for a different section set")
+	}
+}
+
+func TestResults_PartitionedByTenant(t *testing.T) {
+	results := NewResults(newMapCache(), time.Minute, testLogger())
+	teamA := tenant.WithName(context.Background(), "team-a")
+
+	results.Set(teamA, "https://example.com", nil, &analyzer.Result{Title: "Example"})
+
+	if _, ok := results.Get(teamA, "https://example.com", nil); !ok {
+		t.Error("expected a hit for the tenant that cached the result")
+	}
+	if _, ok := results.Get(context.Background(), "https://example.com", nil); ok {
+		t.Error("expected a miss in the default namespace")
+	}
+	if _, ok := results.Get(tenant.WithName(context.Background(), "team-b"), "https://example.com", nil); ok {
+		t.Error("expected a miss for another tenant")
+	}
+}
+
+func TestResults_ErrorsAreMisses(t *testing.T) {
+	store := newMapCache()
+	store.err = errors.New("connection refused")
+	results := NewResults(store, time.Minute, testLogger())
+
+	results.Set(context.Background(), "https://example.com", nil, &analyzer.Result{})
+	if _, ok := results.Get(context.Background(), "https://example.com", nil); ok {
+		t.Error("expected a miss when the cache fails")
+	}
+}
+
+func TestLinks_RoundTrip(t *testing.T) {
+	links := NewLinks(newMapCache(), time.Minute, testLogger())
+	ctx := context.Background()
+
+	up := analyzer.LinkStatus{Accessible: true, ContentType: "application/pdf"}
+	links.Set(ctx, "https://example.com/up", up)
+	links.Set(ctx, "https://example.com/down", analyzer.LinkStatus{})
+
+	if status, ok := links.Get(ctx, "https://example.com/up"); !ok || status != up {
+		t.Errorf("Get(up) = %+v, %v; want %+v, true", status, ok, up)
+	}
+	if status, ok := links.Get(ctx, "https://example.com/down"); !ok || status.Accessible || status.ContentType != "" {
+		t.Errorf("Get(down) = %+v, %v; want inaccessible, true", status, ok)
+	}
+	if _, ok := links.Get(ctx, "https://example.com/other"); ok {
+		t.Error("expected a miss for an unchecked link")
+	}
+}
+
+func TestMemory_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewMemory(2)
+	ctx := context.Background()
+
+	cache.Set(ctx, "a", []byte("1"), time.Minute)
+	cache.Set(ctx, "b", []byte("2"), time.Minute)
+	if _, err := cache.Get(ctx, "a"); err != nil {
+		t.Fatalf("Get(a) failed: %v", err)
+	}
+	cache.Set(ctx, "c", []byte("3"), time.Minute)
+
+	if _, err := cache.Get(ctx, "b"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Expected b to be evicted, got %v", err)
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, err := cache.Get(ctx, key); err != nil {
+			t.Errorf("Get(%s) failed: %v", key, err)
+		}
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+}
+
+func TestMemory_Expires(t *testing.T) {
+	cache := NewMemory(0)
+	ctx := context.Background()
+
+	cache.Set(ctx, "a", []byte("1"), -time.Second)
+	if _, err := cache.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Expected an expired entry to miss, got %v", err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Expected the expired entry to be dropped, Len() = %d", cache.Len())
+	}
+}
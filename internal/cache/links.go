@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// Links shares link check outcomes between analyses and replicas. Cache
+// failures are logged and treated as misses.
+type Links struct {
+	cache  Cache
+	ttl    time.Duration
+	logger *slog.Logger
+}
+
+var _ analyzer.LinkCache = (*Links)(nil)
+
+// NewLinks func creates a new link check cache singleton instance
+func NewLinks(cache Cache, ttl time.Duration, logger *slog.Logger) *Links {
+	return &Links{cache: cache, ttl: ttl, logger: logger}
+}
+
+// Get returns the cached outcome for link
+func (l *Links) Get(ctx context.Context, link string) (status analyzer.LinkStatus, ok bool) {
+	value, err := l.cache.Get(ctx, linkKey(link))
+	if err != nil || len(value) == 0 {
+		if err != nil && !errors.Is(err, ErrMiss) {
+			l.logger.Warn("Link cache lookup failed", "link", link, "error", err)
+		}
+		return analyzer.LinkStatus{}, false
+	}
+	return analyzer.LinkStatus{Accessible: value[0] == '1', ContentType: string(value[1:])}, true
+}
+
+// Set caches the outcome for link as "1" or "0", for accessible or not,
+// followed by the Content-Type
+func (l *Links) Set(ctx context.Context, link string, status analyzer.LinkStatus) {
+	value := []byte("0" + status.ContentType)
+	if status.Accessible {
+		value[0] = '1'
+	}
+
+	if err := l.cache.Set(ctx, linkKey(link), value, l.ttl); err != nil {
+		l.logger.Warn("Link cache store failed", "link", link, "error", err)
+	}
+}
+
+// linkKey identifies a link
+func linkKey(link string) string {
+	sum := sha256.Sum256([]byte(link))
+	return "link:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryEntry is a cached value in the LRU list
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// Memory is a per-process LRU cache. Once it holds maxEntries values, each
+// new key evicts the least recently used one.
+type Memory struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List // front is most recently used
+	maxEntries int
+}
+
+var _ Cache = (*Memory)(nil)
+
+// NewMemory func creates a new in-memory cache singleton instance holding at
+// most maxEntries values; zero or less means no limit
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+	}
+}
+
+// Get returns the value stored under key, or ErrMiss
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+
+	entry := elem.Value.(*memoryEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		m.removeLocked(elem)
+		return nil, ErrMiss
+	}
+
+	m.order.MoveToFront(elem)
+	return entry.value, nil
+}
+
+// Set stores value under key for ttl
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if elem, ok := m.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(elem)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+
+	if m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		m.removeLocked(m.order.Back())
+	}
+	return nil
+}
+
+// Len returns the number of cached values, including expired ones not yet
+// evicted
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.order.Len()
+}
+
+// Close is a no-op; the cache holds no external resources
+func (m *Memory) Close() error {
+	return nil
+}
+
+// removeLocked drops an entry. The caller must hold the lock.
+func (m *Memory) removeLocked(elem *list.Element) {
+	m.order.Remove(elem)
+	delete(m.entries, elem.Value.(*memoryEntry).key)
+}
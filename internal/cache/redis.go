@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// Redis is a cache shared by every replica using the same Redis server
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+var _ Cache = (*Redis)(nil)
+
+// NewRedis func creates a new Redis cache singleton instance. Keys are
+// namespaced with prefix. The server is pinged so misconfiguration is
+// reported at startup.
+func NewRedis(ctx context.Context, cfg config.RedisConfig, prefix string) (*Redis, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("connecting to redis at %s: %w", cfg.Addr, err)
+	}
+
+	return &Redis{client: client, prefix: prefix}, nil
+}
+
+// Get returns the value stored under key, or ErrMiss
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+// Set stores value under key for ttl
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, r.prefix+key, value, ttl).Err()
+}
+
+// Close closes the connection pool
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
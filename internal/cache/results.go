@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/tenant"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// resultEntry is a cached result with the time it was analyzed
+type resultEntry struct {
+	AnalyzedAt time.Time        `json:"analyzed_at"`
+	Result     *analyzer.Result `json:"result"`
+}
+
+// Results caches analysis results by tenant, URL and options. Cache failures are
+// logged and treated as misses so they never fail an analysis.
+type Results struct {
+	cache  Cache
+	ttl    time.Duration
+	logger *slog.Logger
+}
+
+// NewResults func creates a new result cache singleton instance
+func NewResults(cache Cache, ttl time.Duration, logger *slog.Logger) *Results {
+	return &Results{cache: cache, ttl: ttl, logger: logger}
+}
+
+// Get returns the cached result for url analyzed with sections, flagged as
+// cached
+func (r *Results) Get(ctx context.Context, url string, sections []analyzer.Section) (*analyzer.Result, bool) {
+	value, err := r.cache.Get(ctx, resultKey(tenant.FromContext(ctx), url, sections))
+	if err != nil {
+		if !errors.Is(err, ErrMiss) {
+			r.logger.Warn("Result cache lookup failed", "url", url, "error", err)
+		}
+		return nil, false
+	}
+
+	var entry resultEntry
+	if err := json.Unmarshal(value, &entry); err != nil || entry.Result == nil {
+		r.logger.Warn("Discarding undecodable cached result", "url", url, "error", err)
+		return nil, false
+	}
+
+	entry.Result.Cached = true
+	entry.Result.AnalyzedAt = &entry.AnalyzedAt
+	return entry.Result, true
+}
+
+// Set caches result for url analyzed with sections
+func (r *Results) Set(ctx context.Context, url string, sections []analyzer.Section, result *analyzer.Result) {
+	value, err := json.Marshal(resultEntry{AnalyzedAt: time.Now().UTC(), Result: result})
+	if err != nil {
+		r.logger.Warn("Failed to encode result for caching", "url", url, "error", err)
+		return
+	}
+
+	if err := r.cache.Set(ctx, resultKey(tenant.FromContext(ctx), url, sections), value, r.ttl); err != nil {
+		r.logger.Warn("Result cache store failed", "url", url, "error", err)
+	}
+}
+
+// resultKey identifies a tenant's URL and option set. Section order does not
+// matter. Keys in the default namespace carry no tenant.
+func resultKey(tenantName, url string, sections []analyzer.Section) string {
+	names := make([]string, len(sections))
+	for i, section := range sections {
+		names[i] = string(section)
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	sum := sha256.Sum256([]byte(url + "\x00" + strings.Join(names, ",")))
+	if tenantName != "" {
+		return "result:" + tenantName + ":" + hex.EncodeToString(sum[:])
+	}
+	return "result:" + hex.EncodeToString(sum[:])
+}
@@ -2,24 +2,299 @@ package config
 
 import (
 	"time"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
 )
 
 // Config holds application configuration
 type Config struct {
-	Port         string         `yaml:"port"`
-	PprofEnabled bool           `yaml:"pprof_enabled"`
-	PprofPort    string         `yaml:"pprof_port"`
-	LogLevel     string         `yaml:"log_level"`
-	LogFormat    string         `yaml:"log_format"`
-	ReadTimeout  time.Duration  `yaml:"read_timeout"`
-	WriteTimeout time.Duration  `yaml:"write_timeout"`
-	Analyzer     AnalyzerConfig `yaml:"analyzer"`
-}
-
-// AnalyzerConfig holds analyzer-specific configuration
-type AnalyzerConfig struct {
-	MaxWorkers     int           `yaml:"max_workers"`
-	RequestTimeout time.Duration `yaml:"request_timeout"`
-	LinkTimeout    time.Duration `yaml:"link_timeout"`
-	MaxRedirects   int           `yaml:"max_redirects"`
+	Port         string            `yaml:"port"`
+	PprofEnabled bool              `yaml:"pprof_enabled"`
+	PprofPort    string            `yaml:"pprof_port"`
+	PprofToken   string            `yaml:"pprof_token"`
+	PprofMainMux bool              `yaml:"pprof_main_mux"`
+	LogLevel     string            `yaml:"log_level"`
+	LogFormat    string            `yaml:"log_format"`
+	ReadTimeout  time.Duration     `yaml:"read_timeout"`
+	WriteTimeout time.Duration     `yaml:"write_timeout"`
+	Analyzer     AnalyzerConfig    `yaml:"analyzer"`
+	Webhook      WebhookConfig     `yaml:"webhook"`
+	Storage      StorageConfig     `yaml:"storage"`
+	Auth         AuthConfig        `yaml:"auth"`
+	RateLimit    RateLimitConfig   `yaml:"rate_limit"`
+	Idempotency  IdempotencyConfig `yaml:"idempotency"`
+	Cache        CacheConfig       `yaml:"cache"`
+	Export       ExportConfig      `yaml:"export"`
+	BodyLimit    BodyLimitConfig   `yaml:"body_limit"`
+	Tracing      TracingConfig     `yaml:"tracing"`
+	Health       HealthConfig      `yaml:"health"`
+	// TLSCert and TLSKey are PEM files; when both are set the server
+	// serves HTTPS itself
+	TLSCert string `yaml:"tls_cert"`
+	TLSKey  string `yaml:"tls_key"`
+	// TLSClientCA is a PEM bundle; when set, clients must present a
+	// certificate signed by one of its CAs
+	TLSClientCA string     `yaml:"tls_client_ca"`
+	ACME        ACMEConfig `yaml:"acme"`
+	// ShutdownTimeout is how long shutdown waits for in-flight analyses
+	// and background jobs before cutting them short
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// MaxBatchURLs limits the URLs one GraphQL query may analyze across
+	// its analyze and analyzeMany fields; 0 disables the limit
+	MaxBatchURLs   int           `yaml:"max_batch_urls"`
+	Logging        LoggingConfig `yaml:"logging"`
+	HandlerTimeout TimeoutConfig `yaml:"handler_timeout"`
+	// Tenants partitions results, quotas and metrics between teams; API
+	// keys join a tenant with their tenant field
+	Tenants []TenantConfig `yaml:"tenants"`
+	Notify  NotifyConfig   `yaml:"notify"`
+	Wayback WaybackConfig  `yaml:"wayback"`
+}
+
+// AnalyzerConfig holds analyzer-specific configuration. The type belongs to
+// the analyzer package so that package can be used on its own.
+type AnalyzerConfig = analyzer.Config
+
+// PageSpeedConfig holds the PageSpeed Insights settings of the analyzer
+type PageSpeedConfig = analyzer.PageSpeedConfig
+
+// WebhookConfig holds completion callback delivery configuration
+type WebhookConfig struct {
+	// Secret keys the deliveries' HMAC signatures; when it is empty
+	// deliveries are sent unsigned
+	Secret         string        `yaml:"secret"`
+	Timeout        time.Duration `yaml:"timeout"`
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	// AllowPrivateNetworks permits deliveries to loopback, link-local and
+	// private addresses, which are refused by default since callback URLs
+	// come from clients
+	AllowPrivateNetworks bool `yaml:"allow_private_networks"`
+}
+
+// StorageConfig holds result history configuration
+type StorageConfig struct {
+	// Driver is "sqlite" (the default), "bolt" or "memory"
+	Driver          string        `yaml:"driver"`
+	Path            string        `yaml:"path"`
+	MaxAge          time.Duration `yaml:"max_age"`
+	MaxRows         int           `yaml:"max_rows"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval"`
+	// TrendGaugeURLs is how many of the most analyzed URLs get trend gauges
+	// on /metrics; 0 disables them
+	TrendGaugeURLs int `yaml:"trend_gauge_urls"`
+}
+
+// AuthConfig holds API and UI authentication configuration
+type AuthConfig struct {
+	PublicPaths []string       `yaml:"public_paths"`
+	APIKeys     []APIKeyConfig `yaml:"api_keys"`
+	JWT         JWTConfig      `yaml:"jwt"`
+	// Admins lists principals allowed to use the admin API, written as
+	// "api_key:<name>" or "jwt:<subject>"
+	Admins []string `yaml:"admins"`
+	// CSRF requires the UI's CSRF token on state-changing browser requests
+	CSRF bool `yaml:"csrf"`
+}
+
+// APIKeyConfig identifies a client by a static API key. Non-zero limits
+// override the rate_limit defaults for that client. Keys with a tenant use
+// the tenant's limits instead, and their own are ignored.
+type APIKeyConfig struct {
+	Name              string `yaml:"name"`
+	Key               string `yaml:"key"`
+	RequestsPerMinute int    `yaml:"requests_per_minute"`
+	DailyQuota        int    `yaml:"daily_quota"`
+	// Tenant scopes the key's results and quota to a tenant; empty keeps
+	// the key in the shared default namespace
+	Tenant string `yaml:"tenant"`
+}
+
+// TenantConfig declares a tenant. Non-zero limits override the rate_limit
+// defaults and are shared by all of the tenant's API keys.
+type TenantConfig struct {
+	Name              string `yaml:"name"`
+	RequestsPerMinute int    `yaml:"requests_per_minute"`
+	DailyQuota        int    `yaml:"daily_quota"`
+}
+
+// JWTConfig holds bearer JWT validation settings for an OIDC issuer
+type JWTConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	Issuer          string        `yaml:"issuer"`
+	Audience        string        `yaml:"audience"`
+	JWKSURL         string        `yaml:"jwks_url"`
+	CookieName      string        `yaml:"cookie_name"`
+	Leeway          time.Duration `yaml:"leeway"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+// RateLimitConfig holds per-client request limits. Clients are identified by
+// API key or JWT subject, falling back to the remote IP for anonymous requests.
+type RateLimitConfig struct {
+	Enabled           bool `yaml:"enabled"`
+	RequestsPerMinute int  `yaml:"requests_per_minute"`
+	Burst             int  `yaml:"burst"`
+	DailyQuota        int  `yaml:"daily_quota"`
+}
+
+// IdempotencyConfig holds Idempotency-Key handling for analyze requests
+type IdempotencyConfig struct {
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// BodyLimitConfig holds request body size limits in bytes. Routes maps route
+// patterns, such as /api/v1/results/{id}, or exact request paths to their
+// own limit; 0 disables the limit.
+type BodyLimitConfig struct {
+	Default int64            `yaml:"default"`
+	Routes  map[string]int64 `yaml:"routes"`
+}
+
+// TimeoutConfig holds how long handlers may take. Routes maps route
+// patterns, such as /api/v1/results/{id}, or exact request paths to their
+// own timeout; 0 disables the timeout.
+type TimeoutConfig struct {
+	Default time.Duration            `yaml:"default"`
+	Routes  map[string]time.Duration `yaml:"routes"`
+}
+
+// ACMEConfig holds automatic certificate configuration. Certificates are
+// only requested for Hosts.
+type ACMEConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Hosts    []string `yaml:"hosts"`
+	Email    string   `yaml:"email"`
+	CacheDir string   `yaml:"cache_dir"`
+	// DirectoryURL selects the ACME CA; empty uses Let's Encrypt production
+	DirectoryURL string `yaml:"directory_url"`
+	// HTTPPort serves HTTP-01 challenges and redirects other requests to
+	// HTTPS; empty leaves only the TLS-ALPN-01 challenge on the main port
+	HTTPPort string `yaml:"http_port"`
+}
+
+// HealthConfig holds readiness check configuration
+type HealthConfig struct {
+	// DNSProbeHost is resolved to check that outbound DNS works; empty
+	// skips the check
+	DNSProbeHost string `yaml:"dns_probe_host"`
+	// MaxActiveAnalyses is how many concurrent analyses the service takes
+	// before it reports itself not ready; 0 disables the check
+	MaxActiveAnalyses int           `yaml:"max_active_analyses"`
+	ProbeTimeout      time.Duration `yaml:"probe_timeout"`
+	// HTTPSProbeURL is requested by the deep health check to test outbound
+	// HTTPS; empty skips the check
+	HTTPSProbeURL string `yaml:"https_probe_url"`
+}
+
+// LoggingConfig holds access log sampling, log redaction and log file
+// settings
+type LoggingConfig struct {
+	// AccessSampleRate is the fraction of successful requests written to
+	// the access log, from 0 to 1; failed requests are always logged
+	AccessSampleRate float64 `yaml:"access_sample_rate"`
+	// RedactQueryParams lists query parameters whose values are masked in
+	// logged URLs, matched case-insensitively
+	RedactQueryParams []string `yaml:"redact_query_params"`
+	// RedactHeaders lists headers whose values are masked when logged,
+	// matched case-insensitively
+	RedactHeaders []string `yaml:"redact_headers"`
+	// File is where the application log is written; empty writes to
+	// standard output
+	File string `yaml:"file"`
+	// AccessFile is where the access log is written; empty writes it with
+	// the application log
+	AccessFile string            `yaml:"access_file"`
+	Rotation   LogRotationConfig `yaml:"rotation"`
+	Sink       LogSinkConfig     `yaml:"sink"`
+}
+
+// LogSinkConfig selects a log collector that receives every record in
+// addition to the log output. Type is "syslog", "journald" or "network";
+// empty disables the sink. Network and Address locate a remote syslog
+// server, where an empty Network uses the local syslog daemon, or the
+// "tcp" or "udp" collector of the network sink, which receives one record
+// per line. Tag identifies the service in syslog and journald.
+type LogSinkConfig struct {
+	Type    string `yaml:"type"`
+	Network string `yaml:"network"`
+	Address string `yaml:"address"`
+	Tag     string `yaml:"tag"`
+}
+
+// LogRotationConfig controls when log files are rotated. A file is rotated
+// once it reaches MaxSizeMB or has been written for MaxAge, whichever comes
+// first; zero disables that trigger. MaxBackups rotated files are kept per
+// log, and 0 keeps them all.
+type LogRotationConfig struct {
+	MaxSizeMB  int           `yaml:"max_size_mb"`
+	MaxAge     time.Duration `yaml:"max_age"`
+	MaxBackups int           `yaml:"max_backups"`
+}
+
+// TracingConfig holds OpenTelemetry trace export configuration
+type TracingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Endpoint is the OTLP/HTTP collector address, e.g. "localhost:4318"
+	Endpoint    string `yaml:"endpoint"`
+	Insecure    bool   `yaml:"insecure"`
+	ServiceName string `yaml:"service_name"`
+	// SampleRatio is the fraction of new traces recorded, from 0 to 1;
+	// requests that arrive with a sampled parent are always recorded
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// CacheConfig holds result and link check caching configuration
+type CacheConfig struct {
+	// Driver is "memory" for a per-process LRU or "redis" to share the
+	// cache between replicas; empty disables caching
+	Driver     string        `yaml:"driver"`
+	ResultTTL  time.Duration `yaml:"result_ttl"`
+	LinkTTL    time.Duration `yaml:"link_ttl"`
+	KeyPrefix  string        `yaml:"key_prefix"`
+	MaxEntries int           `yaml:"max_entries"`
+	Redis      RedisConfig   `yaml:"redis"`
+}
+
+// ExportConfig holds report export to object storage
+type ExportConfig struct {
+	// Provider is "s3" or "gcs"; empty disables exports
+	Provider string `yaml:"provider"`
+	// Endpoint overrides the provider's endpoint, e.g. for MinIO
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	// KeyTemplate is a text/template for object keys with the fields ID,
+	// URL, Host, Date, Time and Ext
+	KeyTemplate string        `yaml:"key_template"`
+	Formats     []string      `yaml:"formats"`
+	Timeout     time.Duration `yaml:"timeout"`
+}
+
+// NotifyConfig holds chat notifications about finished analyses
+type NotifyConfig struct {
+	// Provider is "slack" or "teams"; empty disables notifications
+	Provider   string `yaml:"provider"`
+	WebhookURL string `yaml:"webhook_url"`
+	// On is "regression" to post only when an analysis regressed against
+	// the previous result, or "always" to post every finished analysis
+	On      string        `yaml:"on"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// WaybackConfig holds Internet Archive settings for comparing pages with
+// archived snapshots
+type WaybackConfig struct {
+	// Endpoint is the Wayback Machine availability API
+	Endpoint string        `yaml:"endpoint"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// RedisConfig holds Redis connection settings
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
 }
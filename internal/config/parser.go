@@ -1,31 +1,23 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+
 	"gopkg.in/yaml.v3"
 )
 
-// Load loads configuration from YAML file and environment variables
+// Load loads configuration from YAML file and environment variables. Invalid
+// analyzer rules fail it, since they would otherwise be skipped silently.
 func Load() (*Config, error) {
-	// Default configuration
-	config := &Config{
-		Port:         ":8080",
-		PprofEnabled: true,
-		PprofPort:    "localhost:6060",
-		LogLevel:     "info",
-		LogFormat:    "json",
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		Analyzer: AnalyzerConfig{
-			MaxWorkers:     10,
-			RequestTimeout: 30 * time.Second,
-			LinkTimeout:    10 * time.Second,
-			MaxRedirects:   5,
-		},
-	}
+	config := defaults()
 
 	// Try to load from YAML file
 	if err := loadFromYAML(config); err != nil {
@@ -35,9 +27,162 @@ func Load() (*Config, error) {
 	// Override with environment variables
 	overrideWithEnv(config)
 
+	if err := analyzer.ValidateRules(config.Analyzer.Rules); err != nil {
+		return nil, fmt.Errorf("analyzer rules: %w", err)
+	}
+
+	return config, nil
+}
+
+// Reload loads configuration like Load, but fails when the configuration
+// file exists and cannot be read or parsed, so that a bad edit does not
+// reset a running service to the defaults
+func Reload() (*Config, error) {
+	config := defaults()
+
+	if err := loadFromYAML(config); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	overrideWithEnv(config)
+
+	if err := analyzer.ValidateRules(config.Analyzer.Rules); err != nil {
+		return nil, fmt.Errorf("analyzer rules: %w", err)
+	}
+
 	return config, nil
 }
 
+// defaults returns the default configuration
+func defaults() *Config {
+	return &Config{
+		Port:         ":8080",
+		PprofEnabled: true,
+		PprofPort:    "localhost:6060",
+		LogLevel:     "info",
+		LogFormat:    "json",
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 65 * time.Second,
+		// Long enough for an analysis started just before shutdown
+		ShutdownTimeout: 30 * time.Second,
+		MaxBatchURLs:    50,
+		Analyzer: AnalyzerConfig{
+			MaxWorkers:         10,
+			RequestTimeout:     30 * time.Second,
+			LinkTimeout:        10 * time.Second,
+			MaxRedirects:       5,
+			MaxPageSize:        10 << 20,
+			MaxNodes:           500_000,
+			MaxDepth:           512,
+			StreamingThreshold: 2 << 20,
+			BatchConcurrency:   4,
+			QueueTimeout:       2 * time.Second,
+			MaxOutlineEntries:  100,
+			PageSpeed: PageSpeedConfig{
+				Strategy: "mobile",
+				Endpoint: analyzer.DefaultPageSpeedEndpoint,
+				Timeout:  time.Minute,
+			},
+		},
+		Webhook: WebhookConfig{
+			Timeout:        10 * time.Second,
+			MaxAttempts:    5,
+			InitialBackoff: time.Second,
+		},
+		Storage: StorageConfig{
+			Driver:          "sqlite",
+			Path:            "data/web-analyzer.db",
+			MaxAge:          30 * 24 * time.Hour,
+			MaxRows:         10000,
+			CleanupInterval: time.Hour,
+		},
+		Auth: AuthConfig{
+			PublicPaths: []string{"/api/v1/health", "/readyz", "/livez", "/api/v1/openapi.json", "/api/v1/schemas/result.json", "/metrics"},
+			CSRF:        true,
+			JWT: JWTConfig{
+				Leeway:          30 * time.Second,
+				RefreshInterval: time.Hour,
+			},
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerMinute: 60,
+			Burst:             10,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: 24 * time.Hour,
+		},
+		BodyLimit: BodyLimitConfig{
+			Default: 1 << 20,
+			Routes: map[string]int64{
+				"/api/v1/admin/history": 256 << 20,
+			},
+		},
+		HandlerTimeout: TimeoutConfig{
+			Default: 30 * time.Second,
+			Routes: map[string]time.Duration{
+				"/api/v1/health":        5 * time.Second,
+				"/readyz":               5 * time.Second,
+				"/livez":                5 * time.Second,
+				"/api/v1/compare":       60 * time.Second,
+				"/api/v1/site":          60 * time.Second,
+				"/api/v1/admin/history": 0,
+			},
+		},
+		ACME: ACMEConfig{
+			CacheDir: "data/acme",
+			HTTPPort: ":80",
+		},
+		Logging: LoggingConfig{
+			AccessSampleRate:  1,
+			RedactQueryParams: []string{"token", "access_token", "id_token", "api_key", "apikey", "key", "password", "secret", "signature", "sig", "code"},
+			RedactHeaders:     []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key"},
+			Rotation: LogRotationConfig{
+				MaxSizeMB:  100,
+				MaxAge:     24 * time.Hour,
+				MaxBackups: 7,
+			},
+			Sink: LogSinkConfig{
+				Tag: "web-analyzer",
+			},
+		},
+		Health: HealthConfig{
+			DNSProbeHost:  "example.com",
+			ProbeTimeout:  2 * time.Second,
+			HTTPSProbeURL: "https://example.com/",
+		},
+		Tracing: TracingConfig{
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			ServiceName: "web-analyzer",
+			SampleRatio: 1,
+		},
+		Export: ExportConfig{
+			Region:      "us-east-1",
+			KeyTemplate: "reports/{{.Host}}/{{.Date}}/{{.ID}}.{{.Ext}}",
+			Formats:     []string{"json"},
+			Timeout:     30 * time.Second,
+		},
+		Notify: NotifyConfig{
+			On:      "regression",
+			Timeout: 10 * time.Second,
+		},
+		Wayback: WaybackConfig{
+			Endpoint: "https://archive.org/wayback/available",
+			Timeout:  30 * time.Second,
+		},
+		Cache: CacheConfig{
+			Driver:     "memory",
+			ResultTTL:  10 * time.Minute,
+			LinkTTL:    time.Hour,
+			KeyPrefix:  "web-analyzer:",
+			MaxEntries: 1000,
+			Redis: RedisConfig{
+				Addr: "localhost:6379",
+			},
+		},
+	}
+}
+
 // loadFromYAML loads configuration from YAML file
 func loadFromYAML(config *Config) error {
 	configPaths := []string{
@@ -79,6 +224,14 @@ func overrideWithEnv(config *Config) {
 		config.PprofPort = pprofPort
 	}
 
+	if pprofToken := os.Getenv("PPROF_TOKEN"); pprofToken != "" {
+		config.PprofToken = pprofToken
+	}
+
+	if pprofMainMux := os.Getenv("PPROF_MAIN_MUX"); pprofMainMux != "" {
+		config.PprofMainMux = pprofMainMux == "true"
+	}
+
 	if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
 		config.LogLevel = logLevel
 	}
@@ -87,12 +240,100 @@ func overrideWithEnv(config *Config) {
 		config.LogFormat = logFormat
 	}
 
+	if sampleRate := os.Getenv("ACCESS_LOG_SAMPLE_RATE"); sampleRate != "" {
+		if rate, err := strconv.ParseFloat(sampleRate, 64); err == nil {
+			config.Logging.AccessSampleRate = rate
+		}
+	}
+
+	if logFile := os.Getenv("LOG_FILE"); logFile != "" {
+		config.Logging.File = logFile
+	}
+
+	if accessLogFile := os.Getenv("ACCESS_LOG_FILE"); accessLogFile != "" {
+		config.Logging.AccessFile = accessLogFile
+	}
+
+	if maxSize := os.Getenv("LOG_MAX_SIZE_MB"); maxSize != "" {
+		if size, err := strconv.Atoi(maxSize); err == nil {
+			config.Logging.Rotation.MaxSizeMB = size
+		}
+	}
+
+	if maxAge := os.Getenv("LOG_MAX_AGE"); maxAge != "" {
+		if age, err := time.ParseDuration(maxAge); err == nil {
+			config.Logging.Rotation.MaxAge = age
+		}
+	}
+
+	if maxBackups := os.Getenv("LOG_MAX_BACKUPS"); maxBackups != "" {
+		if backups, err := strconv.Atoi(maxBackups); err == nil {
+			config.Logging.Rotation.MaxBackups = backups
+		}
+	}
+
+	if logSink := os.Getenv("LOG_SINK"); logSink != "" {
+		config.Logging.Sink.Type = logSink
+	}
+
+	if sinkNetwork := os.Getenv("LOG_SINK_NETWORK"); sinkNetwork != "" {
+		config.Logging.Sink.Network = sinkNetwork
+	}
+
+	if sinkAddress := os.Getenv("LOG_SINK_ADDRESS"); sinkAddress != "" {
+		config.Logging.Sink.Address = sinkAddress
+	}
+
+	if shutdownTimeout := os.Getenv("SHUTDOWN_TIMEOUT"); shutdownTimeout != "" {
+		if timeout, err := time.ParseDuration(shutdownTimeout); err == nil {
+			config.ShutdownTimeout = timeout
+		}
+	}
+
+	if maxBatchURLs := os.Getenv("MAX_BATCH_URLS"); maxBatchURLs != "" {
+		if limit, err := strconv.Atoi(maxBatchURLs); err == nil {
+			config.MaxBatchURLs = limit
+		}
+	}
+
+	if tlsCert := os.Getenv("TLS_CERT"); tlsCert != "" {
+		config.TLSCert = tlsCert
+	}
+
+	if tlsKey := os.Getenv("TLS_KEY"); tlsKey != "" {
+		config.TLSKey = tlsKey
+	}
+
+	if tlsClientCA := os.Getenv("TLS_CLIENT_CA"); tlsClientCA != "" {
+		config.TLSClientCA = tlsClientCA
+	}
+
+	if acmeHosts := os.Getenv("ACME_HOSTS"); acmeHosts != "" {
+		config.ACME.Enabled = true
+		config.ACME.Hosts = nil
+		for _, host := range strings.Split(acmeHosts, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				config.ACME.Hosts = append(config.ACME.Hosts, host)
+			}
+		}
+	}
+
+	if acmeEmail := os.Getenv("ACME_EMAIL"); acmeEmail != "" {
+		config.ACME.Email = acmeEmail
+	}
+
 	if maxWorkers := os.Getenv("MAX_WORKERS"); maxWorkers != "" {
 		if workers, err := strconv.Atoi(maxWorkers); err == nil {
 			config.Analyzer.MaxWorkers = workers
 		}
 	}
 
+	if maxAnalyses := os.Getenv("MAX_CONCURRENT_ANALYSES"); maxAnalyses != "" {
+		if analyses, err := strconv.Atoi(maxAnalyses); err == nil {
+			config.Analyzer.MaxConcurrentAnalyses = analyses
+		}
+	}
+
 	if requestTimeout := os.Getenv("REQUEST_TIMEOUT"); requestTimeout != "" {
 		if timeout, err := time.ParseDuration(requestTimeout); err == nil {
 			config.Analyzer.RequestTimeout = timeout
@@ -105,9 +346,234 @@ func overrideWithEnv(config *Config) {
 		}
 	}
 
+	if fetchBudget := os.Getenv("FETCH_BUDGET"); fetchBudget != "" {
+		if budget, err := time.ParseDuration(fetchBudget); err == nil {
+			config.Analyzer.Budgets.Fetch = budget
+		}
+	}
+
+	if parseBudget := os.Getenv("PARSE_BUDGET"); parseBudget != "" {
+		if budget, err := time.ParseDuration(parseBudget); err == nil {
+			config.Analyzer.Budgets.Parse = budget
+		}
+	}
+
+	if linksBudget := os.Getenv("LINKS_BUDGET"); linksBudget != "" {
+		if budget, err := time.ParseDuration(linksBudget); err == nil {
+			config.Analyzer.Budgets.Links = budget
+		}
+	}
+
 	if maxRedirects := os.Getenv("MAX_REDIRECTS"); maxRedirects != "" {
 		if redirects, err := strconv.Atoi(maxRedirects); err == nil {
 			config.Analyzer.MaxRedirects = redirects
 		}
 	}
+
+	if maxPageSize := os.Getenv("MAX_PAGE_SIZE"); maxPageSize != "" {
+		if size, err := strconv.ParseInt(maxPageSize, 10, 64); err == nil {
+			config.Analyzer.MaxPageSize = size
+		}
+	}
+
+	if maxNodes := os.Getenv("MAX_NODES"); maxNodes != "" {
+		if nodes, err := strconv.Atoi(maxNodes); err == nil {
+			config.Analyzer.MaxNodes = nodes
+		}
+	}
+
+	if maxDepth := os.Getenv("MAX_DEPTH"); maxDepth != "" {
+		if depth, err := strconv.Atoi(maxDepth); err == nil {
+			config.Analyzer.MaxDepth = depth
+		}
+	}
+
+	if streamingThreshold := os.Getenv("STREAMING_THRESHOLD"); streamingThreshold != "" {
+		if threshold, err := strconv.ParseInt(streamingThreshold, 10, 64); err == nil {
+			config.Analyzer.StreamingThreshold = threshold
+		}
+	}
+
+	if maxOutlineEntries := os.Getenv("MAX_OUTLINE_ENTRIES"); maxOutlineEntries != "" {
+		if entries, err := strconv.Atoi(maxOutlineEntries); err == nil {
+			config.Analyzer.MaxOutlineEntries = entries
+		}
+	}
+
+	if maxMetaRefreshes := os.Getenv("MAX_META_REFRESHES"); maxMetaRefreshes != "" {
+		if refreshes, err := strconv.Atoi(maxMetaRefreshes); err == nil {
+			config.Analyzer.MaxMetaRefreshes = refreshes
+		}
+	}
+
+	if maxFrames := os.Getenv("MAX_FRAMES"); maxFrames != "" {
+		if frames, err := strconv.Atoi(maxFrames); err == nil {
+			config.Analyzer.MaxFrames = frames
+		}
+	}
+
+	if internalSubdomains := os.Getenv("INTERNAL_SUBDOMAINS"); internalSubdomains != "" {
+		config.Analyzer.InternalSubdomains = internalSubdomains == "true"
+	}
+
+	if stripTrackingParams := os.Getenv("STRIP_TRACKING_PARAMS"); stripTrackingParams != "" {
+		config.Analyzer.StripTrackingParams = stripTrackingParams == "true"
+	}
+
+	if batchConcurrency := os.Getenv("BATCH_CONCURRENCY"); batchConcurrency != "" {
+		if concurrency, err := strconv.Atoi(batchConcurrency); err == nil {
+			config.Analyzer.BatchConcurrency = concurrency
+		}
+	}
+
+	if storageDriver := os.Getenv("STORAGE_DRIVER"); storageDriver != "" {
+		config.Storage.Driver = storageDriver
+	}
+
+	if storagePath := os.Getenv("STORAGE_PATH"); storagePath != "" {
+		config.Storage.Path = storagePath
+	}
+
+	if webhookSecret := os.Getenv("WEBHOOK_SECRET"); webhookSecret != "" {
+		config.Webhook.Secret = webhookSecret
+	}
+
+	if allowPrivate := os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS"); allowPrivate != "" {
+		config.Webhook.AllowPrivateNetworks = allowPrivate == "true"
+	}
+
+	if maxAge := os.Getenv("HISTORY_MAX_AGE"); maxAge != "" {
+		if age, err := time.ParseDuration(maxAge); err == nil {
+			config.Storage.MaxAge = age
+		}
+	}
+
+	if maxRows := os.Getenv("HISTORY_MAX_ROWS"); maxRows != "" {
+		if rows, err := strconv.Atoi(maxRows); err == nil {
+			config.Storage.MaxRows = rows
+		}
+	}
+
+	if trendURLs := os.Getenv("TREND_GAUGE_URLS"); trendURLs != "" {
+		if urls, err := strconv.Atoi(trendURLs); err == nil {
+			config.Storage.TrendGaugeURLs = urls
+		}
+	}
+
+	if jwtIssuer := os.Getenv("JWT_ISSUER"); jwtIssuer != "" {
+		config.Auth.JWT.Enabled = true
+		config.Auth.JWT.Issuer = jwtIssuer
+	}
+
+	if jwtAudience := os.Getenv("JWT_AUDIENCE"); jwtAudience != "" {
+		config.Auth.JWT.Audience = jwtAudience
+	}
+
+	if rateLimitEnabled := os.Getenv("RATE_LIMIT_ENABLED"); rateLimitEnabled != "" {
+		config.RateLimit.Enabled = rateLimitEnabled == "true"
+	}
+
+	if requestsPerMinute := os.Getenv("RATE_LIMIT_RPM"); requestsPerMinute != "" {
+		if rpm, err := strconv.Atoi(requestsPerMinute); err == nil {
+			config.RateLimit.RequestsPerMinute = rpm
+		}
+	}
+
+	if dailyQuota := os.Getenv("RATE_LIMIT_DAILY_QUOTA"); dailyQuota != "" {
+		if quota, err := strconv.Atoi(dailyQuota); err == nil {
+			config.RateLimit.DailyQuota = quota
+		}
+	}
+
+	if idempotencyTTL := os.Getenv("IDEMPOTENCY_TTL"); idempotencyTTL != "" {
+		if ttl, err := time.ParseDuration(idempotencyTTL); err == nil {
+			config.Idempotency.TTL = ttl
+		}
+	}
+
+	if cacheDriver := os.Getenv("CACHE_DRIVER"); cacheDriver != "" {
+		config.Cache.Driver = cacheDriver
+	}
+
+	if resultTTL := os.Getenv("CACHE_RESULT_TTL"); resultTTL != "" {
+		if ttl, err := time.ParseDuration(resultTTL); err == nil {
+			config.Cache.ResultTTL = ttl
+		}
+	}
+
+	if maxEntries := os.Getenv("CACHE_MAX_ENTRIES"); maxEntries != "" {
+		if entries, err := strconv.Atoi(maxEntries); err == nil {
+			config.Cache.MaxEntries = entries
+		}
+	}
+
+	if maxBodySize := os.Getenv("MAX_BODY_SIZE"); maxBodySize != "" {
+		if size, err := strconv.ParseInt(maxBodySize, 10, 64); err == nil {
+			config.BodyLimit.Default = size
+		}
+	}
+
+	if handlerTimeout := os.Getenv("HANDLER_TIMEOUT"); handlerTimeout != "" {
+		if timeout, err := time.ParseDuration(handlerTimeout); err == nil {
+			config.HandlerTimeout.Default = timeout
+		}
+	}
+
+	if tracingEnabled := os.Getenv("TRACING_ENABLED"); tracingEnabled != "" {
+		config.Tracing.Enabled = tracingEnabled == "true"
+	}
+
+	if otlpEndpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); otlpEndpoint != "" {
+		config.Tracing.Endpoint = otlpEndpoint
+	}
+
+	if serviceName := os.Getenv("OTEL_SERVICE_NAME"); serviceName != "" {
+		config.Tracing.ServiceName = serviceName
+	}
+
+	if sampleRatio := os.Getenv("TRACING_SAMPLE_RATIO"); sampleRatio != "" {
+		if ratio, err := strconv.ParseFloat(sampleRatio, 64); err == nil {
+			config.Tracing.SampleRatio = ratio
+		}
+	}
+
+	if exportProvider := os.Getenv("EXPORT_PROVIDER"); exportProvider != "" {
+		config.Export.Provider = exportProvider
+	}
+
+	if exportEndpoint := os.Getenv("EXPORT_ENDPOINT"); exportEndpoint != "" {
+		config.Export.Endpoint = exportEndpoint
+	}
+
+	if exportBucket := os.Getenv("EXPORT_BUCKET"); exportBucket != "" {
+		config.Export.Bucket = exportBucket
+	}
+
+	if accessKeyID := os.Getenv("EXPORT_ACCESS_KEY_ID"); accessKeyID != "" {
+		config.Export.AccessKeyID = accessKeyID
+	}
+
+	if secretAccessKey := os.Getenv("EXPORT_SECRET_ACCESS_KEY"); secretAccessKey != "" {
+		config.Export.SecretAccessKey = secretAccessKey
+	}
+
+	if pageSpeedAPIKey := os.Getenv("PAGESPEED_API_KEY"); pageSpeedAPIKey != "" {
+		config.Analyzer.PageSpeed.APIKey = pageSpeedAPIKey
+	}
+
+	if notifyProvider := os.Getenv("NOTIFY_PROVIDER"); notifyProvider != "" {
+		config.Notify.Provider = notifyProvider
+	}
+
+	if notifyWebhookURL := os.Getenv("NOTIFY_WEBHOOK_URL"); notifyWebhookURL != "" {
+		config.Notify.WebhookURL = notifyWebhookURL
+	}
+
+	if redisAddr := os.Getenv("REDIS_ADDR"); redisAddr != "" {
+		config.Cache.Redis.Addr = redisAddr
+	}
+
+	if redisPassword := os.Getenv("REDIS_PASSWORD"); redisPassword != "" {
+		config.Cache.Redis.Password = redisPassword
+	}
 }
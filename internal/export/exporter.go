@@ -0,0 +1,246 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/render"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+)
+
+// Export providers
+const (
+	ProviderS3  = "s3"
+	ProviderGCS = "gcs"
+)
+
+// formatHTML selects the HTML report; other formats use the result renderers
+const formatHTML = "html"
+
+// ObjectStore stores report objects
+type ObjectStore interface {
+	Put(ctx context.Context, key, contentType string, body []byte) error
+}
+
+// keyData is the data available to the object key template
+type keyData struct {
+	ID   string
+	URL  string
+	Host string
+	Date string
+	Time string
+	Ext  string
+}
+
+// Exporter writes reports for stored results to object storage in the
+// background. Failures are logged and do not affect the analysis.
+type Exporter struct {
+	store  ObjectStore
+	config config.ExportConfig
+	keys   *template.Template
+	report *render.HTMLReport
+	logger *slog.Logger
+
+	// ctx is cancelled when Shutdown gives up on running exports
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+	pending atomic.Int64
+}
+
+// New func creates a new exporter singleton instance for the configured
+// provider. It returns nil when exports are disabled.
+func New(cfg config.ExportConfig, report *render.HTMLReport, logger *slog.Logger) (*Exporter, error) {
+	endpoint := cfg.Endpoint
+	region := cfg.Region
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case ProviderS3:
+		if endpoint == "" {
+			endpoint = "https://s3." + region + ".amazonaws.com"
+		}
+	case ProviderGCS:
+		if endpoint == "" {
+			endpoint = "https://storage.googleapis.com"
+		}
+		region = "auto"
+	default:
+		return nil, fmt.Errorf("unknown export provider %q", cfg.Provider)
+	}
+
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("export bucket is required")
+	}
+
+	store, err := NewS3(endpoint, region, cfg.Bucket, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithStore(store, cfg, report, logger)
+}
+
+// NewWithStore func creates a new exporter singleton instance writing to
+// store, validating the key template and formats
+func NewWithStore(store ObjectStore, cfg config.ExportConfig, report *render.HTMLReport, logger *slog.Logger) (*Exporter, error) {
+	keys, err := template.New("key").Option("missingkey=error").Parse(cfg.KeyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing key template: %w", err)
+	}
+
+	for _, format := range cfg.Formats {
+		if format == formatHTML {
+			continue
+		}
+		if _, err := render.ForFormat(format); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Exporter{
+		store:  store,
+		config: cfg,
+		keys:   keys,
+		report: report,
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Export writes a report in each configured format for record in the
+// background
+func (e *Exporter) Export(record *storage.Record) {
+	e.pending.Add(1)
+	e.running.Add(1)
+	go func() {
+		defer e.running.Done()
+		defer e.pending.Add(-1)
+
+		ctx, cancel := context.WithTimeout(e.ctx, e.config.Timeout)
+		defer cancel()
+
+		for _, format := range e.config.Formats {
+			if err := e.exportFormat(ctx, record, format); err != nil {
+				e.logger.Error("Report export failed",
+					"id", record.ID,
+					"url", record.URL,
+					"format", format,
+					"error", err,
+				)
+			}
+		}
+	}()
+}
+
+// Pending returns the number of exports still running
+func (e *Exporter) Pending() int {
+	return int(e.pending.Load())
+}
+
+// Shutdown waits for running exports. When ctx ends first, the remaining
+// uploads are cancelled and ctx's error is returned.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	if e.Pending() == 0 {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		e.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		e.logger.Warn("Cancelling running report exports", "pending", e.Pending())
+		e.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// exportFormat renders and uploads a single report
+func (e *Exporter) exportFormat(ctx context.Context, record *storage.Record, format string) error {
+	var body bytes.Buffer
+	var contentType string
+
+	switch format {
+	case formatHTML:
+		contentType = "text/html; charset=utf-8"
+		if err := e.report.Render(&body, record); err != nil {
+			return fmt.Errorf("rendering report: %w", err)
+		}
+	case render.FormatJSON:
+		// JSON exports keep the record envelope, like GET /api/v1/results/{id}
+		contentType = "application/json"
+		if err := json.NewEncoder(&body).Encode(record); err != nil {
+			return fmt.Errorf("encoding record: %w", err)
+		}
+	default:
+		renderer, err := render.ForFormat(format)
+		if err != nil {
+			return err
+		}
+		contentType = renderer.ContentType()
+		if err := renderer.Render(&body, record.Result); err != nil {
+			return fmt.Errorf("rendering result: %w", err)
+		}
+	}
+
+	key, err := e.objectKey(record, format)
+	if err != nil {
+		return err
+	}
+
+	if err := e.store.Put(ctx, key, contentType, body.Bytes()); err != nil {
+		return err
+	}
+
+	e.logger.Debug("Report exported", "id", record.ID, "format", format, "key", key)
+	return nil
+}
+
+// objectKey expands the key template for a record and format
+func (e *Exporter) objectKey(record *storage.Record, format string) (string, error) {
+	data := keyData{
+		ID:   record.ID,
+		URL:  record.URL,
+		Date: record.CreatedAt.Format("2006-01-02"),
+		Time: record.CreatedAt.Format("150405"),
+		Ext:  extension(format),
+	}
+	if u, err := url.Parse(record.URL); err == nil {
+		data.Host = u.Hostname()
+	}
+
+	var key strings.Builder
+	if err := e.keys.Execute(&key, data); err != nil {
+		return "", fmt.Errorf("expanding key template: %w", err)
+	}
+	return strings.TrimPrefix(key.String(), "/"), nil
+}
+
+// extension returns the file extension for a format
+func extension(format string) string {
+	switch format {
+	case render.FormatMarkdown, "md":
+		return "md"
+	case render.FormatJUnit:
+		return "xml"
+	default:
+		return format
+	}
+}
@@ -0,0 +1,158 @@
+package export
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+func TestS3_Put(t *testing.T) {
+	var gotPath, gotAuth, gotBody, gotHash string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotAuth, gotBody = r.URL.EscapedPath(), r.Header.Get("Authorization"), string(body)
+		gotHash = r.Header.Get("X-Amz-Content-Sha256")
+	}))
+	defer server.Close()
+
+	store, err := NewS3(server.URL, "eu-west-1", "reports", "AKID", "secret", time.Second)
+	if err != nil {
+		t.Fatalf("NewS3 failed: %v", err)
+	}
+	store.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	if err := store.Put(context.Background(), "a b/c.json", "application/json", []byte(`{}`)); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	if gotPath != "/reports/a%20b/c.json" {
+		t.Errorf("Unexpected path %q", gotPath)
+	}
+	if gotBody != `{}` || gotHash != sha256Hex([]byte(`{}`)) {
+		t.Errorf("Unexpected body %q or hash %q", gotBody, gotHash)
+	}
+	prefix := "AWS4-HMAC-SHA256 Credential=AKID/20240501/eu-west-1/s3/aws4_request, SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date, Signature="
+	if !strings.HasPrefix(gotAuth, prefix) || len(gotAuth) != len(prefix)+64 {
+		t.Errorf("Unexpected Authorization header %q", gotAuth)
+	}
+}
+
+func TestS3_PutError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	store, err := NewS3(server.URL, "us-east-1", "reports", "AKID", "secret", time.Second)
+	if err != nil {
+		t.Fatalf("NewS3 failed: %v", err)
+	}
+	if err := store.Put(context.Background(), "key", "text/plain", nil); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected an HTTP 403 error, got %v", err)
+	}
+}
+
+// memoryObjects is an in-process ObjectStore for tests
+type memoryObjects struct {
+	mu      sync.Mutex
+	objects map[string]string
+	done    chan struct{}
+}
+
+func (m *memoryObjects) Put(ctx context.Context, key, contentType string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = contentType
+	m.done <- struct{}{}
+	return nil
+}
+
+func TestExporter_Export(t *testing.T) {
+	objects := &memoryObjects{objects: make(map[string]string), done: make(chan struct{}, 2)}
+	cfg := config.ExportConfig{
+		KeyTemplate: "{{.Host}}/{{.Date}}/{{.ID}}.{{.Ext}}",
+		Formats:     []string{"json", "markdown"},
+		Timeout:     time.Second,
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	exporter, err := NewWithStore(objects, cfg, nil, logger)
+	if err != nil {
+		t.Fatalf("NewWithStore failed: %v", err)
+	}
+
+	exporter.Export(&storage.Record{
+		ID:        "abc",
+		URL:       "https://example.com/page",
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Result:    &analyzer.Result{URL: "https://example.com/page", Headings: map[string]int{}},
+	})
+	for range cfg.Formats {
+		<-objects.done
+	}
+
+	objects.mu.Lock()
+	defer objects.mu.Unlock()
+	if objects.objects["example.com/2024-05-01/abc.json"] != "application/json" {
+		t.Errorf("Missing JSON export, got %v", objects.objects)
+	}
+	if _, ok := objects.objects["example.com/2024-05-01/abc.md"]; !ok {
+		t.Errorf("Missing Markdown export, got %v", objects.objects)
+	}
+}
+
+func TestNewWithStore_RejectsUnknownFormat(t *testing.T) {
+	cfg := config.ExportConfig{KeyTemplate: "{{.ID}}", Formats: []string{"pdf"}}
+	if _, err := NewWithStore(&memoryObjects{}, cfg, nil, slog.Default()); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+// blockingObjects is an ObjectStore whose uploads only end when cancelled
+type blockingObjects struct {
+	started chan struct{}
+}
+
+func (b *blockingObjects) Put(ctx context.Context, key, contentType string, body []byte) error {
+	b.started <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestExporter_Shutdown(t *testing.T) {
+	objects := &blockingObjects{started: make(chan struct{}, 1)}
+	cfg := config.ExportConfig{KeyTemplate: "{{.ID}}.{{.Ext}}", Formats: []string{"json"}, Timeout: time.Minute}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	exporter, err := NewWithStore(objects, cfg, nil, logger)
+	if err != nil {
+		t.Fatalf("NewWithStore failed: %v", err)
+	}
+
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected an idle exporter to shut down cleanly, got %v", err)
+	}
+
+	exporter.Export(&storage.Record{ID: "abc", URL: "https://example.com/", Result: &analyzer.Result{}})
+	<-objects.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := exporter.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected the deadline error, got %v", err)
+	}
+	if exporter.Pending() != 0 {
+		t.Errorf("Expected the upload to be cancelled, %d still pending", exporter.Pending())
+	}
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/openapi"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/internal/webhook"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// redacted replaces secrets in the effective configuration
+const redacted = "[redacted]"
+
+// Admin handles operator requests for configuration, runtime statistics,
+// and live tuning
+type Admin struct {
+	mu        sync.Mutex
+	config    *config.Config
+	analyzer  *analyzer.Analyzer
+	store     storage.ResultStore
+	webhooks  *webhook.Dispatcher
+	validator *openapi.Validator
+	startTime time.Time
+	logger    *slog.Logger
+}
+
+// workersRequest is the body of a worker limit update
+type workersRequest struct {
+	MaxWorkers int `json:"max_workers"`
+}
+
+// NewAdmin func creates a new admin singleton handler
+func NewAdmin(cfg *config.Config, analyzer *analyzer.Analyzer, store storage.ResultStore, webhooks *webhook.Dispatcher, validator *openapi.Validator, logger *slog.Logger) *Admin {
+	return &Admin{
+		config:    cfg,
+		analyzer:  analyzer,
+		store:     store,
+		webhooks:  webhooks,
+		validator: validator,
+		startTime: time.Now(),
+		logger:    logger,
+	}
+}
+
+// SetConfig replaces the configuration reported by ServeConfig after a
+// reload
+func (h *Admin) SetConfig(cfg *config.Config) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.config = cfg
+}
+
+// ServeConfig returns the effective configuration with secrets redacted.
+// Settings changed at runtime are reported with their current values.
+func (h *Admin) ServeConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.mu.Lock()
+	loaded := h.config
+	h.mu.Unlock()
+
+	effective := *loaded
+	effective.Analyzer.MaxWorkers = h.analyzer.MaxWorkers()
+	if effective.PprofToken != "" {
+		effective.PprofToken = redacted
+	}
+	if effective.Webhook.Secret != "" {
+		effective.Webhook.Secret = redacted
+	}
+	if effective.Cache.Redis.Password != "" {
+		effective.Cache.Redis.Password = redacted
+	}
+	if effective.Export.SecretAccessKey != "" {
+		effective.Export.SecretAccessKey = redacted
+	}
+	if effective.Analyzer.PageSpeed.APIKey != "" {
+		effective.Analyzer.PageSpeed.APIKey = redacted
+	}
+	// Slack and Teams webhook URLs carry their credentials in the path
+	if effective.Notify.WebhookURL != "" {
+		effective.Notify.WebhookURL = redacted
+	}
+	effective.Auth.APIKeys = make([]config.APIKeyConfig, len(loaded.Auth.APIKeys))
+	for i, key := range loaded.Auth.APIKeys {
+		key.Key = redacted
+		effective.Auth.APIKeys[i] = key
+	}
+
+	// Round-trip through YAML so the response uses the configuration file's
+	// keys and duration format
+	raw, err := yaml.Marshal(&effective)
+	if err != nil {
+		h.logger.Error("Failed to encode configuration", "error", err)
+		writeErrorResponse(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Internal server error")
+		return
+	}
+	var view map[string]interface{}
+	if err := yaml.Unmarshal(raw, &view); err != nil {
+		h.logger.Error("Failed to encode configuration", "error", err)
+		writeErrorResponse(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Internal server error")
+		return
+	}
+
+	writeJSON(w, view)
+}
+
+// ServeStats returns runtime statistics
+func (h *Admin) ServeStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	records, err := h.store.Count(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to count stored results", "error", err)
+		writeErrorResponse(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Internal server error")
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"uptime":     time.Since(h.startTime).String(),
+		"goroutines": runtime.NumGoroutine(),
+		"analyzer":   h.analyzer.Stats(),
+		"webhooks": map[string]int{
+			"pending": h.webhooks.Pending(),
+		},
+		"storage": map[string]int{
+			"records": records,
+		},
+	})
+}
+
+// ServeWorkers reports or changes the analyzer's worker limit. Changes take
+// effect for link checks started afterwards and are not persisted.
+func (h *Admin) ServeWorkers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req workersRequest
+		errs, err := decodeRequest(r, h.validator, openapi.WorkersRequestSchema, &req, h.logger)
+		if err != nil {
+			writeBodyErrorResponse(w, r, err, "Invalid request")
+			return
+		}
+		if len(errs) > 0 {
+			writeValidationErrorResponse(w, r, errs)
+			return
+		}
+
+		h.logger.Info("Worker limit update requested",
+			"max_workers", req.MaxWorkers,
+			"remote_addr", r.RemoteAddr,
+		)
+		h.analyzer.SetMaxWorkers(req.MaxWorkers)
+	default:
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	writeJSON(w, workersRequest{MaxWorkers: h.analyzer.MaxWorkers()})
+}
+
+// ServeHistory exports the stored history with GET, as NDJSON or, with
+// format=json, a JSON array, and imports a dump with POST. Imported records
+// keep their IDs; records already stored are skipped.
+func (h *Admin) ServeHistory(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.exportHistory(w, r)
+	case http.MethodPost:
+		h.importHistory(w, r)
+	default:
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// exportHistory streams every stored record
+func (h *Admin) exportHistory(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = storage.DumpNDJSON
+	}
+
+	contentType := "application/x-ndjson"
+	switch format {
+	case storage.DumpNDJSON:
+	case storage.DumpJSON:
+		contentType = "application/json"
+	default:
+		writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeUnsupportedFormat, "format must be ndjson or json")
+		return
+	}
+
+	h.logger.Info("History export requested", "format", format, "remote_addr", r.RemoteAddr)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="history.%s"`, format))
+
+	// Headers are already sent once records stream, so failures can only
+	// be logged
+	if err := storage.Dump(r.Context(), h.store, w, format); err != nil {
+		h.logger.Error("History export failed", "error", err, "remote_addr", r.RemoteAddr)
+	}
+}
+
+// importHistory loads a dump from the request body
+func (h *Admin) importHistory(w http.ResponseWriter, r *http.Request) {
+	stats, err := storage.Load(r.Context(), h.store, r.Body)
+	if err != nil {
+		h.logger.Error("History import failed",
+			"error", err,
+			"imported", stats.Imported,
+			"remote_addr", r.RemoteAddr,
+		)
+		writeBodyErrorResponse(w, r, err, fmt.Sprintf("Import stopped after %d records: %v", stats.Imported, err))
+		return
+	}
+
+	h.logger.Info("History imported",
+		"imported", stats.Imported,
+		"skipped", stats.Skipped,
+		"remote_addr", r.RemoteAddr,
+	)
+
+	writeJSON(w, stats)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/openapi"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/internal/webhook"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+func newTestAdmin(cfg *config.Config) (*Admin, *analyzer.Analyzer) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	service := analyzer.NewWithOptions(analyzer.WithConfig(cfg.Analyzer), analyzer.WithLogger(logger))
+	return NewAdmin(cfg, service, storage.NewMemoryStore(), webhook.New(cfg.Webhook, logger), openapi.NewValidator(openapi.NewDocument("test")), logger), service
+}
+
+func TestServeWorkers(t *testing.T) {
+	cfg := &config.Config{Analyzer: config.AnalyzerConfig{MaxWorkers: 10}}
+	handler, service := newTestAdmin(cfg)
+
+	request := func(method, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeWorkers(rec, httptest.NewRequest(method, "/api/v1/admin/workers", strings.NewReader(body)))
+		return rec
+	}
+
+	testCases := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		want   int
+	}{
+		{"current limit", http.MethodGet, "", http.StatusOK, 10},
+		{"lower the limit", http.MethodPut, `{"max_workers":3}`, http.StatusOK, 3},
+		{"reads the new limit", http.MethodGet, "", http.StatusOK, 3},
+		{"zero", http.MethodPut, `{"max_workers":0}`, http.StatusBadRequest, 3},
+		{"above the maximum", http.MethodPut, `{"max_workers":1001}`, http.StatusBadRequest, 3},
+		{"not a number", http.MethodPut, `{"max_workers":"many"}`, http.StatusBadRequest, 3},
+		{"missing limit", http.MethodPut, `{}`, http.StatusBadRequest, 3},
+		{"unsupported method", http.MethodDelete, "", http.StatusMethodNotAllowed, 3},
+		{"raise the limit", http.MethodPut, `{"max_workers":50}`, http.StatusOK, 50},
+	}
+	for _, tc := range testCases {
+		rec := request(tc.method, tc.body)
+		if rec.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tc.name, tc.status, rec.Code, rec.Body)
+			continue
+		}
+		if service.MaxWorkers() != tc.want {
+			t.Errorf("%s: expected the analyzer to use %d workers, got %d", tc.name, tc.want, service.MaxWorkers())
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		var got workersRequest
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil || got.MaxWorkers != tc.want {
+			t.Errorf("%s: expected max_workers %d in the response, got %+v, %v", tc.name, tc.want, got, err)
+		}
+	}
+}
+
+func TestServeConfig(t *testing.T) {
+	cfg := &config.Config{
+		PprofToken: "pprof-secret",
+		Analyzer:   config.AnalyzerConfig{MaxWorkers: 10},
+		Webhook:    config.WebhookConfig{Secret: "whsec_test"},
+		Auth: config.AuthConfig{
+			APIKeys: []config.APIKeyConfig{{Name: "ci", Key: "key-secret"}},
+		},
+	}
+	handler, service := newTestAdmin(cfg)
+	// Runtime changes show in the effective configuration
+	service.SetMaxWorkers(4)
+
+	rec := httptest.NewRecorder()
+	handler.ServeConfig(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/config", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	body := rec.Body.String()
+	for _, secret := range []string{"pprof-secret", "whsec_test", "key-secret"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("Expected %s to be redacted, got %s", secret, body)
+		}
+	}
+
+	var view struct {
+		Analyzer struct {
+			MaxWorkers int `json:"max_workers"`
+		} `json:"analyzer"`
+		Auth struct {
+			APIKeys []map[string]interface{} `json:"api_keys"`
+		} `json:"auth"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &view); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if view.Analyzer.MaxWorkers != 4 {
+		t.Errorf("Expected the current max_workers 4, got %d", view.Analyzer.MaxWorkers)
+	}
+	if len(view.Auth.APIKeys) != 1 || view.Auth.APIKeys[0]["name"] != "ci" || view.Auth.APIKeys[0]["key"] != redacted {
+		t.Errorf("Expected the API key's name with its key redacted, got %+v", view.Auth.APIKeys)
+	}
+	// The loaded configuration is not modified
+	if cfg.PprofToken != "pprof-secret" || cfg.Auth.APIKeys[0].Key != "key-secret" {
+		t.Error("Expected redaction to leave the loaded configuration alone")
+	}
+}
@@ -3,37 +3,79 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"time"
 
-	"web-analyzer/pkg/analyzer"
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/cache"
+	"github.com/anjula-paulus/web-analyzer/internal/csp"
+	"github.com/anjula-paulus/web-analyzer/internal/csrf"
+	"github.com/anjula-paulus/web-analyzer/internal/export"
+	"github.com/anjula-paulus/web-analyzer/internal/notify"
+	"github.com/anjula-paulus/web-analyzer/internal/openapi"
+	"github.com/anjula-paulus/web-analyzer/internal/render"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/internal/wayback"
+	"github.com/anjula-paulus/web-analyzer/internal/webhook"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
 )
 
 // Analyzer handles analyzer-related HTTP requests
 type Analyzer struct {
-	analyzer *analyzer.Analyzer
-	template *template.Template
-	logger   *slog.Logger
+	analyzer  analyzer.PageAnalyzer
+	validator *openapi.Validator
+	webhooks  *webhook.Dispatcher
+	store     storage.ResultStore
+	results   *cache.Results
+	exporter  *export.Exporter
+	notifier  *notify.Notifier
+	archive   *wayback.Client
+	template  *template.Template
+	logger    *slog.Logger
 }
 
-// NewAnalyzer func creates a new analyzer singleton handler
-func NewAnalyzer(analyzer *analyzer.Analyzer, logger *slog.Logger) *Analyzer {
-	tmpl := template.Must(template.ParseFiles("web/templates/index.html"))
+// NewAnalyzer func creates a new analyzer singleton handler. results,
+// exporter, notifier and archive may be nil to disable result caching,
+// report exports, chat notifications and comparisons with archived
+// snapshots. Without the UI template, e.g. in a serverless bundle, only the
+// API is served.
+func NewAnalyzer(analyzer analyzer.PageAnalyzer, validator *openapi.Validator, webhooks *webhook.Dispatcher, store storage.ResultStore, results *cache.Results, exporter *export.Exporter, notifier *notify.Notifier, archive *wayback.Client, logger *slog.Logger) *Analyzer {
+	tmpl, err := template.ParseFiles("web/templates/index.html")
+	if err != nil {
+		logger.Warn("UI template unavailable, serving the API only", "error", err)
+	}
 
 	return &Analyzer{
-		analyzer: analyzer,
-		template: tmpl,
-		logger:   logger,
+		analyzer:  analyzer,
+		validator: validator,
+		webhooks:  webhooks,
+		store:     store,
+		results:   results,
+		exporter:  exporter,
+		notifier:  notifier,
+		archive:   archive,
+		template:  tmpl,
+		logger:    logger,
 	}
 }
 
+// indexView is the data rendered by the index template
+type indexView struct {
+	Nonce     string
+	CSRFToken string
+}
+
 // ServeIndex renders the main page
 func (a *Analyzer) ServeIndex(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
+	if r.URL.Path != "/" || a.template == nil {
 		a.logger.Debug("404 request", "path", r.URL.Path, "method", r.Method)
-		http.NotFound(w, r)
+		writeErrorResponse(w, r, http.StatusNotFound, apierror.CodeNotFound, "Not found")
 		return
 	}
 
@@ -41,12 +83,16 @@ func (a *Analyzer) ServeIndex(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 
-	if err := a.template.Execute(w, nil); err != nil {
+	data := indexView{
+		Nonce:     csp.NonceFromContext(r.Context()),
+		CSRFToken: csrf.Issue(w, r),
+	}
+	if err := a.template.Execute(w, data); err != nil {
 		a.logger.Error("Template execution failed",
 			"error", err,
 			"remote_addr", r.RemoteAddr,
 		)
-		http.Error(w, "Template error", http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Template error")
 		return
 	}
 
@@ -60,23 +106,23 @@ func (a *Analyzer) ServeAnalyze(w http.ResponseWriter, r *http.Request) {
 			"method", r.Method,
 			"remote_addr", r.RemoteAddr,
 		)
-		writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	var req analyzer.Request
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		a.logger.Warn("Invalid JSON payload",
-			"error", err,
-			"remote_addr", r.RemoteAddr,
-		)
-		writeErrorResponse(w, http.StatusBadRequest, "Invalid request")
+	renderer, err := render.ForFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeUnsupportedFormat, err.Error())
 		return
 	}
 
-	if req.URL == "" {
-		a.logger.Warn("Empty URL in request", "remote_addr", r.RemoteAddr)
-		writeErrorResponse(w, http.StatusBadRequest, "URL is required")
+	req, errs, err := a.readAnalyzeRequest(r)
+	if err != nil {
+		writeBodyErrorResponse(w, r, err, "Invalid request")
+		return
+	}
+	if len(errs) > 0 {
+		writeValidationErrorResponse(w, r, errs)
 		return
 	}
 
@@ -85,14 +131,25 @@ func (a *Analyzer) ServeAnalyze(w http.ResponseWriter, r *http.Request) {
 		"remote_addr", r.RemoteAddr,
 	)
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-	defer cancel()
+	// The timeout middleware bounds the analysis by the route's timeout
+	ctx := r.Context()
 
 	start := time.Now()
 
 	// Perform analysis
-	result, err := a.analyzer.AnalyzeURL(ctx, req.URL)
+	result, err := a.analyze(ctx, r, req)
+	if errors.Is(err, analyzer.ErrBusy) {
+		// Backpressure is not a result of the page, so it is reported as
+		// an error rather than in the result. The analysis never started
+		// and the client retries it, so no callback is delivered either.
+		a.logger.Warn("Analysis rejected, server busy",
+			"url", req.URL,
+			"remote_addr", r.RemoteAddr,
+		)
+		w.Header().Set("Retry-After", busyRetryAfter)
+		writeErrorResponse(w, r, http.StatusTooManyRequests, apierror.CodeServerBusy, err.Error())
+		return
+	}
 	if err != nil {
 		a.logger.Error("Analysis failed",
 			"url", req.URL,
@@ -117,23 +174,257 @@ func (a *Analyzer) ServeAnalyze(w http.ResponseWriter, r *http.Request) {
 		)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(result); err != nil {
+	result = judge(req, result)
+	a.notifyCallback(req, result)
+
+	w.Header().Set("Content-Type", renderer.ContentType())
+	if err := renderer.Render(w, result); err != nil {
 		a.logger.Error("Failed to encode response",
 			"error", err,
 			"url", req.URL,
 			"remote_addr", r.RemoteAddr,
 		)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeErrorResponse(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Internal server error")
+		return
+	}
+}
+
+// readAnalyzeRequest reads and validates an analyze request body. Schema
+// violations are returned separately from malformed payloads.
+func (a *Analyzer) readAnalyzeRequest(r *http.Request) (*analyzer.Request, []openapi.FieldError, error) {
+	var req analyzer.Request
+	if errs, err := a.decodeRequest(r, openapi.AnalyzeRequestSchema, &req); err != nil || len(errs) > 0 {
+		return nil, errs, err
+	}
+
+	if req.CallbackURL != "" && !isCallbackURL(req.CallbackURL) {
+		return nil, []openapi.FieldError{{Field: "callback_url", Message: "must be an absolute http or https URL"}}, nil
+	}
+	for i, selector := range req.Extract {
+		if err := analyzer.ValidateSelector(selector); err != nil {
+			return nil, []openapi.FieldError{{Field: fmt.Sprintf("extract[%d]", i), Message: err.Error()}}, nil
+		}
+	}
+
+	return &req, nil, nil
+}
+
+// decodeRequest decodes a request body using the handler's validator
+func (a *Analyzer) decodeRequest(r *http.Request, schemaName string, dst interface{}) ([]openapi.FieldError, error) {
+	return decodeRequest(r, a.validator, schemaName, dst, a.logger)
+}
+
+// decodeRequest reads a JSON request body, validates it against the named
+// schema, and decodes it into dst
+func decodeRequest(r *http.Request, validator *openapi.Validator, schemaName string, dst interface{}, logger *slog.Logger) ([]openapi.FieldError, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logger.Warn("Failed to read request body",
+			"error", err,
+			"remote_addr", r.RemoteAddr,
+		)
+		return nil, err
+	}
+
+	if errs := validator.Validate(schemaName, body); len(errs) > 0 {
+		logger.Warn("Request failed schema validation",
+			"schema", schemaName,
+			"errors", errs,
+			"remote_addr", r.RemoteAddr,
+		)
+		return errs, nil
+	}
+
+	if err := json.Unmarshal(body, dst); err != nil {
+		logger.Warn("Invalid JSON payload",
+			"error", err,
+			"remote_addr", r.RemoteAddr,
+		)
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// analyze returns a cached result for the request when there is one, and
+// otherwise analyzes the URL, records the result and caches it unless it is
+// partial. The force=true query parameter skips the cache lookup. Requests
+// with extractions or a device bypass the cache, which is keyed by the
+// normalized URL and sections.
+func (a *Analyzer) analyze(ctx context.Context, r *http.Request, req *analyzer.Request) (*analyzer.Result, error) {
+	return a.analyzeRequest(ctx, req, r.URL.Query().Get("force") == "true")
+}
+
+// analyzeRequest is analyze for callers other than the REST handlers, such
+// as GraphQL; force skips the cache lookup
+func (a *Analyzer) analyzeRequest(ctx context.Context, req *analyzer.Request, force bool) (*analyzer.Result, error) {
+	req.URL = a.analyzer.NormalizeURL(req.URL)
+	cacheable := a.results != nil && len(req.Extract) == 0 && req.Device == ""
+	if cacheable && !force {
+		if result, ok := a.results.Get(ctx, req.URL, req.Sections); ok {
+			a.logger.Debug("Serving cached result", "url", req.URL)
+			countAnalyses(ctx, sourceCache, 1)
+			return result, nil
+		}
+	}
+
+	result, err := a.analyzer.AnalyzeURL(ctx, req.URL, analyzer.IncludeSections(req.Sections...), analyzer.Extract(req.Extract...), analyzer.AsDevice(req.Device))
+	if err != nil {
+		return nil, err
+	}
+	countAnalyses(ctx, sourceAnalyzed, 1)
+
+	// Record results cut short by shutdown too, so the work is not lost
+	a.recordResult(context.WithoutCancel(ctx), req, result)
+
+	if cacheable && !result.Partial {
+		a.results.Set(ctx, req.URL, req.Sections, result)
+	}
+	return result, nil
+}
+
+// analyzeMany analyzes urls as batch work the way analyzeRequest analyzes
+// one: the URLs are normalized, cached results are served, and the rest
+// are analyzed with AnalyzeMany, at most BatchConcurrency at a time, then
+// recorded and cached. Failures are reported through Result.Error.
+func (a *Analyzer) analyzeMany(ctx context.Context, urls []string) []*analyzer.Result {
+	results := make([]*analyzer.Result, len(urls))
+	var pending []string
+	var indexes []int
+	for i, targetURL := range urls {
+		targetURL = a.analyzer.NormalizeURL(targetURL)
+		if a.results != nil {
+			if result, ok := a.results.Get(ctx, targetURL, nil); ok {
+				countAnalyses(ctx, sourceCache, 1)
+				results[i] = result
+				continue
+			}
+		}
+		pending = append(pending, targetURL)
+		indexes = append(indexes, i)
+	}
+	if len(pending) == 0 {
+		return results
+	}
+
+	for i, result := range a.analyzer.AnalyzeMany(ctx, pending) {
+		results[indexes[i]] = result
+		if result.Error != "" {
+			continue
+		}
+		countAnalyses(ctx, sourceAnalyzed, 1)
+		a.recordResult(context.WithoutCancel(ctx), &analyzer.Request{URL: pending[i]}, result)
+		if a.results != nil && !result.Partial {
+			a.results.Set(ctx, pending[i], nil, result)
+		}
+	}
+	return results
+}
+
+// recordResult stores a successful result and, when the URL was analyzed
+// before, attaches a diff against the previous result. Storage failures are
+// logged and do not fail the request.
+func (a *Analyzer) recordResult(ctx context.Context, req *analyzer.Request, result *analyzer.Result) {
+	// Assign the ID up front so stores that serialize the result keep it
+	result.ID = storage.NewID()
+	result.Permalink = permalink(result.ID)
+	record := &storage.Record{
+		ID:        result.ID,
+		URL:       result.URL,
+		CreatedAt: time.Now().UTC(),
+		Options:   storage.Options{Sections: req.Sections, Device: req.Device},
+		Result:    result,
+	}
+
+	if err := a.store.Save(ctx, record); err != nil {
+		a.logger.Error("Failed to store result", "url", result.URL, "error", err)
+		result.ID = ""
+		result.Permalink = ""
+		return
+	}
+
+	// Export and notify once the diff below is attached
+	if a.exporter != nil {
+		defer a.exporter.Export(record)
+	}
+	if a.notifier != nil {
+		defer a.notifier.Notify(result)
+	}
+
+	previous, diff, err := a.store.Diff(ctx, record)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			a.logger.Error("Failed to load previous result", "url", result.URL, "error", err)
+		}
+		return
+	}
+
+	result.PreviousDiff = diff
+
+	a.logger.Debug("Computed diff against previous result",
+		"url", result.URL,
+		"id", record.ID,
+		"previous_id", previous.ID,
+		"changes", len(result.PreviousDiff.Changes),
+	)
+}
+
+// judge returns the result with the verdict for the request's failure
+// policy, if it has one. The verdict is set on a copy, so cached and stored
+// results are left as they were.
+func judge(req *analyzer.Request, result *analyzer.Result) *analyzer.Result {
+	if req.FailOn == "" {
+		return result
+	}
+	judged := *result
+	judged.Verdict = analyzer.Judge(result, req.FailOn)
+	return &judged
+}
+
+// notifyCallback delivers the result to the request's callback URL, if any
+func (a *Analyzer) notifyCallback(req *analyzer.Request, result *analyzer.Result) {
+	if req.CallbackURL == "" {
+		return
+	}
+
+	a.logger.Debug("Scheduling webhook delivery",
+		"url", req.URL,
+		"callback_url", req.CallbackURL,
+	)
+	a.webhooks.Dispatch(req.CallbackURL, result)
+}
+
+// isCallbackURL reports whether rawURL is an absolute http(s) URL
+func isCallbackURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// busyRetryAfter is the Retry-After value, in seconds, sent when the
+// analyzer is at its concurrency limit
+const busyRetryAfter = "5"
+
+// writeErrorResponse writes an error envelope
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, code, message string) {
+	apierror.Write(w, r, statusCode, code, message)
+}
+
+// writeBodyErrorResponse writes a 413 response when reading the request body
+// hit the body size limit, and otherwise a 400 response with message
+func writeBodyErrorResponse(w http.ResponseWriter, r *http.Request, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeErrorResponse(w, r, http.StatusRequestEntityTooLarge, apierror.CodeRequestTooLarge,
+			fmt.Sprintf("Request body must be at most %d bytes", tooLarge.Limit))
 		return
 	}
+	writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, message)
 }
 
-// writeErrorResponse writes an error response
-func writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]string{
-		"error": message,
-	})
+// writeValidationErrorResponse writes a 400 response listing schema violations
+func writeValidationErrorResponse(w http.ResponseWriter, r *http.Request, errs []openapi.FieldError) {
+	apierror.WriteDetails(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Request failed validation", errs)
 }
@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/cache"
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/openapi"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/internal/webhook"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+
+	"golang.org/x/net/html"
+)
+
+// fakeAnalyzer returns a canned result or error
+type fakeAnalyzer struct {
+	result *analyzer.Result
+	err    error
+	calls  int
+}
+
+func (f *fakeAnalyzer) AnalyzeURL(ctx context.Context, targetURL string, opts ...analyzer.AnalyzeOption) (*analyzer.Result, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	result := *f.result
+	result.URL = targetURL
+	return &result, nil
+}
+
+func (f *fakeAnalyzer) AnalyzeHTML(ctx context.Context, r io.Reader, baseURL string, opts ...analyzer.AnalyzeOption) (*analyzer.Result, error) {
+	return f.AnalyzeURL(ctx, baseURL)
+}
+
+func (f *fakeAnalyzer) AnalyzeNode(ctx context.Context, doc *html.Node, baseURL string, opts ...analyzer.AnalyzeOption) (*analyzer.Result, error) {
+	return f.AnalyzeURL(ctx, baseURL)
+}
+
+func (f *fakeAnalyzer) AnalyzeMany(ctx context.Context, urls []string, opts ...analyzer.AnalyzeOption) []*analyzer.Result {
+	results := make([]*analyzer.Result, len(urls))
+	for i, targetURL := range urls {
+		result, err := f.AnalyzeURL(ctx, targetURL)
+		if err != nil {
+			result = &analyzer.Result{URL: targetURL, Error: err.Error()}
+		}
+		results[i] = result
+	}
+	return results
+}
+
+func (f *fakeAnalyzer) AnalyzeSite(ctx context.Context, domain string, opts ...analyzer.AnalyzeOption) (*analyzer.SiteReport, error) {
+	result, err := f.AnalyzeURL(ctx, "https://"+domain+"/")
+	if err != nil {
+		return nil, err
+	}
+	return &analyzer.SiteReport{Domain: domain, Result: result}, nil
+}
+
+func (f *fakeAnalyzer) NormalizeURL(rawURL string) string {
+	return rawURL
+}
+
+func newTestAnalyzerHandler(pageAnalyzer analyzer.PageAnalyzer) *Analyzer {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	return &Analyzer{
+		analyzer:  pageAnalyzer,
+		validator: openapi.NewValidator(openapi.NewDocument("test")),
+		webhooks:  webhook.New(config.WebhookConfig{}, logger),
+		store:     storage.NewMemoryStore(),
+		logger:    logger,
+	}
+}
+
+func TestServeAnalyzeV2_Success(t *testing.T) {
+	handler := newTestAnalyzerHandler(&fakeAnalyzer{result: &analyzer.Result{Title: "Fake", Headings: map[string]int{}}})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/analyze", strings.NewReader(`{"url":"https://example.com"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeAnalyzeV2(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var result analyzer.Result
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Title != "Fake" {
+		t.Errorf("Expected title 'Fake', got '%s'", result.Title)
+	}
+	if result.ID == "" {
+		t.Error("Expected stored result ID to be set")
+	}
+	if result.Permalink != "/r/"+result.ID {
+		t.Errorf("Permalink = %q, want /r/%s", result.Permalink, result.ID)
+	}
+}
+
+func TestServeAnalyzeV2_Cache(t *testing.T) {
+	fake := &fakeAnalyzer{result: &analyzer.Result{Title: "Fake", Headings: map[string]int{}}}
+	handler := newTestAnalyzerHandler(fake)
+	handler.results = cache.NewResults(cache.NewMemory(10), time.Minute, handler.logger)
+
+	analyze := func(target string) analyzer.Result {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"url":"https://example.com"}`))
+		rec := httptest.NewRecorder()
+		handler.ServeAnalyzeV2(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var result analyzer.Result
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return result
+	}
+
+	first := analyze("/api/v2/analyze")
+	if first.Cached {
+		t.Error("Expected the first result not to be cached")
+	}
+
+	second := analyze("/api/v2/analyze")
+	if !second.Cached || second.AnalyzedAt == nil || second.ID != first.ID {
+		t.Errorf("Expected the cached first result, got %+v", second)
+	}
+	if fake.calls != 1 {
+		t.Errorf("Expected 1 analysis, got %d", fake.calls)
+	}
+
+	forced := analyze("/api/v2/analyze?force=true")
+	if forced.Cached || fake.calls != 2 {
+		t.Errorf("Expected force=true to analyze again, got cached=%v after %d analyses", forced.Cached, fake.calls)
+	}
+}
+
+func TestServeAnalyzeV2_FailOn(t *testing.T) {
+	handler := newTestAnalyzerHandler(&fakeAnalyzer{result: &analyzer.Result{
+		Title:    "Fake",
+		Headings: map[string]int{},
+		Findings: []analyzer.Finding{
+			{ID: "h1-count", Severity: analyzer.SeverityWarning, Category: analyzer.CategorySEO, Message: "expected exactly one h1 heading, found 0"},
+			{ID: "missing-canonical", Severity: analyzer.SeverityInfo, Category: analyzer.CategorySEO, Message: "page has no canonical link"},
+		},
+	}})
+
+	tests := []struct {
+		body string
+		want *analyzer.Verdict
+	}{
+		{`{"url":"https://example.com"}`, nil},
+		{`{"url":"https://example.com","fail_on":"error"}`, &analyzer.Verdict{FailOn: analyzer.SeverityError, Passed: true}},
+		{`{"url":"https://example.com","fail_on":"warning"}`, &analyzer.Verdict{FailOn: analyzer.SeverityWarning, Failures: 1}},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/analyze", strings.NewReader(tt.body))
+		rec := httptest.NewRecorder()
+		handler.ServeAnalyzeV2(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", tt.body, rec.Code, rec.Body.String())
+		}
+
+		var result analyzer.Result
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if fmt.Sprint(result.Verdict) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: verdict = %+v, want %+v", tt.body, result.Verdict, tt.want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/analyze", strings.NewReader(`{"url":"https://example.com","fail_on":"info"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeAnalyzeV2(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for fail_on info, got %d", rec.Code)
+	}
+}
+
+func TestServeAnalyzeV2_InvalidExtract(t *testing.T) {
+	fake := &fakeAnalyzer{result: &analyzer.Result{Headings: map[string]int{}}}
+	handler := newTestAnalyzerHandler(fake)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/analyze", strings.NewReader(`{"url":"https://example.com","extract":["h1","a:hover"]}`))
+	rec := httptest.NewRecorder()
+	handler.ServeAnalyzeV2(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"extract[1]"`) {
+		t.Errorf("Expected the invalid selector's field, got %s", rec.Body.String())
+	}
+	if fake.calls != 0 {
+		t.Errorf("Expected no analysis, got %d", fake.calls)
+	}
+}
+
+func TestServeAnalyzeV2_BodyTooLarge(t *testing.T) {
+	handler := newTestAnalyzerHandler(&fakeAnalyzer{result: &analyzer.Result{}})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/analyze", strings.NewReader(`{"url":"https://example.com"}`))
+	rec := httptest.NewRecorder()
+	req.Body = http.MaxBytesReader(rec, req.Body, 8)
+	handler.ServeAnalyzeV2(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected status 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"request_too_large"`) {
+		t.Errorf("Expected request_too_large code, got %s", rec.Body.String())
+	}
+}
+
+func TestServeAnalyzeV2_Errors(t *testing.T) {
+	testCases := []struct {
+		name           string
+		body           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"timeout", `{"url":"https://example.com"}`, context.DeadlineExceeded, http.StatusGatewayTimeout, "fetch_timeout"},
+		{"canceled", `{"url":"https://example.com"}`, context.Canceled, http.StatusServiceUnavailable, "request_canceled"},
+		{"fetch timeout", `{"url":"https://example.com"}`, fmt.Errorf("failed to fetch HTML: %w", analyzer.ErrFetchTimeout), http.StatusGatewayTimeout, "fetch_timeout"},
+		{"http status", `{"url":"https://example.com"}`, &analyzer.ErrHTTPStatus{Code: 404, Status: "404 Not Found"}, http.StatusBadGateway, "upstream_http_error"},
+		{"not html", `{"url":"https://example.com"}`, analyzer.ErrNotHTML, http.StatusUnprocessableEntity, "not_html"},
+		{"too large", `{"url":"https://example.com"}`, analyzer.ErrTooLarge, http.StatusUnprocessableEntity, "page_too_large"},
+		{"too complex", `{"url":"https://example.com"}`, analyzer.ErrTooComplex, http.StatusUnprocessableEntity, "document_too_complex"},
+		{"fetch failed", `{"url":"https://example.com"}`, errors.New("connection refused"), http.StatusBadGateway, "fetch_failed"},
+		{"invalid device", `{"url":"https://example.com"}`, fmt.Errorf("%w %q", analyzer.ErrInvalidDevice, "watch"), http.StatusBadRequest, "invalid_request"},
+		{"missing url", `{}`, nil, http.StatusBadRequest, "invalid_request"},
+		{"unknown section", `{"url":"https://example.com","sections":["bogus"]}`, nil, http.StatusBadRequest, "invalid_request"},
+		{"unsupported scheme", `{"url":"ftp://example.com"}`, nil, http.StatusBadRequest, "invalid_url"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newTestAnalyzerHandler(&fakeAnalyzer{err: tc.err, result: &analyzer.Result{}})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/analyze", strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			handler.ServeAnalyzeV2(rec, req)
+
+			if rec.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rec.Code)
+			}
+
+			var envelope struct {
+				Error struct {
+					Code string `json:"code"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&envelope); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if envelope.Error.Code != tc.expectedCode {
+				t.Errorf("Expected code %s, got %s", tc.expectedCode, envelope.Error.Code)
+			}
+		})
+	}
+}
+
+func TestClassifyAnalysisError(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"invalid url", fmt.Errorf("%w: missing host", analyzer.ErrInvalidURL), http.StatusBadRequest, apierror.CodeInvalidURL},
+		{"invalid selector", fmt.Errorf("%w: a::before", analyzer.ErrInvalidSelector), http.StatusBadRequest, apierror.CodeInvalidRequest},
+		{"invalid device", fmt.Errorf("%w %q", analyzer.ErrInvalidDevice, "watch"), http.StatusBadRequest, apierror.CodeInvalidRequest},
+		{"busy", analyzer.ErrBusy, http.StatusTooManyRequests, apierror.CodeServerBusy},
+		{"fetch timeout", fmt.Errorf("failed to fetch HTML: %w", analyzer.ErrFetchTimeout), http.StatusGatewayTimeout, apierror.CodeFetchTimeout},
+		{"parse timeout", fmt.Errorf("%w: %w", analyzer.ErrParseTimeout, context.DeadlineExceeded), http.StatusGatewayTimeout, apierror.CodeFetchTimeout},
+		{"deadline", context.DeadlineExceeded, http.StatusGatewayTimeout, apierror.CodeFetchTimeout},
+		{"network timeout", &net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}, http.StatusGatewayTimeout, apierror.CodeFetchTimeout},
+		{"canceled", fmt.Errorf("failed to fetch HTML: %w", context.Canceled), http.StatusServiceUnavailable, apierror.CodeCanceled},
+		{"http status", fmt.Errorf("fetching page: %w", &analyzer.ErrHTTPStatus{Code: 500, Status: "500 Internal Server Error"}), http.StatusBadGateway, apierror.CodeUpstreamStatus},
+		{"not html", analyzer.ErrNotHTML, http.StatusUnprocessableEntity, apierror.CodeNotHTML},
+		{"too large", analyzer.ErrTooLarge, http.StatusUnprocessableEntity, apierror.CodePageTooLarge},
+		{"too complex", analyzer.ErrTooComplex, http.StatusUnprocessableEntity, apierror.CodeDocumentTooComplex},
+		{"network error", &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, http.StatusBadGateway, apierror.CodeFetchFailed},
+		{"other", errors.New("connection refused"), http.StatusBadGateway, apierror.CodeFetchFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if status, code := classifyAnalysisError(tc.err); status != tc.status || code != tc.code {
+				t.Errorf("Expected %d %s, got %d %s", tc.status, tc.code, status, code)
+			}
+		})
+	}
+}
+
+func TestServeAnalyzeV2_Busy(t *testing.T) {
+	handler := newTestAnalyzerHandler(&fakeAnalyzer{err: analyzer.ErrBusy})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/analyze", strings.NewReader(`{"url":"https://example.com"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeAnalyzeV2(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), apierror.CodeServerBusy) {
+		t.Errorf("Expected 429 %s, got %d %s", apierror.CodeServerBusy, rec.Code, rec.Body)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+}
+
+func TestServeAnalyze_RequestErrors(t *testing.T) {
+	// v1 and v2 reject malformed requests alike
+	testCases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   string
+	}{
+		{"method", http.MethodGet, "/api/v2/analyze", "", http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed},
+		{"format", http.MethodPost, "/api/v2/analyze?format=pdf", `{"url":"https://example.com"}`, http.StatusBadRequest, apierror.CodeUnsupportedFormat},
+		{"malformed JSON", http.MethodPost, "/api/v2/analyze", `{"url":`, http.StatusBadRequest, apierror.CodeInvalidRequest},
+		{"unknown field", http.MethodPost, "/api/v2/analyze", `{"url":"https://example.com","depth":2}`, http.StatusBadRequest, apierror.CodeInvalidRequest},
+	}
+
+	for _, tc := range testCases {
+		for version, serve := range map[string]func(*Analyzer) http.HandlerFunc{
+			"v1": func(a *Analyzer) http.HandlerFunc { return a.ServeAnalyze },
+			"v2": func(a *Analyzer) http.HandlerFunc { return a.ServeAnalyzeV2 },
+		} {
+			t.Run(version+" "+tc.name, func(t *testing.T) {
+				fake := &fakeAnalyzer{result: &analyzer.Result{}}
+				req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+				rec := httptest.NewRecorder()
+				serve(newTestAnalyzerHandler(fake))(rec, req)
+
+				if rec.Code != tc.status || !strings.Contains(rec.Body.String(), `"code":"`+tc.code+`"`) {
+					t.Errorf("Expected %d %s, got %d %s", tc.status, tc.code, rec.Code, rec.Body)
+				}
+				if fake.calls != 0 {
+					t.Error("Expected the request not to be analyzed")
+				}
+			})
+		}
+	}
+}
+
+func TestServeAnalyze_V1ReportsErrorsInResult(t *testing.T) {
+	handler := newTestAnalyzerHandler(&fakeAnalyzer{err: &analyzer.ErrHTTPStatus{Code: 404, Status: "404 Not Found"}})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/analyze", strings.NewReader(`{"url":"https://example.com"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeAnalyze(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected v1 to answer 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var result analyzer.Result
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.URL != "https://example.com" || !strings.Contains(result.Error, "404") {
+		t.Errorf("Expected the error in the result, got %+v", result)
+	}
+}
+
+func TestServeAnalyze_BusyCallback(t *testing.T) {
+	var deliveries atomic.Int32
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deliveries.Add(1)
+	}))
+	defer callback.Close()
+
+	testCases := []struct {
+		name   string
+		serve  func(a *Analyzer) http.HandlerFunc
+		err    error
+		status int
+		want   int32
+	}{
+		{"v1 busy", func(a *Analyzer) http.HandlerFunc { return a.ServeAnalyze }, analyzer.ErrBusy, http.StatusTooManyRequests, 0},
+		{"v2 busy", func(a *Analyzer) http.HandlerFunc { return a.ServeAnalyzeV2 }, analyzer.ErrBusy, http.StatusTooManyRequests, 0},
+		// Failed analyses still report their error to the callback
+		{"v2 failed", func(a *Analyzer) http.HandlerFunc { return a.ServeAnalyzeV2 }, analyzer.ErrNotHTML, http.StatusUnprocessableEntity, 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deliveries.Store(0)
+			handler := newTestAnalyzerHandler(&fakeAnalyzer{err: tc.err})
+			handler.webhooks = webhook.New(config.WebhookConfig{Timeout: time.Second, MaxAttempts: 1, AllowPrivateNetworks: true}, handler.logger)
+
+			body := fmt.Sprintf(`{"url":"https://example.com","callback_url":%q}`, callback.URL)
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/analyze", strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			tc.serve(handler)(rec, req)
+
+			if err := handler.webhooks.Shutdown(context.Background()); err != nil {
+				t.Fatalf("Shutdown: %v", err)
+			}
+			if rec.Code != tc.status {
+				t.Errorf("Expected status %d, got %d", tc.status, rec.Code)
+			}
+			if got := deliveries.Load(); got != tc.want {
+				t.Errorf("Expected %d callback deliveries, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestServeCompare_Sections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		description := "Production"
+		if r.URL.Path == "/staging" {
+			description = "Staging"
+			w.Header().Set("Content-Security-Policy", "default-src 'self'")
+		} else {
+			w.Header().Set("X-Frame-Options", "DENY")
+		}
+		fmt.Fprintf(w, `<html lang="en"><head><title>Home</title><meta name="description" content="%s">
+			<link rel="canonical" href="%s"></head><body><h1>Home</h1></body></html>`, description, r.URL.Path)
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := newTestAnalyzerHandler(analyzer.NewWithOptions(analyzer.WithLogger(logger)))
+
+	body := fmt.Sprintf(`{"base_url":%q,"target_url":%q}`, server.URL+"/production", server.URL+"/staging")
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/compare", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeCompare(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp compareResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Diff == nil {
+		t.Fatalf("Expected a diff, got base %+v and target %+v", resp.Base, resp.Target)
+	}
+
+	want := map[string]bool{
+		`meta description changed from "Production" to "Staging"`:                                           true,
+		fmt.Sprintf("canonical URL changed from %q to %q", server.URL+"/production", server.URL+"/staging"): true,
+		"Content-Security-Policy header added":                                                              true,
+		"X-Frame-Options header removed":                                                                    true,
+	}
+	if len(resp.Diff.Summary) != len(want) {
+		t.Errorf("Expected %d summary lines, got %q", len(want), resp.Diff.Summary)
+	}
+	for _, line := range resp.Diff.Summary {
+		if !want[line] {
+			t.Errorf("Unexpected summary line: %q", line)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/render"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// ServeAnalyzeV2 handles URL analysis requests for the v2 API. Unlike v1,
+// failures are reported with a matching HTTP status and an error envelope
+// instead of HTTP 200 with Result.Error set.
+func (a *Analyzer) ServeAnalyzeV2(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.logger.Warn("Invalid method for analyze endpoint",
+			"method", r.Method,
+			"remote_addr", r.RemoteAddr,
+		)
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	renderer, err := render.ForFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeUnsupportedFormat, err.Error())
+		return
+	}
+
+	req, errs, err := a.readAnalyzeRequest(r)
+	if err != nil {
+		writeBodyErrorResponse(w, r, err, "Request body must be valid JSON")
+		return
+	}
+	if len(errs) > 0 {
+		writeValidationErrorResponse(w, r, errs)
+		return
+	}
+
+	if !isAnalyzableURL(req.URL) {
+		a.logger.Warn("Invalid URL in request", "url", req.URL, "remote_addr", r.RemoteAddr)
+		writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeInvalidURL, "URL must be an http or https address with a host")
+		return
+	}
+
+	a.logger.Info("Starting URL analysis",
+		"url", req.URL,
+		"api_version", "v2",
+		"remote_addr", r.RemoteAddr,
+	)
+
+	ctx := r.Context()
+
+	start := time.Now()
+
+	result, err := a.analyze(ctx, r, req)
+	if err != nil {
+		status, code := classifyAnalysisError(err)
+		a.logger.Error("Analysis failed",
+			"url", req.URL,
+			"error", err,
+			"status", status,
+			"code", code,
+			"duration", time.Since(start),
+			"remote_addr", r.RemoteAddr,
+		)
+		if status == http.StatusTooManyRequests {
+			// The analysis never started and the client retries it, so
+			// no callback is delivered
+			w.Header().Set("Retry-After", busyRetryAfter)
+		} else {
+			a.notifyCallback(req, &analyzer.Result{URL: req.URL, Error: err.Error()})
+		}
+		writeErrorResponse(w, r, status, code, err.Error())
+		return
+	}
+
+	a.logger.Info("Analysis completed successfully",
+		"url", req.URL,
+		"api_version", "v2",
+		"duration", time.Since(start),
+		"remote_addr", r.RemoteAddr,
+	)
+
+	result = judge(req, result)
+
+	a.notifyCallback(req, result)
+
+	w.Header().Set("Content-Type", renderer.ContentType())
+	if err := renderer.Render(w, result); err != nil {
+		a.logger.Error("Failed to encode response",
+			"error", err,
+			"url", req.URL,
+			"remote_addr", r.RemoteAddr,
+		)
+	}
+}
+
+// isAnalyzableURL reports whether the URL, after the analyzer's scheme
+// defaulting, is an http(s) URL with a host
+func isAnalyzableURL(rawURL string) bool {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// classifyAnalysisError maps an analysis error to an HTTP status and code
+func classifyAnalysisError(err error) (int, string) {
+	var netErr net.Error
+	var statusErr *analyzer.ErrHTTPStatus
+
+	switch {
+	case errors.Is(err, analyzer.ErrInvalidURL):
+		return http.StatusBadRequest, apierror.CodeInvalidURL
+	case errors.Is(err, analyzer.ErrInvalidSelector), errors.Is(err, analyzer.ErrInvalidDevice):
+		return http.StatusBadRequest, apierror.CodeInvalidRequest
+	case errors.Is(err, analyzer.ErrBusy):
+		return http.StatusTooManyRequests, apierror.CodeServerBusy
+	case errors.Is(err, analyzer.ErrFetchTimeout), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, apierror.CodeFetchTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return http.StatusGatewayTimeout, apierror.CodeFetchTimeout
+	case errors.Is(err, context.Canceled):
+		return http.StatusServiceUnavailable, apierror.CodeCanceled
+	case errors.As(err, &statusErr):
+		return http.StatusBadGateway, apierror.CodeUpstreamStatus
+	case errors.Is(err, analyzer.ErrNotHTML):
+		return http.StatusUnprocessableEntity, apierror.CodeNotHTML
+	case errors.Is(err, analyzer.ErrTooLarge):
+		return http.StatusUnprocessableEntity, apierror.CodePageTooLarge
+	case errors.Is(err, analyzer.ErrTooComplex):
+		return http.StatusUnprocessableEntity, apierror.CodeDocumentTooComplex
+	default:
+		return http.StatusBadGateway, apierror.CodeFetchFailed
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/openapi"
+	"github.com/anjula-paulus/web-analyzer/internal/wayback"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// compareRequest represents a request to compare two URLs
+type compareRequest struct {
+	BaseURL   string `json:"base_url"`
+	TargetURL string `json:"target_url"`
+	// BaseDate selects an archived snapshot of BaseURL as the base
+	BaseDate string `json:"base_date"`
+}
+
+// compareSections are the sections both pages are analyzed with, so the
+// diff covers their meta description, canonical URL and security headers
+var compareSections = []analyzer.Section{analyzer.SectionSEO, analyzer.SectionSecurity}
+
+// compareResponse holds both analyses and their differences
+type compareResponse struct {
+	Base     *analyzer.Result  `json:"base"`
+	Target   *analyzer.Result  `json:"target"`
+	Diff     *analyzer.Diff    `json:"diff,omitempty"`
+	Snapshot *wayback.Snapshot `json:"snapshot,omitempty"`
+}
+
+// ServeCompare analyzes two URLs concurrently and returns a diff of their
+// metrics, e.g. for staging-vs-production checks
+func (a *Analyzer) ServeCompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.logger.Warn("Invalid method for compare endpoint",
+			"method", r.Method,
+			"remote_addr", r.RemoteAddr,
+		)
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req compareRequest
+	errs, err := a.decodeRequest(r, openapi.CompareRequestSchema, &req)
+	if err != nil {
+		writeBodyErrorResponse(w, r, err, "Invalid request")
+		return
+	}
+	if len(errs) > 0 {
+		writeValidationErrorResponse(w, r, errs)
+		return
+	}
+
+	// Constraints the schema cannot express
+	if req.BaseDate == "" && req.TargetURL == "" {
+		errs = append(errs, openapi.FieldError{Field: "target_url", Message: "is required without base_date"})
+	}
+	if req.BaseDate != "" && a.archive == nil {
+		errs = append(errs, openapi.FieldError{Field: "base_date", Message: "is not supported by this server"})
+	}
+	if len(errs) > 0 {
+		writeValidationErrorResponse(w, r, errs)
+		return
+	}
+	if req.TargetURL == "" {
+		req.TargetURL = req.BaseURL
+	}
+
+	a.logger.Info("Starting URL comparison",
+		"base_url", req.BaseURL,
+		"base_date", req.BaseDate,
+		"target_url", req.TargetURL,
+		"remote_addr", r.RemoteAddr,
+	)
+
+	ctx := r.Context()
+
+	start := time.Now()
+
+	var resp compareResponse
+	if req.BaseDate != "" {
+		resp = a.compareWithSnapshot(ctx, req)
+	} else {
+		// The caller is waiting, so the pair does not queue behind batch work
+		results := a.analyzer.AnalyzeMany(ctx, []string{req.BaseURL, req.TargetURL},
+			analyzer.AtPriority(analyzer.PriorityHigh), analyzer.IncludeSections(compareSections...))
+		resp = compareResponse{Base: results[0], Target: results[1]}
+	}
+	for _, result := range []*analyzer.Result{resp.Base, resp.Target} {
+		if result.Error == "" {
+			countAnalyses(ctx, sourceAnalyzed, 1)
+		}
+	}
+
+	if resp.Base.Error == "" && resp.Target.Error == "" {
+		resp.Diff = analyzer.DiffResults(resp.Base, resp.Target)
+	}
+
+	a.logger.Info("URL comparison completed",
+		"base_url", req.BaseURL,
+		"target_url", req.TargetURL,
+		"duration", time.Since(start),
+		"identical", resp.Diff != nil && resp.Diff.Identical,
+		"remote_addr", r.RemoteAddr,
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		a.logger.Error("Failed to encode response",
+			"error", err,
+			"remote_addr", r.RemoteAddr,
+		)
+	}
+}
+
+// compareWithSnapshot analyzes the archived snapshot of the base URL closest
+// to the requested date alongside the live target. Failures, including a
+// URL the archive never captured, are reported in the result's error field
+// like failed live analyses.
+func (a *Analyzer) compareWithSnapshot(ctx context.Context, req compareRequest) compareResponse {
+	// Validated by the schema
+	date, _ := time.Parse(time.DateOnly, req.BaseDate)
+
+	var resp compareResponse
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp.Target = a.analyzeOrError(ctx, req.TargetURL)
+	}()
+
+	snapshot, err := a.archive.Closest(ctx, req.BaseURL, date)
+	if err == nil {
+		resp.Snapshot = snapshot
+		resp.Base, err = a.analyzeSnapshot(ctx, req.BaseURL, snapshot)
+	}
+	if err != nil {
+		a.logger.Warn("Snapshot analysis failed",
+			"base_url", req.BaseURL,
+			"base_date", req.BaseDate,
+			"error", err,
+		)
+		resp.Base = &analyzer.Result{URL: req.BaseURL, Error: err.Error()}
+	}
+
+	wg.Wait()
+	return resp
+}
+
+// analyzeSnapshot analyzes an archived page as if it were pageURL, so links
+// are classified against the original site rather than the archive
+func (a *Analyzer) analyzeSnapshot(ctx context.Context, pageURL string, snapshot *wayback.Snapshot) (*analyzer.Result, error) {
+	body, err := a.archive.Fetch(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return a.analyzer.AnalyzeHTML(ctx, body, pageURL, analyzer.AtPriority(analyzer.PriorityHigh), analyzer.IncludeSections(compareSections...))
+}
+
+// analyzeOrError analyzes a live page, reporting a failure in the result
+func (a *Analyzer) analyzeOrError(ctx context.Context, pageURL string) *analyzer.Result {
+	result, err := a.analyzer.AnalyzeURL(ctx, pageURL, analyzer.AtPriority(analyzer.PriorityHigh), analyzer.IncludeSections(compareSections...))
+	if err != nil {
+		return &analyzer.Result{URL: pageURL, Error: err.Error()}
+	}
+	return result
+}
@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// GraphQL handles GraphQL queries against the analyzer. Analyses go through
+// the REST handler, so they share its URL normalization, result cache and
+// storage.
+type GraphQL struct {
+	analyzer *Analyzer
+	// maxBatchURLs limits the URLs one query may analyze across its
+	// analyze and analyzeMany fields; 0 disables the limit
+	maxBatchURLs int
+	schema       graphql.Schema
+	logger       *slog.Logger
+}
+
+// graphQLRequest represents a GraphQL request body
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// headingCount represents a single heading level count in GraphQL responses
+type headingCount struct {
+	Level string `json:"level"`
+	Count int    `json:"count"`
+}
+
+// NewGraphQL func creates a new GraphQL singleton handler
+func NewGraphQL(analyzer *Analyzer, maxBatchURLs int, logger *slog.Logger) *GraphQL {
+	g := &GraphQL{
+		analyzer:     analyzer,
+		maxBatchURLs: maxBatchURLs,
+		logger:       logger,
+	}
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: g.queryType(),
+	})
+	if err != nil {
+		// The schema is static, so a failure here is a programming error
+		panic(err)
+	}
+	g.schema = schema
+
+	return g
+}
+
+// queryType builds the root query type
+func (g *GraphQL) queryType() *graphql.Object {
+	headingType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "HeadingCount",
+		Fields: graphql.Fields{
+			"level": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"count": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	findingType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Finding",
+		Fields: graphql.Fields{
+			"id":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"severity": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"category": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"message":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"evidence": &graphql.Field{Type: graphql.NewList(graphql.String)},
+		},
+	})
+
+	resultType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "AnalysisResult",
+		Fields: graphql.Fields{
+			"url":         &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: resultField(func(r *analyzer.Result) interface{} { return r.URL })},
+			"htmlVersion": &graphql.Field{Type: graphql.String, Resolve: resultField(func(r *analyzer.Result) interface{} { return r.HTMLVersion })},
+			"title":       &graphql.Field{Type: graphql.String, Resolve: resultField(func(r *analyzer.Result) interface{} { return r.Title })},
+			"titleCount":  &graphql.Field{Type: graphql.Int, Resolve: resultField(func(r *analyzer.Result) interface{} { return r.TitleCount })},
+			"headings": &graphql.Field{
+				Type:    graphql.NewList(headingType),
+				Resolve: resultField(func(r *analyzer.Result) interface{} { return sortedHeadings(r.Headings) }),
+			},
+			"internalLinks":     &graphql.Field{Type: graphql.Int, Resolve: resultField(func(r *analyzer.Result) interface{} { return r.InternalLinks })},
+			"externalLinks":     &graphql.Field{Type: graphql.Int, Resolve: resultField(func(r *analyzer.Result) interface{} { return r.ExternalLinks })},
+			"inaccessibleLinks": &graphql.Field{Type: graphql.Int, Resolve: resultField(func(r *analyzer.Result) interface{} { return r.InaccessibleLinks })},
+			"hasLoginForm":      &graphql.Field{Type: graphql.Boolean, Resolve: resultField(func(r *analyzer.Result) interface{} { return r.HasLoginForm })},
+			"error":             &graphql.Field{Type: graphql.String, Resolve: resultField(func(r *analyzer.Result) interface{} { return r.Error })},
+			"findings":          &graphql.Field{Type: graphql.NewList(findingType), Resolve: resultField(func(r *analyzer.Result) interface{} { return r.Findings })},
+		},
+	})
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"analyze": &graphql.Field{
+				Type:        resultType,
+				Description: "Analyze a single web page",
+				Args: graphql.FieldConfigArgument{
+					"url": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: g.resolveAnalyze,
+			},
+			"analyzeMany": &graphql.Field{
+				Type:        graphql.NewList(resultType),
+				Description: "Analyze several web pages as batch work",
+				Args: graphql.FieldConfigArgument{
+					"urls": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+				},
+				Resolve: g.resolveAnalyzeMany,
+			},
+		},
+	})
+}
+
+// resolveAnalyze starts the analysis in the background and returns a thunk so
+// that aliased analyze fields in one query run concurrently. Analysis
+// failures are reported through Result.Error, matching the REST API
+// behavior.
+func (g *GraphQL) resolveAnalyze(p graphql.ResolveParams) (interface{}, error) {
+	targetURL, _ := p.Args["url"].(string)
+	done := make(chan *analyzer.Result, 1)
+
+	go func() {
+		start := time.Now()
+		result, err := g.analyzer.analyzeRequest(p.Context, &analyzer.Request{URL: targetURL}, false)
+		if err != nil {
+			g.logger.Error("GraphQL analysis failed",
+				"url", targetURL,
+				"error", err,
+				"duration", time.Since(start),
+			)
+			result = &analyzer.Result{
+				URL:   targetURL,
+				Error: err.Error(),
+			}
+		}
+		done <- result
+	}()
+
+	return func() (interface{}, error) {
+		return <-done, nil
+	}, nil
+}
+
+// resolveAnalyzeMany analyzes the requested URLs as batch work
+func (g *GraphQL) resolveAnalyzeMany(p graphql.ResolveParams) (interface{}, error) {
+	rawURLs, _ := p.Args["urls"].([]interface{})
+
+	urls := make([]string, 0, len(rawURLs))
+	for _, raw := range rawURLs {
+		targetURL, _ := raw.(string)
+		urls = append(urls, targetURL)
+	}
+
+	done := make(chan []*analyzer.Result, 1)
+	go func() {
+		done <- g.analyzer.analyzeMany(p.Context, urls)
+	}()
+
+	return func() (interface{}, error) {
+		results := <-done
+		list := make([]interface{}, 0, len(results))
+		for _, result := range results {
+			list = append(list, result)
+		}
+		return list, nil
+	}, nil
+}
+
+// ServeGraphQL executes GraphQL queries sent via GET or POST
+func (g *GraphQL) ServeGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				g.logger.Warn("Invalid GraphQL variables", "error", err, "remote_addr", r.RemoteAddr)
+				writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Invalid variables")
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			g.logger.Warn("Invalid GraphQL payload", "error", err, "remote_addr", r.RemoteAddr)
+			writeBodyErrorResponse(w, r, err, "Invalid request")
+			return
+		}
+	default:
+		g.logger.Warn("Invalid method for GraphQL endpoint",
+			"method", r.Method,
+			"remote_addr", r.RemoteAddr,
+		)
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if req.Query == "" {
+		g.logger.Warn("Empty GraphQL query", "remote_addr", r.RemoteAddr)
+		writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Query is required")
+		return
+	}
+
+	// Every field of the query analyzes, so the analyses are counted and
+	// limited before any of them starts. GET requests must not change
+	// state, and the CSRF protection lets them through, so they cannot
+	// analyze at all.
+	if analyses := queryAnalyses(req); analyses > 0 {
+		if r.Method == http.MethodGet {
+			g.logger.Warn("GraphQL analysis sent via GET", "remote_addr", r.RemoteAddr)
+			w.Header().Set("Allow", http.MethodPost)
+			writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Analyses must be sent via POST")
+			return
+		}
+		if g.maxBatchURLs > 0 && analyses > g.maxBatchURLs {
+			g.logger.Warn("GraphQL query analyzes too many URLs", "urls", analyses, "limit", g.maxBatchURLs, "remote_addr", r.RemoteAddr)
+			writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest,
+				fmt.Sprintf("Query analyzes %d URLs; at most %d are allowed", analyses, g.maxBatchURLs))
+			return
+		}
+	}
+
+	g.logger.Debug("Executing GraphQL query",
+		"operation", req.OperationName,
+		"remote_addr", r.RemoteAddr,
+	)
+
+	result := graphql.Do(graphql.Params{
+		Schema:         g.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        r.Context(),
+	})
+
+	if result.HasErrors() {
+		g.logger.Warn("GraphQL query returned errors",
+			"errors", len(result.Errors),
+			"remote_addr", r.RemoteAddr,
+		)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		g.logger.Error("Failed to encode GraphQL response",
+			"error", err,
+			"remote_addr", r.RemoteAddr,
+		)
+	}
+}
+
+// queryAnalyses returns the number of URLs the request's operation
+// analyzes: one per analyze field and one per URL of each analyzeMany
+// field, following fragments. Queries that do not parse count none and are
+// left to graphql.Do to reject.
+func queryAnalyses(req graphQLRequest) int {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return 0
+	}
+
+	fragments := make(map[string]*ast.FragmentDefinition)
+	var operations []*ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if req.OperationName == "" || (definition.Name != nil && definition.Name.Value == req.OperationName) {
+				operations = append(operations, definition)
+			}
+		}
+	}
+
+	// Only the root fields analyze; the fields selected from their results
+	// do not
+	var count func(set *ast.SelectionSet, spread map[string]bool) int
+	count = func(set *ast.SelectionSet, spread map[string]bool) int {
+		if set == nil {
+			return 0
+		}
+		total := 0
+		for _, selection := range set.Selections {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				switch selection.Name.Value {
+				case "analyze":
+					total++
+				case "analyzeMany":
+					total += countURLs(selection, req.Variables)
+				}
+			case *ast.InlineFragment:
+				total += count(selection.SelectionSet, spread)
+			case *ast.FragmentSpread:
+				name := selection.Name.Value
+				// Cyclic spreads are invalid; graphql.Do rejects them
+				if fragment := fragments[name]; fragment != nil && !spread[name] {
+					spread[name] = true
+					total += count(fragment.SelectionSet, spread)
+					delete(spread, name)
+				}
+			}
+		}
+		return total
+	}
+
+	total := 0
+	for _, operation := range operations {
+		total += count(operation.SelectionSet, make(map[string]bool))
+	}
+	return total
+}
+
+// countURLs returns the number of URLs an analyzeMany field lists, inline
+// or in a variable
+func countURLs(field *ast.Field, variables map[string]interface{}) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "urls" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.ListValue:
+			return len(value.Values)
+		case *ast.Variable:
+			if urls, ok := variables[value.Name.Value].([]interface{}); ok {
+				return len(urls)
+			}
+		}
+		// A single value is coerced to a list of one
+		return 1
+	}
+	return 0
+}
+
+// resultField adapts a Result accessor into a GraphQL field resolver
+func resultField(get func(*analyzer.Result) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		result, ok := p.Source.(*analyzer.Result)
+		if !ok || result == nil {
+			return nil, nil
+		}
+		return get(result), nil
+	}
+}
+
+// sortedHeadings converts the heading map into a list ordered by level
+func sortedHeadings(headings map[string]int) []headingCount {
+	levels := make([]string, 0, len(headings))
+	for level := range headings {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+
+	counts := make([]headingCount, 0, len(levels))
+	for _, level := range levels {
+		counts = append(counts, headingCount{Level: level, Count: headings[level]})
+	}
+	return counts
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/cache"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// trimmingAnalyzer normalizes URLs by dropping a trailing slash
+type trimmingAnalyzer struct {
+	*fakeAnalyzer
+}
+
+func (t trimmingAnalyzer) NormalizeURL(rawURL string) string {
+	return strings.TrimSuffix(rawURL, "/")
+}
+
+// graphQLResponse is the part of a GraphQL response the tests read
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func serveGraphQL(t *testing.T, g *GraphQL, query string) graphQLResponse {
+	t.Helper()
+	body, _ := json.Marshal(graphQLRequest{Query: query})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	rec := httptest.NewRecorder()
+	g.ServeGraphQL(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp graphQLResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestServeGraphQL_AnalysisLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		want      int
+	}{
+		{"within the limit", `{ a: analyze(url: "https://a.example") { url } many: analyzeMany(urls: ["https://b.example", "https://c.example"]) { url } }`, nil, http.StatusOK},
+		{"long list", `{ analyzeMany(urls: ["https://a.example", "https://b.example", "https://c.example", "https://d.example"]) { url } }`, nil, http.StatusBadRequest},
+		{"aliased analyze fields", `{ a: analyze(url: "https://a.example") { url } b: analyze(url: "https://b.example") { url } c: analyze(url: "https://c.example") { url } d: analyze(url: "https://d.example") { url } }`, nil, http.StatusBadRequest},
+		{"several analyzeMany fields", `{ a: analyzeMany(urls: ["https://a.example", "https://b.example"]) { url } b: analyzeMany(urls: ["https://c.example", "https://d.example"]) { url } }`, nil, http.StatusBadRequest},
+		{"fragments", `{ ...pages ... on Query { c: analyze(url: "https://c.example") { url } } } fragment pages on Query { a: analyze(url: "https://a.example") { url } b: analyzeMany(urls: ["https://b.example", "https://d.example"]) { url } }`, nil, http.StatusBadRequest},
+		{"variables", `query($urls: [String!]!) { analyzeMany(urls: $urls) { url } }`, map[string]interface{}{"urls": []interface{}{"https://a.example", "https://b.example", "https://c.example", "https://d.example"}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeAnalyzer{result: &analyzer.Result{Title: "Fake", Headings: map[string]int{}}}
+			handler := newTestAnalyzerHandler(fake)
+			g := NewGraphQL(handler, 3, handler.logger)
+
+			body, _ := json.Marshal(graphQLRequest{Query: tt.query, Variables: tt.variables})
+			rec := httptest.NewRecorder()
+			g.ServeGraphQL(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+			if rec.Code != tt.want {
+				t.Fatalf("Expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+			if tt.want != http.StatusOK && fake.calls != 0 {
+				t.Errorf("Expected no analyses, got %d", fake.calls)
+			}
+		})
+	}
+}
+
+func TestServeGraphQL_GetDoesNotAnalyze(t *testing.T) {
+	fake := &fakeAnalyzer{result: &analyzer.Result{Title: "Fake", Headings: map[string]int{}}}
+	handler := newTestAnalyzerHandler(fake)
+	g := NewGraphQL(handler, 0, handler.logger)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		g.ServeGraphQL(rec, httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(query), nil))
+		return rec
+	}
+
+	if rec := get(`{ analyze(url: "https://example.com") { url } }`); rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("Expected 405 with Allow: POST, got %d %v", rec.Code, rec.Header())
+	}
+	if fake.calls != 0 {
+		t.Errorf("Expected no analyses, got %d", fake.calls)
+	}
+	if rec := get(`{ __schema { queryType { name } } }`); rec.Code != http.StatusOK {
+		t.Errorf("Expected introspection via GET to be served, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServeGraphQL_SharesCacheAndStorage(t *testing.T) {
+	fake := &fakeAnalyzer{result: &analyzer.Result{Title: "Fake", Headings: map[string]int{}}}
+	handler := newTestAnalyzerHandler(trimmingAnalyzer{fake})
+	handler.results = cache.NewResults(cache.NewMemory(10), time.Minute, handler.logger)
+	g := NewGraphQL(handler, 0, handler.logger)
+
+	resp := serveGraphQL(t, g, `{ analyzeMany(urls: ["https://a.example/", "https://b.example"]) { url } }`)
+	if len(resp.Errors) != 0 {
+		t.Fatalf("Unexpected errors: %+v", resp.Errors)
+	}
+	var many []struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(resp.Data["analyzeMany"], &many); err != nil {
+		t.Fatalf("Failed to decode analyzeMany: %v", err)
+	}
+	if len(many) != 2 || many[0].URL != "https://a.example" || many[1].URL != "https://b.example" {
+		t.Errorf("Expected normalized URLs in order, got %+v", many)
+	}
+
+	records, err := handler.store.List(t.Context(), storage.ListOptions{})
+	if err != nil || len(records) != 2 {
+		t.Errorf("Expected 2 stored results, got %d (%v)", len(records), err)
+	}
+
+	// The REST handler and the analyze field are served from the cache
+	// analyzeMany filled
+	resp = serveGraphQL(t, g, `{ analyze(url: "https://a.example/") { url title } }`)
+	if len(resp.Errors) != 0 || fake.calls != 2 {
+		t.Errorf("Expected a cached result after 2 analyses, got %d and %+v", fake.calls, resp.Errors)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v2/analyze", strings.NewReader(`{"url":"https://b.example"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeAnalyzeV2(rec, req)
+	if rec.Code != http.StatusOK || fake.calls != 2 {
+		t.Errorf("Expected a cached REST result after 2 analyses, got status %d after %d", rec.Code, fake.calls)
+	}
+}
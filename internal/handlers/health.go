@@ -1,27 +1,161 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"runtime"
 	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/cache"
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
 )
 
 type Health struct {
+	config    config.HealthConfig
+	store     storage.ResultStore
+	analyzer  *analyzer.Analyzer
+	cache     cache.Cache
+	resolver  *net.Resolver
+	client    *http.Client
 	startTime time.Time
 	logger    *slog.Logger
 }
 
-// NewHealth func creates a new health singleton handler
-func NewHealth(logger *slog.Logger) *Health {
+// NewHealth func creates a new health singleton handler. sharedCache may be
+// nil when caching is disabled.
+func NewHealth(cfg config.HealthConfig, store storage.ResultStore, analyzer *analyzer.Analyzer, sharedCache cache.Cache, logger *slog.Logger) *Health {
 	return &Health{
+		config:   cfg,
+		store:    store,
+		analyzer: analyzer,
+		cache:    sharedCache,
+		resolver: net.DefaultResolver,
+		client: &http.Client{
+			// Any response proves connectivity, so redirects are not followed
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
 		startTime: time.Now(),
 		logger:    logger,
 	}
 }
 
-// ServeHealth returns application health status
+// ServeLiveness reports that the process is running and able to serve
+// requests. It checks no dependencies, so a failing dependency never gets
+// the process restarted.
+func (h *Health) ServeLiveness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	writeJSON(w, map[string]string{"status": "alive"})
+}
+
+// ServeReadiness reports whether the service should receive traffic: the
+// result store answers, the analyzer is not saturated, and outbound DNS
+// works. It answers 503 when any check fails.
+func (h *Health) ServeReadiness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	checks, ready := runProbes(r.Context(), h.readinessProbes(), h.config.ProbeTimeout)
+
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+		h.logger.Warn("Readiness check failed", "checks", checks, "remote_addr", r.RemoteAddr)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// readinessProbes returns the checks that decide readiness
+func (h *Health) readinessProbes() []probe {
+	probes := []probe{{name: "storage", run: h.probeStorage}}
+	if h.config.MaxActiveAnalyses > 0 {
+		probes = append(probes, probe{name: "capacity", run: h.probeCapacity})
+	}
+	if h.config.DNSProbeHost != "" {
+		probes = append(probes, probe{name: "dns", run: h.probeDNS})
+	}
+	return probes
+}
+
+// dependencyProbes returns the checks run by the deep health check
+func (h *Health) dependencyProbes() []probe {
+	probes := []probe{{name: "storage", run: h.probeStorage}}
+	if h.cache != nil {
+		probes = append(probes, probe{name: "cache", run: h.probeCache})
+	}
+	if h.config.HTTPSProbeURL != "" {
+		probes = append(probes, probe{name: "outbound_https", run: h.probeHTTPS})
+	}
+	return probes
+}
+
+// probeCache checks that the cache answers lookups. A miss is an answer.
+func (h *Health) probeCache(ctx context.Context) error {
+	if _, err := h.cache.Get(ctx, "health-probe"); err != nil && !errors.Is(err, cache.ErrMiss) {
+		return err
+	}
+	return nil
+}
+
+// probeHTTPS checks that outbound HTTPS requests get a response of any
+// status
+func (h *Health) probeHTTPS(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, h.config.HTTPSProbeURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// probeStorage checks that the result store answers queries
+func (h *Health) probeStorage(ctx context.Context) error {
+	_, err := h.store.Count(ctx)
+	return err
+}
+
+// probeCapacity checks that the analyzer has room for more analyses
+func (h *Health) probeCapacity(ctx context.Context) error {
+	if active := h.analyzer.Stats().ActiveAnalyses; active >= h.config.MaxActiveAnalyses {
+		return fmt.Errorf("%d analyses running, limit is %d", active, h.config.MaxActiveAnalyses)
+	}
+	return nil
+}
+
+// probeDNS checks that outbound name resolution works
+func (h *Health) probeDNS(ctx context.Context) error {
+	_, err := h.resolver.LookupHost(ctx, h.config.DNSProbeHost)
+	return err
+}
+
+// ServeHealth returns application health status. With deep=true it also
+// probes the service's dependencies and reports "degraded" when one fails.
 func (h *Health) ServeHealth(w http.ResponseWriter, r *http.Request) {
 	h.logger.Debug("Health check requested", "remote_addr", r.RemoteAddr)
 
@@ -45,6 +179,15 @@ func (h *Health) ServeHealth(w http.ResponseWriter, r *http.Request) {
 		"goroutines": goroutines,
 	}
 
+	if r.URL.Query().Get("deep") == "true" {
+		dependencies, ok := runProbes(r.Context(), h.dependencyProbes(), h.config.ProbeTimeout)
+		health["dependencies"] = dependencies
+		if !ok {
+			health["status"] = "degraded"
+			h.logger.Warn("Dependency probe failed", "dependencies", dependencies, "remote_addr", r.RemoteAddr)
+		}
+	}
+
 	h.logger.Info("Health check completed",
 		"uptime", uptime.String(),
 		"memory_alloc_mb", bToMb(m.Alloc),
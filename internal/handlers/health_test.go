@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// unreachableStore fails every count, like a store whose database is gone
+type unreachableStore struct {
+	storage.ResultStore
+}
+
+func (unreachableStore) Count(ctx context.Context) (int, error) {
+	return 0, errors.New("database is locked")
+}
+
+func TestServeReadiness(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	cfg := config.HealthConfig{MaxActiveAnalyses: 5, ProbeTimeout: time.Second}
+
+	testCases := []struct {
+		name     string
+		store    storage.ResultStore
+		expected int
+	}{
+		{"ready", storage.NewMemoryStore(), http.StatusOK},
+		{"storage down", unreachableStore{}, http.StatusServiceUnavailable},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHealth(cfg, tc.store, analyzer.NewWithOptions(analyzer.WithLogger(logger)), nil, logger)
+
+			rec := httptest.NewRecorder()
+			handler.ServeReadiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tc.expected {
+				t.Errorf("Expected status %d, got %d", tc.expected, rec.Code)
+			}
+
+			var body struct {
+				Checks map[string]probeResult `json:"checks"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if _, ok := body.Checks["storage"]; !ok {
+				t.Error("Expected a storage check")
+			}
+			if body.Checks["capacity"].Status != probeOK {
+				t.Errorf("Expected the capacity check to pass, got %+v", body.Checks["capacity"])
+			}
+		})
+	}
+}
+
+func TestServeHealth_Deep(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer upstream.Close()
+
+	cfg := config.HealthConfig{ProbeTimeout: time.Second, HTTPSProbeURL: upstream.URL}
+	handler := NewHealth(cfg, unreachableStore{}, analyzer.NewWithOptions(analyzer.WithLogger(logger)), nil, logger)
+	handler.client.Transport = upstream.Client().Transport
+
+	rec := httptest.NewRecorder()
+	handler.ServeHealth(rec, httptest.NewRequest(http.MethodGet, "/api/v1/health?deep=true", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	var body struct {
+		Status       string                 `json:"status"`
+		Dependencies map[string]probeResult `json:"dependencies"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Status != "degraded" {
+		t.Errorf("Expected status degraded, got %q", body.Status)
+	}
+	if body.Dependencies["storage"].Status != probeFailed {
+		t.Errorf("Expected the storage probe to fail, got %+v", body.Dependencies["storage"])
+	}
+	if body.Dependencies["outbound_https"].Status != probeOK {
+		t.Errorf("Expected the outbound HTTPS probe to pass, got %+v", body.Dependencies["outbound_https"])
+	}
+	if _, ok := body.Dependencies["cache"]; ok {
+		t.Error("Expected no cache probe without a cache")
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+)
+
+// historyTemplate is the history page template, relative to the working
+// directory
+const historyTemplate = "web/templates/history.html"
+
+// historyPageSize is the number of analyses on each history page
+const historyPageSize = 25
+
+// historyPeriods are the choices of the history page's period filter
+var historyPeriods = []struct {
+	Value string
+	Label string
+	Age   time.Duration
+}{
+	{"", "Any time", 0},
+	{"day", "Last 24 hours", 24 * time.Hour},
+	{"week", "Last 7 days", 7 * 24 * time.Hour},
+	{"month", "Last 30 days", 30 * 24 * time.Hour},
+}
+
+// historyView is the data rendered by the history template
+type historyView struct {
+	URL      string
+	Periods  []historyPeriod
+	Rows     []historyRow
+	PrevLink string
+	NextLink string
+}
+
+// historyPeriod is an option of the period filter
+type historyPeriod struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+// historyRow is a listed analysis
+type historyRow struct {
+	Record     *storage.Record
+	CreatedAt  time.Time
+	DetailLink string
+	FilterLink string
+}
+
+// ServeHistoryPage renders the most recent stored analyses as an HTML page,
+// newest first, filtered by the url and period query parameters and paged
+// with page
+func (h *Results) ServeHistoryPage(w http.ResponseWriter, r *http.Request) {
+	if h.history == nil {
+		writeErrorResponse(w, r, http.StatusNotFound, apierror.CodeNotFound, "Not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	view := historyView{URL: query.Get("url")}
+	opts := storage.ListOptions{URL: view.URL, Limit: historyPageSize + 1}
+
+	period := query.Get("period")
+	known := false
+	for _, p := range historyPeriods {
+		view.Periods = append(view.Periods, historyPeriod{Value: p.Value, Label: p.Label, Selected: p.Value == period})
+		if p.Value == period {
+			known = true
+			if p.Age > 0 {
+				opts.Since = time.Now().Add(-p.Age)
+			}
+		}
+	}
+	if !known {
+		writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "period must be day, week or month")
+		return
+	}
+
+	pageNumber := 1
+	if raw := query.Get("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "page must be a positive integer")
+			return
+		}
+		pageNumber = n
+	}
+	opts.Offset = (pageNumber - 1) * historyPageSize
+
+	// One extra record tells whether there is an older page
+	records, err := h.store.List(r.Context(), opts)
+	if err != nil {
+		h.logger.Error("Failed to list results", "url", opts.URL, "error", err)
+		writeErrorResponse(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list results")
+		return
+	}
+	if len(records) > historyPageSize {
+		records = records[:historyPageSize]
+		view.NextLink = historyLink(view.URL, period, pageNumber+1)
+	}
+	if pageNumber > 1 {
+		view.PrevLink = historyLink(view.URL, period, pageNumber-1)
+	}
+
+	for _, record := range records {
+		view.Rows = append(view.Rows, historyRow{
+			Record:     record,
+			CreatedAt:  record.CreatedAt.Local(),
+			DetailLink: permalink(record.ID),
+			FilterLink: historyLink(record.URL, period, 1),
+		})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.history.Execute(w, view); err != nil {
+		h.logger.Error("History template execution failed",
+			"error", err,
+			"remote_addr", r.RemoteAddr,
+		)
+	}
+}
+
+// historyLink returns the history page URL for the given filters and page
+func historyLink(targetURL, period string, pageNumber int) string {
+	query := url.Values{}
+	if targetURL != "" {
+		query.Set("url", targetURL)
+	}
+	if period != "" {
+		query.Set("period", period)
+	}
+	if pageNumber > 1 {
+		query.Set("page", strconv.Itoa(pageNumber))
+	}
+	if len(query) == 0 {
+		return "/history"
+	}
+	return "/history?" + query.Encode()
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+func TestServeHistoryPage(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	store := storage.NewMemoryStore()
+	now := time.Now().UTC()
+
+	// 30 recent analyses of one page and an old one of another
+	for i := range 30 {
+		record := &storage.Record{ID: fmt.Sprintf("r%02d", i), URL: "https://a.example", CreatedAt: now.Add(-time.Duration(i) * time.Minute), Result: &analyzer.Result{URL: "https://a.example", Title: "Page A"}}
+		if err := store.Save(context.Background(), record); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	old := &storage.Record{ID: "old", URL: "https://b.example", CreatedAt: now.Add(-48 * time.Hour), Result: &analyzer.Result{URL: "https://b.example", Title: "<Page B>"}}
+	if err := store.Save(context.Background(), old); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	handler := &Results{
+		store:   store,
+		history: template.Must(template.ParseFiles("../../" + historyTemplate)),
+		logger:  logger,
+	}
+
+	testCases := []struct {
+		target   string
+		status   int
+		contains []string
+		excludes []string
+	}{
+		{"/history", http.StatusOK, []string{"/r/r00", "Older &rarr;", "/history?page=2"}, []string{"r29", "&larr; Newer"}},
+		{"/history?page=2", http.StatusOK, []string{"r29", "&lt;Page B&gt;", "&larr; Newer"}, []string{"/r/r00\"", "Older &rarr;"}},
+		{"/history?period=day&page=2", http.StatusOK, []string{"r29"}, []string{"Page B"}},
+		{"/history?url=https://b.example", http.StatusOK, []string{"/r/old"}, []string{"r00"}},
+		{"/history?period=year", http.StatusBadRequest, nil, nil},
+		{"/history?page=0", http.StatusBadRequest, nil, nil},
+	}
+
+	for _, tc := range testCases {
+		rec := httptest.NewRecorder()
+		handler.ServeHistoryPage(rec, httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+		if rec.Code != tc.status {
+			t.Errorf("GET %s status = %d, want %d", tc.target, rec.Code, tc.status)
+			continue
+		}
+		body := rec.Body.String()
+		for _, want := range tc.contains {
+			if !strings.Contains(body, want) {
+				t.Errorf("GET %s does not contain %q", tc.target, want)
+			}
+		}
+		for _, unwanted := range tc.excludes {
+			if strings.Contains(body, unwanted) {
+				t.Errorf("GET %s unexpectedly contains %q", tc.target, unwanted)
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/anjula-paulus/web-analyzer/internal/tenant"
+)
+
+// tenantAnalyses counts analyses per tenant. Tenants come from the
+// configuration, so the label stays bounded.
+var tenantAnalyses = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "tenant_analyses_total",
+		Help: "Total number of URLs analyzed per tenant",
+	},
+	[]string{"tenant", "source"},
+)
+
+func init() {
+	prometheus.MustRegister(tenantAnalyses)
+}
+
+// Analysis sources recorded by countAnalyses
+const (
+	sourceAnalyzed = "analyzed"
+	sourceCache    = "cache"
+)
+
+// countAnalyses records n analyses for the tenant of ctx
+func countAnalyses(ctx context.Context, source string, n int) {
+	tenantAnalyses.WithLabelValues(tenant.Label(ctx), source).Add(float64(n))
+}
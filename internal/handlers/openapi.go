@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/openapi"
+)
+
+// OpenAPI serves the OpenAPI document and the JSON Schemas derived from it
+type OpenAPI struct {
+	document     []byte
+	resultSchema []byte
+	logger       *slog.Logger
+}
+
+// NewOpenAPI func creates a new OpenAPI singleton handler
+func NewOpenAPI(doc *openapi.Document, logger *slog.Logger) *OpenAPI {
+	// The documents are static, so encode them once up front
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	resultSchema, err := json.MarshalIndent(openapi.NewResultSchema(), "", "  ")
+	if err != nil {
+		panic(err)
+	}
+
+	return &OpenAPI{
+		document:     data,
+		resultSchema: resultSchema,
+		logger:       logger,
+	}
+}
+
+// ServeOpenAPI returns the OpenAPI document
+func (o *OpenAPI) ServeOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	o.logger.Debug("Serving OpenAPI document", "remote_addr", r.RemoteAddr)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(o.document)
+}
+
+// ServeResultSchema returns the JSON Schema of analysis results
+func (o *OpenAPI) ServeResultSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(o.resultSchema)
+}
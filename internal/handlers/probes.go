@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Probe statuses
+const (
+	probeOK     = "ok"
+	probeFailed = "failed"
+)
+
+// probe checks a single dependency
+type probe struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// probeResult is the outcome of a probe
+type probeResult struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// runProbes runs probes concurrently, each bounded by timeout, and reports
+// whether all of them passed
+func runProbes(ctx context.Context, probes []probe, timeout time.Duration) (map[string]probeResult, bool) {
+	results := make(map[string]probeResult, len(probes))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, p := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := p.run(ctx)
+			result := probeResult{Status: probeOK, LatencyMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = probeFailed
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[p.name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result.Status != probeOK {
+			return results, false
+		}
+	}
+	return results, true
+}
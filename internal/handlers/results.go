@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/render"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// Results handles requests for stored analysis results
+type Results struct {
+	store   storage.ResultStore
+	report  *render.HTMLReport
+	history *template.Template
+	logger  *slog.Logger
+}
+
+// resultDiffResponse describes the changes between a result and its
+// predecessor for the same URL
+type resultDiffResponse struct {
+	ID         string         `json:"id"`
+	PreviousID string         `json:"previous_id"`
+	URL        string         `json:"url"`
+	Diff       *analyzer.Diff `json:"diff"`
+}
+
+// NewResults func creates a new results singleton handler. Without the
+// history template the history page is not served.
+func NewResults(store storage.ResultStore, report *render.HTMLReport, logger *slog.Logger) *Results {
+	history, err := template.ParseFiles(historyTemplate)
+	if err != nil {
+		logger.Warn("History template unavailable, not serving the history page", "error", err)
+	}
+
+	return &Results{
+		store:   store,
+		report:  report,
+		history: history,
+		logger:  logger,
+	}
+}
+
+// ServeResults handles the result collection. GET lists stored results,
+// newest first, optionally for a single url; DELETE with a url query
+// parameter removes every stored result for that URL.
+func (h *Results) ServeResults(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.listResults(w, r)
+		return
+	case http.MethodDelete:
+	default:
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	targetURL := r.URL.Query().Get("url")
+	if targetURL == "" {
+		writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "url query parameter is required")
+		return
+	}
+
+	deleted, err := h.store.DeleteByURL(r.Context(), targetURL)
+	if err != nil {
+		h.logger.Error("Failed to delete results", "url", targetURL, "error", err)
+		writeErrorResponse(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete results")
+		return
+	}
+
+	h.logger.Info("Deleted results by URL",
+		"url", targetURL,
+		"deleted", deleted,
+		"remote_addr", r.RemoteAddr,
+	)
+
+	writeJSON(w, map[string]int{"deleted": deleted})
+}
+
+// defaultListLimit is the page size when a list request does not set one
+const defaultListLimit = 50
+
+// resultList is a page of stored results
+type resultList struct {
+	Results []*storage.Record `json:"results"`
+	Limit   int               `json:"limit"`
+	Offset  int               `json:"offset"`
+}
+
+// listResults returns a page of stored results selected by the url, limit
+// and offset query parameters
+func (h *Results) listResults(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := storage.ListOptions{URL: query.Get("url"), Limit: defaultListLimit}
+
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "limit must be a positive integer")
+			return
+		}
+		opts.Limit = n
+	}
+	if raw := query.Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "offset must be a non-negative integer")
+			return
+		}
+		opts.Offset = n
+	}
+
+	records, err := h.store.List(r.Context(), opts)
+	if err != nil {
+		h.logger.Error("Failed to list results", "url", opts.URL, "error", err)
+		writeErrorResponse(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Failed to list results")
+		return
+	}
+	if records == nil {
+		records = []*storage.Record{}
+	}
+
+	writeJSON(w, resultList{Results: records, Limit: opts.Limit, Offset: opts.Offset})
+}
+
+// ServeResult returns or deletes a stored analysis record
+func (h *Results) ServeResult(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+		h.deleteResult(w, r)
+		return
+	default:
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	renderer, err := render.ForFormat(format)
+	if err != nil {
+		writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeUnsupportedFormat, err.Error())
+		return
+	}
+
+	record, ok := h.loadRecord(w, r)
+	if !ok {
+		return
+	}
+
+	// JSON keeps the record envelope; other formats render the result itself
+	if format == "" || format == render.FormatJSON {
+		writeJSON(w, record)
+		return
+	}
+
+	w.Header().Set("Content-Type", renderer.ContentType())
+	if err := renderer.Render(w, record.Result); err != nil {
+		h.logger.Error("Failed to render result", "id", record.ID, "format", format, "error", err)
+	}
+}
+
+// deleteResult removes the record named by the {id} path segment
+func (h *Results) deleteResult(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := h.store.Delete(r.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeErrorResponse(w, r, http.StatusNotFound, apierror.CodeNotFound, "Result not found")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to delete result", "id", id, "error", err)
+		writeErrorResponse(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Failed to delete result")
+		return
+	}
+
+	h.logger.Info("Deleted result", "id", id, "remote_addr", r.RemoteAddr)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ServeResultDiff returns the diff between a stored result and the previous
+// result for the same URL
+func (h *Results) ServeResultDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	record, ok := h.loadRecord(w, r)
+	if !ok {
+		return
+	}
+
+	previous, diff, err := h.store.Diff(r.Context(), record)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeErrorResponse(w, r, http.StatusNotFound, apierror.CodeNotFound, "No earlier result for this URL")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to load previous result", "id", record.ID, "error", err)
+		writeErrorResponse(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load previous result")
+		return
+	}
+
+	writeJSON(w, resultDiffResponse{
+		ID:         record.ID,
+		PreviousID: previous.ID,
+		URL:        record.URL,
+		Diff:       diff,
+	})
+}
+
+// ServeReport renders a stored result as a self-contained HTML report that
+// can be emailed or archived
+func (h *Results) ServeReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	record, ok := h.loadRecord(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="analysis-%s.html"`, record.ID))
+
+	if err := h.report.Render(w, record); err != nil {
+		h.logger.Error("Report template execution failed",
+			"error", err,
+			"id", record.ID,
+			"remote_addr", r.RemoteAddr,
+		)
+		return
+	}
+
+	h.logger.Debug("Report served", "id", record.ID, "remote_addr", r.RemoteAddr)
+}
+
+// ServePermalink renders a stored result as an HTML page at its permalink,
+// so a finding can be shared without re-running the analysis
+func (h *Results) ServePermalink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	record, ok := h.loadRecord(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := h.report.Render(w, record); err != nil {
+		h.logger.Error("Report template execution failed",
+			"error", err,
+			"id", record.ID,
+			"remote_addr", r.RemoteAddr,
+		)
+	}
+}
+
+// permalink returns the path of the page showing the stored result id
+func permalink(id string) string {
+	return "/r/" + url.PathEscape(id)
+}
+
+// defaultTrendLimit is the number of results in a trend when the request
+// does not set a limit
+const defaultTrendLimit = 100
+
+// ServeTrends returns how the stored results for the url query parameter
+// changed over time, using at most limit of the most recent results
+func (h *Results) ServeTrends(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	targetURL := r.URL.Query().Get("url")
+	if targetURL == "" {
+		writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "url query parameter is required")
+		return
+	}
+
+	limit := defaultTrendLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeErrorResponse(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	records, err := h.store.History(r.Context(), targetURL, limit)
+	if err != nil {
+		h.logger.Error("Failed to load history", "url", targetURL, "error", err)
+		writeErrorResponse(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load history")
+		return
+	}
+	if len(records) == 0 {
+		writeErrorResponse(w, r, http.StatusNotFound, apierror.CodeNotFound, "No results stored for this URL")
+		return
+	}
+
+	writeJSON(w, storage.NewTrend(targetURL, records))
+}
+
+// loadRecord fetches the record named by the {id} path segment, writing an
+// error response when it cannot be loaded
+func (h *Results) loadRecord(w http.ResponseWriter, r *http.Request) (*storage.Record, bool) {
+	id := r.PathValue("id")
+
+	record, err := h.store.Get(r.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) {
+		h.logger.Debug("Result not found", "id", id, "remote_addr", r.RemoteAddr)
+		writeErrorResponse(w, r, http.StatusNotFound, apierror.CodeNotFound, "Result not found")
+		return nil, false
+	}
+	if err != nil {
+		h.logger.Error("Failed to load result", "id", id, "error", err)
+		writeErrorResponse(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Failed to load result")
+		return nil, false
+	}
+
+	return record, true
+}
+
+// writeJSON writes a 200 JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
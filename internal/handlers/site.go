@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/openapi"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// siteRequest represents a request to probe the variants of a domain
+type siteRequest struct {
+	Domain   string             `json:"domain"`
+	Sections []analyzer.Section `json:"sections"`
+}
+
+// ServeSite probes the http/https and www/non-www variants of a domain and
+// analyzes the page they lead to
+func (a *Analyzer) ServeSite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.logger.Warn("Invalid method for site endpoint",
+			"method", r.Method,
+			"remote_addr", r.RemoteAddr,
+		)
+		writeErrorResponse(w, r, http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req siteRequest
+	errs, err := a.decodeRequest(r, openapi.SiteRequestSchema, &req)
+	if err != nil {
+		writeBodyErrorResponse(w, r, err, "Invalid request")
+		return
+	}
+	if len(errs) > 0 {
+		writeValidationErrorResponse(w, r, errs)
+		return
+	}
+
+	a.logger.Info("Starting site analysis",
+		"domain", req.Domain,
+		"remote_addr", r.RemoteAddr,
+	)
+
+	ctx := r.Context()
+
+	start := time.Now()
+
+	report, err := a.analyzer.AnalyzeSite(ctx, req.Domain, analyzer.AtPriority(analyzer.PriorityHigh), analyzer.IncludeSections(req.Sections...))
+	if err != nil {
+		status, code := classifyAnalysisError(err)
+		a.logger.Warn("Site analysis failed",
+			"domain", req.Domain,
+			"error", err,
+			"status", status,
+			"remote_addr", r.RemoteAddr,
+		)
+		if errors.Is(err, analyzer.ErrBusy) {
+			w.Header().Set("Retry-After", busyRetryAfter)
+		}
+		writeErrorResponse(w, r, status, code, err.Error())
+		return
+	}
+	if report.Result != nil && report.Result.Error == "" {
+		countAnalyses(ctx, sourceAnalyzed, 1)
+	}
+
+	a.logger.Info("Site analysis completed",
+		"domain", report.Domain,
+		"canonical_origin", report.CanonicalOrigin,
+		"duration", time.Since(start),
+		"remote_addr", r.RemoteAddr,
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		a.logger.Error("Failed to encode response",
+			"error", err,
+			"remote_addr", r.RemoteAddr,
+		)
+	}
+}
@@ -0,0 +1,115 @@
+package idempotency
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInProgress is returned when a request with the same key is still
+	// being processed
+	ErrInProgress = errors.New("request with this idempotency key is in progress")
+
+	// ErrMismatch is returned when a key is reused with a different request
+	ErrMismatch = errors.New("idempotency key was used with a different request")
+)
+
+// pruneInterval bounds how often expired entries are swept
+const pruneInterval = time.Minute
+
+// Response is a stored response replayed for retried requests
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// entry tracks a single idempotency key. A nil response means the original
+// request is still running.
+type entry struct {
+	fingerprint string
+	response    *Response
+	expiresAt   time.Time
+}
+
+// Store keeps responses by idempotency key in memory
+type Store struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	ttl       time.Duration
+	lastPrune time.Time
+}
+
+// NewStore func creates a new idempotency store singleton instance. Stored
+// responses expire after ttl.
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		entries: make(map[string]*entry),
+		ttl:     ttl,
+	}
+}
+
+// Begin claims key for a request with the given fingerprint. It returns the
+// stored response when the request was already completed, or nil when the
+// caller should process the request and then call Complete or Abandon.
+func (s *Store) Begin(key, fingerprint string) (*Response, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked(now)
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expiresAt) {
+		switch {
+		case e.fingerprint != fingerprint:
+			return nil, ErrMismatch
+		case e.response == nil:
+			return nil, ErrInProgress
+		default:
+			return e.response, nil
+		}
+	}
+
+	s.entries[key] = &entry{
+		fingerprint: fingerprint,
+		expiresAt:   now.Add(s.ttl),
+	}
+	return nil, nil
+}
+
+// Complete stores the response for a claimed key
+func (s *Store) Complete(key string, response *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.response = response
+		e.expiresAt = time.Now().Add(s.ttl)
+	}
+}
+
+// Abandon releases a claimed key without storing a response, so that a retry
+// processes the request again
+func (s *Store) Abandon(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+}
+
+// pruneLocked drops expired entries. The caller must hold the lock.
+func (s *Store) pruneLocked(now time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+type debugKey struct{}
+
+// WithDebug returns a context whose log records are written at every
+// level, whatever the configured level, for debugging a single request in
+// production. The records are tagged with id as debug_request, so they can
+// be picked out of the log.
+func WithDebug(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, debugKey{}, id)
+}
+
+// debugID returns the id of a WithDebug context
+func debugID(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(debugKey{}).(string)
+	return id, ok
+}
+
+// NewDebugHandler func wraps h so that records logged with a WithDebug
+// context are written regardless of h's level. Only records logged with
+// the *Context methods carry the context.
+func NewDebugHandler(h slog.Handler) slog.Handler {
+	return &debugHandler{h}
+}
+
+type debugHandler struct {
+	slog.Handler
+}
+
+func (h *debugHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if _, ok := debugID(ctx); ok {
+		return true
+	}
+	return h.Handler.Enabled(ctx, level)
+}
+
+func (h *debugHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id, ok := debugID(ctx); ok && id != "" {
+		r = r.Clone()
+		r.AddAttrs(slog.String("debug_request", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *debugHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &debugHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *debugHandler) WithGroup(name string) slog.Handler {
+	return &debugHandler{h.Handler.WithGroup(name)}
+}
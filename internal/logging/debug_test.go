@@ -0,0 +1,32 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestDebugHandler(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(NewDebugHandler(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo}))).With("component", "analyzer")
+
+	logger.DebugContext(context.Background(), "hidden")
+	logger.DebugContext(WithDebug(context.Background(), "req-1"), "shown", "url", "https://example.com/")
+	logger.InfoContext(WithDebug(context.Background(), "req-1"), "tagged")
+	logger.Debug("hidden without a context")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected the two records of the debug request, got %q", out.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "component=analyzer") || !strings.HasSuffix(line, "debug_request=req-1") {
+			t.Errorf("Expected a tagged record with the logger's attributes, got %q", line)
+		}
+	}
+	if !strings.Contains(lines[0], "level=DEBUG msg=shown") {
+		t.Errorf("Expected the debug record first, got %q", lines[0])
+	}
+}
@@ -0,0 +1,115 @@
+// Package logging keeps secrets out of the service's structured logs
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// redacted replaces secret values in log output
+const redacted = "[redacted]"
+
+// Redactor masks sensitive query parameters, URL passwords and headers in
+// log attributes. It is installed as the handler's ReplaceAttr, so it covers
+// every logger derived from it, including the analyzer's.
+type Redactor struct {
+	params  map[string]bool
+	headers map[string]bool
+}
+
+// NewRedactor func creates a new redactor from configuration
+func NewRedactor(cfg config.LoggingConfig) *Redactor {
+	r := &Redactor{
+		params:  make(map[string]bool, len(cfg.RedactQueryParams)),
+		headers: make(map[string]bool, len(cfg.RedactHeaders)),
+	}
+	for _, param := range cfg.RedactQueryParams {
+		r.params[strings.ToLower(param)] = true
+	}
+	for _, header := range cfg.RedactHeaders {
+		r.headers[attrKey(header)] = true
+	}
+	return r
+}
+
+// ReplaceAttr redacts a single attribute. Attributes named after a
+// sensitive header are masked outright; strings, errors and headers are
+// searched for secrets.
+func (r *Redactor) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if r.headers[attrKey(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if s := a.Value.String(); strings.ContainsAny(s, "?@") {
+			return slog.String(a.Key, r.RedactURL(s))
+		}
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case http.Header:
+			return slog.Any(a.Key, r.redactHeader(v))
+		case error:
+			var urlErr *url.Error
+			if errors.As(v, &urlErr) {
+				return slog.String(a.Key, strings.Replace(v.Error(), urlErr.URL, r.RedactURL(urlErr.URL), 1))
+			}
+		}
+	}
+	return a
+}
+
+// RedactURL masks the password and sensitive query parameters of a URL.
+// Strings that do not parse as URLs are returned unchanged.
+func (r *Redactor) RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	changed := false
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+		changed = true
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			if r.params[strings.ToLower(name)] {
+				query[name] = []string{redacted}
+				changed = true
+			}
+		}
+		if changed {
+			u.RawQuery = query.Encode()
+		}
+	}
+
+	if !changed {
+		return raw
+	}
+	return u.String()
+}
+
+// redactHeader returns a copy of header with sensitive values masked
+func (r *Redactor) redactHeader(header http.Header) http.Header {
+	clean := header.Clone()
+	for name := range clean {
+		if r.headers[attrKey(name)] {
+			clean[name] = []string{redacted}
+		}
+	}
+	return clean
+}
+
+// attrKey normalizes a header name or attribute key so that "X-API-Key"
+// matches the attribute "x_api_key"
+func attrKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
@@ -0,0 +1,154 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// backupTimeFormat names rotated files, e.g. app.log.20240102-150405.000.
+// It sorts in time order.
+const backupTimeFormat = "20060102-150405.000"
+
+// RotatingFile is a log file that is rotated by size and age. Rotated files
+// are renamed with the time of the rotation appended, and the oldest are
+// removed once there are more than the configured backups. It is safe for
+// concurrent use.
+type RotatingFile struct {
+	path string
+	cfg  config.LogRotationConfig
+	now  func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// OpenFile func opens or creates the log file at path, appending to it
+func OpenFile(path string, cfg config.LogRotationConfig) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, cfg: cfg, now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes p to the file, rotating it first when p would take it past
+// the size limit or it has been written for longer than the age limit
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.due(len(p)) {
+		if err := f.rotate(); err != nil {
+			// Keep logging to the current file rather than losing records
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the file
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// due reports whether the file must be rotated before writing n bytes
+func (f *RotatingFile) due(n int) bool {
+	if f.size == 0 {
+		return false
+	}
+	if limit := int64(f.cfg.MaxSizeMB) << 20; limit > 0 && f.size+int64(n) > limit {
+		return true
+	}
+	return f.cfg.MaxAge > 0 && f.now().Sub(f.opened) >= f.cfg.MaxAge
+}
+
+// rotate renames the file, opens a new one and removes old backups
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	backup := f.path + "." + f.now().Format(backupTimeFormat)
+	if err := os.Rename(f.path, backup); err != nil {
+		// Reopen so writes go on
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.prune()
+}
+
+// open opens the file for appending
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("open log file: %w", err)
+	}
+	f.file, f.size, f.opened = file, info.Size(), f.now()
+	return nil
+}
+
+// prune removes the oldest backups beyond MaxBackups
+func (f *RotatingFile) prune() error {
+	if f.cfg.MaxBackups <= 0 {
+		return nil
+	}
+	backups := f.backups()
+	if len(backups) <= f.cfg.MaxBackups {
+		return nil
+	}
+	for _, backup := range backups[:len(backups)-f.cfg.MaxBackups] {
+		if err := os.Remove(backup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backups returns the rotated files of the log, oldest first
+func (f *RotatingFile) backups() []string {
+	matches, _ := filepath.Glob(f.path + ".*")
+	var backups []string
+	for _, match := range matches {
+		suffix := strings.TrimPrefix(match, f.path+".")
+		if _, err := time.Parse(backupTimeFormat, suffix); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	slices.Sort(backups)
+	return backups
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	f, err := OpenFile(path, config.LogRotationConfig{MaxSizeMB: 1, MaxAge: time.Hour, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer f.Close()
+
+	clock := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	f.now = func() time.Time { return clock }
+	f.opened = clock
+
+	line := []byte(strings.Repeat("x", 1<<19-1) + "\n")
+	write := func() {
+		t.Helper()
+		if _, err := f.Write(line); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	// Two lines fill the first megabyte, so the third starts a new file
+	write()
+	write()
+	if backups := f.backups(); len(backups) != 0 {
+		t.Fatalf("Expected no rotation below the size limit, got %v", backups)
+	}
+	clock = clock.Add(time.Second)
+	write()
+	if backups := f.backups(); len(backups) != 1 {
+		t.Fatalf("Expected a rotation at the size limit, got %v", backups)
+	}
+
+	// An hour later the file is rotated by age
+	clock = clock.Add(time.Hour)
+	write()
+	if backups := f.backups(); len(backups) != 2 {
+		t.Fatalf("Expected a rotation at the age limit, got %v", backups)
+	}
+
+	// A third backup removes the oldest
+	clock = clock.Add(time.Hour)
+	write()
+	backups := f.backups()
+	if len(backups) != 2 || !strings.HasSuffix(backups[0], ".20240102-160406.000") {
+		t.Fatalf("Expected the two newest backups, got %v", backups)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != int64(len(line)) {
+		t.Errorf("Expected the current file to hold one line, got %v, %v", info, err)
+	}
+}
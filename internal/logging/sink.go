@@ -0,0 +1,263 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// Log sink types
+const (
+	SinkSyslog   = "syslog"
+	SinkJournald = "journald"
+	SinkNetwork  = "network"
+)
+
+// journaldSocket is where journald accepts native protocol datagrams
+const journaldSocket = "/run/systemd/journal/socket"
+
+// sinkDialTimeout bounds connecting to a log collector
+const sinkDialTimeout = 5 * time.Second
+
+// Sink ships formatted log records to a log collector
+type Sink interface {
+	// Send delivers one record, formatted as a line, at its level
+	Send(level slog.Level, record []byte) error
+	Close() error
+}
+
+// OpenSink func opens the configured log sink. It returns nil when none is
+// configured.
+func OpenSink(cfg config.LogSinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case "":
+		return nil, nil
+	case SinkSyslog:
+		return openSyslog(cfg)
+	case SinkJournald:
+		conn, err := net.DialTimeout("unixgram", journaldSocket, sinkDialTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("connect to journald: %w", err)
+		}
+		return &journaldSink{conn: conn, tag: cfg.Tag}, nil
+	case SinkNetwork:
+		if cfg.Network != "tcp" && cfg.Network != "udp" {
+			return nil, fmt.Errorf("log sink network must be tcp or udp, got %q", cfg.Network)
+		}
+		if cfg.Address == "" {
+			return nil, errors.New("log sink address is required for the network sink")
+		}
+		return &networkSink{network: cfg.Network, address: cfg.Address}, nil
+	default:
+		return nil, fmt.Errorf("unknown log sink %q", cfg.Type)
+	}
+}
+
+// NewSinkHandler func creates a handler that formats records like the
+// service's other logs, as JSON or text, and sends each to sink. Records
+// that cannot be sent are dropped, since there is nowhere to report them.
+func NewSinkHandler(sink Sink, opts *slog.HandlerOptions, text bool) slog.Handler {
+	return &sinkHandler{sink: sink, opts: opts, text: text}
+}
+
+// sinkHandler formats each record with a fresh JSON or text handler, so
+// the formatted line and its level can be sent together. The attributes
+// and groups added to the logger are replayed onto that handler.
+type sinkHandler struct {
+	sink Sink
+	opts *slog.HandlerOptions
+	text bool
+	with []func(slog.Handler) slog.Handler
+}
+
+func (h *sinkHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts != nil && h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *sinkHandler) Handle(ctx context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	var formatter slog.Handler
+	if h.text {
+		formatter = slog.NewTextHandler(&buf, h.opts)
+	} else {
+		formatter = slog.NewJSONHandler(&buf, h.opts)
+	}
+	for _, with := range h.with {
+		formatter = with(formatter)
+	}
+	if err := formatter.Handle(ctx, r); err != nil {
+		return err
+	}
+	h.sink.Send(r.Level, buf.Bytes())
+	return nil
+}
+
+func (h *sinkHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.chain(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *sinkHandler) WithGroup(name string) slog.Handler {
+	return h.chain(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *sinkHandler) chain(with func(slog.Handler) slog.Handler) slog.Handler {
+	next := *h
+	next.with = append(h.with[:len(h.with):len(h.with)], with)
+	return &next
+}
+
+// Fanout func creates a handler that passes each record to every handler
+// that is enabled for its level
+func Fanout(handlers ...slog.Handler) slog.Handler {
+	return fanout(handlers)
+}
+
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := make(fanout, len(f))
+	for i, h := range f {
+		next[i] = h.WithAttrs(attrs)
+	}
+	return next
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	next := make(fanout, len(f))
+	for i, h := range f {
+		next[i] = h.WithGroup(name)
+	}
+	return next
+}
+
+// priority maps a level to a syslog priority, which journald uses too
+func priority(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 4
+	case level >= slog.LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// journaldSink writes records to journald with its native protocol. Records
+// larger than a datagram are dropped.
+type journaldSink struct {
+	conn net.Conn
+	tag  string
+}
+
+func (j *journaldSink) Send(level slog.Level, record []byte) error {
+	var b bytes.Buffer
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(priority(level)))
+	if j.tag != "" {
+		writeJournalField(&b, "SYSLOG_IDENTIFIER", j.tag)
+	}
+	writeJournalField(&b, "MESSAGE", strings.TrimSuffix(string(record), "\n"))
+	_, err := j.conn.Write(b.Bytes())
+	return err
+}
+
+func (j *journaldSink) Close() error {
+	return j.conn.Close()
+}
+
+// writeJournalField writes a field of the journald native protocol. Values
+// with newlines are written with their length instead of as NAME=value.
+func writeJournalField(b *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(b, "%s=%s\n", name, value)
+		return
+	}
+	b.WriteString(name)
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// sinkRetryDelay is how long records are dropped after a collector could
+// not be reached, so logging does not wait on every dial
+const sinkRetryDelay = 10 * time.Second
+
+// networkSink writes records as lines to a TCP or UDP log collector,
+// connecting again after a failed write
+type networkSink struct {
+	network, address string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	retryAt time.Time
+}
+
+func (n *networkSink) Send(_ slog.Level, record []byte) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		if time.Now().Before(n.retryAt) {
+			return errors.New("log collector unreachable")
+		}
+		conn, err := net.DialTimeout(n.network, n.address, sinkDialTimeout)
+		if err != nil {
+			n.retryAt = time.Now().Add(sinkRetryDelay)
+			return err
+		}
+		n.conn = conn
+	}
+	if _, err := n.conn.Write(record); err != nil {
+		n.conn.Close()
+		n.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (n *networkSink) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	return err
+}
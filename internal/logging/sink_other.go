@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package logging
+
+import (
+	"errors"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+func openSyslog(config.LogSinkConfig) (Sink, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,43 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"log/syslog"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// syslogSink writes records to the local syslog daemon or, with a network,
+// to a remote syslog server. The writer reconnects by itself.
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func openSyslog(cfg config.LogSinkConfig) (Sink, error) {
+	w, err := syslog.Dial(cfg.Network, cfg.Address, syslog.LOG_INFO|syslog.LOG_DAEMON, cfg.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("connect to syslog: %w", err)
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Send(level slog.Level, record []byte) error {
+	msg := string(record)
+	switch priority(level) {
+	case 3:
+		return s.w.Err(msg)
+	case 4:
+		return s.w.Warning(msg)
+	case 6:
+		return s.w.Info(msg)
+	default:
+		return s.w.Debug(msg)
+	}
+}
+
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// recordingSink keeps the records sent to it
+type recordingSink struct {
+	levels  []slog.Level
+	records []string
+}
+
+func (s *recordingSink) Send(level slog.Level, record []byte) error {
+	s.levels = append(s.levels, level)
+	s.records = append(s.records, string(record))
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func TestSinkHandler(t *testing.T) {
+	var out bytes.Buffer
+	sink := &recordingSink{}
+	opts := &slog.HandlerOptions{Level: slog.LevelInfo}
+	logger := slog.New(Fanout(slog.NewJSONHandler(&out, opts), NewSinkHandler(sink, opts, false)))
+
+	logger.Debug("hidden")
+	logger.With("request_id", "abc").WithGroup("req").Warn("slow", "ms", 1200)
+
+	if len(sink.records) != 1 || sink.levels[0] != slog.LevelWarn {
+		t.Fatalf("Expected one warning in the sink, got %v %q", sink.levels, sink.records)
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(sink.records[0]), &record); err != nil {
+		t.Fatalf("Sink record is not JSON: %v", err)
+	}
+	if record["msg"] != "slow" || record["request_id"] != "abc" || record["req"].(map[string]any)["ms"] != float64(1200) {
+		t.Errorf("Unexpected sink record %v", record)
+	}
+	if out.String() != sink.records[0] {
+		t.Errorf("Expected the sink to get the output's line %q, got %q", out.String(), sink.records[0])
+	}
+}
+
+func TestNetworkSink(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+
+	lines := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	sink, err := OpenSink(config.LogSinkConfig{Type: SinkNetwork, Network: "tcp", Address: listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("OpenSink failed: %v", err)
+	}
+	defer sink.Close()
+
+	for _, record := range []string{"first\n", "second\n"} {
+		if err := sink.Send(slog.LevelInfo, []byte(record)); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	for _, want := range []string{"first", "second"} {
+		select {
+		case line := <-lines:
+			if line != want {
+				t.Errorf("Expected %q, got %q", want, line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %q", want)
+		}
+	}
+
+	if _, err := OpenSink(config.LogSinkConfig{Type: SinkNetwork, Network: "http", Address: "localhost:1"}); err == nil {
+		t.Error("Expected an error for an unsupported network")
+	}
+}
+
+func TestWriteJournalField(t *testing.T) {
+	var b bytes.Buffer
+	writeJournalField(&b, "PRIORITY", "6")
+	writeJournalField(&b, "MESSAGE", "two\nlines")
+
+	want := "PRIORITY=6\nMESSAGE\n\x09\x00\x00\x00\x00\x00\x00\x00two\nlines\n"
+	if b.String() != want {
+		t.Errorf("Expected %q, got %q", want, b.String())
+	}
+}
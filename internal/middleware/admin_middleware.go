@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/auth"
+)
+
+// NewAdminMiddleware restricts a handler to the configured admin principals.
+// It relies on the auth middleware having authenticated the request.
+func NewAdminMiddleware(admins []string, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := auth.FromContext(r.Context())
+			if principal == nil {
+				logger.Warn("Anonymous admin request rejected",
+					"path", r.URL.Path,
+					"remote_addr", r.RemoteAddr,
+				)
+				apierror.Write(w, r, http.StatusUnauthorized, apierror.CodeUnauthorized, "Authentication required")
+				return
+			}
+
+			if !slices.Contains(admins, principal.ClientID()) {
+				logger.Warn("Admin request forbidden",
+					"principal", principal.ClientID(),
+					"path", r.URL.Path,
+					"remote_addr", r.RemoteAddr,
+				)
+				apierror.Write(w, r, http.StatusForbidden, apierror.CodeForbidden, "Admin access required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/auth"
+)
+
+func TestAdminMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		status    int
+		code      string
+	}{
+		{"anonymous", nil, http.StatusUnauthorized, apierror.CodeUnauthorized},
+		{"not an admin", &auth.Principal{Subject: "ci", Method: auth.MethodAPIKey}, http.StatusForbidden, apierror.CodeForbidden},
+		// Admins are listed by method and subject, so a JWT subject does
+		// not match an API key of the same name
+		{"same name, other method", &auth.Principal{Subject: "ops", Method: auth.MethodJWT}, http.StatusForbidden, apierror.CodeForbidden},
+		{"api key admin", &auth.Principal{Subject: "ops", Method: auth.MethodAPIKey}, http.StatusOK, ""},
+		{"jwt admin", &auth.Principal{Subject: "alice", Method: auth.MethodJWT}, http.StatusOK, ""},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewAdminMiddleware([]string{"api_key:ops", "jwt:alice"}, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/stats", nil)
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.code != "" && !strings.Contains(rec.Body.String(), `"code":"`+tt.code+`"`) {
+				t.Errorf("Expected %s, got %s", tt.code, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/auth"
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/tenant"
+)
+
+// NewAuthMiddleware authenticates requests by API key or bearer JWT. When a
+// JWT validator is given, every request outside the configured public paths
+// must present a valid credential; otherwise API keys are optional and
+// anonymous requests pass through. Browsers behind an SSO proxy may present
+// the token in a cookie instead of the Authorization header. API keys that
+// belong to a tenant scope the request to that tenant.
+func NewAuthMiddleware(validator *auth.JWTValidator, apiKeys *auth.APIKeys, cfg config.AuthConfig, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions || isPublicPath(r.URL.Path, cfg.PublicPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if key := r.Header.Get("X-API-Key"); key != "" && apiKeys != nil {
+				principal, err := apiKeys.Authenticate(key)
+				if err != nil {
+					logger.Warn("API key rejected",
+						"path", r.URL.Path,
+						"remote_addr", r.RemoteAddr,
+					)
+					apierror.Write(w, r, http.StatusUnauthorized, apierror.CodeInvalidAPIKey, "Invalid API key")
+					return
+				}
+
+				logger.Debug("Request authenticated",
+					"subject", principal.Subject,
+					"method", principal.Method,
+					"tenant", principal.Tenant,
+					"path", r.URL.Path,
+				)
+
+				ctx := auth.WithPrincipal(r.Context(), principal)
+				if principal.Tenant != "" {
+					ctx = tenant.WithName(ctx, principal.Tenant)
+				}
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if validator == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := bearerToken(r, cfg.JWT.CookieName)
+			if token == "" {
+				logger.Debug("Missing bearer token",
+					"path", r.URL.Path,
+					"remote_addr", r.RemoteAddr,
+				)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="web-analyzer"`)
+				apierror.Write(w, r, http.StatusUnauthorized, apierror.CodeUnauthorized, "Authentication required")
+				return
+			}
+
+			principal, err := validator.Validate(r.Context(), token)
+			if err != nil {
+				logger.Warn("Bearer token rejected",
+					"error", err,
+					"path", r.URL.Path,
+					"remote_addr", r.RemoteAddr,
+				)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="web-analyzer", error="invalid_token"`)
+				apierror.Write(w, r, http.StatusUnauthorized, apierror.CodeInvalidToken, "Invalid token")
+				return
+			}
+
+			logger.Debug("Request authenticated",
+				"subject", principal.Subject,
+				"method", principal.Method,
+				"path", r.URL.Path,
+			)
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// bearerToken extracts the token from the Authorization header, falling back
+// to the configured cookie
+func bearerToken(r *http.Request, cookieName string) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+
+	if cookieName != "" {
+		if cookie, err := r.Cookie(cookieName); err == nil {
+			return cookie.Value
+		}
+	}
+
+	return ""
+}
+
+// isPublicPath reports whether path matches a public path. Entries ending in
+// "/" match as prefixes.
+func isPublicPath(path string, publicPaths []string) bool {
+	for _, public := range publicPaths {
+		if path == public || (strings.HasSuffix(public, "/") && strings.HasPrefix(path, public)) {
+			return true
+		}
+	}
+	return false
+}
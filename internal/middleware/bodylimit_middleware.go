@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// NewBodyLimitMiddleware caps request bodies at the limit configured for
+// their route in routes, or the default limit. Requests that declare a larger
+// Content-Length are rejected with 413 up front; other bodies fail with an
+// *http.MaxBytesError once the limit is read, which handlers report as 413.
+func NewBodyLimitMiddleware(cfg config.BodyLimitConfig, routes *http.ServeMux, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit, ok := routeSetting(cfg.Routes, routes, r)
+			if !ok {
+				limit = cfg.Default
+			}
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > limit {
+				logger.Warn("Request body too large",
+					"path", r.URL.Path,
+					"content_length", r.ContentLength,
+					"limit", limit,
+					"remote_addr", r.RemoteAddr,
+				)
+				apierror.Write(w, r, http.StatusRequestEntityTooLarge, apierror.CodeRequestTooLarge,
+					fmt.Sprintf("Request body must be at most %d bytes", limit))
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	cfg := config.BodyLimitConfig{
+		Default: 8,
+		Routes: map[string]int64{
+			"/api/v1/compare":           4,
+			"/api/v1/results/{id}":      16,
+			"/api/v1/results/{id}/diff": 0,
+		},
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"default limit", "/api/v2/analyze", 8, false, http.StatusOK},
+		{"over the default limit", "/api/v2/analyze", 9, false, http.StatusRequestEntityTooLarge},
+		{"over a route's limit", "/api/v1/compare", 5, false, http.StatusRequestEntityTooLarge},
+		{"pattern limit", "/api/v1/results/abc", 16, false, http.StatusOK},
+		{"over a pattern's limit", "/api/v1/results/abc", 17, false, http.StatusRequestEntityTooLarge},
+		{"over a pattern's limit without a length", "/api/v1/results/abc", 17, true, http.StatusRequestEntityTooLarge},
+		{"disabled limit", "/api/v1/results/abc/diff", 1024, false, http.StatusOK},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewBodyLimitMiddleware(cfg, testRoutes(), logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Handlers report a body cut off at the limit as 413
+		var tooLarge *http.MaxBytesError
+		if _, err := io.ReadAll(r.Body); errors.As(err, &tooLarge) {
+			apierror.Write(w, r, http.StatusRequestEntityTooLarge, apierror.CodeRequestTooLarge, "Request body too large")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(strings.Repeat("x", tt.size)))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, rec.Code)
+			}
+			if rec.Code == http.StatusRequestEntityTooLarge && !strings.Contains(rec.Body.String(), apierror.CodeRequestTooLarge) {
+				t.Errorf("Expected %s, got %s", apierror.CodeRequestTooLarge, rec.Body)
+			}
+		})
+	}
+}
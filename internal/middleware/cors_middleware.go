@@ -10,8 +10,9 @@ func NewCORSMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID, Idempotency-Key, X-CSRF-Token")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, X-Quota-Remaining, Idempotent-Replayed")
 
 			if r.Method == "OPTIONS" {
 				logger.Debug("CORS preflight request",
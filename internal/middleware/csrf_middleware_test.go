@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anjula-paulus/web-analyzer/internal/csrf"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	const token = "0123456789abcdef0123456789abcdef"
+	cookie := &http.Cookie{Name: csrf.CookieName, Value: token}
+
+	tests := []struct {
+		name    string
+		method  string
+		cookie  *http.Cookie
+		headers map[string]string
+		want    int
+	}{
+		{"safe method", http.MethodGet, cookie, nil, http.StatusOK},
+		{"matching token", http.MethodPost, cookie, map[string]string{csrf.Header: token}, http.StatusOK},
+		{"token mismatch", http.MethodPost, cookie, map[string]string{csrf.Header: "fedcba9876543210fedcba9876543210"}, http.StatusForbidden},
+		{"missing header", http.MethodPost, cookie, nil, http.StatusForbidden},
+		{"missing cookie", http.MethodPost, nil, map[string]string{"Origin": "https://app.example.com", csrf.Header: token}, http.StatusForbidden},
+		{"cross-site origin", http.MethodPost, nil, map[string]string{"Origin": "https://evil.example.com"}, http.StatusForbidden},
+		{"cross-site origin with cookie", http.MethodDelete, cookie, map[string]string{"Origin": "https://evil.example.com"}, http.StatusForbidden},
+		{"api key", http.MethodPost, cookie, map[string]string{"Origin": "https://evil.example.com", "X-API-Key": "key"}, http.StatusOK},
+		{"bearer token", http.MethodPost, cookie, map[string]string{"Origin": "https://evil.example.com", "Authorization": "bearer abc"}, http.StatusOK},
+		{"basic auth", http.MethodPost, cookie, map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, http.StatusForbidden},
+		{"no cookie or origin", http.MethodPost, nil, nil, http.StatusOK},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewCSRFMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v2/analyze", nil)
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCSRFIssue(t *testing.T) {
+	rec := httptest.NewRecorder()
+	token := csrf.Issue(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != csrf.CookieName || cookies[0].Value != token {
+		t.Fatalf("Expected the token %q as a cookie, got %+v", token, cookies)
+	}
+
+	// A request with a token keeps it
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	if again := csrf.Issue(rec, req); again != token || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("Expected the existing token %q, got %q", token, again)
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/auth"
+	"github.com/anjula-paulus/web-analyzer/internal/logging"
+	"github.com/anjula-paulus/web-analyzer/internal/requestid"
+)
+
+// DebugLogHeader and DebugLogParam ask for debug logging of a single request
+const (
+	DebugLogHeader = "X-Debug-Log"
+	DebugLogParam  = "debug_log"
+)
+
+// NewDebugLogMiddleware logs a request at debug level, whatever the
+// configured level, when it sets the X-Debug-Log header or debug_log query
+// parameter to true. Only the configured admin principals may ask for it;
+// it relies on the auth middleware having authenticated the request.
+func NewDebugLogMiddleware(admins []string, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := r.Header.Get(DebugLogHeader)
+			if value == "" {
+				value = r.URL.Query().Get(DebugLogParam)
+			}
+			if debug, _ := strconv.ParseBool(value); !debug {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal := auth.FromContext(r.Context())
+			if principal == nil || !slices.Contains(admins, principal.ClientID()) {
+				logger.Warn("Debug logging request forbidden",
+					"path", r.URL.Path,
+					"remote_addr", r.RemoteAddr,
+				)
+				apierror.Write(w, r, http.StatusForbidden, apierror.CodeForbidden, "Debug logging requires admin access")
+				return
+			}
+
+			id := requestid.FromContext(r.Context())
+			logger.Info("Debug logging enabled for request",
+				"principal", principal.ClientID(),
+				"path", r.URL.Path,
+				"debug_request", id,
+				"remote_addr", r.RemoteAddr,
+			)
+			next.ServeHTTP(w, r.WithContext(logging.WithDebug(r.Context(), id)))
+		})
+	}
+}
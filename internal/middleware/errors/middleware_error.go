@@ -1,6 +1,6 @@
 package errors
 
-import "web-analyzer/internal/errors"
+import "github.com/anjula-paulus/web-analyzer/internal/errors"
 
 // MiddlewareError is the type of errors thrown by middleware.
 type MiddlewareError struct {
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/auth"
+	"github.com/anjula-paulus/web-analyzer/internal/idempotency"
+)
+
+// IdempotencyKeyHeader lets clients safely retry POST requests
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds client-supplied keys
+const maxIdempotencyKeyLength = 255
+
+// NewIdempotencyMiddleware replays the stored response for POST requests that
+// repeat an Idempotency-Key instead of processing them again. Keys are scoped
+// to the caller and path; anonymous callers are told apart by IP address.
+// Replays carry the headers the handler set. Server errors and rate limit
+// responses are not stored, so retrying them processes the request again.
+func NewIdempotencyMiddleware(store *idempotency.Store, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || r.Method != http.MethodPost {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					apierror.Write(w, r, http.StatusRequestEntityTooLarge, apierror.CodeRequestTooLarge, "Request body is too large")
+					return
+				}
+				apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidRequest, "Failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scopedKey := idempotencyScope(r) + " " + r.URL.Path + " " + key
+			fingerprint := requestFingerprint(r, body)
+
+			stored, err := store.Begin(scopedKey, fingerprint)
+			switch {
+			case errors.Is(err, idempotency.ErrInProgress):
+				logger.Debug("Idempotent request still in progress", "path", r.URL.Path, "remote_addr", r.RemoteAddr)
+				apierror.Write(w, r, http.StatusConflict, apierror.CodeIdempotencyInProgress, "A request with this Idempotency-Key is still in progress")
+				return
+			case errors.Is(err, idempotency.ErrMismatch):
+				logger.Warn("Idempotency key reused with a different request", "path", r.URL.Path, "remote_addr", r.RemoteAddr)
+				apierror.Write(w, r, http.StatusUnprocessableEntity, apierror.CodeIdempotencyMismatch, "Idempotency-Key was already used with a different request")
+				return
+			case stored != nil:
+				logger.Info("Replaying idempotent response",
+					"path", r.URL.Path,
+					"status", stored.StatusCode,
+					"remote_addr", r.RemoteAddr,
+				)
+				for name, values := range stored.Header {
+					w.Header()[name] = values
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.StatusCode)
+				w.Write(stored.Body)
+				return
+			}
+
+			// Headers set before the handler, such as the request ID and
+			// quota, describe this request rather than the response
+			outer := w.Header().Clone()
+			rec := &recordingWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			completed := false
+			defer func() {
+				if !completed {
+					store.Abandon(scopedKey)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.statusCode >= http.StatusInternalServerError || rec.statusCode == http.StatusTooManyRequests {
+				return
+			}
+
+			header := make(http.Header)
+			for name, values := range w.Header() {
+				if !slices.Equal(outer[name], values) {
+					header[name] = slices.Clone(values)
+				}
+			}
+			store.Complete(scopedKey, &idempotency.Response{
+				StatusCode: rec.statusCode,
+				Header:     header,
+				Body:       rec.body.Bytes(),
+			})
+			completed = true
+		})
+	}
+}
+
+// idempotencyScope keeps one caller's keys from matching another's
+func idempotencyScope(r *http.Request) string {
+	if principal := auth.FromContext(r.Context()); principal != nil {
+		return principal.ClientID()
+	}
+	return "ip:" + remoteHost(r)
+}
+
+// requestFingerprint identifies the request a key was first used with
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.URL.RawQuery))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter passes a response through while keeping a copy of it
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
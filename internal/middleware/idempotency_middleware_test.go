@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/auth"
+	"github.com/anjula-paulus/web-analyzer/internal/idempotency"
+)
+
+// countingHandler answers each request with the number of requests it has
+// processed
+type countingHandler struct {
+	mu     sync.Mutex
+	calls  int
+	status int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.calls++
+	calls := h.calls
+	h.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(h.status)
+	fmt.Fprintf(w, `{"call":%d}`, calls)
+}
+
+func newIdempotencyTestHandler(status int) (http.Handler, *countingHandler) {
+	next := &countingHandler{status: status}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewIdempotencyMiddleware(idempotency.NewStore(time.Hour), logger)(next), next
+}
+
+// postIdempotent sends a POST with an Idempotency-Key, as principal when it
+// is not nil
+func postIdempotent(handler http.Handler, path, key, body string, principal *auth.Principal) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	if principal != nil {
+		req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyMiddleware_Replay(t *testing.T) {
+	handler, next := newIdempotencyTestHandler(http.StatusCreated)
+
+	first := postIdempotent(handler, "/api/v2/analyze", "key-1", `{"url":"https://example.com"}`, nil)
+	replay := postIdempotent(handler, "/api/v2/analyze", "key-1", `{"url":"https://example.com"}`, nil)
+
+	if next.calls != 1 {
+		t.Errorf("Expected the request to be processed once, got %d", next.calls)
+	}
+	if replay.Code != http.StatusCreated || replay.Body.String() != first.Body.String() {
+		t.Errorf("Expected the stored %d %s, got %d %s", first.Code, first.Body, replay.Code, replay.Body)
+	}
+	if replay.Header().Get("Idempotent-Replayed") != "true" || replay.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected replay headers %v", replay.Header())
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("Expected the first response not to be marked as replayed")
+	}
+}
+
+func TestIdempotencyMiddleware_Mismatch(t *testing.T) {
+	handler, next := newIdempotencyTestHandler(http.StatusOK)
+
+	postIdempotent(handler, "/api/v2/analyze", "key-1", `{"url":"https://example.com"}`, nil)
+	rec := postIdempotent(handler, "/api/v2/analyze", "key-1", `{"url":"https://example.org"}`, nil)
+
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), apierror.CodeIdempotencyMismatch) {
+		t.Errorf("Expected %s, got %d %s", apierror.CodeIdempotencyMismatch, rec.Code, rec.Body)
+	}
+	if next.calls != 1 {
+		t.Errorf("Expected the different request not to be processed, got %d calls", next.calls)
+	}
+}
+
+func TestIdempotencyMiddleware_InProgress(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewIdempotencyMiddleware(idempotency.NewStore(time.Hour), logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		postIdempotent(handler, "/api/v2/analyze", "key-1", `{}`, nil)
+	}()
+	<-started
+
+	rec := postIdempotent(handler, "/api/v2/analyze", "key-1", `{}`, nil)
+	close(release)
+	<-done
+
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), apierror.CodeIdempotencyInProgress) {
+		t.Errorf("Expected %s, got %d %s", apierror.CodeIdempotencyInProgress, rec.Code, rec.Body)
+	}
+}
+
+func TestIdempotencyMiddleware_Scope(t *testing.T) {
+	handler, next := newIdempotencyTestHandler(http.StatusOK)
+	alice := &auth.Principal{Subject: "alice", Method: auth.MethodAPIKey}
+	bob := &auth.Principal{Subject: "bob", Method: auth.MethodAPIKey}
+	body := `{"url":"https://example.com"}`
+
+	// The same key from other principals, anonymous callers at other
+	// addresses or on another path is a request of its own
+	post := func(path, remoteAddr string, principal *auth.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		req.RemoteAddr = remoteAddr
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	for _, req := range []struct {
+		path       string
+		remoteAddr string
+		principal  *auth.Principal
+	}{
+		{"/api/v2/analyze", "192.0.2.1:1234", alice},
+		{"/api/v2/analyze", "192.0.2.1:1234", bob},
+		{"/api/v2/analyze", "192.0.2.1:1234", nil},
+		{"/api/v2/analyze", "198.51.100.7:1234", nil},
+		{"/api/v1/analyze", "192.0.2.1:1234", alice},
+	} {
+		if rec := post(req.path, req.remoteAddr, req.principal); rec.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("Expected %s from %s for %+v not to be replayed", req.path, req.remoteAddr, req.principal)
+		}
+	}
+	if next.calls != 5 {
+		t.Errorf("Expected 5 processed requests, got %d", next.calls)
+	}
+
+	if rec := post("/api/v2/analyze", "198.51.100.7:1234", alice); rec.Body.String() != `{"call":1}` {
+		t.Errorf("Expected alice's own response to be replayed, got %s", rec.Body)
+	}
+	// Anonymous callers get their own responses back from any port
+	if rec := post("/api/v2/analyze", "198.51.100.7:5678", nil); rec.Body.String() != `{"call":4}` {
+		t.Errorf("Expected the anonymous caller's own response to be replayed, got %s", rec.Body)
+	}
+}
+
+func TestIdempotencyMiddleware_ReplayHeaders(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	idempotent := NewIdempotencyMiddleware(idempotency.NewStore(time.Hour), logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="result.csv"`)
+		w.Header().Add("Link", "</r/1>; rel=alternate")
+		w.Header().Add("Link", "</api/v1/results/1>; rel=related")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	// Headers set before the middleware belong to each request
+	requests := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-Request-ID", fmt.Sprintf("request-%d", requests))
+		idempotent.ServeHTTP(w, r)
+	})
+
+	first := postIdempotent(handler, "/api/v2/analyze", "key-1", `{}`, nil)
+	replay := postIdempotent(handler, "/api/v2/analyze", "key-1", `{}`, nil)
+
+	if replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatal("Expected the second response to be replayed")
+	}
+	for _, name := range []string{"Content-Type", "Content-Disposition", "Link"} {
+		if got, want := replay.Header().Values(name), first.Header().Values(name); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("Expected %s %q to be replayed, got %q", name, want, got)
+		}
+	}
+	if got := replay.Header().Get("X-Request-ID"); got != "request-2" {
+		t.Errorf("Expected the replay's own request ID, got %q", got)
+	}
+}
+
+func TestIdempotencyMiddleware_ServerErrorsNotStored(t *testing.T) {
+	handler, next := newIdempotencyTestHandler(http.StatusServiceUnavailable)
+
+	postIdempotent(handler, "/api/v2/analyze", "key-1", `{}`, nil)
+	rec := postIdempotent(handler, "/api/v2/analyze", "key-1", `{}`, nil)
+
+	if next.calls != 2 || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("Expected the retry to be processed again, got %d calls", next.calls)
+	}
+}
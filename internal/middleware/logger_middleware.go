@@ -2,12 +2,17 @@ package middleware
 
 import (
 	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/requestid"
 )
 
-// Logger func creates a logging middleware with structured logging
-func NewLoggerMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+// Logger func creates a logging middleware with structured logging. Only
+// sampleRate of the successful requests are logged, picked at random;
+// requests answered with a 4xx or 5xx status are always logged.
+func NewLoggerMiddleware(sampleRate float64, logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -19,10 +24,15 @@ func NewLoggerMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 
 			duration := time.Since(start)
 
+			if ww.statusCode < http.StatusBadRequest && sampleRate < 1 && rand.Float64() >= sampleRate {
+				return
+			}
+
 			logger.Info("HTTP request",
 				"method", r.Method,
 				"path", r.URL.Path,
 				"status", ww.statusCode,
+				"request_id", requestid.FromContext(r.Context()),
 				"duration", duration,
 				"remote_addr", r.RemoteAddr,
 				"user_agent", r.UserAgent(),
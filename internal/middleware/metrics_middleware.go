@@ -46,8 +46,10 @@ func getStatusClass(statusCode int) string {
 	}
 }
 
-// NewMetricsMiddleware creates a new metrics middleware
-func NewMetricsMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+// NewMetricsMiddleware creates a new metrics middleware. Requests are
+// labelled with the route pattern matched in routes rather than the raw
+// path, which keeps the number of series bounded.
+func NewMetricsMiddleware(routes *http.ServeMux, logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -63,8 +65,8 @@ func NewMetricsMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 
 			// Record metrics
 			duration := time.Since(start).Seconds()
-			method := r.Method
-			path := r.URL.Path
+			method := methodLabel(r.Method)
+			path := routeLabel(routes, r)
 			statusCode := rw.statusCode
 			statusClass := getStatusClass(statusCode)
 			statusCodeStr := strconv.Itoa(statusCode)
@@ -74,8 +76,9 @@ func NewMetricsMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 			httpRequestDuration.WithLabelValues(method, path).Observe(duration)
 
 			logger.Debug("Request processed",
-				"method", method,
-				"path", path,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"route", path,
 				"status", statusCode,
 				"duration_ms", duration*1000,
 			)
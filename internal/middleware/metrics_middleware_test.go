@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRouteLabel(t *testing.T) {
+	routes := testRoutes()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/v1/compare", "/api/v1/compare"},
+		{"/api/v1/results/abc", "/api/v1/results/{id}"},
+		{"/api/v1/results/0123456789abcdef", "/api/v1/results/{id}"},
+		{"/api/v1/results/abc/diff", "/api/v1/results/{id}/diff"},
+		{"/", "/"},
+		// Paths only the index's catch-all matches share one label
+		{"/wp-login.php", unmatchedRoute},
+		{"/api/v1/results/abc/diff/extra", unmatchedRoute},
+		{"/.env", unmatchedRoute},
+	}
+	for _, tt := range tests {
+		if got := routeLabel(routes, httptest.NewRequest(http.MethodGet, tt.path, nil)); got != tt.want {
+			t.Errorf("routeLabel(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	// Without a catch-all, unknown paths match no pattern at all
+	bare := http.NewServeMux()
+	bare.HandleFunc("/api/v1/compare", func(w http.ResponseWriter, r *http.Request) {})
+	if got := routeLabel(bare, httptest.NewRequest(http.MethodGet, "/missing", nil)); got != unmatchedRoute {
+		t.Errorf("routeLabel(/missing) = %q, want %q", got, unmatchedRoute)
+	}
+}
+
+func TestMethodLabel(t *testing.T) {
+	for method, want := range map[string]string{
+		http.MethodGet:    http.MethodGet,
+		http.MethodDelete: http.MethodDelete,
+		"PROPFIND":        "OTHER",
+		"get":             "OTHER",
+	} {
+		if got := methodLabel(method); got != want {
+			t.Errorf("methodLabel(%s) = %q, want %q", method, got, want)
+		}
+	}
+}
+
+func TestMetricsMiddleware_PathLabels(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// A status no other test uses keeps this test's series apart
+	handler := NewMetricsMiddleware(testRoutes(), logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	for _, path := range []string{"/api/v1/results/a", "/api/v1/results/b", "/api/v1/results/c", "/scan/1", "/scan/2", "/admin.php"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	counts := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != "http_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["status_code"] == "418" {
+				counts[labels["path"]] += metric.GetCounter().GetValue()
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(counts))
+	for path := range counts {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	if !slices.Equal(paths, []string{"/api/v1/results/{id}", unmatchedRoute}) {
+		t.Errorf("Expected the route pattern and the unmatched label, got %v", paths)
+	}
+	if counts["/api/v1/results/{id}"] != 3 || counts[unmatchedRoute] != 3 {
+		t.Errorf("Expected 3 requests per label, got %v", counts)
+	}
+}
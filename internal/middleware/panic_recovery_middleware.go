@@ -3,6 +3,8 @@ package middleware
 import (
 	"log/slog"
 	"net/http"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
 )
 
 // Recovery middleware recovers from panics
@@ -18,7 +20,7 @@ func NewRecoveryMiddleware(logger *slog.Logger) func(http.Handler) http.Handler
 						"remote_addr", r.RemoteAddr,
 						"user_agent", r.UserAgent(),
 					)
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, "Internal server error")
 				}
 			}()
 
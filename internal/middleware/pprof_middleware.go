@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+)
+
+// NewPprofMiddleware requires the pprof token, presented as a bearer token or
+// as the basic auth password with any username. Basic auth lets go tool pprof
+// pass the token in the profile URL. An empty token disables the check.
+func NewPprofMiddleware(token string, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if token == "" {
+			return next
+		}
+
+		// Digests have a fixed length, so comparing them does not leak the
+		// token length
+		want := sha256.Sum256([]byte(token))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			presented := bearerToken(r, "")
+			if _, password, ok := r.BasicAuth(); ok {
+				presented = password
+			}
+
+			got := sha256.Sum256([]byte(presented))
+			if subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+				logger.Warn("pprof request rejected",
+					"path", r.URL.Path,
+					"remote_addr", r.RemoteAddr,
+				)
+				w.Header().Set("WWW-Authenticate", `Basic realm="pprof"`)
+				apierror.Write(w, r, http.StatusUnauthorized, apierror.CodeUnauthorized, "Authentication required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
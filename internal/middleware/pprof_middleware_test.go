@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPprofMiddleware(t *testing.T) {
+	const token = "pprof-secret"
+
+	tests := []struct {
+		name  string
+		setup func(r *http.Request)
+		want  int
+	}{
+		{"missing token", func(r *http.Request) {}, http.StatusUnauthorized},
+		{"wrong bearer token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer wrong") }, http.StatusUnauthorized},
+		{"bearer token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }, http.StatusOK},
+		{"wrong basic password", func(r *http.Request) { r.SetBasicAuth("", "wrong") }, http.StatusUnauthorized},
+		{"token as basic username", func(r *http.Request) { r.SetBasicAuth(token, "") }, http.StatusUnauthorized},
+		{"basic password", func(r *http.Request) { r.SetBasicAuth("anyone", token) }, http.StatusOK},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewPprofMiddleware(token, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/pprof/heap", nil)
+			tt.setup(req)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, rec.Code)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected a WWW-Authenticate challenge")
+			}
+		})
+	}
+
+	// Without a token the endpoints are open
+	rec := httptest.NewRecorder()
+	NewPprofMiddleware("", logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/heap", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 without a token, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/auth"
+	"github.com/anjula-paulus/web-analyzer/internal/ratelimit"
+)
+
+// NewRateLimitMiddleware enforces per-client request rates and daily quotas.
+// Authenticated requests are counted per principal, anonymous ones per
+// remote IP, and the API keys of a tenant share the tenant's limits, which
+// take the place of the keys' own. Requests to the given public paths are
+// not limited so that probes and scrapers keep working.
+func NewRateLimitMiddleware(limiter *ratelimit.Limiter, publicPaths []string, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions || isPublicPath(r.URL.Path, publicPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			clientID, limitsKey := rateLimitClient(r)
+			decision := limiter.Allow(clientID, limitsKey)
+
+			if decision.QuotaRemaining >= 0 {
+				w.Header().Set("X-Quota-Remaining", strconv.Itoa(decision.QuotaRemaining))
+			}
+
+			if !decision.Allowed {
+				logger.Warn("Rate limit exceeded",
+					"client", clientID,
+					"reason", decision.Reason,
+					"retry_after", decision.RetryAfter,
+					"path", r.URL.Path,
+					"remote_addr", r.RemoteAddr,
+				)
+
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+				if decision.Reason == "quota" {
+					apierror.Write(w, r, http.StatusTooManyRequests, apierror.CodeQuotaExceeded, "Daily quota exceeded")
+				} else {
+					apierror.Write(w, r, http.StatusTooManyRequests, apierror.CodeRateLimited, "Rate limit exceeded")
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitClient identifies the client of a request and, for API key
+// clients, the name used to select per-key or per-tenant limits
+func rateLimitClient(r *http.Request) (string, string) {
+	if principal := auth.FromContext(r.Context()); principal != nil {
+		if principal.Tenant != "" {
+			key := ratelimit.TenantKey(principal.Tenant)
+			return key, key
+		}
+		if principal.Method == auth.MethodAPIKey {
+			return principal.ClientID(), principal.Subject
+		}
+		return principal.ClientID(), ""
+	}
+
+	return "ip:" + remoteHost(r), ""
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anjula-paulus/web-analyzer/internal/auth"
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/ratelimit"
+)
+
+func TestRateLimitMiddleware_TenantLimitsReplaceKeyLimits(t *testing.T) {
+	limiter := ratelimit.New(
+		config.RateLimitConfig{Enabled: true, DailyQuota: 1000},
+		[]config.APIKeyConfig{
+			{Name: "solo", DailyQuota: 100},
+			{Name: "team-ci", DailyQuota: 100, Tenant: "team-a"},
+			{Name: "team-dev", Tenant: "team-a"},
+		},
+		[]config.TenantConfig{{Name: "team-a", DailyQuota: 2}},
+	)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := NewRateLimitMiddleware(limiter, nil, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(key, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v2/analyze", nil)
+		req = req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{Subject: key, Method: auth.MethodAPIKey, Tenant: tenant}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		key, tenant string
+		status      int
+		remaining   string
+	}{
+		// A key without a tenant has its own quota
+		{"solo", "", http.StatusOK, "99"},
+		// The tenant's quota applies to its keys, not the key's own, and
+		// is shared between them
+		{"team-ci", "team-a", http.StatusOK, "1"},
+		{"team-dev", "team-a", http.StatusOK, "0"},
+		{"team-ci", "team-a", http.StatusTooManyRequests, "0"},
+	}
+	for _, tt := range tests {
+		rec := request(tt.key, tt.tenant)
+		if rec.Code != tt.status || rec.Header().Get("X-Quota-Remaining") != tt.remaining {
+			t.Errorf("%s: expected %d with %s remaining, got %d with %s", tt.key, tt.status, tt.remaining, rec.Code, rec.Header().Get("X-Quota-Remaining"))
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/anjula-paulus/web-analyzer/internal/requestid"
+)
+
+// NewRequestIDMiddleware tags each request with an ID, reusing a valid
+// X-Request-ID from the client, and echoes it in the response
+func NewRequestIDMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(requestid.Header)
+			if !requestid.Valid(id) {
+				if id != "" {
+					logger.Debug("Replacing invalid request ID", "remote_addr", r.RemoteAddr)
+				}
+				id = requestid.New()
+			}
+
+			w.Header().Set(requestid.Header, id)
+			next.ServeHTTP(w, r.WithContext(requestid.WithID(r.Context(), id)))
+		})
+	}
+}
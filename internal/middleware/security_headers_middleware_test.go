@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+	"github.com/anjula-paulus/web-analyzer/internal/csp"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	handler := NewSecurityHeadersMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			// The UI renders its scripts with the request's nonce
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprintf(w, `<script nonce="%s"></script>`, csp.NonceFromContext(r.Context()))
+		default:
+			apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, "Result not found")
+		}
+	}))
+
+	nonces := make(map[string]bool)
+	for _, path := range []string{"/", "/", "/api/v1/results/missing"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		for name, want := range map[string]string{
+			"X-Content-Type-Options": "nosniff",
+			"X-Frame-Options":        "DENY",
+			"Referrer-Policy":        "no-referrer",
+		} {
+			if got := rec.Header().Get(name); got != want {
+				t.Errorf("%s: expected %s %q, got %q", path, name, want, got)
+			}
+		}
+
+		policy := rec.Header().Get("Content-Security-Policy")
+		for _, directive := range []string{"default-src 'self'", "object-src 'none'", "frame-ancestors 'none'"} {
+			if !strings.Contains(policy, directive) {
+				t.Errorf("%s: expected %q in the policy, got %q", path, directive, policy)
+			}
+		}
+
+		if path != "/" {
+			continue
+		}
+		nonce := strings.TrimSuffix(strings.TrimPrefix(rec.Body.String(), `<script nonce="`), `"></script>`)
+		if nonce == "" || !strings.Contains(policy, "'nonce-"+nonce+"'") {
+			t.Errorf("Expected the page's nonce %q in the policy %q", nonce, policy)
+		}
+		if nonces[nonce] {
+			t.Errorf("Expected a fresh nonce per request, got %q twice", nonce)
+		}
+		nonces[nonce] = true
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// NewTimeoutMiddleware gives each request a deadline from the timeout
+// configured for its route in routes, or the default timeout. Handlers stop
+// their work when the request context ends and answer with their own
+// timeout error, so long-running routes can have larger budgets than quick
+// ones without a handler being cut off mid-response.
+func NewTimeoutMiddleware(cfg config.TimeoutConfig, routes *http.ServeMux, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout, ok := routeSetting(cfg.Routes, routes, r)
+			if !ok {
+				timeout = cfg.Default
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			if ctx.Err() == context.DeadlineExceeded {
+				logger.Warn("Request exceeded handler timeout",
+					"path", r.URL.Path,
+					"timeout", timeout,
+					"remote_addr", r.RemoteAddr,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// testRoutes returns a mux with the shapes of the server's routes: the
+// index catch-all, a fixed path and a path with an ID
+func testRoutes() *http.ServeMux {
+	routes := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	routes.HandleFunc("/", ok)
+	routes.HandleFunc("/api/v1/compare", ok)
+	routes.HandleFunc("/api/v1/results/{id}", ok)
+	routes.HandleFunc("/api/v1/results/{id}/diff", ok)
+	return routes
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	cfg := config.TimeoutConfig{
+		Default: 30 * time.Second,
+		Routes: map[string]time.Duration{
+			"/api/v1/compare":           time.Minute,
+			"/api/v1/results/{id}":      5 * time.Second,
+			"/api/v1/results/{id}/diff": 0,
+			// Exact paths still apply when no pattern is configured
+			"/legacy/export": 2 * time.Minute,
+		},
+	}
+
+	tests := []struct {
+		path string
+		want time.Duration
+	}{
+		{"/api/v1/compare", time.Minute},
+		{"/api/v1/results/abc", 5 * time.Second},
+		{"/api/v1/results/def", 5 * time.Second},
+		{"/api/v1/results/abc/diff", 0},
+		{"/legacy/export", 2 * time.Minute},
+		{"/", 30 * time.Second},
+		{"/unknown", 30 * time.Second},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tt := range tests {
+		var deadline time.Time
+		var hasDeadline bool
+		handler := NewTimeoutMiddleware(cfg, testRoutes(), logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, hasDeadline = r.Context().Deadline()
+		}))
+
+		before := time.Now()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		after := time.Now()
+
+		if tt.want == 0 {
+			if hasDeadline {
+				t.Errorf("%s: expected no deadline, got %v", tt.path, deadline.Sub(before))
+			}
+			continue
+		}
+		if !hasDeadline || deadline.Before(before.Add(tt.want)) || deadline.After(after.Add(tt.want)) {
+			t.Errorf("%s: expected a %v deadline, got %v", tt.path, tt.want, deadline.Sub(before))
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/anjula-paulus/web-analyzer/internal/requestid"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTracingMiddleware starts a server span for each request, continuing
+// the caller's trace when the request carries a W3C traceparent header.
+// Spans are named after the route pattern matched in routes so that
+// requests for different IDs share a name.
+func NewTracingMiddleware(routes *http.ServeMux, logger *slog.Logger) func(http.Handler) http.Handler {
+	tracer := otel.Tracer("github.com/anjula-paulus/web-analyzer/internal/middleware")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeLabel(routes, r)
+
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("http.route", route),
+					attribute.String("url.path", r.URL.Path),
+					attribute.String("client.address", r.RemoteAddr),
+				),
+			)
+			defer span.End()
+
+			rw := &responseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			span.SetAttributes(attribute.Int("http.response.status_code", rw.statusCode))
+			if id := rw.Header().Get(requestid.Header); id != "" {
+				span.SetAttributes(attribute.String("http.request_id", id))
+			}
+			if rw.statusCode >= 500 {
+				span.SetStatus(codes.Error, http.StatusText(rw.statusCode))
+			}
+
+			if span.SpanContext().IsSampled() {
+				logger.Debug("Request traced",
+					"trace_id", span.SpanContext().TraceID().String(),
+					"path", r.URL.Path,
+					"remote_addr", r.RemoteAddr,
+				)
+			}
+		})
+	}
+}
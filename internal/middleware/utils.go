@@ -1,9 +1,62 @@
 package middleware
 
 import (
+	"net"
 	"net/http"
 )
 
+// unmatchedRoute labels requests that no specific route matched
+const unmatchedRoute = "unmatched"
+
+// routeLabel returns the route pattern in routes that matches r, so that
+// requests for different IDs share a label. Requests that only reach the
+// index's catch-all pattern get unmatchedRoute, so scans for random paths
+// do not each create a label.
+func routeLabel(routes *http.ServeMux, r *http.Request) string {
+	_, route := routes.Handler(r)
+	if route == "" || (route == "/" && r.URL.Path != "/") {
+		return unmatchedRoute
+	}
+	return route
+}
+
+// routeSetting returns the setting configured for the route pattern in
+// routes that matches r, such as "/api/v1/results/{id}", so one entry covers
+// every ID. Settings keyed on the exact request path are honored too, and
+// are the only ones that apply when routes is nil.
+func routeSetting[V any](settings map[string]V, routes *http.ServeMux, r *http.Request) (V, bool) {
+	if routes != nil {
+		if _, pattern := routes.Handler(r); pattern != "" {
+			if value, ok := settings[pattern]; ok {
+				return value, true
+			}
+		}
+	}
+	value, ok := settings[r.URL.Path]
+	return value, ok
+}
+
+// remoteHost returns the IP address of the request's client
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// methodLabel returns the request method, or "OTHER" for methods the API
+// does not use
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
 	http.ResponseWriter
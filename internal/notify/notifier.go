@@ -0,0 +1,284 @@
+// Package notify posts summary cards about finished analyses to Slack or
+// Microsoft Teams incoming webhooks.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// Notification providers
+const (
+	ProviderSlack = "slack"
+	ProviderTeams = "teams"
+)
+
+// When to notify
+const (
+	OnRegression = "regression"
+	OnAlways     = "always"
+)
+
+// fact is a labelled value on a summary card
+type fact struct {
+	Name  string
+	Value string
+}
+
+// summary is the provider-independent content of a card
+type summary struct {
+	Heading string
+	URL     string
+	Facts   []fact
+}
+
+// Notifier posts a card for finished analyses in the background. Failures
+// are logged and do not affect the analysis.
+type Notifier struct {
+	client *http.Client
+	config config.NotifyConfig
+	encode func(summary) ([]byte, error)
+	logger *slog.Logger
+
+	// ctx is cancelled when Shutdown gives up on running posts
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+	pending atomic.Int64
+}
+
+// New func creates a new notifier singleton instance for the configured
+// provider. It returns nil when notifications are disabled.
+func New(cfg config.NotifyConfig, logger *slog.Logger) (*Notifier, error) {
+	var encode func(summary) ([]byte, error)
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case ProviderSlack:
+		encode = slackPayload
+	case ProviderTeams:
+		encode = teamsPayload
+	default:
+		return nil, fmt.Errorf("unknown notification provider %q", cfg.Provider)
+	}
+
+	if u, err := url.Parse(cfg.WebhookURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("notification webhook URL must be an absolute HTTP(S) URL")
+	}
+
+	switch cfg.On {
+	case OnRegression, OnAlways:
+	default:
+		return nil, fmt.Errorf("unknown notification trigger %q, want %q or %q", cfg.On, OnRegression, OnAlways)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Notifier{
+		client: &http.Client{Timeout: cfg.Timeout},
+		config: cfg,
+		encode: encode,
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Notify posts a card for result in the background when it qualifies:
+// failed and partial results are skipped, and with the "regression"
+// trigger so are results that did not regress against the previous one
+func (n *Notifier) Notify(result *analyzer.Result) {
+	if result.Error != "" || result.Partial {
+		return
+	}
+
+	var regressions []string
+	if result.PreviousDiff != nil {
+		regressions = result.PreviousDiff.Regressions()
+	}
+	if n.config.On == OnRegression && len(regressions) == 0 {
+		return
+	}
+
+	// Build the card now, as the result may be changed once this returns
+	card := summarize(result, regressions)
+
+	n.pending.Add(1)
+	n.running.Add(1)
+	go func() {
+		defer n.running.Done()
+		defer n.pending.Add(-1)
+
+		if err := n.post(card); err != nil {
+			n.logger.Error("Notification failed",
+				"provider", n.config.Provider,
+				"url", result.URL,
+				"error", err,
+			)
+			return
+		}
+		n.logger.Debug("Notification sent", "provider", n.config.Provider, "url", card.URL)
+	}()
+}
+
+// Pending returns the number of notifications still being posted
+func (n *Notifier) Pending() int {
+	return int(n.pending.Load())
+}
+
+// Shutdown waits for running notifications. When ctx ends first, the
+// remaining posts are cancelled and ctx's error is returned.
+func (n *Notifier) Shutdown(ctx context.Context) error {
+	if n.Pending() == 0 {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		n.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		n.logger.Warn("Cancelling running notifications", "pending", n.Pending())
+		n.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// post sends a card to the webhook
+func (n *Notifier) post(card summary) error {
+	body, err := n.encode(card)
+	if err != nil {
+		return fmt.Errorf("encoding card: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, n.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// Webhook URLs hold credentials, so keep them out of the log
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// summarize builds the card content for a result
+func summarize(result *analyzer.Result, regressions []string) summary {
+	card := summary{URL: result.URL}
+
+	host := result.URL
+	if u, err := url.Parse(result.URL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	if len(regressions) > 0 {
+		card.Heading = "Regression detected on " + host
+	} else {
+		card.Heading = "Analysis finished for " + host
+	}
+
+	title := result.Title
+	if title == "" {
+		title = "(none)"
+	}
+	card.Facts = []fact{
+		{Name: "Title", Value: title},
+		{Name: "Broken links", Value: strconv.Itoa(result.InaccessibleLinks)},
+		{Name: "Internal links", Value: strconv.Itoa(result.InternalLinks)},
+		{Name: "External links", Value: strconv.Itoa(result.ExternalLinks)},
+	}
+
+	if len(regressions) > 0 {
+		card.Facts = append(card.Facts, fact{Name: "Regressions", Value: strings.Join(regressions, "; ")})
+	} else if result.PreviousDiff != nil && !result.PreviousDiff.Identical {
+		card.Facts = append(card.Facts, fact{Name: "Changes", Value: strings.Join(result.PreviousDiff.Summary, "; ")})
+	}
+	return card
+}
+
+// slackPayload encodes a card as a Slack message with Block Kit blocks and
+// a plain text fallback for notifications
+func slackPayload(card summary) ([]byte, error) {
+	type text struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	type block struct {
+		Type   string `json:"type"`
+		Text   *text  `json:"text,omitempty"`
+		Fields []text `json:"fields,omitempty"`
+	}
+
+	fields := make([]text, 0, len(card.Facts))
+	for _, f := range card.Facts {
+		fields = append(fields, text{Type: "mrkdwn", Text: "*" + f.Name + "*\n" + slackEscape(f.Value)})
+	}
+
+	return json.Marshal(map[string]any{
+		"text": card.Heading + ": " + card.URL,
+		"blocks": []block{
+			{Type: "header", Text: &text{Type: "plain_text", Text: card.Heading}},
+			{Type: "section", Text: &text{Type: "mrkdwn", Text: "<" + card.URL + ">"}},
+			{Type: "section", Fields: fields},
+		},
+	})
+}
+
+// slackEscape escapes the characters Slack treats as markup
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// teamsPayload encodes a card as a Teams message carrying an Adaptive Card
+func teamsPayload(card summary) ([]byte, error) {
+	facts := make([]map[string]string, 0, len(card.Facts)+1)
+	facts = append(facts, map[string]string{"title": "URL", "value": card.URL})
+	for _, f := range card.Facts {
+		facts = append(facts, map[string]string{"title": f.Name, "value": f.Value})
+	}
+
+	return json.Marshal(map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]any{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body": []map[string]any{
+					{"type": "TextBlock", "text": card.Heading, "weight": "Bolder", "size": "Medium", "wrap": true},
+					{"type": "FactSet", "facts": facts},
+				},
+			},
+		}},
+	})
+}
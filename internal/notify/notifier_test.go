@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// regressed returns a result whose broken links went from 0 to 2
+func regressed() *analyzer.Result {
+	before := &analyzer.Result{URL: "https://example.com/docs", Title: "Docs"}
+	after := &analyzer.Result{URL: "https://example.com/docs", Title: "Docs", InternalLinks: 5, InaccessibleLinks: 2}
+	after.PreviousDiff = analyzer.DiffResults(before, after)
+	return after
+}
+
+func TestNew(t *testing.T) {
+	if n, err := New(config.NotifyConfig{}, testLogger()); n != nil || err != nil {
+		t.Errorf("New without a provider = %v, %v, want nil, nil", n, err)
+	}
+
+	for _, cfg := range []config.NotifyConfig{
+		{Provider: "discord", WebhookURL: "https://example.com/hook", On: OnAlways},
+		{Provider: ProviderSlack, On: OnAlways},
+		{Provider: ProviderSlack, WebhookURL: "https://example.com/hook", On: "sometimes"},
+	} {
+		if _, err := New(cfg, testLogger()); err == nil {
+			t.Errorf("New(%+v) succeeded, want an error", cfg)
+		}
+	}
+}
+
+func TestNotifier_Notify(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	n, err := New(config.NotifyConfig{Provider: ProviderSlack, WebhookURL: server.URL, On: OnRegression, Timeout: time.Second}, testLogger())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	n.Notify(&analyzer.Result{URL: "https://example.com", Title: "Fine"})
+	n.Notify(&analyzer.Result{URL: "https://example.com", Error: "fetch failed"})
+	n.Notify(regressed())
+	if err := n.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	if len(bodies) != 1 {
+		t.Fatalf("Posted %d notifications, want only the regression", len(bodies))
+	}
+	var payload struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Type string `json:"type"`
+		} `json:"blocks"`
+	}
+	if err := json.Unmarshal([]byte(bodies[0]), &payload); err != nil {
+		t.Fatalf("Undecodable Slack payload %s: %v", bodies[0], err)
+	}
+	if payload.Text != "Regression detected on example.com: https://example.com/docs" || len(payload.Blocks) != 3 {
+		t.Errorf("Unexpected Slack payload %s", bodies[0])
+	}
+	if !strings.Contains(bodies[0], "2 new broken links") {
+		t.Errorf("Slack payload %s does not list the regression", bodies[0])
+	}
+}
+
+func TestTeamsPayload(t *testing.T) {
+	body, err := teamsPayload(summarize(regressed(), regressed().PreviousDiff.Regressions()))
+	if err != nil {
+		t.Fatalf("teamsPayload failed: %v", err)
+	}
+
+	var payload struct {
+		Type        string `json:"type"`
+		Attachments []struct {
+			ContentType string `json:"contentType"`
+			Content     struct {
+				Type string `json:"type"`
+				Body []struct {
+					Type  string `json:"type"`
+					Facts []struct {
+						Title string `json:"title"`
+						Value string `json:"value"`
+					} `json:"facts"`
+				} `json:"body"`
+			} `json:"content"`
+		} `json:"attachments"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("Undecodable Teams payload %s: %v", body, err)
+	}
+	if payload.Type != "message" || len(payload.Attachments) != 1 || payload.Attachments[0].Content.Type != "AdaptiveCard" {
+		t.Fatalf("Unexpected Teams payload %s", body)
+	}
+
+	facts := payload.Attachments[0].Content.Body[1].Facts
+	got := map[string]string{}
+	for _, f := range facts {
+		got[f.Title] = f.Value
+	}
+	if got["URL"] != "https://example.com/docs" || got["Broken links"] != "2" || got["Regressions"] != "2 new broken links" {
+		t.Errorf("Unexpected facts %+v", facts)
+	}
+}
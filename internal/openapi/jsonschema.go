@@ -0,0 +1,74 @@
+package openapi
+
+import (
+	"strings"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// jsonSchemaDialect is the JSON Schema draft of the published schemas
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is a standalone JSON Schema for one API type. The component
+// schemas it uses are copied under $defs.
+type JSONSchema struct {
+	Schema      string             `json:"$schema"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	Ref         string             `json:"$ref"`
+	Defs        map[string]*Schema `json:"$defs"`
+}
+
+// NewResultSchema builds the JSON Schema of analysis results, for
+// consumers that validate results outside the API
+func NewResultSchema() *JSONSchema {
+	s := newJSONSchema("AnalysisResult")
+	s.Title = "Web Page Analyzer result"
+	s.Description = "Analysis result, schema_version " + analyzer.SchemaVersion
+	return s
+}
+
+// newJSONSchema builds a JSON Schema for the component schema name
+func newJSONSchema(name string) *JSONSchema {
+	components := schemas()
+	defs := map[string]*Schema{}
+
+	var add func(name string)
+	add = func(name string) {
+		if _, ok := defs[name]; ok {
+			return
+		}
+		defs[name] = nil
+		defs[name] = rebase(components[name], add)
+	}
+	add(name)
+
+	return &JSONSchema{
+		Schema: jsonSchemaDialect,
+		Ref:    "#/$defs/" + name,
+		Defs:   defs,
+	}
+}
+
+// rebase copies s with references to component schemas pointed at $defs,
+// calling add with the name of each referenced component
+func rebase(s *Schema, add func(name string)) *Schema {
+	if s == nil {
+		return nil
+	}
+
+	c := *s
+	if name, ok := strings.CutPrefix(s.Ref, componentRef); ok {
+		c.Ref = "#/$defs/" + name
+		add(name)
+	}
+	c.Items = rebase(s.Items, add)
+	c.AdditionalProperties = rebase(s.AdditionalProperties, add)
+	if s.Properties != nil {
+		c.Properties = make(map[string]*Schema, len(s.Properties))
+		for key, property := range s.Properties {
+			c.Properties[key] = rebase(property, add)
+		}
+	}
+	return &c
+}
@@ -0,0 +1,987 @@
+package openapi
+
+import "encoding/json"
+
+// Schema is the subset of the OpenAPI 3 schema object used by the API.
+// The same definitions are served in the document and used for validation.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Closed               bool               `json:"-"`
+}
+
+// MarshalJSON renders closed object schemas with additionalProperties: false
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	if s.Closed {
+		return json.Marshal(struct {
+			*plain
+			AdditionalProperties bool `json:"additionalProperties"`
+		}{(*plain)(s), false})
+	}
+	return json.Marshal((*plain)(s))
+}
+
+// Document is the root OpenAPI 3 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info holds API metadata
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem maps HTTP methods to operations
+type PathItem map[string]Operation
+
+// Operation describes a single API operation
+type Operation struct {
+	Summary     string              `json:"summary"`
+	Description string              `json:"description,omitempty"`
+	OperationID string              `json:"operationId"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// RequestBody describes an operation's request payload
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes an operation response
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType binds a schema to a content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds reusable schema definitions
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema names used by handlers for request validation
+const (
+	AnalyzeRequestSchema = "AnalyzeRequest"
+	CompareRequestSchema = "CompareRequest"
+	SiteRequestSchema    = "SiteRequest"
+	WorkersRequestSchema = "WorkersRequest"
+)
+
+// maxURLLength bounds URLs accepted by the API
+const maxURLLength = 2048
+
+// maxExtractSelectors and maxSelectorLength bound the extract option
+const (
+	maxExtractSelectors = 20
+	maxSelectorLength   = 512
+)
+
+// schemas returns the reusable component schemas
+func schemas() map[string]*Schema {
+	return map[string]*Schema{
+		AnalyzeRequestSchema: {
+			Type:        "object",
+			Description: "Request to analyze a single web page",
+			Required:    []string{"url"},
+			Closed:      true,
+			Properties: map[string]*Schema{
+				"url": {
+					Type:        "string",
+					Format:      "uri",
+					Description: "URL of the page to analyze. A missing scheme defaults to http.",
+					MinLength:   intPtr(1),
+					MaxLength:   intPtr(maxURLLength),
+				},
+				"callback_url": {
+					Type:        "string",
+					Format:      "uri",
+					Description: "Absolute http(s) URL that receives the result as a signed POST once the analysis finishes.",
+					MaxLength:   intPtr(maxURLLength),
+				},
+				"sections": {
+					Type:        "array",
+					Description: "Optional result sections to populate",
+					Items:       &Schema{Type: "string", Enum: []string{"seo", "security", "accessibility", "performance", "web_vitals", "outline", "third_party", "phishing"}},
+				},
+				"fail_on": {
+					Type:        "string",
+					Enum:        []string{"error", "warning"},
+					Description: "Add a verdict to the result that fails on findings of this severity or worse",
+				},
+				"device": {
+					Type:        "string",
+					Enum:        []string{"desktop", "mobile", "tablet"},
+					Description: "Fetch the page with this device's User-Agent and, for the web_vitals section, run Lighthouse emulating it; tablet runs Lighthouse's mobile strategy",
+				},
+				"extract": {
+					Type:        "array",
+					Description: "CSS selectors whose match counts and text are reported in extractions. Type, universal, id, class and attribute selectors with descendant and child combinators are supported.",
+					Items:       &Schema{Type: "string", MinLength: intPtr(1), MaxLength: intPtr(maxSelectorLength)},
+					MaxItems:    intPtr(maxExtractSelectors),
+				},
+			},
+		},
+		CompareRequestSchema: {
+			Type:        "object",
+			Description: "Request to analyze two web pages and diff their metrics",
+			Required:    []string{"base_url"},
+			Closed:      true,
+			Properties: map[string]*Schema{
+				"base_url":   {Type: "string", Format: "uri", Description: "Reference page, e.g. production", MinLength: intPtr(1), MaxLength: intPtr(maxURLLength)},
+				"target_url": {Type: "string", Format: "uri", Description: "Page compared against the base, e.g. staging. Required unless base_date is set, when it defaults to base_url.", MinLength: intPtr(1), MaxLength: intPtr(maxURLLength)},
+				"base_date":  {Type: "string", Format: "date", Description: "Analyze the Wayback Machine snapshot of base_url closest to this date instead of the live page"},
+			},
+		},
+		SiteRequestSchema: {
+			Type:        "object",
+			Description: "Request to probe the http/https and www/non-www variants of a domain and analyze the page they lead to",
+			Required:    []string{"domain"},
+			Closed:      true,
+			Properties: map[string]*Schema{
+				"domain": {Type: "string", Description: "Domain to probe, e.g. example.com. A www. prefix, scheme and path are ignored.", MinLength: intPtr(1), MaxLength: intPtr(maxURLLength)},
+				"sections": {
+					Type:        "array",
+					Description: "Optional result sections to populate",
+					Items:       &Schema{Type: "string", Enum: []string{"seo", "security", "accessibility", "performance", "web_vitals", "outline", "third_party", "phishing"}},
+				},
+			},
+		},
+		WorkersRequestSchema: {
+			Type:        "object",
+			Description: "Analyzer worker limit",
+			Required:    []string{"max_workers"},
+			Closed:      true,
+			Properties: map[string]*Schema{
+				"max_workers": {Type: "integer", Description: "Concurrent link checkers per analysis", Minimum: intPtr(1), Maximum: intPtr(1000)},
+			},
+		},
+		"CompareResult": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"base":   ref("AnalysisResult"),
+				"target": ref("AnalysisResult"),
+				"diff":   ref("Diff"),
+				"snapshot": {
+					Type:        "object",
+					Description: "The archived copy analyzed as the base, when base_date was set",
+					Properties: map[string]*Schema{
+						"url":       {Type: "string"},
+						"timestamp": {Type: "string", Format: "date-time"},
+					},
+				},
+			},
+		},
+		"SiteReport": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"domain": {Type: "string", Description: "Probed domain without a www. prefix"},
+				"variants": {
+					Type:        "array",
+					Description: "https, https with www, http and http with www, in that order",
+					Items: &Schema{
+						Type: "object",
+						Properties: map[string]*Schema{
+							"url":          {Type: "string"},
+							"responds":     {Type: "boolean"},
+							"status":       {Type: "integer", Description: "Status of the variant's own response"},
+							"redirects":    {Type: "array", Description: "Location of each redirect followed", Items: &Schema{Type: "string"}},
+							"final_url":    {Type: "string"},
+							"final_status": {Type: "integer"},
+							"error":        {Type: "string", Description: "Why the variant or a redirect target did not respond"},
+						},
+					},
+				},
+				"canonical_origin": {Type: "string", Description: "Origin every responding variant ends up on; omitted when they differ"},
+				"result":           ref("AnalysisResult"),
+			},
+		},
+		"Diff": {
+			Type:        "object",
+			Description: "Metric changes from base to target. Omitted when either analysis failed.",
+			Properties: map[string]*Schema{
+				"identical": {Type: "boolean"},
+				"summary":   {Type: "array", Items: &Schema{Type: "string"}},
+				"changes": {
+					Type: "array",
+					Items: &Schema{
+						Type: "object",
+						Properties: map[string]*Schema{
+							"field":  {Type: "string", Description: "e.g. title, headings.h2, seo.meta_description or security.headers.Content-Security-Policy"},
+							"before": {Description: "Value in the base result"},
+							"after":  {Description: "Value in the target result"},
+							"delta":  {Type: "integer", Description: "after minus before, for counts"},
+						},
+					},
+				},
+			},
+		},
+		"AnalysisRecord": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":         {Type: "string"},
+				"url":        {Type: "string"},
+				"created_at": {Type: "string", Format: "date-time"},
+				"result":     ref("AnalysisResult"),
+			},
+		},
+		"ResultList": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"results": {Type: "array", Items: ref("AnalysisRecord")},
+				"limit":   {Type: "integer"},
+				"offset":  {Type: "integer"},
+			},
+		},
+		"ImportStats": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"imported": {Type: "integer"},
+				"skipped":  {Type: "integer", Description: "Records whose ID was already stored"},
+			},
+		},
+		"Trend": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"url": {Type: "string"},
+				"points": {
+					Type: "array",
+					Items: &Schema{
+						Type: "object",
+						Properties: map[string]*Schema{
+							"id":                 {Type: "string"},
+							"created_at":         {Type: "string", Format: "date-time"},
+							"inaccessible_links": {Type: "integer"},
+							"page_bytes":         {Type: "integer", Description: "Set for results analyzed with the performance section"},
+						},
+					},
+				},
+				"inaccessible_links_change": {Type: "integer", Description: "Last minus first point"},
+				"page_bytes_change":         {Type: "integer", Description: "Last minus first measured page size"},
+			},
+		},
+		"ResultDiff": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":          {Type: "string"},
+				"previous_id": {Type: "string"},
+				"url":         {Type: "string"},
+				"diff":        ref("Diff"),
+			},
+		},
+		"Finding": {
+			Type:     "object",
+			Required: []string{"id", "severity", "category", "message"},
+			Properties: map[string]*Schema{
+				"id":       {Type: "string", Description: "Stable name of the check, e.g. missing-title"},
+				"severity": {Type: "string", Enum: []string{"error", "warning", "info"}},
+				"category": {Type: "string", Enum: []string{"seo", "links", "content", "accessibility", "security", "custom"}, Description: "custom holds the findings of configured rules"},
+				"message":  {Type: "string"},
+				"evidence": {Type: "array", Items: &Schema{Type: "string"}, Description: "What the check found, such as URLs or header names"},
+			},
+		},
+		"IDNHost": {
+			Type:        "object",
+			Description: "An internationalized domain name in both forms",
+			Required:    []string{"ascii", "unicode", "scripts"},
+			Properties: map[string]*Schema{
+				"ascii":     {Type: "string", Description: "Punycode form, as sent in DNS and HTTP"},
+				"unicode":   {Type: "string", Description: "Decoded form people read"},
+				"scripts":   {Type: "array", Items: &Schema{Type: "string"}, Description: "Unicode scripts of the name's letters, e.g. Latin and Cyrillic"},
+				"homograph": {Type: "boolean", Description: "Set when a label mixes scripts that are not written together"},
+			},
+		},
+		"Download": {
+			Type:        "object",
+			Description: "A link to a file rather than a page",
+			Required:    []string{"url", "kind"},
+			Properties: map[string]*Schema{
+				"url":          {Type: "string"},
+				"kind":         {Type: "string", Enum: []string{"document", "spreadsheet", "presentation", "archive", "installer"}, Description: "From the Content-Type of the link check when it names a file, otherwise from the extension"},
+				"extension":    {Type: "string", Description: "Lower case file extension, e.g. pdf, when it names a file"},
+				"content_type": {Type: "string", Description: "Media type of the link check's response; absent when the link was not requested"},
+			},
+		},
+		"LinkDepth": {
+			Type:        "object",
+			Description: "Path depth distribution of the http(s) internal links; a link's depth is the number of segments in its path, so / is 0",
+			Required:    []string{"counts", "max", "mean", "deep"},
+			Properties: map[string]*Schema{
+				"counts": {Type: "object", AdditionalProperties: &Schema{Type: "integer"}, Description: "Number of links by depth"},
+				"max":    {Type: "integer"},
+				"mean":   {Type: "number", Description: "Average depth, to two decimals"},
+				"deep":   {Type: "integer", Description: "Number of links at depth 4 or more"},
+			},
+		},
+		"AnalysisResult": {
+			Type:     "object",
+			Required: []string{"schema_version", "url"},
+			Properties: map[string]*Schema{
+				"schema_version":     {Type: "string", Description: "Version of this schema, MAJOR.MINOR. Minor versions only add optional fields."},
+				"id":                 {Type: "string", Description: "Stored result ID, set for successful analyses"},
+				"permalink":          {Type: "string", Description: "Path of the stored result's shareable page, /r/{id}"},
+				"url":                {Type: "string"},
+				"html_version":       {Type: "string"},
+				"title":              {Type: "string", Description: "Text of the first title element, whitespace collapsed; SVG and MathML titles are ignored"},
+				"title_count":        {Type: "integer", Description: "Number of title elements, not counting SVG and MathML titles"},
+				"headings":           {Type: "object", AdditionalProperties: &Schema{Type: "integer"}},
+				"internal_links":     {Type: "integer"},
+				"external_links":     {Type: "integer"},
+				"inaccessible_links": {Type: "integer"},
+				"link_schemes":       {Type: "object", AdditionalProperties: &Schema{Type: "integer"}, Description: "Links that are not http(s), counted by scheme: mailto, tel, sms, javascript, ftp, data or other"},
+				"link_depth":         ref("LinkDepth"),
+				"downloads":          {Type: "array", Items: ref("Download"), Description: "Links to documents, spreadsheets, presentations, archives and installers, in document order"},
+				"has_login_form":     {Type: "boolean"},
+				"error":              {Type: "string", Description: "Set when the analysis failed"},
+				"previous_diff":      ref("Diff"),
+				"cached":             {Type: "boolean", Description: "Set when the result was served from the result cache"},
+				"analyzed_at":        {Type: "string", Format: "date-time", Description: "When a cached result was analyzed"},
+				"partial":            {Type: "boolean", Description: "Set when the analysis was cut short before every link was checked"},
+				"findings": {
+					Type:        "array",
+					Items:       ref("Finding"),
+					Description: "Problems found by the checks on the core fields and the requested sections, grouped by category; absent when there are none",
+				},
+				"verdict": {
+					Type:        "object",
+					Description: "Set when the request has fail_on",
+					Properties: map[string]*Schema{
+						"fail_on":  {Type: "string", Enum: []string{"error", "warning"}},
+						"passed":   {Type: "boolean", Description: "False when the analysis failed or a finding is at least as severe as fail_on"},
+						"failures": {Type: "integer", Description: "Findings at least as severe as fail_on"},
+					},
+				},
+				"device": {Type: "string", Enum: []string{"desktop", "mobile", "tablet"}, Description: "Device preset the page was analyzed as, when the request had one"},
+				"extractions": {
+					Type:        "array",
+					Description: "Matches of the request's extract selectors, in the same order; absent when none were given",
+					Items: &Schema{
+						Type:     "object",
+						Required: []string{"selector", "count", "matches"},
+						Properties: map[string]*Schema{
+							"selector":  {Type: "string"},
+							"count":     {Type: "integer", Description: "Number of matching elements"},
+							"matches":   {Type: "array", Items: &Schema{Type: "string"}, Description: "Text content of the matching elements in document order, whitespace collapsed, up to 100"},
+							"truncated": {Type: "boolean", Description: "Set when more elements matched than matches lists"},
+						},
+					},
+				},
+				"script_dependency": {
+					Type:        "object",
+					Description: "Set when the page seems to need JavaScript to show its content, so the result describes the HTML before scripts run",
+					Properties: map[string]*Schema{
+						"noscript_only":        {Type: "boolean", Description: "The page's text is inside noscript elements"},
+						"empty_body":           {Type: "boolean", Description: "The page has scripts but hardly any text, like a single-page app shell"},
+						"text_length":          {Type: "integer", Description: "Non-space characters of page text outside scripts, styles, templates and noscript"},
+						"noscript_text_length": {Type: "integer", Description: "Non-space characters of text inside noscript elements"},
+						"scripts":              {Type: "integer"},
+						"advice":               {Type: "string"},
+					},
+				},
+				"idn": {
+					Type:        "object",
+					Description: "Set when the page or its links are on internationalized domain names",
+					Properties: map[string]*Schema{
+						"host":  ref("IDNHost"),
+						"links": {Type: "array", Items: ref("IDNHost"), Description: "Other internationalized hosts links point to, in document order"},
+					},
+				},
+				"parking": {
+					Type:        "object",
+					Description: "Set when the page is a parked domain or a coming soon placeholder",
+					Properties: map[string]*Schema{
+						"kind":    {Type: "string", Enum: []string{"parked", "coming_soon"}},
+						"signals": {Type: "array", Items: &Schema{Type: "string"}, Description: "What gave the page away, such as a parking service it points to or a phrase it says"},
+					},
+				},
+				"meta_refresh": {
+					Type:        "object",
+					Description: "Set when the requested page redirects with a meta refresh",
+					Properties: map[string]*Schema{
+						"url":           {Type: "string", Description: "Resolved redirect target; absent when the refresh reloads the page"},
+						"delay_seconds": {Type: "integer"},
+						"followed":      {Type: "array", Items: &Schema{Type: "string"}, Description: "Pages fetched by following meta refreshes; the result describes the last"},
+					},
+				},
+				"frames": {
+					Type:        "object",
+					Description: "Set when the page is built from legacy frameset and frame elements",
+					Properties: map[string]*Schema{
+						"frameset":  {Type: "boolean"},
+						"sources":   {Type: "array", Items: &Schema{Type: "string"}, Description: "Resolved frame URLs in document order"},
+						"noframes":  {Type: "boolean", Description: "Set when the frameset has a noframes fallback"},
+						"documents": {Type: "array", Items: ref("AnalysisResult"), Description: "Analyses of the frame sources, with analyzer.max_frames; failed ones have only url and error"},
+					},
+				},
+				"seo": {
+					Type:        "object",
+					Description: "Set when the seo section was requested",
+					Properties: map[string]*Schema{
+						"lang":             {Type: "string"},
+						"meta_description": {Type: "string"},
+						"canonical":        {Type: "string"},
+						"breadcrumbs": {
+							Type:        "object",
+							Description: "Set when the page has a breadcrumb trail",
+							Properties: map[string]*Schema{
+								"source": {Type: "string", Enum: []string{"json-ld", "microdata", "nav", "class"}, Description: "How the trail was found; structured data is preferred"},
+								"items": {
+									Type: "array",
+									Items: &Schema{
+										Type: "object",
+										Properties: map[string]*Schema{
+											"name": {Type: "string"},
+											"url":  {Type: "string", Description: "Resolved link; absent for steps without one"},
+										},
+									},
+								},
+							},
+						},
+						"languages": {
+							Type:        "object",
+							Description: "Set when the page declares a language or its text is in one that can be detected",
+							Properties: map[string]*Schema{
+								"content_language": {Type: "array", Items: &Schema{Type: "string"}, Description: "Languages of the Content-Language header, for fetched pages"},
+								"hreflang":         {Type: "string", Description: "hreflang of the page's alternate link to itself"},
+								"detected":         {Type: "string", Description: "Language of the page's text: en, de, fr, es, it, pt, nl, ja, zh, ko, he, el or th"},
+								"mismatch":         {Type: "boolean", Description: "Set when lang and the languages above disagree; only primary subtags are compared"},
+							},
+						},
+					},
+				},
+				"security": {
+					Type:        "object",
+					Description: "Set when the security section was requested",
+					Properties: map[string]*Schema{
+						"https":           {Type: "boolean"},
+						"headers":         {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+						"missing_headers": {Type: "array", Items: &Schema{Type: "string"}},
+						"referrer_policy": {
+							Type:        "object",
+							Description: "Parsed Referrer-Policy header, when the response has one",
+							Properties: map[string]*Schema{
+								"policy":  {Type: "string", Description: "Policy browsers apply: the last recognized value"},
+								"unknown": {Type: "array", Description: "Values browsers ignore", Items: &Schema{Type: "string"}},
+								"issues":  {Type: "array", Description: "Risky or missing policy, e.g. unsafe-url", Items: &Schema{Type: "string"}},
+							},
+						},
+						"permissions_policy": {
+							Type:        "object",
+							Description: "Parsed Permissions-Policy header, when the response has one",
+							Properties: map[string]*Schema{
+								"features": {
+									Type: "array",
+									Items: &Schema{
+										Type: "object",
+										Properties: map[string]*Schema{
+											"name":      {Type: "string"},
+											"allowlist": {Type: "array", Description: "*, self, src and origins; empty when the feature is disabled", Items: &Schema{Type: "string"}},
+										},
+									},
+								},
+								"issues": {Type: "array", Description: "Features allowed for every origin and members that could not be parsed", Items: &Schema{Type: "string"}},
+							},
+						},
+						"open_redirect_candidates": {
+							Type:        "array",
+							Description: "Links and form actions on the page's host whose query passes an absolute URL, possible open redirects to review",
+							Items: &Schema{
+								Type: "object",
+								Properties: map[string]*Schema{
+									"element":   {Type: "string", Enum: []string{"a", "area", "form"}},
+									"url":       {Type: "string", Description: "Resolved link or form action"},
+									"parameter": {Type: "string"},
+									"target":    {Type: "string", Description: "Decoded absolute URL in the parameter"},
+								},
+							},
+						},
+					},
+				},
+				"accessibility": {
+					Type:        "object",
+					Description: "Set when the accessibility section was requested",
+					Properties: map[string]*Schema{
+						"images":             {Type: "integer"},
+						"images_missing_alt": {Type: "integer"},
+						"tables": {
+							Type: "array",
+							Items: &Schema{
+								Type: "object",
+								Properties: map[string]*Schema{
+									"position":       {Type: "integer", Description: "Counts the page's tables from 1 in document order"},
+									"rows":           {Type: "integer"},
+									"header_cells":   {Type: "integer", Description: "Number of th cells"},
+									"scoped_headers": {Type: "integer", Description: "Number of th cells with a scope attribute"},
+									"caption":        {Type: "boolean"},
+									"layout":         {Type: "boolean", Description: "The table appears to lay out the page rather than hold data"},
+								},
+							},
+						},
+					},
+				},
+				"performance": {
+					Type:        "object",
+					Description: "Set when the performance section was requested",
+					Properties: map[string]*Schema{
+						"response_ms":   {Type: "integer"},
+						"page_bytes":    {Type: "integer"},
+						"link_check_ms": {Type: "integer"},
+						"inline_svgs":   {Type: "integer", Description: "svg elements not inside another svg"},
+						"icon_fonts":    {Type: "array", Items: &Schema{Type: "string"}, Description: "Icon fonts the page uses, by library or @font-face family"},
+						"icon_elements": {Type: "integer", Description: "Elements with icon font classes"},
+						"caching": {
+							Type:        "object",
+							Description: "How the response may be cached, for fetched pages",
+							Required:    []string{"cacheability"},
+							Properties: map[string]*Schema{
+								"cacheability":  {Type: "string", Enum: []string{"none", "private", "revalidate", "heuristic", "shared"}, Description: "shared means CDNs and other shared caches may store the response"},
+								"cache_control": {Type: "string"},
+								"max_age":       {Type: "integer", Description: "Seconds"},
+								"s_maxage":      {Type: "integer", Description: "Seconds"},
+								"etag":          {Type: "string"},
+								"last_modified": {Type: "string"},
+								"vary":          {Type: "array", Items: &Schema{Type: "string"}},
+								"age":           {Type: "integer", Description: "Seconds the response had been cached, from the Age header"},
+								"issues":        {Type: "array", Items: &Schema{Type: "string"}},
+							},
+						},
+						"cdn": {
+							Type:        "object",
+							Description: "Edge provider that served the page, detected from its headers",
+							Required:    []string{"provider", "evidence"},
+							Properties: map[string]*Schema{
+								"provider": {Type: "string", Description: "e.g. Cloudflare, Fastly, Amazon CloudFront or Akamai"},
+								"cache":    {Type: "string", Description: "Cache status the provider reported, e.g. HIT or MISS"},
+								"evidence": {Type: "array", Items: &Schema{Type: "string"}, Description: "Headers the provider was recognized by"},
+							},
+						},
+					},
+				},
+				"web_vitals": {
+					Type:        "object",
+					Description: "Set when the web_vitals section was requested; Core Web Vitals from PageSpeed Insights",
+					Properties: map[string]*Schema{
+						"strategy":          {Type: "string"},
+						"performance_score": {Type: "integer", Description: "Lighthouse performance score from 0 to 100"},
+						"lcp_ms":            {Type: "number"},
+						"fcp_ms":            {Type: "number"},
+						"tbt_ms":            {Type: "number"},
+						"speed_index_ms":    {Type: "number"},
+						"cls":               {Type: "number"},
+						"field": {
+							Type:        "object",
+							Description: "75th percentile values for real users, when available",
+							Properties: map[string]*Schema{
+								"category": {Type: "string"},
+								"lcp_ms":   {Type: "integer"},
+								"inp_ms":   {Type: "integer"},
+								"cls":      {Type: "number"},
+							},
+						},
+						"error": {Type: "string", Description: "Set when PageSpeed Insights could not be queried"},
+					},
+				},
+				"outline": {
+					Type:        "object",
+					Description: "Set when the outline section was requested; the page's headings in document order",
+					Properties: map[string]*Schema{
+						"headings": {
+							Type: "array",
+							Items: &Schema{
+								Type: "object",
+								Properties: map[string]*Schema{
+									"level":    {Type: "integer", Description: "1 for h1 through 6 for h6"},
+									"text":     {Type: "string"},
+									"position": {Type: "integer", Description: "Place among the page's headings, from 1"},
+								},
+							},
+						},
+						"truncated": {Type: "boolean", Description: "Set when the page has more headings than analyzer.max_outline_entries"},
+					},
+				},
+				"third_party": {
+					Type:        "object",
+					Description: "Set when the third_party section was requested",
+					Properties: map[string]*Schema{
+						"origins": {
+							Type:        "array",
+							Description: "Other origins the page loads resources from, most used first",
+							Items: &Schema{
+								Type: "object",
+								Properties: map[string]*Schema{
+									"origin":      {Type: "string"},
+									"resources":   {Type: "integer"},
+									"scripts":     {Type: "integer"},
+									"styles":      {Type: "integer"},
+									"images":      {Type: "integer"},
+									"frames":      {Type: "integer"},
+									"preconnects": {Type: "integer", Description: "preconnect and dns-prefetch hints"},
+								},
+							},
+						},
+					},
+				},
+				"phishing": {
+					Type:        "object",
+					Description: "Set when the phishing section was requested",
+					Properties: map[string]*Schema{
+						"score": {Type: "integer", Description: "Sum of the signal weights, from 0 to 100"},
+						"risk":  {Type: "string", Enum: []string{"low", "medium", "high"}, Description: "high from a score of 60, medium from 30"},
+						"signals": {
+							Type: "array",
+							Items: &Schema{
+								Type: "object",
+								Properties: map[string]*Schema{
+									"id":     {Type: "string", Enum: []string{"cross-origin-login", "brand-mismatch", "data-uri-form", "punycode-host"}},
+									"weight": {Type: "integer"},
+									"detail": {Type: "string"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"Error": {
+			Type:        "object",
+			Description: "Error envelope returned by every endpoint",
+			Required:    []string{"error"},
+			Properties: map[string]*Schema{
+				"error": {
+					Type:     "object",
+					Required: []string{"code", "message"},
+					Properties: map[string]*Schema{
+						"code":    {Type: "string", Description: "Machine-readable error code"},
+						"message": {Type: "string"},
+						"details": {
+							Type:        "array",
+							Description: "Per-field validation problems",
+							Items: &Schema{
+								Type: "object",
+								Properties: map[string]*Schema{
+									"field":   {Type: "string"},
+									"message": {Type: "string"},
+								},
+							},
+						},
+						"request_id": {Type: "string", Description: "Matches the X-Request-ID response header"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// NewDocument builds the OpenAPI document describing the HTTP API
+func NewDocument(version string) *Document {
+	return &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "Web Page Analyzer API",
+			Description: "Analyzes web pages for HTML structure, links, and forms.",
+			Version:     version,
+		},
+		Paths: map[string]PathItem{
+			"/api/v1/analyze": {
+				"post": {
+					Summary:     "Analyze a web page",
+					OperationID: "analyze",
+					RequestBody: jsonBody(AnalyzeRequestSchema),
+					Responses: map[string]Response{
+						"200": jsonResponse("Analysis result. Failed analyses set the error field.", "AnalysisResult"),
+						"400": jsonResponse("Invalid request", "Error"),
+						"405": jsonResponse("Method not allowed", "Error"),
+						"429": jsonResponse("Too many analyses are running", "Error"),
+					},
+				},
+			},
+			"/api/v2/analyze": {
+				"post": {
+					Summary:     "Analyze a web page, reporting failures with HTTP status codes",
+					OperationID: "analyzeV2",
+					RequestBody: jsonBody(AnalyzeRequestSchema),
+					Responses: map[string]Response{
+						"200": jsonResponse("Analysis result", "AnalysisResult"),
+						"400": jsonResponse("Invalid request or URL", "Error"),
+						"405": jsonResponse("Method not allowed", "Error"),
+						"422": jsonResponse("Target page is not HTML or is too large", "Error"),
+						"429": jsonResponse("Too many analyses are running", "Error"),
+						"502": jsonResponse("Target page could not be fetched or answered with an error status", "Error"),
+						"503": jsonResponse("Request was canceled", "Error"),
+						"504": jsonResponse("Target page timed out", "Error"),
+					},
+				},
+			},
+			"/api/v1/compare": {
+				"post": {
+					Summary:     "Analyze two web pages and diff their metrics",
+					OperationID: "compare",
+					RequestBody: jsonBody(CompareRequestSchema),
+					Responses: map[string]Response{
+						"200": jsonResponse("Both results and their diff. Failed analyses set the error field.", "CompareResult"),
+						"400": jsonResponse("Invalid request", "Error"),
+						"405": jsonResponse("Method not allowed", "Error"),
+					},
+				},
+			},
+			"/api/v1/site": {
+				"post": {
+					Summary:     "Probe the http/https and www/non-www variants of a domain and analyze the page they lead to",
+					OperationID: "analyzeSite",
+					RequestBody: jsonBody(SiteRequestSchema),
+					Responses: map[string]Response{
+						"200": jsonResponse("Variants and the analysis of the first responding one's final page. A failed analysis sets the result's error field.", "SiteReport"),
+						"400": jsonResponse("Invalid request or domain", "Error"),
+						"405": jsonResponse("Method not allowed", "Error"),
+						"429": jsonResponse("Too many analyses are running", "Error"),
+						"503": jsonResponse("Request was canceled", "Error"),
+						"504": jsonResponse("Request timed out", "Error"),
+					},
+				},
+			},
+			"/api/v1/results": {
+				"get": {
+					Summary:     "List stored results newest first, optionally for the url query parameter, paged with limit (default 50) and offset",
+					OperationID: "listResults",
+					Responses: map[string]Response{
+						"200": jsonResponse("A page of stored results", "ResultList"),
+						"400": jsonResponse("Invalid limit or offset", "Error"),
+					},
+				},
+				"delete": {
+					Summary:     "Delete every stored result for the URL given in the url query parameter",
+					OperationID: "deleteResultsByURL",
+					Responses: map[string]Response{
+						"200": {Description: "Number of deleted results"},
+						"400": jsonResponse("Missing url parameter", "Error"),
+					},
+				},
+			},
+			"/api/v1/results/{id}": {
+				"get": {
+					Summary:     "Fetch a stored analysis result",
+					OperationID: "getResult",
+					Responses: map[string]Response{
+						"200": jsonResponse("Stored result", "AnalysisRecord"),
+						"404": jsonResponse("Result not found", "Error"),
+					},
+				},
+				"delete": {
+					Summary:     "Delete a stored analysis result",
+					OperationID: "deleteResult",
+					Responses: map[string]Response{
+						"204": {Description: "Result deleted"},
+						"404": jsonResponse("Result not found", "Error"),
+					},
+				},
+			},
+			"/api/v1/results/{id}/diff": {
+				"get": {
+					Summary:     "Diff a stored result against the previous result for the same URL",
+					OperationID: "getResultDiff",
+					Responses: map[string]Response{
+						"200": jsonResponse("Changes since the previous result", "ResultDiff"),
+						"404": jsonResponse("Result or previous result not found", "Error"),
+					},
+				},
+			},
+			"/api/v1/trends": {
+				"get": {
+					Summary:     "Inaccessible links and page size over time for the url query parameter, using at most limit recent results (default 100)",
+					OperationID: "getTrends",
+					Responses: map[string]Response{
+						"200": jsonResponse("Stored results for the URL, oldest first", "Trend"),
+						"400": jsonResponse("Missing url or invalid limit", "Error"),
+						"404": jsonResponse("No results stored for the URL", "Error"),
+					},
+				},
+			},
+			"/api/v1/results/{id}/report.html": {
+				"get": {
+					Summary:     "Render a stored result as a standalone HTML report",
+					OperationID: "getResultReport",
+					Responses: map[string]Response{
+						"200": {Description: "Self-contained HTML report"},
+						"404": jsonResponse("Result not found", "Error"),
+					},
+				},
+			},
+			"/api/v1/admin/config": {
+				"get": {
+					Summary:     "Effective configuration with secrets redacted. Requires an admin principal.",
+					OperationID: "adminConfig",
+					Responses:   adminResponses(Response{Description: "Effective configuration"}),
+				},
+			},
+			"/api/v1/admin/stats": {
+				"get": {
+					Summary:     "Runtime statistics. Requires an admin principal.",
+					OperationID: "adminStats",
+					Responses:   adminResponses(Response{Description: "Analyzer workload, pending webhooks, and stored results"}),
+				},
+			},
+			"/api/v1/admin/workers": {
+				"get": {
+					Summary:     "Current analyzer worker limit. Requires an admin principal.",
+					OperationID: "getWorkers",
+					Responses:   adminResponses(jsonResponse("Worker limit", WorkersRequestSchema)),
+				},
+				"put": {
+					Summary:     "Change the analyzer worker limit without restarting. Requires an admin principal.",
+					OperationID: "setWorkers",
+					RequestBody: jsonBody(WorkersRequestSchema),
+					Responses: map[string]Response{
+						"200": jsonResponse("Updated worker limit", WorkersRequestSchema),
+						"400": jsonResponse("Invalid request", "Error"),
+						"401": jsonResponse("Not authenticated", "Error"),
+						"403": jsonResponse("Not an admin", "Error"),
+					},
+				},
+			},
+			"/api/v1/admin/history": {
+				"get": {
+					Summary:     "Export every stored record, oldest first, as NDJSON or with format=json as a JSON array. Requires an admin principal.",
+					OperationID: "exportHistory",
+					Responses:   adminResponses(jsonResponse("One AnalysisRecord per line, or an array of them", "AnalysisRecord")),
+				},
+				"post": {
+					Summary:     "Import an NDJSON or JSON array history export. Records already stored are skipped. Requires an admin principal.",
+					OperationID: "importHistory",
+					Responses: map[string]Response{
+						"200": jsonResponse("Import counts", "ImportStats"),
+						"400": jsonResponse("Malformed dump; records before the error were imported", "Error"),
+						"401": jsonResponse("Not authenticated", "Error"),
+						"403": jsonResponse("Not an admin", "Error"),
+					},
+				},
+			},
+			"/api/v1/graphql": {
+				"post": {
+					Summary:     "Execute a GraphQL query",
+					Description: "A query may analyze at most max_batch_urls URLs across its analyze and analyzeMany fields.",
+					OperationID: "graphql",
+					Responses: map[string]Response{
+						"200": {Description: "GraphQL response"},
+						"400": jsonResponse("Invalid request or too many URLs to analyze", "Error"),
+					},
+				},
+			},
+			"/api/v1/health": {
+				"get": {
+					Summary:     "Service health",
+					Description: "With deep=true, also probes storage, the cache and outbound HTTPS and reports status \"degraded\" when one fails.",
+					OperationID: "health",
+					Responses: map[string]Response{
+						"200": {Description: "Service is healthy or degraded"},
+					},
+				},
+			},
+			"/livez": {
+				"get": {
+					Summary:     "Liveness probe",
+					OperationID: "liveness",
+					Responses: map[string]Response{
+						"200": {Description: "Process is running"},
+					},
+				},
+			},
+			"/readyz": {
+				"get": {
+					Summary:     "Readiness probe",
+					OperationID: "readiness",
+					Responses: map[string]Response{
+						"200": {Description: "Service is ready for traffic"},
+						"503": {Description: "A readiness check failed"},
+					},
+				},
+			},
+			"/api/v1/schemas/result.json": {
+				"get": {
+					Summary:     "JSON Schema of analysis results",
+					OperationID: "resultSchema",
+					Responses: map[string]Response{
+						"200": {Description: "JSON Schema (draft 2020-12) document"},
+					},
+				},
+			},
+			"/api/v1/openapi.json": {
+				"get": {
+					Summary:     "This document",
+					OperationID: "openapi",
+					Responses: map[string]Response{
+						"200": {Description: "OpenAPI document"},
+					},
+				},
+			},
+		},
+		Components: Components{
+			Schemas: schemas(),
+		},
+	}
+}
+
+// jsonBody references a component schema as a required JSON request body
+func jsonBody(name string) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content: map[string]MediaType{
+			"application/json": {Schema: ref(name)},
+		},
+	}
+}
+
+// jsonResponse references a component schema as a JSON response
+func jsonResponse(description, name string) Response {
+	return Response{
+		Description: description,
+		Content: map[string]MediaType{
+			"application/json": {Schema: ref(name)},
+		},
+	}
+}
+
+// adminResponses adds the authorization failures shared by admin operations
+// to a success response
+func adminResponses(ok Response) map[string]Response {
+	return map[string]Response{
+		"200": ok,
+		"401": jsonResponse("Not authenticated", "Error"),
+		"403": jsonResponse("Not an admin", "Error"),
+	}
+}
+
+// componentRef prefixes references to component schemas in the document
+const componentRef = "#/components/schemas/"
+
+// ref creates a reference to a component schema
+func ref(name string) *Schema {
+	return &Schema{Ref: componentRef + name}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,194 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError describes a single validation failure
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validator validates JSON payloads against the document's component schemas
+type Validator struct {
+	schemas map[string]*Schema
+}
+
+// NewValidator creates a validator for the given document
+func NewValidator(doc *Document) *Validator {
+	return &Validator{schemas: doc.Components.Schemas}
+}
+
+// Validate checks a raw JSON payload against the named component schema and
+// returns every violation found
+func (v *Validator) Validate(schemaName string, payload []byte) []FieldError {
+	schema, ok := v.schemas[schemaName]
+	if !ok {
+		return []FieldError{{Field: "", Message: fmt.Sprintf("unknown schema %q", schemaName)}}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return []FieldError{{Field: "", Message: "body must be valid JSON"}}
+	}
+
+	var errs []FieldError
+	v.validate(schema, data, "", &errs)
+	return errs
+}
+
+// validate recursively checks a decoded value against a schema
+func (v *Validator) validate(schema *Schema, data interface{}, path string, errs *[]FieldError) {
+	if schema.Ref != "" {
+		resolved, ok := v.schemas[strings.TrimPrefix(schema.Ref, componentRef)]
+		if !ok {
+			*errs = append(*errs, FieldError{Field: path, Message: "unresolvable schema reference"})
+			return
+		}
+		schema = resolved
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			*errs = append(*errs, FieldError{Field: path, Message: "must be an object"})
+			return
+		}
+		v.validateObject(schema, obj, path, errs)
+	case "array":
+		items, ok := data.([]interface{})
+		if !ok {
+			*errs = append(*errs, FieldError{Field: path, Message: "must be an array"})
+			return
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must contain at least %d items", *schema.MinItems)})
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must contain at most %d items", *schema.MaxItems)})
+		}
+		if schema.Items != nil {
+			for i, item := range items {
+				v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case "string":
+		str, ok := data.(string)
+		if !ok {
+			*errs = append(*errs, FieldError{Field: path, Message: "must be a string"})
+			return
+		}
+		v.validateString(schema, str, path, errs)
+	case "integer":
+		num, ok := data.(json.Number)
+		if !ok {
+			*errs = append(*errs, FieldError{Field: path, Message: "must be an integer"})
+			return
+		}
+		n, err := num.Int64()
+		if err != nil {
+			*errs = append(*errs, FieldError{Field: path, Message: "must be an integer"})
+			return
+		}
+		if schema.Minimum != nil && n < int64(*schema.Minimum) {
+			*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must be at least %d", *schema.Minimum)})
+		}
+		if schema.Maximum != nil && n > int64(*schema.Maximum) {
+			*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must be at most %d", *schema.Maximum)})
+		}
+	case "number":
+		if _, ok := data.(json.Number); !ok {
+			*errs = append(*errs, FieldError{Field: path, Message: "must be a number"})
+		}
+	case "boolean":
+		if _, ok := data.(bool); !ok {
+			*errs = append(*errs, FieldError{Field: path, Message: "must be a boolean"})
+		}
+	}
+}
+
+// validateObject checks required, known, and nested properties
+func (v *Validator) validateObject(schema *Schema, obj map[string]interface{}, path string, errs *[]FieldError) {
+	for _, name := range schema.Required {
+		if _, ok := obj[name]; !ok {
+			*errs = append(*errs, FieldError{Field: joinPath(path, name), Message: "is required"})
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fieldPath := joinPath(path, key)
+		if prop, ok := schema.Properties[key]; ok {
+			v.validate(prop, obj[key], fieldPath, errs)
+			continue
+		}
+		if schema.AdditionalProperties != nil {
+			v.validate(schema.AdditionalProperties, obj[key], fieldPath, errs)
+			continue
+		}
+		if schema.Closed {
+			*errs = append(*errs, FieldError{Field: fieldPath, Message: "is not a recognized field"})
+		}
+	}
+}
+
+// validateString checks string length and format constraints
+func (v *Validator) validateString(schema *Schema, str, path string, errs *[]FieldError) {
+	length := utf8.RuneCountInString(str)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must be at least %d characters", *schema.MinLength)})
+		return
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		*errs = append(*errs, FieldError{Field: path, Message: fmt.Sprintf("must be at most %d characters", *schema.MaxLength)})
+		return
+	}
+
+	if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, str) {
+		*errs = append(*errs, FieldError{Field: path, Message: "must be one of " + strings.Join(schema.Enum, ", ")})
+		return
+	}
+
+	if schema.Format == "date" {
+		if _, err := time.Parse(time.DateOnly, str); err != nil {
+			*errs = append(*errs, FieldError{Field: path, Message: "must be a date (YYYY-MM-DD)"})
+		}
+		return
+	}
+
+	if schema.Format == "uri" {
+		if strings.ContainsAny(str, " \t\r\n") {
+			*errs = append(*errs, FieldError{Field: path, Message: "must be a valid URL"})
+			return
+		}
+		if _, err := url.Parse(str); err != nil {
+			*errs = append(*errs, FieldError{Field: path, Message: "must be a valid URL"})
+		}
+	}
+}
+
+// joinPath builds a dotted field path
+func joinPath(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+	return parent + "." + field
+}
@@ -0,0 +1,110 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testValidator validates against a small document exercising every
+// supported keyword
+func testValidator() *Validator {
+	return &Validator{schemas: map[string]*Schema{
+		"Item": {
+			Type:     "object",
+			Required: []string{"name"},
+			Closed:   true,
+			Properties: map[string]*Schema{
+				"name":    {Type: "string", MinLength: intPtr(1), MaxLength: intPtr(5)},
+				"kind":    {Type: "string", Enum: []string{"page", "site"}},
+				"count":   {Type: "integer", Minimum: intPtr(1), Maximum: intPtr(10)},
+				"ratio":   {Type: "number"},
+				"enabled": {Type: "boolean"},
+				"url":     {Type: "string", Format: "uri"},
+				"day":     {Type: "string", Format: "date"},
+				"tags":    {Type: "array", Items: &Schema{Type: "string"}, MinItems: intPtr(1), MaxItems: intPtr(2)},
+				"child":   ref("Child"),
+				"missing": ref("Missing"),
+				"labels":  {Type: "object", AdditionalProperties: &Schema{Type: "integer"}},
+				"open":    {Type: "object", Properties: map[string]*Schema{}},
+			},
+		},
+		"Child": {
+			Type:       "object",
+			Required:   []string{"id"},
+			Properties: map[string]*Schema{"id": {Type: "integer"}},
+		},
+	}}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		payload string
+		want    []FieldError
+	}{
+		{"valid", "Item", `{"name":"home","kind":"page","count":3,"ratio":0.5,"enabled":true,"url":"https://example.com/a?b=c","day":"2024-02-29","tags":["x"],"child":{"id":1},"labels":{"a":1},"open":{"any":"thing"}}`, nil},
+		{"unknown schema", "Nope", `{}`, []FieldError{{"", `unknown schema "Nope"`}}},
+		{"invalid JSON", "Item", `{"name":`, []FieldError{{"", "body must be valid JSON"}}},
+
+		// Objects
+		{"not an object", "Item", `["name"]`, []FieldError{{"", "must be an object"}}},
+		{"required field", "Item", `{}`, []FieldError{{"name", "is required"}}},
+		{"closed object", "Item", `{"name":"a","extra":1,"another":2}`, []FieldError{{"another", "is not a recognized field"}, {"extra", "is not a recognized field"}}},
+		{"open object", "Item", `{"name":"a","open":{"extra":1}}`, nil},
+		{"additional properties", "Item", `{"name":"a","labels":{"a":1,"b":"two"}}`, []FieldError{{"labels.b", "must be an integer"}}},
+
+		// Strings
+		{"not a string", "Item", `{"name":5}`, []FieldError{{"name", "must be a string"}}},
+		{"min length", "Item", `{"name":""}`, []FieldError{{"name", "must be at least 1 characters"}}},
+		{"max length", "Item", `{"name":"abcdef"}`, []FieldError{{"name", "must be at most 5 characters"}}},
+		{"max length counts characters", "Item", `{"name":"héllo"}`, nil},
+		{"enum", "Item", `{"name":"a","kind":"book"}`, []FieldError{{"kind", "must be one of page, site"}}},
+		{"uri", "Item", `{"name":"a","url":"/relative/path"}`, nil},
+		{"uri with whitespace", "Item", `{"name":"a","url":"https://example.com/a b"}`, []FieldError{{"url", "must be a valid URL"}}},
+		{"malformed uri", "Item", `{"name":"a","url":"http://[::1"}`, []FieldError{{"url", "must be a valid URL"}}},
+		{"date", "Item", `{"name":"a","day":"2024-13-01"}`, []FieldError{{"day", "must be a date (YYYY-MM-DD)"}}},
+		{"date with time", "Item", `{"name":"a","day":"2024-01-01T00:00:00Z"}`, []FieldError{{"day", "must be a date (YYYY-MM-DD)"}}},
+
+		// Numbers and booleans
+		{"minimum", "Item", `{"name":"a","count":0}`, []FieldError{{"count", "must be at least 1"}}},
+		{"maximum", "Item", `{"name":"a","count":11}`, []FieldError{{"count", "must be at most 10"}}},
+		{"fractional integer", "Item", `{"name":"a","count":1.5}`, []FieldError{{"count", "must be an integer"}}},
+		{"string integer", "Item", `{"name":"a","count":"1"}`, []FieldError{{"count", "must be an integer"}}},
+		{"not a number", "Item", `{"name":"a","ratio":"half"}`, []FieldError{{"ratio", "must be a number"}}},
+		{"not a boolean", "Item", `{"name":"a","enabled":"yes"}`, []FieldError{{"enabled", "must be a boolean"}}},
+
+		// Arrays
+		{"not an array", "Item", `{"name":"a","tags":"x"}`, []FieldError{{"tags", "must be an array"}}},
+		{"min items", "Item", `{"name":"a","tags":[]}`, []FieldError{{"tags", "must contain at least 1 items"}}},
+		{"max items", "Item", `{"name":"a","tags":["x","y","z"]}`, []FieldError{{"tags", "must contain at most 2 items"}}},
+		{"item schema", "Item", `{"name":"a","tags":["x",2]}`, []FieldError{{"tags[1]", "must be a string"}}},
+
+		// References
+		{"reference", "Item", `{"name":"a","child":{}}`, []FieldError{{"child.id", "is required"}}},
+		{"reference type", "Item", `{"name":"a","child":{"id":"one"}}`, []FieldError{{"child.id", "must be an integer"}}},
+		{"unresolvable reference", "Item", `{"name":"a","missing":{}}`, []FieldError{{"missing", "unresolvable schema reference"}}},
+
+		{"every violation", "Item", `{"name":"","count":0,"tags":[1]}`, []FieldError{{"count", "must be at least 1"}, {"name", "must be at least 1 characters"}, {"tags[0]", "must be a string"}}},
+	}
+
+	v := testValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := v.Validate(tt.schema, []byte(tt.payload)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate(%s) = %+v, want %+v", tt.payload, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_Document(t *testing.T) {
+	v := NewValidator(NewDocument("test"))
+
+	if errs := v.Validate(AnalyzeRequestSchema, []byte(`{"url":"https://example.com"}`)); len(errs) != 0 {
+		t.Errorf("Expected a valid analyze request, got %+v", errs)
+	}
+	if errs := v.Validate(AnalyzeRequestSchema, []byte(`{}`)); len(errs) != 1 || errs[0].Field != "url" {
+		t.Errorf("Expected the missing url to be reported, got %+v", errs)
+	}
+}
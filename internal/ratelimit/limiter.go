@@ -0,0 +1,208 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// idleTTL is how long an idle client's state is kept once its daily usage
+// no longer matters
+const idleTTL = 10 * time.Minute
+
+// Limits bounds a single client's usage. Zero values disable a limit.
+type Limits struct {
+	RequestsPerMinute int
+	Burst             int
+	DailyQuota        int
+}
+
+// Decision is the outcome of a rate limit check
+type Decision struct {
+	Allowed bool
+	// Reason is "rate" or "quota" for denied requests
+	Reason string
+	// RetryAfter is how long the client should wait before retrying
+	RetryAfter time.Duration
+	// QuotaRemaining is the number of requests left today, or -1 when the
+	// client has no daily quota
+	QuotaRemaining int
+}
+
+// Limiter tracks per-client request rates and daily quotas in memory
+type Limiter struct {
+	mu        sync.Mutex
+	clients   map[string]*client
+	defaults  Limits
+	overrides map[string]Limits
+	lastSweep time.Time
+}
+
+// client holds a single client's usage
+type client struct {
+	limiter  *rate.Limiter
+	limits   Limits
+	day      time.Time
+	used     int
+	lastSeen time.Time
+}
+
+// New func creates a new limiter singleton instance. API keys and tenants
+// with their own limits override the defaults for their clients; callers
+// select a tenant key's limits by the tenant, so its own go unused.
+func New(cfg config.RateLimitConfig, apiKeys []config.APIKeyConfig, tenants []config.TenantConfig) *Limiter {
+	l := &Limiter{clients: make(map[string]*client)}
+	l.SetLimits(cfg, apiKeys, tenants)
+	return l
+}
+
+// TenantKey returns the name selecting a tenant's limits in Allow
+func TenantKey(name string) string {
+	return "tenant:" + name
+}
+
+// SetLimits replaces the default, per-key and per-tenant limits. Clients
+// keep their usage; their buckets take on the new limits with their next
+// request.
+func (l *Limiter) SetLimits(cfg config.RateLimitConfig, apiKeys []config.APIKeyConfig, tenants []config.TenantConfig) {
+	defaults := Limits{
+		RequestsPerMinute: cfg.RequestsPerMinute,
+		Burst:             cfg.Burst,
+		DailyQuota:        cfg.DailyQuota,
+	}
+
+	overrides := make(map[string]Limits)
+	for _, k := range apiKeys {
+		if k.Name == "" || (k.RequestsPerMinute == 0 && k.DailyQuota == 0) {
+			continue
+		}
+		limits := defaults
+		if k.RequestsPerMinute != 0 {
+			limits.RequestsPerMinute = k.RequestsPerMinute
+		}
+		if k.DailyQuota != 0 {
+			limits.DailyQuota = k.DailyQuota
+		}
+		overrides[k.Name] = limits
+	}
+	for _, t := range tenants {
+		if t.Name == "" || (t.RequestsPerMinute == 0 && t.DailyQuota == 0) {
+			continue
+		}
+		limits := defaults
+		if t.RequestsPerMinute != 0 {
+			limits.RequestsPerMinute = t.RequestsPerMinute
+		}
+		if t.DailyQuota != 0 {
+			limits.DailyQuota = t.DailyQuota
+		}
+		overrides[TenantKey(t.Name)] = limits
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.defaults = defaults
+	l.overrides = overrides
+}
+
+// Allow records a request from clientID and reports whether it may proceed.
+// limitsKey selects per-key or per-tenant limits and may be empty.
+func (l *Limiter) Allow(clientID, limitsKey string) Decision {
+	now := time.Now()
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limits := l.defaults
+	if override, ok := l.overrides[limitsKey]; ok {
+		limits = override
+	}
+
+	l.sweepLocked(now, today)
+
+	c, ok := l.clients[clientID]
+	if !ok {
+		c = &client{limiter: newRateLimiter(limits), limits: limits, day: today}
+		l.clients[clientID] = c
+	}
+	if c.limits != limits {
+		limit, burst := bucketFor(limits)
+		c.limiter.SetLimitAt(now, limit)
+		c.limiter.SetBurstAt(now, burst)
+		c.limits = limits
+	}
+	c.lastSeen = now
+	if !c.day.Equal(today) {
+		c.day = today
+		c.used = 0
+	}
+
+	remaining := -1
+	if limits.DailyQuota > 0 {
+		remaining = limits.DailyQuota - c.used
+		if remaining <= 0 {
+			return Decision{
+				Reason:         "quota",
+				RetryAfter:     today.Add(24 * time.Hour).Sub(now),
+				QuotaRemaining: 0,
+			}
+		}
+	}
+
+	reservation := c.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); !reservation.OK() || delay > 0 {
+		reservation.CancelAt(now)
+		if delay <= 0 {
+			delay = time.Minute
+		}
+		return Decision{
+			Reason:         "rate",
+			RetryAfter:     delay,
+			QuotaRemaining: remaining,
+		}
+	}
+
+	c.used++
+	if remaining > 0 {
+		remaining--
+	}
+
+	return Decision{Allowed: true, QuotaRemaining: remaining}
+}
+
+// sweepLocked drops clients that have been idle long enough that neither
+// their rate bucket nor their daily usage still matter
+func (l *Limiter) sweepLocked(now, today time.Time) {
+	if now.Sub(l.lastSweep) < idleTTL {
+		return
+	}
+	l.lastSweep = now
+
+	for id, c := range l.clients {
+		if now.Sub(c.lastSeen) > idleTTL && (c.limits.DailyQuota == 0 || !c.day.Equal(today)) {
+			delete(l.clients, id)
+		}
+	}
+}
+
+// newRateLimiter creates a token bucket refilling at the per-minute rate
+func newRateLimiter(limits Limits) *rate.Limiter {
+	return rate.NewLimiter(bucketFor(limits))
+}
+
+// bucketFor returns the token bucket rate and size for limits
+func bucketFor(limits Limits) (rate.Limit, int) {
+	if limits.RequestsPerMinute <= 0 {
+		return rate.Inf, 0
+	}
+
+	burst := limits.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return rate.Limit(float64(limits.RequestsPerMinute) / 60), burst
+}
@@ -0,0 +1,51 @@
+package render
+
+import (
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// categoryTitles are the headings reports group findings under
+var categoryTitles = map[analyzer.Category]string{
+	analyzer.CategorySEO:           "SEO",
+	analyzer.CategoryLinks:         "Links",
+	analyzer.CategoryContent:       "Content",
+	analyzer.CategoryAccessibility: "Accessibility",
+	analyzer.CategorySecurity:      "Security",
+	analyzer.CategoryCustom:        "Custom rules",
+}
+
+// findingGroup is the findings of one category
+type findingGroup struct {
+	Category analyzer.Category
+	Title    string
+	Findings []analyzer.Finding
+}
+
+// groupFindings groups findings by category in the order of
+// analyzer.Categories, leaving out categories without findings. Findings
+// of categories this version does not know come last, in one group each.
+func groupFindings(findings []analyzer.Finding) []findingGroup {
+	var groups []findingGroup
+	index := map[analyzer.Category]int{}
+	for _, category := range analyzer.Categories {
+		index[category] = len(groups)
+		groups = append(groups, findingGroup{Category: category, Title: categoryTitles[category]})
+	}
+	for _, finding := range findings {
+		i, ok := index[finding.Category]
+		if !ok {
+			i = len(groups)
+			index[finding.Category] = i
+			groups = append(groups, findingGroup{Category: finding.Category, Title: string(finding.Category)})
+		}
+		groups[i].Findings = append(groups[i].Findings, finding)
+	}
+
+	nonEmpty := groups[:0]
+	for _, group := range groups {
+		if len(group.Findings) > 0 {
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+	return nonEmpty
+}
@@ -0,0 +1,100 @@
+package render
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// FormatJUnit renders findings as JUnit XML test cases for CI systems
+const FormatJUnit = "junit"
+
+// junitRenderer renders findings as a JUnit test suite. The analysis itself
+// is one test case and each finding another, named by its ID and classed by
+// its category. Failing findings carry a <failure> typed with the severity
+// and the others are skipped, and a failed analysis is reported as a single
+// errored test case.
+type junitRenderer struct{}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	Skipped   *junitFailure `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func (junitRenderer) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+func (junitRenderer) Render(w io.Writer, result *analyzer.Result) error {
+	suite := junitTestSuite{Name: result.URL}
+
+	if result.Error != "" {
+		suite.Cases = append(suite.Cases, junitTestCase{
+			ClassName: result.URL,
+			Name:      "page can be analyzed",
+			Error:     &junitFailure{Message: "analysis failed", Text: result.Error},
+		})
+		suite.Errors++
+	} else {
+		suite.Cases = append(suite.Cases, junitTestCase{ClassName: result.URL, Name: "page can be analyzed"})
+		for _, finding := range result.Findings {
+			testCase := junitTestCase{ClassName: result.URL + "." + string(finding.Category), Name: finding.ID}
+			text := finding.Message
+			if len(finding.Evidence) > 0 {
+				text += "\n" + strings.Join(finding.Evidence, "\n")
+			}
+			if !fails(result, finding) {
+				testCase.Skipped = &junitFailure{Message: finding.Message, Text: text}
+				suite.Skipped++
+			} else {
+				testCase.Failure = &junitFailure{Message: finding.Message, Text: text, Type: string(finding.Severity)}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+	}
+	suite.Tests = len(suite.Cases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(junitTestSuites{Suites: []junitTestSuite{suite}})
+}
+
+// fails reports whether finding fails its test case: when it is as severe
+// as the result's verdict fails on, or without a verdict when it is an
+// error or warning
+func fails(result *analyzer.Result, finding analyzer.Finding) bool {
+	if result.Verdict != nil {
+		return finding.Severity.AtLeast(result.Verdict.FailOn)
+	}
+	return finding.Severity.AtLeast(analyzer.SeverityWarning)
+}
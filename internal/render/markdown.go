@@ -0,0 +1,132 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// FormatMarkdown renders a Markdown summary for PR descriptions and wikis
+const FormatMarkdown = "markdown"
+
+// markdownRenderer renders a result as a Markdown summary
+type markdownRenderer struct{}
+
+func (markdownRenderer) ContentType() string {
+	return "text/markdown; charset=utf-8"
+}
+
+func (markdownRenderer) Render(w io.Writer, result *analyzer.Result) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## Analysis of %s\n\n", result.URL)
+
+	if result.Error != "" {
+		fmt.Fprintf(&b, "**Analysis failed:** %s\n", markdownText(result.Error))
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	if verdict := result.Verdict; verdict != nil {
+		if verdict.Passed {
+			fmt.Fprintf(&b, "**Verdict:** ✅ passed, failing on %s\n\n", verdict.FailOn)
+		} else {
+			fmt.Fprintf(&b, "**Verdict:** ❌ failed with %d findings at %s or worse\n\n", verdict.Failures, verdict.FailOn)
+		}
+	}
+
+	b.WriteString("| Metric | Value |\n|---|---|\n")
+	fmt.Fprintf(&b, "| HTML version | %s |\n", markdownCell(orDefault(result.HTMLVersion, "Not detected")))
+	fmt.Fprintf(&b, "| Title | %s |\n", markdownCell(orDefault(result.Title, "No title found")))
+	fmt.Fprintf(&b, "| Internal links | %d |\n", result.InternalLinks)
+	fmt.Fprintf(&b, "| External links | %d |\n", result.ExternalLinks)
+	fmt.Fprintf(&b, "| Broken links | %d |\n", result.InaccessibleLinks)
+	fmt.Fprintf(&b, "| Login form | %s |\n", yesNo(result.HasLoginForm))
+
+	if len(result.Headings) > 0 {
+		levels := make([]string, 0, len(result.Headings))
+		for level := range result.Headings {
+			levels = append(levels, level)
+		}
+		sort.Strings(levels)
+
+		b.WriteString("\n### Headings\n\n| Level | Count |\n|---|---|\n")
+		for _, level := range levels {
+			fmt.Fprintf(&b, "| %s | %d |\n", level, result.Headings[level])
+		}
+	}
+
+	b.WriteString("\n### Findings\n\n")
+	groups := groupFindings(result.Findings)
+	if len(groups) == 0 {
+		b.WriteString("✅ No findings.\n")
+	}
+	for i, group := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "#### %s\n\n", group.Title)
+		for _, finding := range group.Findings {
+			fmt.Fprintf(&b, "- %s **%s** `%s`: %s\n", severityIcons[finding.Severity], finding.Severity, finding.ID, markdownText(finding.Message))
+			for _, evidence := range finding.Evidence {
+				fmt.Fprintf(&b, "  - %s\n", markdownText(evidence))
+			}
+		}
+	}
+
+	if diff := result.PreviousDiff; diff != nil {
+		b.WriteString("\n### Changes since previous analysis\n\n")
+		if diff.Identical {
+			b.WriteString("No changes.\n")
+		}
+		for _, line := range diff.Summary {
+			fmt.Fprintf(&b, "- %s\n", markdownText(line))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// severityIcons mark findings by severity
+var severityIcons = map[analyzer.Severity]string{
+	analyzer.SeverityError:   "❌",
+	analyzer.SeverityWarning: "⚠️",
+	analyzer.SeverityInfo:    "ℹ️",
+}
+
+// markdownText escapes characters that would otherwise start Markdown
+// formatting in free text
+var markdownText = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"<", `&lt;`,
+	">", `&gt;`,
+).Replace
+
+// markdownCell escapes text for use inside a table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(markdownText(s), "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// orDefault returns fallback when s is empty
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// yesNo renders a flag as Yes or No
+func yesNo(v bool) string {
+	if v {
+		return "Yes"
+	}
+	return "No"
+}
@@ -0,0 +1,49 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// Format names accepted by the ?format= query parameter
+const (
+	FormatJSON = "json"
+	FormatXML  = "xml"
+)
+
+// Renderer encodes an analysis result in a particular output format
+type Renderer interface {
+	ContentType() string
+	Render(w io.Writer, result *analyzer.Result) error
+}
+
+// ForFormat returns the renderer for a format name. An empty name selects
+// JSON.
+func ForFormat(format string) (Renderer, error) {
+	switch format {
+	case "", FormatJSON:
+		return jsonRenderer{}, nil
+	case FormatXML:
+		return xmlRenderer{}, nil
+	case FormatJUnit:
+		return junitRenderer{}, nil
+	case FormatMarkdown, "md":
+		return markdownRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// jsonRenderer renders results as JSON
+type jsonRenderer struct{}
+
+func (jsonRenderer) ContentType() string {
+	return "application/json"
+}
+
+func (jsonRenderer) Render(w io.Writer, result *analyzer.Result) error {
+	return json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,61 @@
+package render
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+)
+
+// ReportTemplate is the HTML report template, relative to the working
+// directory
+const ReportTemplate = "web/templates/report.html"
+
+// HTMLReport renders stored results as self-contained HTML reports with
+// inline CSS and no external assets
+type HTMLReport struct {
+	tmpl *template.Template
+}
+
+// reportView is the data rendered by the HTML report template
+type reportView struct {
+	Record      *storage.Record
+	Headings    []reportHeading
+	Findings    []findingGroup
+	GeneratedAt time.Time
+}
+
+// reportHeading is a heading level count, ordered by level in the report
+type reportHeading struct {
+	Level string
+	Count int
+}
+
+// NewHTMLReport func creates a new HTML report singleton renderer from
+// ReportTemplate
+func NewHTMLReport() *HTMLReport {
+	return &HTMLReport{tmpl: template.Must(template.ParseFiles(ReportTemplate))}
+}
+
+// Render writes the report for record
+func (h *HTMLReport) Render(w io.Writer, record *storage.Record) error {
+	levels := make([]string, 0, len(record.Result.Headings))
+	for level := range record.Result.Headings {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+
+	headings := make([]reportHeading, 0, len(levels))
+	for _, level := range levels {
+		headings = append(headings, reportHeading{Level: level, Count: record.Result.Headings[level]})
+	}
+
+	return h.tmpl.Execute(w, reportView{
+		Record:      record,
+		Headings:    headings,
+		Findings:    groupFindings(record.Result.Findings),
+		GeneratedAt: time.Now().UTC(),
+	})
+}
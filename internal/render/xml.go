@@ -0,0 +1,134 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// XMLSchemaVersion identifies the XML document layout. It is incremented
+// only for incompatible changes; new optional elements keep the version.
+const XMLSchemaVersion = "1"
+
+// xmlRenderer renders results as XML for legacy tooling. Documents follow
+// this layout; optional elements are omitted when empty:
+//
+//	<analysis schemaVersion="1">
+//	  <id>...</id>                              (optional)
+//	  <url>https://example.com</url>
+//	  <htmlVersion>HTML5</htmlVersion>
+//	  <title>...</title>
+//	  <headings>
+//	    <heading level="h1" count="1"></heading>  (one per level, sorted)
+//	  </headings>
+//	  <links internal="3" external="1" inaccessible="0"></links>
+//	  <hasLoginForm>false</hasLoginForm>
+//	  <error>...</error>                        (optional, failed analyses)
+//	  <previousDiff identical="false">          (optional)
+//	    <change field="title">
+//	      <before>Old</before>
+//	      <after>New</after>
+//	    </change>
+//	    <change field="inaccessible_links" delta="3">...</change>
+//	    <summary>3 new broken links</summary>   (one per line)
+//	  </previousDiff>
+//	</analysis>
+type xmlRenderer struct{}
+
+type xmlAnalysis struct {
+	XMLName       xml.Name   `xml:"analysis"`
+	SchemaVersion string     `xml:"schemaVersion,attr"`
+	ID            string     `xml:"id,omitempty"`
+	URL           string     `xml:"url"`
+	HTMLVersion   string     `xml:"htmlVersion"`
+	Title         string     `xml:"title"`
+	Headings      []xmlCount `xml:"headings>heading"`
+	Links         xmlLinks   `xml:"links"`
+	HasLoginForm  bool       `xml:"hasLoginForm"`
+	Error         string     `xml:"error,omitempty"`
+	PreviousDiff  *xmlDiff   `xml:"previousDiff,omitempty"`
+}
+
+type xmlCount struct {
+	Level string `xml:"level,attr"`
+	Count int    `xml:"count,attr"`
+}
+
+type xmlLinks struct {
+	Internal     int `xml:"internal,attr"`
+	External     int `xml:"external,attr"`
+	Inaccessible int `xml:"inaccessible,attr"`
+}
+
+type xmlDiff struct {
+	Identical bool        `xml:"identical,attr"`
+	Changes   []xmlChange `xml:"change"`
+	Summary   []string    `xml:"summary"`
+}
+
+type xmlChange struct {
+	Field  string `xml:"field,attr"`
+	Delta  *int   `xml:"delta,attr,omitempty"`
+	Before string `xml:"before"`
+	After  string `xml:"after"`
+}
+
+func (xmlRenderer) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+func (xmlRenderer) Render(w io.Writer, result *analyzer.Result) error {
+	doc := xmlAnalysis{
+		SchemaVersion: XMLSchemaVersion,
+		ID:            result.ID,
+		URL:           result.URL,
+		HTMLVersion:   result.HTMLVersion,
+		Title:         result.Title,
+		Headings:      xmlHeadings(result.Headings),
+		Links: xmlLinks{
+			Internal:     result.InternalLinks,
+			External:     result.ExternalLinks,
+			Inaccessible: result.InaccessibleLinks,
+		},
+		HasLoginForm: result.HasLoginForm,
+		Error:        result.Error,
+	}
+
+	if diff := result.PreviousDiff; diff != nil {
+		doc.PreviousDiff = &xmlDiff{Identical: diff.Identical, Summary: diff.Summary}
+		for _, change := range diff.Changes {
+			doc.PreviousDiff.Changes = append(doc.PreviousDiff.Changes, xmlChange{
+				Field:  change.Field,
+				Delta:  change.Delta,
+				Before: fmt.Sprint(change.Before),
+				After:  fmt.Sprint(change.After),
+			})
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// xmlHeadings converts the heading map into elements sorted by level
+func xmlHeadings(headings map[string]int) []xmlCount {
+	levels := make([]string, 0, len(headings))
+	for level := range headings {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+
+	counts := make([]xmlCount, 0, len(levels))
+	for _, level := range levels {
+		counts = append(counts, xmlCount{Level: level, Count: headings[level]})
+	}
+	return counts
+}
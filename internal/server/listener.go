@@ -0,0 +1,60 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// unixPrefix marks a port setting that is a unix socket path
+const unixPrefix = "unix://"
+
+// listen listens on addr, which is a TCP address such as ":8080" or a unix
+// socket path such as "unix:///var/run/web-analyzer.sock". The socket file
+// is removed when the listener is closed, which Shutdown does.
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(true)
+
+	return ln, nil
+}
+
+// removeStaleSocket removes a socket file left behind by a process that did
+// not shut down cleanly. A socket something still listens on is left alone,
+// as are files that are not sockets.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another process", path)
+	}
+
+	return os.Remove(path)
+}
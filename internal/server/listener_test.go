@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// socketPath returns a socket path in a new directory. t.TempDir is not
+// used because its paths can exceed the length limit of socket addresses.
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "wa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "s.sock")
+}
+
+func TestListen_TCP(t *testing.T) {
+	ln, err := listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer ln.Close()
+	if ln.Addr().Network() != "tcp" {
+		t.Errorf("Expected a TCP listener, got %s", ln.Addr().Network())
+	}
+}
+
+func TestListen_Unix(t *testing.T) {
+	path := socketPath(t)
+	ln, err := listen(unixPrefix + path)
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	if ln.Addr().Network() != "unix" || ln.Addr().String() != path {
+		t.Errorf("Expected a unix listener on %s, got %s %s", path, ln.Addr().Network(), ln.Addr())
+	}
+
+	ln.Close()
+	if _, err := os.Lstat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the socket file to be removed on close, got %v", err)
+	}
+}
+
+func TestListen_StaleSocket(t *testing.T) {
+	// A listener closed without unlinking leaves the socket file behind like
+	// a process that was killed
+	path := socketPath(t)
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := listen(unixPrefix + path)
+	if err != nil {
+		t.Fatalf("Expected the stale socket to be replaced, got %v", err)
+	}
+	ln.Close()
+}
+
+func TestListen_UnixRefuses(t *testing.T) {
+	inUse := socketPath(t)
+	other, err := net.Listen("unix", inUse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	regular := socketPath(t)
+	if err := os.WriteFile(regular, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, path := range map[string]string{"socket in use": inUse, "regular file": regular} {
+		if ln, err := listen(unixPrefix + path); err == nil {
+			ln.Close()
+			t.Errorf("%s: expected an error", name)
+		}
+		if _, err := os.Lstat(path); err != nil {
+			t.Errorf("%s: expected the file to be left alone, got %v", name, err)
+		}
+	}
+}
+
+func TestStart_UnixSocketTLS(t *testing.T) {
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t)
+	dir := t.TempDir()
+	path := socketPath(t)
+	cfg := &config.Config{
+		Port:    unixPrefix + path,
+		TLSCert: writeTestFile(t, dir, "cert.pem", certPEM),
+		TLSKey:  writeTestFile(t, dir, "key.pem", keyPEM),
+	}
+	s := New(cfg, Handlers{}, testLogger(), testLogger())
+
+	started := make(chan error, 1)
+	go func() { started <- s.Start() }()
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.pem)
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+			TLSClientConfig: &tls.Config{RootCAs: roots},
+		},
+	}
+
+	var resp *http.Response
+	var err error
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, err = client.Get("https://localhost/metrics"); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("Expected HTTPS over the socket, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+		t.Errorf("Expected 200 over TLS, got %d", resp.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if err := <-started; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Expected Start to return ErrServerClosed, got %v", err)
+	}
+	if _, err := os.Lstat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the socket file to be removed on shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/middleware"
+)
+
+// PprofHandler serves the pprof endpoints under /debug/pprof/ on the pprof
+// port, behind the pprof token when one is configured
+func PprofHandler(cfg *config.Config, logger *slog.Logger) http.Handler {
+	return middleware.NewPprofMiddleware(cfg.PprofToken, logger)(pprofMux())
+}
+
+// pprofMux serves the pprof endpoints under /debug/pprof/ without any access
+// check. On the main mux the admin middleware guards them instead of the
+// token, since admins signed in with a JWT already send it in the
+// Authorization header the token would be read from.
+func pprofMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// StartPprof serves pprof on its own port unless it is mounted on the main
+// mux. It returns once the server stops.
+func StartPprof(cfg *config.Config, logger *slog.Logger) error {
+	if cfg.PprofToken == "" && !isLoopback(cfg.PprofPort) {
+		logger.Warn("pprof server is reachable without a token", "port", cfg.PprofPort)
+	}
+
+	logger.Info("Starting pprof server", "port", cfg.PprofPort)
+	return http.ListenAndServe(cfg.PprofPort, PprofHandler(cfg, logger))
+}
+
+// isLoopback reports whether addr only listens on a loopback interface
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newTestIssuer serves a JWKS with a fresh RSA key and returns its URL and a
+// function signing tokens for a subject with that key
+func newTestIssuer(t *testing.T) (string, func(subject string) string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "k1",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	sign := func(subject string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"sub": subject,
+			"iss": "https://issuer.example.com",
+			"aud": "web-analyzer",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		token.Header["kid"] = "k1"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	return server.URL, sign
+}
+
+func TestPprof_MainMuxAdmins(t *testing.T) {
+	jwksURL, sign := newTestIssuer(t)
+	cfg := &config.Config{
+		PprofEnabled: true,
+		PprofMainMux: true,
+		// The token only guards pprof_port, so it must not get in the way
+		// of admins sending their JWT in the Authorization header
+		PprofToken: "pprof-secret",
+		Auth: config.AuthConfig{
+			Admins: []string{"jwt:admin"},
+			JWT: config.JWTConfig{
+				Enabled:         true,
+				Issuer:          "https://issuer.example.com",
+				Audience:        "web-analyzer",
+				JWKSURL:         jwksURL,
+				RefreshInterval: time.Hour,
+			},
+		},
+	}
+	handler := New(cfg, Handlers{}, testLogger(), testLogger()).httpServer.Handler
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"pprof token", "Bearer pprof-secret", http.StatusUnauthorized},
+		{"non-admin", "Bearer " + sign("user"), http.StatusForbidden},
+		{"admin", "Bearer " + sign("admin"), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/pprof/cmdline", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestPprofHandler_Token(t *testing.T) {
+	handler := PprofHandler(&config.Config{PprofToken: "pprof-secret"}, testLogger())
+
+	for _, tt := range []struct {
+		password string
+		want     int
+	}{
+		{"", http.StatusUnauthorized},
+		{"wrong", http.StatusUnauthorized},
+		{"pprof-secret", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/debug/pprof/cmdline", nil)
+		if tt.password != "" {
+			req.SetBasicAuth("", tt.password)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("Password %q: expected status %d, got %d", tt.password, tt.want, rec.Code)
+		}
+	}
+}
@@ -2,27 +2,60 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"log/slog"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/crypto/acme"
 
-	"web-analyzer/internal/config"
-	"web-analyzer/internal/handlers"
-	"web-analyzer/internal/middleware"
+	"github.com/anjula-paulus/web-analyzer/internal/auth"
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/idempotency"
+	"github.com/anjula-paulus/web-analyzer/internal/middleware"
+	"github.com/anjula-paulus/web-analyzer/internal/ratelimit"
 )
 
-// New func creates a new server singleton instance
-func New(cfg *config.Config, analyzerHandler *handlers.Analyzer, healthHandler *handlers.Health, logger *slog.Logger) *Server {
+// New func creates a new server singleton instance. Requests are logged to
+// accessLogger and everything else to logger.
+func New(cfg *config.Config, h Handlers, logger, accessLogger *slog.Logger) *Server {
 	r := http.NewServeMux()
 
 	// Register routes
-	r.HandleFunc("/", analyzerHandler.ServeIndex)
-	r.HandleFunc("/api/v1/analyze", analyzerHandler.ServeAnalyze)
-	r.HandleFunc("/api/v1/health", healthHandler.ServeHealth)
+	r.HandleFunc("/", h.Analyzer.ServeIndex)
+	r.HandleFunc("/history", h.Results.ServeHistoryPage)
+	r.HandleFunc("/r/{id}", h.Results.ServePermalink)
+	idempotent := middleware.NewIdempotencyMiddleware(idempotency.NewStore(cfg.Idempotency.TTL), logger)
+	r.Handle("/api/v1/analyze", idempotent(http.HandlerFunc(h.Analyzer.ServeAnalyze)))
+	r.Handle("/api/v2/analyze", idempotent(http.HandlerFunc(h.Analyzer.ServeAnalyzeV2)))
+	r.HandleFunc("/api/v1/compare", h.Analyzer.ServeCompare)
+	r.HandleFunc("/api/v1/site", h.Analyzer.ServeSite)
+	r.HandleFunc("/api/v1/results", h.Results.ServeResults)
+	r.HandleFunc("/api/v1/results/{id}", h.Results.ServeResult)
+	r.HandleFunc("/api/v1/results/{id}/diff", h.Results.ServeResultDiff)
+	r.HandleFunc("/api/v1/results/{id}/report.html", h.Results.ServeReport)
+	r.HandleFunc("/api/v1/trends", h.Results.ServeTrends)
+	r.HandleFunc("/api/v1/graphql", h.GraphQL.ServeGraphQL)
+	r.HandleFunc("/api/v1/health", h.Health.ServeHealth)
+	r.HandleFunc("/readyz", h.Health.ServeReadiness)
+	r.HandleFunc("/livez", h.Health.ServeLiveness)
+	r.HandleFunc("/api/v1/openapi.json", h.OpenAPI.ServeOpenAPI)
+	r.HandleFunc("/api/v1/schemas/result.json", h.OpenAPI.ServeResultSchema)
 	r.Handle("/metrics", promhttp.Handler())
 
+	adminOnly := middleware.NewAdminMiddleware(cfg.Auth.Admins, logger)
+	r.Handle("/api/v1/admin/config", adminOnly(http.HandlerFunc(h.Admin.ServeConfig)))
+	r.Handle("/api/v1/admin/stats", adminOnly(http.HandlerFunc(h.Admin.ServeStats)))
+	r.Handle("/api/v1/admin/workers", adminOnly(http.HandlerFunc(h.Admin.ServeWorkers)))
+	r.Handle("/api/v1/admin/history", adminOnly(http.HandlerFunc(h.Admin.ServeHistory)))
+	if cfg.PprofEnabled && cfg.PprofMainMux {
+		r.Handle("/debug/pprof/", adminOnly(pprofMux()))
+		logger.Info("pprof mounted on the main server", "path", "/debug/pprof/")
+	}
+
 	// Serve static files if they exist
 	if _, err := http.Dir("web/static").Open("/"); err == nil {
 		fs := http.FileServer(http.Dir("web/static/"))
@@ -32,10 +65,43 @@ func New(cfg *config.Config, analyzerHandler *handlers.Analyzer, healthHandler *
 
 	// Apply middleware
 	var handler http.Handler = r
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter = ratelimit.New(cfg.RateLimit, cfg.Auth.APIKeys, cfg.Tenants)
+		handler = middleware.NewRateLimitMiddleware(limiter, cfg.Auth.PublicPaths, logger)(handler)
+		logger.Info("Rate limiting enabled",
+			"requests_per_minute", cfg.RateLimit.RequestsPerMinute,
+			"burst", cfg.RateLimit.Burst,
+			"daily_quota", cfg.RateLimit.DailyQuota,
+		)
+	}
+	handler = middleware.NewDebugLogMiddleware(cfg.Auth.Admins, logger)(handler)
+	if apiKeys := auth.NewAPIKeys(cfg.Auth.APIKeys); cfg.Auth.JWT.Enabled || apiKeys.Len() > 0 {
+		var validator *auth.JWTValidator
+		if cfg.Auth.JWT.Enabled {
+			validator = auth.NewJWTValidator(cfg.Auth.JWT, logger)
+			logger.Info("JWT authentication enabled",
+				"issuer", cfg.Auth.JWT.Issuer,
+				"audience", cfg.Auth.JWT.Audience,
+			)
+		}
+		if apiKeys.Len() > 0 {
+			logger.Info("API key authentication enabled", "keys", apiKeys.Len())
+		}
+		handler = middleware.NewAuthMiddleware(validator, apiKeys, cfg.Auth, logger)(handler)
+	}
+	if cfg.Auth.CSRF {
+		handler = middleware.NewCSRFMiddleware(logger)(handler)
+	}
+	handler = middleware.NewBodyLimitMiddleware(cfg.BodyLimit, r, logger)(handler)
+	handler = middleware.NewTimeoutMiddleware(cfg.HandlerTimeout, r, logger)(handler)
 	handler = middleware.NewRecoveryMiddleware(logger)(handler)
 	handler = middleware.NewCORSMiddleware(logger)(handler)
-	handler = middleware.NewLoggerMiddleware(logger)(handler)
-	handler = middleware.NewMetricsMiddleware(logger)(handler)
+	handler = middleware.NewSecurityHeadersMiddleware()(handler)
+	handler = middleware.NewLoggerMiddleware(cfg.Logging.AccessSampleRate, accessLogger)(handler)
+	handler = middleware.NewRequestIDMiddleware(logger)(handler)
+	handler = middleware.NewMetricsMiddleware(r, logger)(handler)
+	handler = middleware.NewTracingMiddleware(r, logger)(handler)
 
 	logger.Info("Server configured",
 		"port", cfg.Port,
@@ -43,9 +109,13 @@ func New(cfg *config.Config, analyzerHandler *handlers.Analyzer, healthHandler *
 		"write_timeout", cfg.WriteTimeout,
 	)
 
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+
 	return &Server{
-		config: cfg,
-		logger: logger,
+		config:         cfg,
+		logger:         logger,
+		limiter:        limiter,
+		cancelRequests: cancelRequests,
 		httpServer: &http.Server{
 			Addr:         cfg.Port,
 			Handler:      handler,
@@ -53,23 +123,143 @@ func New(cfg *config.Config, analyzerHandler *handlers.Analyzer, healthHandler *
 			WriteTimeout: cfg.WriteTimeout,
 			IdleTimeout:  60 * time.Second,
 			ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+			BaseContext:  func(net.Listener) context.Context { return requestCtx },
 		},
 	}
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server, serving HTTPS when a certificate is
+// configured
 func (s *Server) Start() error {
-	s.logger.Info("HTTP server starting", "addr", s.config.Port)
-	return s.httpServer.ListenAndServe()
+	if s.config.ACME.Enabled {
+		return s.startACME()
+	}
+
+	var tlsConfig *tls.Config
+	if tlsEnabled(s.config) {
+		var err error
+		if tlsConfig, err = newTLSConfig(s.config); err != nil {
+			return err
+		}
+		s.httpServer.TLSConfig = tlsConfig
+	}
+
+	ln, err := listen(s.config.Port)
+	if err != nil {
+		return err
+	}
+
+	if tlsConfig == nil {
+		s.logger.Info("HTTP server starting", "addr", s.config.Port)
+		return s.httpServer.Serve(ln)
+	}
+
+	s.logger.Info("HTTPS server starting",
+		"addr", s.config.Port,
+		"client_certs_required", tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert,
+	)
+	return s.httpServer.ServeTLS(ln, s.config.TLSCert, s.config.TLSKey)
+}
+
+// startACME serves HTTPS with certificates obtained automatically, and
+// answers HTTP-01 challenges on the configured HTTP port
+func (s *Server) startACME() error {
+	if tlsEnabled(s.config) {
+		return errors.New("tls_cert and acme cannot be used together")
+	}
+
+	manager, err := newACMEManager(s.config.ACME)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := newTLSConfig(s.config)
+	if err != nil {
+		return err
+	}
+	tlsConfig.GetCertificate = manager.GetCertificate
+	tlsConfig.NextProtos = append(tlsConfig.NextProtos, acme.ALPNProto)
+	s.httpServer.TLSConfig = tlsConfig
+
+	if s.config.ACME.HTTPPort != "" {
+		challengeServer := &http.Server{
+			Addr:         s.config.ACME.HTTPPort,
+			Handler:      manager.HTTPHandler(nil),
+			ReadTimeout:  s.config.ReadTimeout,
+			WriteTimeout: s.config.WriteTimeout,
+			ErrorLog:     s.httpServer.ErrorLog,
+		}
+		s.mu.Lock()
+		s.challengeServer = challengeServer
+		s.mu.Unlock()
+
+		go func() {
+			s.logger.Info("ACME challenge server starting", "addr", s.config.ACME.HTTPPort)
+			if err := challengeServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.logger.Error("ACME challenge server failed", "error", err)
+			}
+		}()
+	}
+
+	ln, err := listen(s.config.Port)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("HTTPS server starting with ACME certificates",
+		"addr", s.config.Port,
+		"hosts", s.config.ACME.Hosts,
+		"cache_dir", s.config.ACME.CacheDir,
+	)
+	return s.httpServer.ServeTLS(ln, "", "")
+}
+
+// Reload applies the rate limits from cfg. Enabling or disabling rate
+// limiting and all other server settings need a restart.
+func (s *Server) Reload(cfg *config.Config) {
+	if s.limiter == nil {
+		return
+	}
+
+	s.limiter.SetLimits(cfg.RateLimit, cfg.Auth.APIKeys, cfg.Tenants)
+	s.logger.Info("Rate limits reloaded",
+		"requests_per_minute", cfg.RateLimit.RequestsPerMinute,
+		"burst", cfg.RateLimit.Burst,
+		"daily_quota", cfg.RateLimit.DailyQuota,
+	)
 }
 
-// Shutdown gracefully shuts down the server
+// Shutdown stops accepting connections and waits for in-flight requests,
+// including the analyses they run, until ctx ends. Requests still running
+// then are cancelled, so analyses stop checking links and record partial
+// results, and get cancelGrace more to finish.
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("Starting graceful shutdown")
 
+	s.mu.Lock()
+	challengeServer := s.challengeServer
+	s.mu.Unlock()
+
+	if challengeServer != nil {
+		if err := challengeServer.Shutdown(ctx); err != nil {
+			s.logger.Error("ACME challenge server shutdown failed", "error", err)
+		}
+	}
+
 	err := s.httpServer.Shutdown(ctx)
+	if ctx.Err() != nil {
+		s.logger.Warn("Shutdown deadline reached, cancelling in-flight requests", "grace", cancelGrace)
+		s.cancelRequests()
+
+		graceCtx, cancel := context.WithTimeout(context.Background(), cancelGrace)
+		defer cancel()
+		err = s.httpServer.Shutdown(graceCtx)
+	}
+	s.cancelRequests()
+
 	if err != nil {
 		s.logger.Error("Server shutdown failed", "error", err)
+		s.httpServer.Close()
 		return err
 	}
 
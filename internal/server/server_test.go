@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+func TestNew_SecurityHeaders(t *testing.T) {
+	handler := New(&config.Config{}, Handlers{}, testLogger(), testLogger()).httpServer.Handler
+
+	// Responses from handlers and from middleware rejecting the request
+	// carry the headers alike
+	for path, status := range map[string]int{
+		"/metrics":             http.StatusOK,
+		"/api/v1/admin/stats":  http.StatusUnauthorized,
+		"/api/v1/admin/config": http.StatusUnauthorized,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != status {
+			t.Errorf("%s: expected status %d, got %d", path, status, rec.Code)
+		}
+		for _, name := range []string{"Content-Security-Policy", "X-Content-Type-Options", "X-Frame-Options", "Referrer-Policy"} {
+			if rec.Header().Get(name) == "" {
+				t.Errorf("%s: expected a %s header", path, name)
+			}
+		}
+	}
+}
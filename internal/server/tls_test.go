@@ -0,0 +1,179 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// testCA is a certificate authority issuing test certificates
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for localhost, usable by servers
+// and clients
+func (ca *testCA) issue(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeTestFile writes data to name in dir and returns its path
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	tlsConfig, err := newTLSConfig(&config.Config{})
+	if err != nil {
+		t.Fatalf("newTLSConfig failed: %v", err)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS12 || tlsConfig.ClientAuth != tls.NoClientCert {
+		t.Errorf("Expected TLS 1.2 or later without client certificates, got %+v", tlsConfig)
+	}
+	for _, id := range tlsConfig.CipherSuites {
+		for _, insecure := range tls.InsecureCipherSuites() {
+			if id == insecure.ID {
+				t.Errorf("Expected no insecure cipher suites, got %s", insecure.Name)
+			}
+		}
+	}
+
+	tlsConfig, err = newTLSConfig(&config.Config{TLSClientCA: writeTestFile(t, dir, "ca.pem", newTestCA(t).pem)})
+	if err != nil {
+		t.Fatalf("newTLSConfig failed: %v", err)
+	}
+	if tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert || tlsConfig.ClientCAs == nil {
+		t.Errorf("Expected verified client certificates, got %+v", tlsConfig)
+	}
+
+	for name, path := range map[string]string{
+		"missing client CA":              filepath.Join(dir, "missing.pem"),
+		"client CA without certificates": writeTestFile(t, dir, "empty.pem", []byte("not a certificate")),
+	} {
+		if _, err := newTLSConfig(&config.Config{TLSClientCA: path}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestNewTLSConfig_Handshakes(t *testing.T) {
+	ca, other := newTestCA(t), newTestCA(t)
+	tlsConfig, err := newTLSConfig(&config.Config{TLSClientCA: writeTestFile(t, t.TempDir(), "ca.pem", ca.pem)})
+	if err != nil {
+		t.Fatalf("newTLSConfig failed: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = tlsConfig
+	server.StartTLS()
+	defer server.Close()
+
+	clientCert := func(ca *testCA) []tls.Certificate {
+		cert, err := tls.X509KeyPair(ca.issue(t))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []tls.Certificate{cert}
+	}
+
+	tests := []struct {
+		name   string
+		certs  []tls.Certificate
+		maxTLS uint16
+		ok     bool
+	}{
+		{"client certificate", clientCert(ca), 0, true},
+		{"no client certificate", nil, 0, false},
+		{"certificate from another CA", clientCert(other), 0, false},
+		{"TLS 1.1", clientCert(ca), tls.VersionTLS11, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := server.Client().Transport.(*http.Transport).Clone()
+			transport.TLSClientConfig.Certificates = tt.certs
+			transport.TLSClientConfig.MaxVersion = tt.maxTLS
+			client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if ok := err == nil; ok != tt.ok {
+				t.Errorf("Expected success %v, got %v", tt.ok, err)
+			}
+		})
+	}
+}
@@ -1,14 +1,43 @@
 package server
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
-	"web-analyzer/internal/config"
+	"sync"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/handlers"
+	"github.com/anjula-paulus/web-analyzer/internal/ratelimit"
 )
 
 // Server wraps the HTTP server
 type Server struct {
 	httpServer *http.Server
-	config     *config.Config
-	logger     *slog.Logger
+	// challengeServer answers ACME HTTP-01 challenges; nil unless ACME is
+	// enabled with an HTTP port. It is set by Start, so mu guards it.
+	mu              sync.Mutex
+	challengeServer *http.Server
+	config          *config.Config
+	logger          *slog.Logger
+	// limiter is nil unless rate limiting is enabled
+	limiter *ratelimit.Limiter
+
+	// cancelRequests cancels the contexts of all requests; Shutdown calls
+	// it once the deadline passes
+	cancelRequests context.CancelFunc
+}
+
+// cancelGrace is how long requests get to finish after Shutdown cancels them
+const cancelGrace = 5 * time.Second
+
+// Handlers groups the HTTP handlers mounted by the server
+type Handlers struct {
+	Analyzer *handlers.Analyzer
+	Health   *handlers.Health
+	GraphQL  *handlers.GraphQL
+	OpenAPI  *handlers.OpenAPI
+	Results  *handlers.Results
+	Admin    *handlers.Admin
 }
@@ -0,0 +1,50 @@
+// Package serverless runs the analyze endpoint without a long-lived server,
+// on AWS Lambda or Google Cloud Functions. Each invocation takes an analysis
+// request and answers like POST /api/v2/analyze: the Result on success, an
+// error envelope otherwise.
+package serverless
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/handlers"
+	"github.com/anjula-paulus/web-analyzer/internal/logging"
+	"github.com/anjula-paulus/web-analyzer/internal/middleware"
+	"github.com/anjula-paulus/web-analyzer/internal/openapi"
+	"github.com/anjula-paulus/web-analyzer/internal/storage"
+	"github.com/anjula-paulus/web-analyzer/internal/webhook"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// NewHandler func creates a new serverless analyze handler singleton
+// instance. Results are kept in memory for the life of the instance only,
+// so previous-run diffs are limited to analyses served by the same warm
+// instance.
+func NewHandler(cfg *config.Config, version string, logger *slog.Logger) http.Handler {
+	service := analyzer.NewWithOptions(analyzer.WithConfig(cfg.Analyzer), analyzer.WithLogger(logger))
+	validator := openapi.NewValidator(openapi.NewDocument(version))
+	analyzerHandler := handlers.NewAnalyzer(service, validator, webhook.New(cfg.Webhook, logger), storage.NewMemoryStore(), nil, nil, nil, nil, logger)
+
+	var handler http.Handler = http.HandlerFunc(analyzerHandler.ServeAnalyzeV2)
+	handler = middleware.NewBodyLimitMiddleware(cfg.BodyLimit, nil, logger)(handler)
+	handler = middleware.NewRecoveryMiddleware(logger)(handler)
+	handler = middleware.NewRequestIDMiddleware(logger)(handler)
+	return handler
+}
+
+// NewLogger returns a JSON logger on standard output, which both platforms
+// collect as structured logs
+func NewLogger(cfg *config.Config) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		level = slog.LevelInfo
+	}
+
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: logging.NewRedactor(cfg.Logging).ReplaceAttr,
+	}))
+}
@@ -0,0 +1,217 @@
+package serverless
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+)
+
+// runtimeAPIVersion prefixes the Lambda Runtime API paths
+const runtimeAPIVersion = "/2018-06-01/runtime"
+
+// analyzePath is the path invocations are served as
+const analyzePath = "/api/v2/analyze"
+
+// proxyEvent is the part of an API Gateway HTTP API or function URL event
+// (payload format 2.0) the adapter uses. Direct invocations have no
+// requestContext.
+type proxyEvent struct {
+	RequestContext *struct {
+		HTTP struct {
+			Method string `json:"method"`
+		} `json:"http"`
+	} `json:"requestContext"`
+	RawQueryString  string            `json:"rawQueryString"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// proxyResponse answers an API Gateway or function URL event
+type proxyResponse struct {
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// invocationError reports a failed direct invocation to Lambda
+type invocationError struct {
+	ErrorMessage string `json:"errorMessage"`
+	ErrorType    string `json:"errorType"`
+}
+
+// StartLambda serves Lambda invocations with handler through the Lambda
+// Runtime API until ctx ends, so the binary can run as a custom runtime
+// ("provided.al2023") without the AWS SDK. Direct invocations take an
+// analysis request as the event and return the Result, or fail with the
+// error code as the error type. HTTP API and function URL events get an
+// HTTP response with the same status and body as POST /api/v2/analyze.
+func StartLambda(ctx context.Context, handler http.Handler, logger *slog.Logger) error {
+	api := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	if api == "" {
+		return errors.New("AWS_LAMBDA_RUNTIME_API is not set; not running on Lambda")
+	}
+	baseURL := "http://" + api + runtimeAPIVersion
+
+	// Polling for the next event blocks until one arrives, so the client
+	// has no timeout
+	client := &http.Client{}
+
+	logger.Info("Lambda runtime started")
+	for {
+		id, deadline, event, err := nextInvocation(ctx, client, baseURL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("fetching next invocation: %w", err)
+		}
+
+		path, body := invoke(ctx, handler, event, deadline, logger)
+		if err := post(ctx, client, baseURL+"/invocation/"+id+path, body); err != nil {
+			logger.Error("Failed to report invocation result", "request_id", id, "error", err)
+		}
+	}
+}
+
+// nextInvocation waits for the next event and returns its request ID and
+// deadline along with the event
+func nextInvocation(ctx context.Context, client *http.Client, baseURL string) (string, time.Time, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/invocation/next", nil)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, nil, fmt.Errorf("runtime API returned HTTP %d", resp.StatusCode)
+	}
+
+	event, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+
+	var deadline time.Time
+	if ms, err := strconv.ParseInt(resp.Header.Get("Lambda-Runtime-Deadline-Ms"), 10, 64); err == nil {
+		deadline = time.UnixMilli(ms)
+	}
+	return resp.Header.Get("Lambda-Runtime-Aws-Request-Id"), deadline, event, nil
+}
+
+// invoke serves a single event and returns the Runtime API path suffix to
+// report to, "/response" or "/error", with the body to send
+func invoke(ctx context.Context, handler http.Handler, event []byte, deadline time.Time, logger *slog.Logger) (string, []byte) {
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	var proxy proxyEvent
+	if err := json.Unmarshal(event, &proxy); err != nil || proxy.RequestContext == nil {
+		return invokeDirect(ctx, handler, event)
+	}
+
+	body := []byte(proxy.Body)
+	if proxy.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(proxy.Body)
+		if err != nil {
+			logger.Warn("Undecodable proxy event body", "error", err)
+			return "/response", encodeProxyError(http.StatusBadRequest, apierror.CodeInvalidRequest, "Body is not valid base64")
+		}
+		body = decoded
+	}
+
+	target := analyzePath
+	if proxy.RawQueryString != "" {
+		target += "?" + proxy.RawQueryString
+	}
+	r := httptest.NewRequestWithContext(ctx, proxy.RequestContext.HTTP.Method, target, bytes.NewReader(body))
+	for name, value := range proxy.Headers {
+		r.Header.Set(name, value)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	headers := make(map[string]string, len(rec.Header()))
+	for name := range rec.Header() {
+		headers[name] = rec.Header().Get(name)
+	}
+	resp, _ := json.Marshal(proxyResponse{StatusCode: rec.Code, Headers: headers, Body: rec.Body.String()})
+	return "/response", resp
+}
+
+// invokeDirect serves an event that is itself an analysis request
+func invokeDirect(ctx context.Context, handler http.Handler, event []byte) (string, []byte) {
+	r := httptest.NewRequestWithContext(ctx, http.MethodPost, analyzePath, bytes.NewReader(event))
+	r.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	if rec.Code < 300 {
+		return "/response", rec.Body.Bytes()
+	}
+
+	var envelope struct {
+		Error apierror.Error `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil || envelope.Error.Code == "" {
+		envelope.Error = apierror.Error{Code: apierror.CodeInternal, Message: strings.TrimSpace(rec.Body.String())}
+	}
+	body, _ := json.Marshal(invocationError{ErrorMessage: envelope.Error.Message, ErrorType: envelope.Error.Code})
+	return "/error", body
+}
+
+// encodeProxyError builds a proxy response carrying an error envelope
+func encodeProxyError(status int, code, message string) []byte {
+	body, _ := json.Marshal(map[string]apierror.Error{"error": {Code: code, Message: message}})
+	resp, _ := json.Marshal(proxyResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	})
+	return resp
+}
+
+// post sends body to a Runtime API endpoint
+func post(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("runtime API returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
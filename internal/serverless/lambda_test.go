@@ -0,0 +1,61 @@
+package serverless
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/apierror"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// echoHandler answers with the request body, or with an invalid_url error
+// when the body is empty
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if len(body) == 0 {
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeInvalidURL, "URL is required")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+})
+
+func TestInvoke_Direct(t *testing.T) {
+	path, body := invoke(context.Background(), echoHandler, []byte(`{"url":"https://example.com"}`), time.Time{}, testLogger())
+	if path != "/response" || string(body) != `{"url":"https://example.com"}` {
+		t.Errorf("invoke = %s %s, want the handler's response", path, body)
+	}
+
+	path, body = invoke(context.Background(), echoHandler, []byte(``), time.Time{}, testLogger())
+	var got invocationError
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("Undecodable error body %s: %v", body, err)
+	}
+	if path != "/error" || got.ErrorType != apierror.CodeInvalidURL {
+		t.Errorf("invoke = %s %+v, want an invalid_url error", path, got)
+	}
+}
+
+func TestInvoke_Proxy(t *testing.T) {
+	event := `{"requestContext":{"http":{"method":"POST"}},"headers":{"content-type":"application/json"},"body":"eyJ1cmwiOiJ4In0=","isBase64Encoded":true}`
+
+	path, body := invoke(context.Background(), echoHandler, []byte(event), time.Time{}, testLogger())
+	var got proxyResponse
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("Undecodable proxy response %s: %v", body, err)
+	}
+	if path != "/response" || got.StatusCode != http.StatusOK || got.Body != `{"url":"x"}` {
+		t.Errorf("invoke = %s %+v, want the decoded body echoed with 200", path, got)
+	}
+	if got.Headers["Content-Type"] != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got.Headers["Content-Type"])
+	}
+}
@@ -0,0 +1,373 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// Bolt buckets. byURL keys are url, 0x00, created_at and id; byTime keys are
+// created_at and id. created_at is big-endian Unix nanoseconds so keys sort
+// by time.
+var (
+	boltRecords = []byte("records")
+	boltByURL   = []byte("by_url")
+	boltByTime  = []byte("by_time")
+)
+
+// BoltStore keeps analysis records in an embedded bbolt key-value file, for
+// single-binary deployments that want history without a database server
+type BoltStore struct {
+	db *bolt.DB
+}
+
+var _ ResultStore = (*BoltStore)(nil)
+
+// NewBoltStore func creates a new bbolt store singleton instance backed by
+// the file at path, creating the file and its directory if needed
+func NewBoltStore(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating database directory: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltRecords, boltByURL, boltByTime} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating buckets: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Save stores a record, assigning an ID if it has none
+func (s *BoltStore) Save(ctx context.Context, record *Record) error {
+	if record.ID == "" {
+		record.ID = NewID()
+	}
+
+	value, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encoding record: %w", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(boltRecords).Put([]byte(record.ID), value); err != nil {
+			return err
+		}
+		if err := tx.Bucket(boltByURL).Put(urlKey(record), nil); err != nil {
+			return err
+		}
+		return tx.Bucket(boltByTime).Put(timeKey(record), nil)
+	})
+}
+
+// Get returns the record with the given ID
+func (s *BoltStore) Get(ctx context.Context, id string) (*Record, error) {
+	var record *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		record, err = getRecord(tx, id)
+		return err
+	})
+	return record, err
+}
+
+// Previous returns the most recent record for the same URL and equal options
+// created before the given record
+func (s *BoltStore) Previous(ctx context.Context, record *Record) (*Record, error) {
+	var previous *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := urlPrefix(record.URL)
+		c := tx.Bucket(boltByURL).Cursor()
+
+		// Seek to the first key at the record's creation time and step back
+		// to the latest earlier one with equal options
+		c.Seek(append(prefix, timeBytes(record.CreatedAt)...))
+		for k, _ := c.Prev(); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Prev() {
+			id := string(k[len(prefix)+8:])
+			if id == record.ID {
+				continue
+			}
+			candidate, err := getRecord(tx, id)
+			if err != nil {
+				return err
+			}
+			if candidate.Options.Equal(record.Options) {
+				previous = candidate
+				return nil
+			}
+		}
+		return ErrNotFound
+	})
+	return previous, err
+}
+
+// Diff compares record with the previous record for the same URL and options
+func (s *BoltStore) Diff(ctx context.Context, record *Record) (*Record, *analyzer.Diff, error) {
+	return diffPrevious(ctx, s, record)
+}
+
+// List returns a page of records, newest first
+func (s *BoltStore) List(ctx context.Context, opts ListOptions) ([]*Record, error) {
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket, prefix, idAt := boltByTime, []byte(nil), 8
+		if opts.URL != "" {
+			bucket, prefix = boltByURL, urlPrefix(opts.URL)
+			idAt = len(prefix) + 8
+		}
+
+		// Walk the index backwards from the end of the prefix range
+		c := tx.Bucket(bucket).Cursor()
+		var k []byte
+		if prefix == nil {
+			k, _ = c.Last()
+		} else {
+			c.Seek(append(append([]byte(nil), prefix[:len(prefix)-1]...), 1))
+			k, _ = c.Prev()
+		}
+
+		skipped := 0
+		for ; k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Prev() {
+			// Keys sort by creation time, so every later key is older
+			createdAt := time.Unix(0, int64(binary.BigEndian.Uint64(k[idAt-8:idAt])))
+			if !opts.Since.IsZero() && createdAt.Before(opts.Since) {
+				break
+			}
+			if skipped < opts.Offset {
+				skipped++
+				continue
+			}
+			record, err := getRecord(tx, string(k[idAt:]))
+			if err != nil {
+				return err
+			}
+			records = append(records, record)
+			if opts.Limit > 0 && len(records) == opts.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+// History returns up to limit of the most recent records for a URL, oldest
+// first. A limit of zero or less returns every record.
+func (s *BoltStore) History(ctx context.Context, url string, limit int) ([]*Record, error) {
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := urlPrefix(url)
+		c := tx.Bucket(boltByURL).Cursor()
+
+		var ids []string
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			ids = append(ids, string(k[len(prefix)+8:]))
+		}
+		if limit > 0 && len(ids) > limit {
+			ids = ids[len(ids)-limit:]
+		}
+
+		for _, id := range ids {
+			record, err := getRecord(tx, id)
+			if err != nil {
+				return err
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// Each calls fn for every record, oldest first, stopping at the first error
+func (s *BoltStore) Each(ctx context.Context, fn func(*Record) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltByTime).Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			record, err := getRecord(tx, string(k[8:]))
+			if err != nil {
+				return err
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MostAnalyzed returns up to n URLs with the most records, most first
+func (s *BoltStore) MostAnalyzed(ctx context.Context, n int) ([]string, error) {
+	counts := make(map[string]int)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltByURL).ForEach(func(k, _ []byte) error {
+			if i := bytes.IndexByte(k, 0); i >= 0 {
+				counts[string(k[:i])]++
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return topURLs(counts, n), nil
+}
+
+// Delete removes the record with the given ID
+func (s *BoltStore) Delete(ctx context.Context, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		record, err := getRecord(tx, id)
+		if err != nil {
+			return err
+		}
+		return deleteRecord(tx, record)
+	})
+}
+
+// DeleteByURL removes every record for a URL and returns how many were removed
+func (s *BoltStore) DeleteByURL(ctx context.Context, url string) (int, error) {
+	removed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		prefix := urlPrefix(url)
+		c := tx.Bucket(boltByURL).Cursor()
+
+		var ids []string
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			ids = append(ids, string(k[len(prefix)+8:]))
+		}
+
+		for _, id := range ids {
+			record, err := getRecord(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := deleteRecord(tx, record); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// Prune removes records created before cutoff and then the oldest records
+// beyond maxRows. A zero cutoff or maxRows disables that limit.
+func (s *BoltStore) Prune(ctx context.Context, cutoff time.Time, maxRows int) (int, error) {
+	removed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		total := tx.Bucket(boltRecords).Stats().KeyN
+
+		// Collect first; keys must not be deleted while iterating
+		var ids []string
+		c := tx.Bucket(boltByTime).Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			createdAt := time.Unix(0, int64(binary.BigEndian.Uint64(k[:8])))
+			expired := !cutoff.IsZero() && createdAt.Before(cutoff)
+			overflow := maxRows > 0 && total-len(ids) > maxRows
+			if !expired && !overflow {
+				break
+			}
+			ids = append(ids, string(k[8:]))
+		}
+
+		for _, id := range ids {
+			record, err := getRecord(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := deleteRecord(tx, record); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// Count returns the number of stored records
+func (s *BoltStore) Count(ctx context.Context) (int, error) {
+	count := 0
+	err := s.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(boltRecords).Stats().KeyN
+		return nil
+	})
+	return count, err
+}
+
+// Close closes the database file
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// getRecord decodes the record with the given ID
+func getRecord(tx *bolt.Tx, id string) (*Record, error) {
+	value := tx.Bucket(boltRecords).Get([]byte(id))
+	if value == nil {
+		return nil, ErrNotFound
+	}
+
+	var record Record
+	if err := json.Unmarshal(value, &record); err != nil {
+		return nil, fmt.Errorf("decoding record %s: %w", id, err)
+	}
+	return &record, nil
+}
+
+// deleteRecord removes a record and its index entries
+func deleteRecord(tx *bolt.Tx, record *Record) error {
+	if err := tx.Bucket(boltRecords).Delete([]byte(record.ID)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(boltByURL).Delete(urlKey(record)); err != nil {
+		return err
+	}
+	return tx.Bucket(boltByTime).Delete(timeKey(record))
+}
+
+// urlPrefix is the byURL key prefix shared by a URL's records
+func urlPrefix(url string) []byte {
+	return append([]byte(url), 0)
+}
+
+// urlKey is a record's byURL key
+func urlKey(record *Record) []byte {
+	key := append(urlPrefix(record.URL), timeBytes(record.CreatedAt)...)
+	return append(key, record.ID...)
+}
+
+// timeKey is a record's byTime key
+func timeKey(record *Record) []byte {
+	return append(timeBytes(record.CreatedAt), record.ID...)
+}
+
+// timeBytes encodes t as big-endian Unix nanoseconds
+func timeBytes(t time.Time) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(t.UnixNano()))
+	return b
+}
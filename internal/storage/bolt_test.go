@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func newTestBoltStore(t *testing.T) *BoltStore {
+	t.Helper()
+
+	store, err := NewBoltStore(filepath.Join(t.TempDir(), "results.bolt"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestBoltStore_SaveGetPrevious(t *testing.T) {
+	testSaveGetPrevious(t, newTestBoltStore(t))
+}
+
+func TestBoltStore_DeleteAndPrune(t *testing.T) {
+	testDeleteAndPrune(t, newTestBoltStore(t))
+}
@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// diffPrevious compares record with the previous record for the same URL and
+// options. The drivers implement ResultStore.Diff with it.
+func diffPrevious(ctx context.Context, store ResultStore, record *Record) (*Record, *analyzer.Diff, error) {
+	previous, err := store.Previous(ctx, record)
+	if err != nil {
+		return nil, nil, err
+	}
+	return previous, analyzer.DiffResults(previous.Result, record.Result), nil
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Dump formats
+const (
+	DumpNDJSON = "ndjson"
+	DumpJSON   = "json"
+)
+
+// ImportStats counts the outcome of an import
+type ImportStats struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// Dump writes every record in store to w, oldest first, as NDJSON (one
+// record per line) or as a JSON array
+func Dump(ctx context.Context, store ResultStore, w io.Writer, format string) error {
+	if format != DumpNDJSON && format != DumpJSON {
+		return fmt.Errorf("unsupported dump format %q", format)
+	}
+
+	enc := json.NewEncoder(w)
+	first := true
+
+	if format == DumpJSON {
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+	}
+
+	err := store.Each(ctx, func(record *Record) error {
+		if format == DumpJSON && !first {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+		return enc.Encode(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	if format == DumpJSON {
+		_, err = io.WriteString(w, "]\n")
+	}
+	return err
+}
+
+// Load reads records written by Dump, in either format, into store. Records
+// whose ID is already stored are skipped, so a dump can be loaded again
+// after an interrupted import.
+func Load(ctx context.Context, store ResultStore, r io.Reader) (ImportStats, error) {
+	var stats ImportStats
+
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+
+	// A JSON array is read element by element; NDJSON is a stream of values
+	array, err := startsArray(br)
+	if err != nil {
+		return stats, err
+	}
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return stats, err
+		}
+	}
+
+	for n := 1; ; n++ {
+		if array && !dec.More() {
+			break
+		}
+
+		var record Record
+		if err := dec.Decode(&record); err != nil {
+			if !array && errors.Is(err, io.EOF) {
+				break
+			}
+			return stats, fmt.Errorf("record %d: %w", n, err)
+		}
+		if record.ID == "" || record.Result == nil {
+			return stats, fmt.Errorf("record %d: id and result are required", n)
+		}
+
+		if _, err := store.Get(ctx, record.ID); err == nil {
+			stats.Skipped++
+			continue
+		} else if !errors.Is(err, ErrNotFound) {
+			return stats, err
+		}
+
+		if err := store.Save(ctx, &record); err != nil {
+			return stats, fmt.Errorf("record %d: %w", n, err)
+		}
+		stats.Imported++
+	}
+
+	return stats, nil
+}
+
+// startsArray reports whether the first non-space byte is '['
+func startsArray(br *bufio.Reader) (bool, error) {
+	for {
+		b, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '[', br.UnreadByte()
+	}
+}
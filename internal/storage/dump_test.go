@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+func TestDumpLoad_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	source := NewMemoryStore()
+	for i, url := range []string{"https://a.example", "https://b.example", "https://a.example"} {
+		record := &Record{URL: url, CreatedAt: now.Add(time.Duration(i) * time.Minute), Result: &analyzer.Result{URL: url, Title: url}}
+		if err := source.Save(ctx, record); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	for _, format := range []string{DumpNDJSON, DumpJSON} {
+		t.Run(format, func(t *testing.T) {
+			var dump bytes.Buffer
+			if err := Dump(ctx, source, &dump, format); err != nil {
+				t.Fatalf("Dump failed: %v", err)
+			}
+
+			target := newTestSQLiteStore(t)
+			stats, err := Load(ctx, target, bytes.NewReader(dump.Bytes()))
+			if err != nil || stats.Imported != 3 || stats.Skipped != 0 {
+				t.Fatalf("Expected 3 records imported, got %+v (%v)", stats, err)
+			}
+
+			history, err := target.History(ctx, "https://a.example", 0)
+			if err != nil || len(history) != 2 || history[1].Result.Title != "https://a.example" {
+				t.Errorf("Unexpected imported history %v (%v)", history, err)
+			}
+
+			stats, err = Load(ctx, target, bytes.NewReader(dump.Bytes()))
+			if err != nil || stats.Imported != 0 || stats.Skipped != 3 {
+				t.Errorf("Expected a repeated import to skip every record, got %+v (%v)", stats, err)
+			}
+		})
+	}
+}
+
+func TestLoad_Malformed(t *testing.T) {
+	store := NewMemoryStore()
+	input := `{"id":"a","url":"https://a.example","result":{}}
+{"id":"b","url":`
+
+	stats, err := Load(context.Background(), store, strings.NewReader(input))
+	if err == nil || stats.Imported != 1 {
+		t.Errorf("Expected an error after 1 imported record, got %+v (%v)", stats, err)
+	}
+
+	if _, err := Load(context.Background(), store, strings.NewReader(`{"url":"https://a.example"}`)); err == nil {
+		t.Error("Expected an error for a record without id and result")
+	}
+}
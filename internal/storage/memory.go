@@ -0,0 +1,288 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// MemoryStore keeps analysis records in process memory. History is lost on
+// restart.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]*Record
+	byURL   map[string][]*Record
+}
+
+var _ ResultStore = (*MemoryStore)(nil)
+
+// NewMemoryStore func creates a new in-memory store singleton instance
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]*Record),
+		byURL:   make(map[string][]*Record),
+	}
+}
+
+// Save stores a record, assigning an ID if it has none
+func (s *MemoryStore) Save(ctx context.Context, record *Record) error {
+	if record.ID == "" {
+		record.ID = NewID()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[record.ID] = record
+
+	// Keep per-URL history ordered by creation time
+	history := append(s.byURL[record.URL], record)
+	for i := len(history) - 1; i > 0 && history[i].CreatedAt.Before(history[i-1].CreatedAt); i-- {
+		history[i], history[i-1] = history[i-1], history[i]
+	}
+	s.byURL[record.URL] = history
+
+	return nil
+}
+
+// Get returns the record with the given ID
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return record, nil
+}
+
+// Previous returns the most recent record for the same URL and equal options
+// created before the given record
+func (s *MemoryStore) Previous(ctx context.Context, record *Record) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.byURL[record.URL]
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].ID != record.ID && history[i].CreatedAt.Before(record.CreatedAt) &&
+			history[i].Options.Equal(record.Options) {
+			return history[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Diff compares record with the previous record for the same URL and options
+func (s *MemoryStore) Diff(ctx context.Context, record *Record) (*Record, *analyzer.Diff, error) {
+	return diffPrevious(ctx, s, record)
+}
+
+// List returns a page of records, newest first
+func (s *MemoryStore) List(ctx context.Context, opts ListOptions) ([]*Record, error) {
+	s.mu.RLock()
+	var records []*Record
+	if opts.URL != "" {
+		records = append(records, s.byURL[opts.URL]...)
+	} else {
+		records = make([]*Record, 0, len(s.records))
+		for _, record := range s.records {
+			records = append(records, record)
+		}
+	}
+	s.mu.RUnlock()
+
+	if !opts.Since.IsZero() {
+		records = slices.DeleteFunc(records, func(record *Record) bool {
+			return record.CreatedAt.Before(opts.Since)
+		})
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+
+	return page(records, opts), nil
+}
+
+// History returns up to limit of the most recent records for a URL, oldest
+// first. A limit of zero or less returns every record.
+func (s *MemoryStore) History(ctx context.Context, url string, limit int) ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.byURL[url]
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return append([]*Record(nil), history...), nil
+}
+
+// Each calls fn for every record, oldest first, stopping at the first error
+func (s *MemoryStore) Each(ctx context.Context, fn func(*Record) error) error {
+	s.mu.RLock()
+	records := make([]*Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+
+	for _, record := range records {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MostAnalyzed returns up to n URLs with the most records, most first
+func (s *MemoryStore) MostAnalyzed(ctx context.Context, n int) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int, len(s.byURL))
+	for url, history := range s.byURL {
+		counts[url] = len(history)
+	}
+	return topURLs(counts, n), nil
+}
+
+// Delete removes the record with the given ID
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	s.removeLocked(record)
+	return nil
+}
+
+// DeleteByURL removes every record for a URL and returns how many were removed
+func (s *MemoryStore) DeleteByURL(ctx context.Context, url string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.byURL[url]
+	for _, record := range history {
+		delete(s.records, record.ID)
+	}
+	delete(s.byURL, url)
+
+	return len(history), nil
+}
+
+// Prune removes records created before cutoff and then the oldest records
+// beyond maxRows. A zero cutoff or maxRows disables that limit.
+func (s *MemoryStore) Prune(ctx context.Context, cutoff time.Time, maxRows int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]*Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+
+	removed := 0
+	for i, record := range records {
+		expired := !cutoff.IsZero() && record.CreatedAt.Before(cutoff)
+		overflow := maxRows > 0 && len(records)-i > maxRows
+		if !expired && !overflow {
+			break
+		}
+		s.removeLocked(record)
+		removed++
+	}
+
+	return removed, nil
+}
+
+// Count returns the number of stored records
+func (s *MemoryStore) Count(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.records), nil
+}
+
+// Close is a no-op; memory is released with the store
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// removeLocked deletes a record from both indexes. The caller must hold the
+// write lock.
+func (s *MemoryStore) removeLocked(record *Record) {
+	delete(s.records, record.ID)
+
+	history := s.byURL[record.URL]
+	for i, r := range history {
+		if r.ID == record.ID {
+			history = append(history[:i], history[i+1:]...)
+			break
+		}
+	}
+
+	if len(history) == 0 {
+		delete(s.byURL, record.URL)
+	} else {
+		s.byURL[record.URL] = history
+	}
+}
+
+// page applies the offset and limit of opts to records
+func page(records []*Record, opts ListOptions) []*Record {
+	if opts.Offset >= len(records) {
+		return nil
+	}
+	records = records[max(opts.Offset, 0):]
+	if opts.Limit > 0 && len(records) > opts.Limit {
+		records = records[:opts.Limit]
+	}
+	return records
+}
+
+// topURLs returns up to n URLs with the highest counts, most first and then
+// by URL
+func topURLs(counts map[string]int, n int) []string {
+	urls := make([]string, 0, len(counts))
+	for url := range counts {
+		urls = append(urls, url)
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		if ci, cj := counts[urls[i]], counts[urls[j]]; ci != cj {
+			return ci > cj
+		}
+		return urls[i] < urls[j]
+	})
+
+	if n < len(urls) {
+		urls = urls[:n]
+	}
+	return urls
+}
+
+// NewID generates a random record identifier
+func NewID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// Storage drivers
+const (
+	DriverSQLite = "sqlite"
+	DriverMemory = "memory"
+	DriverBolt   = "bolt"
+)
+
+// DriverFunc opens a store from the storage configuration
+type DriverFunc func(cfg config.StorageConfig) (ResultStore, error)
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]DriverFunc{
+		DriverSQLite: func(cfg config.StorageConfig) (ResultStore, error) { return NewSQLiteStore(cfg.Path) },
+		DriverBolt:   func(cfg config.StorageConfig) (ResultStore, error) { return NewBoltStore(cfg.Path) },
+		DriverMemory: func(cfg config.StorageConfig) (ResultStore, error) { return NewMemoryStore(), nil },
+	}
+)
+
+// RegisterDriver makes a store available to Open under name, so other
+// databases can be plugged in without changing callers. Registering an
+// existing name replaces it.
+func RegisterDriver(name string, open DriverFunc) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	drivers[name] = open
+}
+
+// Drivers returns the registered driver names, sorted
+func Drivers() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open creates the store selected by the configuration. An empty driver
+// selects SQLite.
+func Open(cfg config.StorageConfig) (ResultStore, error) {
+	name := cfg.Driver
+	if name == "" {
+		name = DriverSQLite
+	}
+
+	driversMu.RLock()
+	open, ok := drivers[name]
+	driversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown storage driver %q (available: %v)", cfg.Driver, Drivers())
+	}
+	return open(cfg)
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// RunRetention prunes expired and excess records on every cleanup interval
+// until ctx is canceled
+func RunRetention(ctx context.Context, store ResultStore, cfg config.StorageConfig, logger *slog.Logger) {
+	if cfg.CleanupInterval <= 0 || (cfg.MaxAge <= 0 && cfg.MaxRows <= 0) {
+		logger.Info("History retention cleanup disabled")
+		return
+	}
+
+	logger.Info("History retention cleanup started",
+		"max_age", cfg.MaxAge,
+		"max_rows", cfg.MaxRows,
+		"interval", cfg.CleanupInterval,
+	)
+
+	ticker := time.NewTicker(cfg.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("History retention cleanup stopped")
+			return
+		case <-ticker.C:
+			pruneOnce(ctx, store, cfg, logger)
+		}
+	}
+}
+
+// pruneOnce applies the retention limits a single time
+func pruneOnce(ctx context.Context, store ResultStore, cfg config.StorageConfig, logger *slog.Logger) {
+	var cutoff time.Time
+	if cfg.MaxAge > 0 {
+		cutoff = time.Now().Add(-cfg.MaxAge)
+	}
+
+	removed, err := store.Prune(ctx, cutoff, cfg.MaxRows)
+	if err != nil {
+		logger.Error("History retention cleanup failed", "error", err)
+		return
+	}
+
+	if removed > 0 {
+		logger.Info("Pruned stored results", "removed", removed)
+	} else {
+		logger.Debug("History retention cleanup found nothing to prune")
+	}
+}
@@ -0,0 +1,338 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// sqliteSchema creates the results table. created_at holds Unix nanoseconds.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS results (
+	id         TEXT PRIMARY KEY,
+	url        TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	options    TEXT NOT NULL,
+	result     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS results_url_created_at ON results (url, created_at);
+CREATE INDEX IF NOT EXISTS results_created_at ON results (created_at);
+`
+
+// SQLiteStore keeps analysis records in a SQLite database, so history
+// survives restarts
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+var _ ResultStore = (*SQLiteStore)(nil)
+
+// NewSQLiteStore func creates a new SQLite store singleton instance backed by
+// the database file at path, creating the file and its directory if needed.
+// ":memory:" opens a private in-memory database.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if path != ":memory:" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("creating database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	// A single connection serializes writes and keeps ":memory:" databases
+	// from being split across connections
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating schema: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Save stores a record, assigning an ID if it has none
+func (s *SQLiteStore) Save(ctx context.Context, record *Record) error {
+	if record.ID == "" {
+		record.ID = NewID()
+	}
+
+	options, err := json.Marshal(record.Options)
+	if err != nil {
+		return fmt.Errorf("encoding options: %w", err)
+	}
+	result, err := json.Marshal(record.Result)
+	if err != nil {
+		return fmt.Errorf("encoding result: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO results (id, url, created_at, options, result) VALUES (?, ?, ?, ?, ?)`,
+		record.ID, record.URL, record.CreatedAt.UnixNano(), string(options), string(result),
+	)
+	return err
+}
+
+// Get returns the record with the given ID
+func (s *SQLiteStore) Get(ctx context.Context, id string) (*Record, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT id, url, created_at, options, result FROM results WHERE id = ?`, id)
+	return scanRecord(row)
+}
+
+// Previous returns the most recent record for the same URL and equal options
+// created before the given record. Options are stored as JSON, so they are
+// compared after decoding rather than in SQL.
+func (s *SQLiteStore) Previous(ctx context.Context, record *Record) (*Record, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, url, created_at, options, result FROM results
+		WHERE url = ? AND id <> ? AND created_at < ?
+		ORDER BY created_at DESC`,
+		record.URL, record.ID, record.CreatedAt.UnixNano(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		previous, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		if previous.Options.Equal(record.Options) {
+			return previous, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return nil, ErrNotFound
+}
+
+// Diff compares record with the previous record for the same URL and options
+func (s *SQLiteStore) Diff(ctx context.Context, record *Record) (*Record, *analyzer.Diff, error) {
+	return diffPrevious(ctx, s, record)
+}
+
+// List returns a page of records, newest first
+func (s *SQLiteStore) List(ctx context.Context, opts ListOptions) ([]*Record, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	var since int64
+	if !opts.Since.IsZero() {
+		since = opts.Since.UnixNano()
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, url, created_at, options, result FROM results
+		WHERE (? = '' OR url = ?) AND created_at >= ?
+		ORDER BY created_at DESC LIMIT ? OFFSET ?`,
+		opts.URL, opts.URL, since, limit, max(opts.Offset, 0),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*Record
+	for rows.Next() {
+		record, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// History returns up to limit of the most recent records for a URL, oldest
+// first. A limit of zero or less returns every record.
+func (s *SQLiteStore) History(ctx context.Context, url string, limit int) ([]*Record, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, url, created_at, options, result FROM (
+			SELECT * FROM results WHERE url = ? ORDER BY created_at DESC LIMIT ?
+		) ORDER BY created_at`,
+		url, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*Record
+	for rows.Next() {
+		record, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// Each calls fn for every record, oldest first, stopping at the first error
+func (s *SQLiteStore) Each(ctx context.Context, fn func(*Record) error) error {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, url, created_at, options, result FROM results ORDER BY created_at`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		record, err := scanRecord(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// MostAnalyzed returns up to n URLs with the most records, most first
+func (s *SQLiteStore) MostAnalyzed(ctx context.Context, n int) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT url FROM results GROUP BY url ORDER BY COUNT(*) DESC, url LIMIT ?`, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, rows.Err()
+}
+
+// Delete removes the record with the given ID
+func (s *SQLiteStore) Delete(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM results WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteByURL removes every record for a URL and returns how many were removed
+func (s *SQLiteStore) DeleteByURL(ctx context.Context, url string) (int, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM results WHERE url = ?`, url)
+	if err != nil {
+		return 0, err
+	}
+
+	removed, err := res.RowsAffected()
+	return int(removed), err
+}
+
+// Prune removes records created before cutoff and then the oldest records
+// beyond maxRows. A zero cutoff or maxRows disables that limit.
+func (s *SQLiteStore) Prune(ctx context.Context, cutoff time.Time, maxRows int) (int, error) {
+	removed := 0
+
+	if !cutoff.IsZero() {
+		res, err := s.db.ExecContext(ctx, `DELETE FROM results WHERE created_at < ?`, cutoff.UnixNano())
+		if err != nil {
+			return removed, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return removed, err
+		}
+		removed += int(n)
+	}
+
+	if maxRows > 0 {
+		res, err := s.db.ExecContext(ctx,
+			`DELETE FROM results WHERE id IN (
+				SELECT id FROM results ORDER BY created_at DESC LIMIT -1 OFFSET ?
+			)`, maxRows)
+		if err != nil {
+			return removed, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return removed, err
+		}
+		removed += int(n)
+	}
+
+	return removed, nil
+}
+
+// Count returns the number of stored records
+func (s *SQLiteStore) Count(ctx context.Context) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM results`).Scan(&count)
+	return count, err
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanRecord decodes a single results row
+func scanRecord(row rowScanner) (*Record, error) {
+	var (
+		record    Record
+		createdAt int64
+		options   string
+		result    string
+	)
+
+	if err := row.Scan(&record.ID, &record.URL, &createdAt, &options, &result); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	record.CreatedAt = time.Unix(0, createdAt).UTC()
+	if err := json.Unmarshal([]byte(options), &record.Options); err != nil {
+		return nil, fmt.Errorf("decoding options: %w", err)
+	}
+	if err := json.Unmarshal([]byte(result), &record.Result); err != nil {
+		return nil, fmt.Errorf("decoding result: %w", err)
+	}
+
+	return &record, nil
+}
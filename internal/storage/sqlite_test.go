@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+func newTestSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "results.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteStore_SaveGetPrevious(t *testing.T) {
+	testSaveGetPrevious(t, newTestSQLiteStore(t))
+}
+
+func TestSQLiteStore_DeleteAndPrune(t *testing.T) {
+	testDeleteAndPrune(t, newTestSQLiteStore(t))
+}
+
+// testSaveGetPrevious checks round-tripping and history lookups for a
+// persistent store
+func testSaveGetPrevious(t *testing.T, store ResultStore) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	first := &Record{
+		URL:       "https://example.com",
+		CreatedAt: now.Add(-time.Hour),
+		Options:   Options{Sections: []analyzer.Section{analyzer.SectionSEO}},
+		Result:    &analyzer.Result{URL: "https://example.com", Title: "First", Headings: map[string]int{"h1": 1}},
+	}
+	second := &Record{
+		URL:       "https://example.com",
+		CreatedAt: now,
+		Options:   Options{Sections: []analyzer.Section{analyzer.SectionSEO}},
+		Result:    &analyzer.Result{URL: "https://example.com", Title: "Second", SEO: &analyzer.SEO{Lang: "en"}},
+	}
+	for _, record := range []*Record{first, second} {
+		if err := store.Save(ctx, record); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	got, err := store.Get(ctx, second.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Result.Title != "Second" || got.Result.SEO == nil || got.Result.SEO.Lang != "en" {
+		t.Errorf("Unexpected result: %+v", got.Result)
+	}
+	if !got.CreatedAt.Equal(second.CreatedAt) {
+		t.Errorf("Expected created_at %v, got %v", second.CreatedAt, got.CreatedAt)
+	}
+	if len(got.Options.Sections) != 1 || got.Options.Sections[0] != analyzer.SectionSEO {
+		t.Errorf("Unexpected options: %+v", got.Options)
+	}
+
+	previous, err := store.Previous(ctx, got)
+	if err != nil {
+		t.Fatalf("Previous failed: %v", err)
+	}
+	if previous.ID != first.ID {
+		t.Errorf("Expected previous record %s, got %s", first.ID, previous.ID)
+	}
+
+	previous, diff, err := store.Diff(ctx, got)
+	if err != nil || previous.ID != first.ID || diff.Identical {
+		t.Errorf("Expected a diff against %s, got %+v, %+v, %v", first.ID, previous, diff, err)
+	}
+
+	if _, err := store.Previous(ctx, first); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound before the first record, got %v", err)
+	}
+	if _, _, err := store.Diff(ctx, first); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound diffing the first record, got %v", err)
+	}
+	if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown ID, got %v", err)
+	}
+}
+
+// testDeleteAndPrune checks deletion and retention for a store
+func testDeleteAndPrune(t *testing.T, store ResultStore) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for i, url := range []string{"https://a.example", "https://b.example", "https://b.example", "https://c.example"} {
+		record := &Record{
+			ID:        string(rune('a' + i)),
+			URL:       url,
+			CreatedAt: now.Add(time.Duration(i-4) * time.Hour),
+			Result:    &analyzer.Result{URL: url},
+		}
+		if err := store.Save(ctx, record); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	if err := store.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Delete(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	removed, err := store.DeleteByURL(ctx, "https://b.example")
+	if err != nil || removed != 2 {
+		t.Errorf("Expected 2 records removed by URL, got %d (%v)", removed, err)
+	}
+
+	if err := store.Save(ctx, &Record{URL: "https://d.example", CreatedAt: now, Result: &analyzer.Result{}}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	removed, err = store.Prune(ctx, time.Time{}, 1)
+	if err != nil || removed != 1 {
+		t.Errorf("Expected 1 record pruned, got %d (%v)", removed, err)
+	}
+
+	count, err := store.Count(ctx)
+	if err != nil || count != 1 {
+		t.Errorf("Expected 1 record left, got %d (%v)", count, err)
+	}
+	if _, err := store.Get(ctx, "d"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the oldest record to be pruned, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for name, store := range map[string]ResultStore{"memory": NewMemoryStore(), "sqlite": newTestSQLiteStore(t), "bolt": newTestBoltStore(t)} {
+		t.Run(name, func(t *testing.T) {
+			for i, url := range []string{"https://a.example", "https://b.example", "https://a.example", "https://c.example"} {
+				record := &Record{ID: string(rune('a' + i)), URL: url, CreatedAt: now.Add(time.Duration(i) * time.Minute), Result: &analyzer.Result{URL: url}}
+				if err := store.Save(ctx, record); err != nil {
+					t.Fatalf("Save failed: %v", err)
+				}
+			}
+
+			testCases := []struct {
+				opts     ListOptions
+				expected string
+			}{
+				{ListOptions{}, "dcba"},
+				{ListOptions{Limit: 2}, "dc"},
+				{ListOptions{Limit: 2, Offset: 1}, "cb"},
+				{ListOptions{Offset: 10}, ""},
+				{ListOptions{URL: "https://a.example"}, "ca"},
+				{ListOptions{URL: "https://a.example", Offset: 1}, "a"},
+				{ListOptions{URL: "https://b.example"}, "b"},
+				{ListOptions{URL: "https://missing.example"}, ""},
+				{ListOptions{Since: now.Add(2 * time.Minute)}, "dc"},
+				{ListOptions{URL: "https://a.example", Since: now.Add(time.Minute)}, "c"},
+			}
+
+			for _, tc := range testCases {
+				records, err := store.List(ctx, tc.opts)
+				if err != nil {
+					t.Fatalf("List(%+v) failed: %v", tc.opts, err)
+				}
+				ids := ""
+				for _, record := range records {
+					ids += record.ID
+				}
+				if ids != tc.expected {
+					t.Errorf("List(%+v) = %q, want %q", tc.opts, ids, tc.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestPrevious_MatchesOptions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	seo := []analyzer.Section{analyzer.SectionSEO}
+	seoAndSecurity := []analyzer.Section{analyzer.SectionSEO, analyzer.SectionSecurity}
+
+	for name, store := range map[string]ResultStore{"memory": NewMemoryStore(), "sqlite": newTestSQLiteStore(t), "bolt": newTestBoltStore(t)} {
+		t.Run(name, func(t *testing.T) {
+			records := []*Record{
+				{ID: "a", Options: Options{Sections: seoAndSecurity}},
+				{ID: "b", Options: Options{Sections: seo}},
+				{ID: "c", Options: Options{Sections: seo, Device: analyzer.DeviceMobile}},
+				{ID: "d"},
+			}
+			for i, record := range records {
+				record.URL = "https://example.com"
+				record.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+				record.Result = &analyzer.Result{URL: record.URL}
+				if err := store.Save(ctx, record); err != nil {
+					t.Fatalf("Save failed: %v", err)
+				}
+			}
+
+			testCases := []struct {
+				name     string
+				options  Options
+				expected string
+			}{
+				{"same sections", Options{Sections: seo}, "b"},
+				{"sections in another order", Options{Sections: []analyzer.Section{analyzer.SectionSecurity, analyzer.SectionSEO}}, "a"},
+				{"same device", Options{Sections: seo, Device: analyzer.DeviceMobile}, "c"},
+				{"other device", Options{Sections: seo, Device: analyzer.DeviceTablet}, ""},
+				{"default options", Options{}, "d"},
+			}
+			for _, tc := range testCases {
+				latest := &Record{ID: "z", URL: "https://example.com", CreatedAt: now.Add(time.Hour), Options: tc.options}
+				previous, err := store.Previous(ctx, latest)
+				switch {
+				case tc.expected == "" && !errors.Is(err, ErrNotFound):
+					t.Errorf("%s: expected ErrNotFound, got %+v, %v", tc.name, previous, err)
+				case tc.expected != "" && (err != nil || previous.ID != tc.expected):
+					t.Errorf("%s: expected record %s, got %+v, %v", tc.name, tc.expected, previous, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/tenant"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// ErrUnknownTenant is returned for requests acting for a tenant that has no
+// store
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// TenantStore partitions records between tenants by keeping a separate
+// store per tenant, selected by the tenant of each call's context. Calls
+// without a tenant use the default store.
+type TenantStore struct {
+	def     ResultStore
+	tenants map[string]ResultStore
+}
+
+var _ ResultStore = (*TenantStore)(nil)
+
+// NewTenantStore func creates a new tenant store singleton instance routing
+// calls to def and the given per-tenant stores
+func NewTenantStore(def ResultStore, tenants map[string]ResultStore) *TenantStore {
+	return &TenantStore{def: def, tenants: tenants}
+}
+
+// OpenTenants opens the configured store for the default namespace and one
+// store per named tenant. File-backed tenant stores live next to the
+// default one, under tenants/<name>/. Without tenants it returns the plain
+// store.
+func OpenTenants(cfg config.StorageConfig, names []string) (ResultStore, error) {
+	def, err := Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return def, nil
+	}
+
+	stores := make(map[string]ResultStore, len(names))
+	closeAll := func() {
+		def.Close()
+		for _, s := range stores {
+			s.Close()
+		}
+	}
+
+	for _, name := range names {
+		if !tenant.Valid(name) {
+			closeAll()
+			return nil, fmt.Errorf("invalid tenant name %q", name)
+		}
+
+		tenantCfg := cfg
+		tenantCfg.Path = tenantPath(cfg.Path, name)
+		s, err := Open(tenantCfg)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("opening store for tenant %q: %w", name, err)
+		}
+		stores[name] = s
+	}
+
+	return NewTenantStore(def, stores), nil
+}
+
+// tenantPath returns the path of a tenant's database given the default one
+func tenantPath(path, name string) string {
+	if path == "" || path == ":memory:" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(path), "tenants", name, filepath.Base(path))
+}
+
+// store returns the store for the tenant of ctx
+func (s *TenantStore) store(ctx context.Context) (ResultStore, error) {
+	name := tenant.FromContext(ctx)
+	if name == "" {
+		return s.def, nil
+	}
+	if store, ok := s.tenants[name]; ok {
+		return store, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownTenant, name)
+}
+
+// Save stores a record in the tenant's store
+func (s *TenantStore) Save(ctx context.Context, record *Record) error {
+	store, err := s.store(ctx)
+	if err != nil {
+		return err
+	}
+	return store.Save(ctx, record)
+}
+
+// Get returns the record with the given ID from the tenant's store
+func (s *TenantStore) Get(ctx context.Context, id string) (*Record, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.Get(ctx, id)
+}
+
+// Previous returns the most recent earlier record for the same URL and
+// equal options from the tenant's store
+func (s *TenantStore) Previous(ctx context.Context, record *Record) (*Record, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.Previous(ctx, record)
+}
+
+// Diff compares record with the previous record from the tenant's store
+func (s *TenantStore) Diff(ctx context.Context, record *Record) (*Record, *analyzer.Diff, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.Diff(ctx, record)
+}
+
+// Delete removes the record with the given ID from the tenant's store
+func (s *TenantStore) Delete(ctx context.Context, id string) error {
+	store, err := s.store(ctx)
+	if err != nil {
+		return err
+	}
+	return store.Delete(ctx, id)
+}
+
+// List returns a page of the tenant's records, newest first
+func (s *TenantStore) List(ctx context.Context, opts ListOptions) ([]*Record, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.List(ctx, opts)
+}
+
+// History returns the tenant's most recent records for a URL, oldest first
+func (s *TenantStore) History(ctx context.Context, url string, limit int) ([]*Record, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.History(ctx, url, limit)
+}
+
+// Each calls fn for every record of the tenant, oldest first
+func (s *TenantStore) Each(ctx context.Context, fn func(*Record) error) error {
+	store, err := s.store(ctx)
+	if err != nil {
+		return err
+	}
+	return store.Each(ctx, fn)
+}
+
+// MostAnalyzed returns the tenant's most analyzed URLs, most first
+func (s *TenantStore) MostAnalyzed(ctx context.Context, n int) ([]string, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.MostAnalyzed(ctx, n)
+}
+
+// DeleteByURL removes every record for a URL from the tenant's store
+func (s *TenantStore) DeleteByURL(ctx context.Context, url string) (int, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return store.DeleteByURL(ctx, url)
+}
+
+// Prune applies retention to every tenant, so it can run in the
+// background without a tenant. maxRows limits each tenant separately.
+func (s *TenantStore) Prune(ctx context.Context, cutoff time.Time, maxRows int) (int, error) {
+	removed, err := s.def.Prune(ctx, cutoff, maxRows)
+	if err != nil {
+		return removed, err
+	}
+	for name, store := range s.tenants {
+		n, err := store.Prune(ctx, cutoff, maxRows)
+		removed += n
+		if err != nil {
+			return removed, fmt.Errorf("tenant %q: %w", name, err)
+		}
+	}
+	return removed, nil
+}
+
+// Count returns the number of the tenant's records
+func (s *TenantStore) Count(ctx context.Context) (int, error) {
+	store, err := s.store(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return store.Count(ctx)
+}
+
+// Close closes every tenant's store
+func (s *TenantStore) Close() error {
+	err := s.def.Close()
+	for _, store := range s.tenants {
+		err = errors.Join(err, store.Close())
+	}
+	return err
+}
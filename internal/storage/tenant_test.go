@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+	"github.com/anjula-paulus/web-analyzer/internal/tenant"
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+func TestTenantStore_Partitions(t *testing.T) {
+	store := NewTenantStore(NewMemoryStore(), map[string]ResultStore{"team-a": NewMemoryStore()})
+	defaultCtx := context.Background()
+	teamA := tenant.WithName(defaultCtx, "team-a")
+	now := time.Now().UTC()
+
+	record := &Record{ID: "a", URL: "https://example.com", CreatedAt: now, Result: &analyzer.Result{URL: "https://example.com"}}
+	if err := store.Save(teamA, record); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	old := &Record{ID: "b", URL: "https://example.com", CreatedAt: now.Add(-time.Hour), Result: &analyzer.Result{URL: "https://example.com"}}
+	if err := store.Save(defaultCtx, old); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if _, err := store.Get(teamA, "a"); err != nil {
+		t.Errorf("Get in the owning tenant failed: %v", err)
+	}
+	if _, err := store.Get(defaultCtx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get in the default namespace error = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Previous(teamA, record); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Previous crossed tenants, error = %v", err)
+	}
+	if _, err := store.Count(tenant.WithName(defaultCtx, "team-b")); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("Count for an unknown tenant error = %v, want ErrUnknownTenant", err)
+	}
+
+	removed, err := store.Prune(defaultCtx, now.Add(-time.Minute), 0)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Prune removed %d records, want 1", removed)
+	}
+}
+
+func TestOpenTenants(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.StorageConfig{Driver: DriverSQLite, Path: filepath.Join(dir, "results.db")}
+
+	if _, err := OpenTenants(cfg, []string{"../escape"}); err == nil {
+		t.Error("expected an error for a tenant name that is not path-safe")
+	}
+
+	store, err := OpenTenants(cfg, []string{"team-a"})
+	if err != nil {
+		t.Fatalf("OpenTenants failed: %v", err)
+	}
+	defer store.Close()
+
+	if _, ok := store.(*TenantStore); !ok {
+		t.Fatalf("OpenTenants returned %T, want *TenantStore", store)
+	}
+	if got, want := tenantPath(cfg.Path, "team-a"), filepath.Join(dir, "tenants", "team-a", "results.db"); got != want {
+		t.Errorf("tenantPath = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TrendPoint is one stored result in a URL's trend
+type TrendPoint struct {
+	ID                string    `json:"id"`
+	CreatedAt         time.Time `json:"created_at"`
+	InaccessibleLinks int       `json:"inaccessible_links"`
+	// PageBytes is only known for results analyzed with the performance
+	// section
+	PageBytes int64 `json:"page_bytes,omitempty"`
+}
+
+// Trend describes how a URL's results changed over its stored history
+type Trend struct {
+	URL    string       `json:"url"`
+	Points []TrendPoint `json:"points"`
+	// InaccessibleLinksChange is the change from the first to the last point
+	InaccessibleLinksChange int `json:"inaccessible_links_change"`
+	// PageBytesChange is the change between the first and last points that
+	// have a page size, or zero when fewer than two do
+	PageBytesChange int64 `json:"page_bytes_change"`
+}
+
+// NewTrend builds a trend from records for a single URL, oldest first
+func NewTrend(url string, records []*Record) *Trend {
+	trend := &Trend{URL: url, Points: make([]TrendPoint, 0, len(records))}
+
+	var firstBytes, lastBytes int64
+	for _, record := range records {
+		point := TrendPoint{
+			ID:                record.ID,
+			CreatedAt:         record.CreatedAt,
+			InaccessibleLinks: record.Result.InaccessibleLinks,
+		}
+		if perf := record.Result.Performance; perf != nil && perf.PageBytes > 0 {
+			point.PageBytes = perf.PageBytes
+			if firstBytes == 0 {
+				firstBytes = perf.PageBytes
+			}
+			lastBytes = perf.PageBytes
+		}
+		trend.Points = append(trend.Points, point)
+	}
+
+	if n := len(trend.Points); n > 0 {
+		trend.InaccessibleLinksChange = trend.Points[n-1].InaccessibleLinks - trend.Points[0].InaccessibleLinks
+	}
+	trend.PageBytesChange = lastBytes - firstBytes
+
+	return trend
+}
+
+// TrendCollector exports the latest inaccessible link count and page size of
+// the most analyzed URLs as Prometheus gauges. The store is queried on each
+// scrape.
+type TrendCollector struct {
+	store  ResultStore
+	urls   int
+	logger *slog.Logger
+
+	inaccessibleLinks *prometheus.Desc
+	pageBytes         *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*TrendCollector)(nil)
+
+// NewTrendCollector func creates a new trend collector singleton instance
+// reporting on the urls most analyzed URLs
+func NewTrendCollector(store ResultStore, urls int, logger *slog.Logger) *TrendCollector {
+	return &TrendCollector{
+		store:  store,
+		urls:   urls,
+		logger: logger,
+		inaccessibleLinks: prometheus.NewDesc(
+			"url_inaccessible_links",
+			"Inaccessible links in the latest stored result of a frequently analyzed URL",
+			[]string{"url"}, nil,
+		),
+		pageBytes: prometheus.NewDesc(
+			"url_page_bytes",
+			"Page size in bytes in the latest stored result of a frequently analyzed URL, when measured",
+			[]string{"url"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *TrendCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.inaccessibleLinks
+	ch <- c.pageBytes
+}
+
+// Collect implements prometheus.Collector
+func (c *TrendCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	urls, err := c.store.MostAnalyzed(ctx, c.urls)
+	if err != nil {
+		c.logger.Error("Failed to load most analyzed URLs", "error", err)
+		return
+	}
+
+	for _, url := range urls {
+		records, err := c.store.History(ctx, url, 1)
+		if err != nil {
+			c.logger.Error("Failed to load latest result", "url", url, "error", err)
+			continue
+		}
+		if len(records) == 0 {
+			continue
+		}
+
+		result := records[0].Result
+		ch <- prometheus.MustNewConstMetric(c.inaccessibleLinks, prometheus.GaugeValue, float64(result.InaccessibleLinks), url)
+		if result.Performance != nil && result.Performance.PageBytes > 0 {
+			ch <- prometheus.MustNewConstMetric(c.pageBytes, prometheus.GaugeValue, float64(result.Performance.PageBytes), url)
+		}
+	}
+}
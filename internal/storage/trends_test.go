@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+func TestTrends(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	for name, store := range map[string]ResultStore{"memory": NewMemoryStore(), "sqlite": newTestSQLiteStore(t), "bolt": newTestBoltStore(t)} {
+		t.Run(name, func(t *testing.T) {
+			results := []*analyzer.Result{
+				{URL: "https://a.example", InaccessibleLinks: 1, Performance: &analyzer.Performance{PageBytes: 1000}},
+				{URL: "https://a.example", InaccessibleLinks: 2},
+				{URL: "https://a.example", InaccessibleLinks: 5, Performance: &analyzer.Performance{PageBytes: 1500}},
+				{URL: "https://b.example"},
+			}
+			for i, result := range results {
+				record := &Record{URL: result.URL, CreatedAt: now.Add(time.Duration(i) * time.Minute), Result: result}
+				if err := store.Save(ctx, record); err != nil {
+					t.Fatalf("Save failed: %v", err)
+				}
+			}
+
+			records, err := store.History(ctx, "https://a.example", 0)
+			if err != nil {
+				t.Fatalf("History failed: %v", err)
+			}
+			trend := NewTrend("https://a.example", records)
+			if len(trend.Points) != 3 || trend.Points[0].InaccessibleLinks != 1 || trend.Points[2].InaccessibleLinks != 5 {
+				t.Fatalf("Unexpected points: %+v", trend.Points)
+			}
+			if trend.InaccessibleLinksChange != 4 || trend.PageBytesChange != 500 {
+				t.Errorf("Expected changes 4 and 500, got %d and %d", trend.InaccessibleLinksChange, trend.PageBytesChange)
+			}
+
+			latest, err := store.History(ctx, "https://a.example", 2)
+			if err != nil || len(latest) != 2 || latest[1].Result.InaccessibleLinks != 5 {
+				t.Errorf("Expected the 2 most recent records oldest first, got %v (%v)", latest, err)
+			}
+
+			urls, err := store.MostAnalyzed(ctx, 1)
+			if err != nil || len(urls) != 1 || urls[0] != "https://a.example" {
+				t.Errorf("Expected https://a.example to be most analyzed, got %v (%v)", urls, err)
+			}
+		})
+	}
+}
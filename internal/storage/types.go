@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+// ErrNotFound is returned when a record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// Record is a stored analysis result
+type Record struct {
+	ID        string           `json:"id"`
+	URL       string           `json:"url"`
+	CreatedAt time.Time        `json:"created_at"`
+	Options   Options          `json:"options"`
+	Result    *analyzer.Result `json:"result"`
+}
+
+// Options are the analysis options a record was produced with
+type Options struct {
+	Sections []analyzer.Section `json:"sections,omitempty"`
+	Device   analyzer.Device    `json:"device,omitempty"`
+}
+
+// Equal reports whether o and other select the same sections, in any order,
+// and the same device. Only records produced with equal options are
+// comparable, since a section that was not requested reads as missing.
+func (o Options) Equal(other Options) bool {
+	return o.Device == other.Device &&
+		slices.Equal(sortedSections(o.Sections), sortedSections(other.Sections))
+}
+
+// sortedSections returns a sorted copy of sections
+func sortedSections(sections []analyzer.Section) []analyzer.Section {
+	sorted := slices.Clone(sections)
+	slices.Sort(sorted)
+	return sorted
+}
+
+// ListOptions selects a page of records for List
+type ListOptions struct {
+	// URL limits the list to one URL's records when set
+	URL string
+	// Since limits the list to records created at or after it when set
+	Since  time.Time
+	Limit  int
+	Offset int
+}
+
+// ResultStore persists analysis records. Drivers are selected by name with
+// Open; see RegisterDriver.
+type ResultStore interface {
+	// Save stores a record, assigning an ID if it has none
+	Save(ctx context.Context, record *Record) error
+	// Get returns the record with the given ID
+	Get(ctx context.Context, id string) (*Record, error)
+	// Previous returns the most recent record for the same URL and equal
+	// options created before the given record
+	Previous(ctx context.Context, record *Record) (*Record, error)
+	// Diff compares record with the record Previous returns and returns
+	// that record with the differences. It returns ErrNotFound when there
+	// is no earlier record.
+	Diff(ctx context.Context, record *Record) (*Record, *analyzer.Diff, error)
+	// Delete removes the record with the given ID
+	Delete(ctx context.Context, id string) error
+	// List returns a page of records, newest first. A limit of zero or
+	// less returns every record from the offset on.
+	List(ctx context.Context, opts ListOptions) ([]*Record, error)
+	// History returns up to limit of the most recent records for a URL,
+	// oldest first. A limit of zero or less returns every record.
+	History(ctx context.Context, url string, limit int) ([]*Record, error)
+	// Each calls fn for every record, oldest first, stopping at the first
+	// error. fn must not call the store.
+	Each(ctx context.Context, fn func(*Record) error) error
+	// MostAnalyzed returns up to n URLs with the most records, most first
+	MostAnalyzed(ctx context.Context, n int) ([]string, error)
+	// DeleteByURL removes every record for a URL and returns how many were
+	// removed
+	DeleteByURL(ctx context.Context, url string) (int, error)
+	// Prune removes records created before cutoff and then the oldest
+	// records beyond maxRows. A zero cutoff or maxRows disables that limit.
+	Prune(ctx context.Context, cutoff time.Time, maxRows int) (int, error)
+	// Count returns the number of stored records
+	Count(ctx context.Context) (int, error)
+	// Close releases the store's resources
+	Close() error
+}
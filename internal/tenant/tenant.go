@@ -0,0 +1,75 @@
+// Package tenant carries the tenant a request acts for. Tenants partition
+// stored results, cached results, quotas and metrics between teams sharing
+// a deployment. Requests without a tenant use the shared default namespace.
+package tenant
+
+import (
+	"context"
+	"sort"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// Default labels the shared namespace in metrics and logs
+const Default = "default"
+
+// maxLength bounds tenant names, which appear in paths and metric labels
+const maxLength = 64
+
+type tenantKey struct{}
+
+// Valid reports whether name may be used as a tenant name. Names are used
+// as directory names, so only lowercase letters, digits, "-" and "_" are
+// allowed, and Default is reserved.
+func Valid(name string) bool {
+	if name == "" || len(name) > maxLength || name == Default {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// Names returns the tenants declared in cfg, either under tenants or on an
+// API key, sorted and without duplicates
+func Names(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	for _, t := range cfg.Tenants {
+		seen[t.Name] = true
+	}
+	for _, k := range cfg.Auth.APIKeys {
+		if k.Tenant != "" {
+			seen[k.Tenant] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithName returns a copy of ctx acting for the named tenant
+func WithName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, name)
+}
+
+// FromContext returns the tenant of ctx, or "" for the default namespace
+func FromContext(ctx context.Context) string {
+	name, _ := ctx.Value(tenantKey{}).(string)
+	return name
+}
+
+// Label returns the tenant of ctx for metric labels and logs
+func Label(ctx context.Context) string {
+	if name := FromContext(ctx); name != "" {
+		return name
+	}
+	return Default
+}
@@ -0,0 +1,76 @@
+// Package tracing sets up OpenTelemetry trace export. Instrumented code uses
+// the global tracer provider, so spans are dropped until Setup installs one.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Setup installs a global tracer provider that batches spans to the
+// configured OTLP/HTTP collector. The returned function flushes pending spans
+// and must be called on shutdown. When tracing is disabled, Setup only
+// installs the W3C trace context propagator and the shutdown function is a
+// no-op.
+func Setup(ctx context.Context, cfg config.TracingConfig, logger *slog.Logger) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, endpointOptions(cfg)...)
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("Trace export failed", "error", err)
+	}))
+
+	logger.Info("Tracing enabled",
+		"endpoint", cfg.Endpoint,
+		"service_name", cfg.ServiceName,
+		"sample_ratio", cfg.SampleRatio,
+	)
+
+	return provider.Shutdown, nil
+}
+
+// endpointOptions accepts the endpoint either as host:port, like the
+// exporter's own option, or as a base URL, like OTEL_EXPORTER_OTLP_ENDPOINT,
+// which gets the traces path appended when it has none
+func endpointOptions(cfg config.TracingConfig) []otlptracehttp.Option {
+	if strings.Contains(cfg.Endpoint, "://") {
+		endpoint := strings.TrimSuffix(cfg.Endpoint, "/")
+		if u, err := url.Parse(endpoint); err == nil && u.Path == "" {
+			endpoint += "/v1/traces"
+		}
+		return []otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpoint)}
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	return opts
+}
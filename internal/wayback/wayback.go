@@ -0,0 +1,141 @@
+// Package wayback finds and fetches archived copies of pages from the
+// Internet Archive's Wayback Machine.
+package wayback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// timestampLayout is the Wayback Machine's snapshot timestamp format
+const timestampLayout = "20060102150405"
+
+// ErrNoSnapshot is returned when the archive has no snapshot of a URL
+var ErrNoSnapshot = errors.New("no archived snapshot")
+
+// Snapshot is an archived copy of a page
+type Snapshot struct {
+	// URL is the snapshot's page on web.archive.org
+	URL       string    `json:"url"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// availability is the part of an availability API response the client uses
+type availability struct {
+	ArchivedSnapshots struct {
+		Closest *struct {
+			Available bool   `json:"available"`
+			URL       string `json:"url"`
+			Timestamp string `json:"timestamp"`
+			Status    string `json:"status"`
+		} `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+// Client looks up and fetches snapshots
+type Client struct {
+	client      *http.Client
+	endpoint    string
+	maxPageSize int64
+}
+
+// New func creates a new Wayback Machine client singleton instance.
+// Snapshots larger than maxPageSize bytes fail to read; 0 disables the
+// limit.
+func New(cfg config.WaybackConfig, maxPageSize int64) *Client {
+	return &Client{
+		client:      &http.Client{Timeout: cfg.Timeout},
+		endpoint:    cfg.Endpoint,
+		maxPageSize: maxPageSize,
+	}
+}
+
+// Closest returns the successfully archived snapshot of pageURL closest to
+// at, which may be before or after it
+func (c *Client) Closest(ctx context.Context, pageURL string, at time.Time) (*Snapshot, error) {
+	query := url.Values{}
+	query.Set("url", pageURL)
+	query.Set("timestamp", at.UTC().Format(timestampLayout))
+	// Only consider snapshots of pages that loaded, not archived errors
+	query.Set("status_code", "200")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("querying the Wayback Machine: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wayback availability API returned HTTP %d", resp.StatusCode)
+	}
+
+	var body availability
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding availability response: %w", err)
+	}
+
+	closest := body.ArchivedSnapshots.Closest
+	if closest == nil || !closest.Available {
+		return nil, fmt.Errorf("%w of %s", ErrNoSnapshot, pageURL)
+	}
+
+	timestamp, err := time.Parse(timestampLayout, closest.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot timestamp %q", closest.Timestamp)
+	}
+	return &Snapshot{URL: closest.URL, Timestamp: timestamp}, nil
+}
+
+// Fetch returns the snapshot's page as originally archived, without the
+// Wayback Machine's banner and with its links left as they were, so it
+// analyzes like the live page did at the time
+func (c *Client) Fetch(ctx context.Context, snapshot *Snapshot) (io.ReadCloser, error) {
+	raw, err := rawURL(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, raw, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching snapshot: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching snapshot: HTTP %d", resp.StatusCode)
+	}
+
+	if c.maxPageSize > 0 {
+		resp.Body = http.MaxBytesReader(nil, resp.Body, c.maxPageSize)
+	}
+	return resp.Body, nil
+}
+
+// rawURL turns a snapshot URL, /web/<timestamp>/<page>, into the URL of the
+// unmodified archived page, /web/<timestamp>id_/<page>
+func rawURL(snapshot *Snapshot) (string, error) {
+	stamp := "/web/" + snapshot.Timestamp.Format(timestampLayout) + "/"
+	before, after, ok := strings.Cut(snapshot.URL, stamp)
+	if !ok {
+		return "", fmt.Errorf("unexpected snapshot URL %q", snapshot.URL)
+	}
+	return before + "/web/" + snapshot.Timestamp.Format(timestampLayout) + "id_/" + after, nil
+}
@@ -0,0 +1,64 @@
+package wayback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+func TestClient_ClosestAndFetch(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/available":
+			if r.URL.Query().Get("url") != "https://example.com/" || r.URL.Query().Get("timestamp") != "20230115000000" {
+				t.Errorf("Unexpected availability query %v", r.URL.Query())
+			}
+			fmt.Fprintf(w, `{"archived_snapshots":{"closest":{"available":true,"status":"200","timestamp":"20230114093000","url":"%s/web/20230114093000/https://example.com/"}}}`, server.URL)
+		case "/web/20230114093000id_/https://example.com/":
+			fmt.Fprint(w, "<title>Archived</title>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := New(config.WaybackConfig{Endpoint: server.URL + "/available", Timeout: time.Second}, 0)
+
+	snapshot, err := client.Closest(context.Background(), "https://example.com/", time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Closest failed: %v", err)
+	}
+	if !snapshot.Timestamp.Equal(time.Date(2023, 1, 14, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("Timestamp = %v, want 2023-01-14 09:30:00", snapshot.Timestamp)
+	}
+
+	body, err := client.Fetch(context.Background(), snapshot)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	defer body.Close()
+	page, _ := io.ReadAll(body)
+	if string(page) != "<title>Archived</title>" {
+		t.Errorf("Fetch returned %q, want the raw archived page", page)
+	}
+}
+
+func TestClient_NoSnapshot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"url":"example.com","archived_snapshots":{}}`)
+	}))
+	defer server.Close()
+
+	client := New(config.WaybackConfig{Endpoint: server.URL, Timeout: time.Second}, 0)
+	if _, err := client.Closest(context.Background(), "https://example.com/", time.Now()); !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("Closest error = %v, want ErrNoSnapshot", err)
+	}
+}
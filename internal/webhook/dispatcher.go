@@ -0,0 +1,230 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+// Headers set on every webhook delivery
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+)
+
+// maxBackoff caps the delay between delivery attempts
+const maxBackoff = time.Minute
+
+// ErrPrivateAddress is returned for deliveries to loopback, link-local,
+// private or unspecified addresses unless private networks are allowed.
+// Such deliveries are not retried.
+var ErrPrivateAddress = errors.New("callback address is not public")
+
+// Dispatcher delivers analysis results to client callback URLs
+type Dispatcher struct {
+	client  *http.Client
+	config  config.WebhookConfig
+	logger  *slog.Logger
+	pending atomic.Int64
+
+	// ctx is cancelled when Shutdown gives up on pending deliveries
+	ctx        context.Context
+	cancel     context.CancelFunc
+	deliveries sync.WaitGroup
+}
+
+// New func creates a new webhook dispatcher singleton instance
+func New(config config.WebhookConfig, logger *slog.Logger) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !config.AllowPrivateNetworks {
+		// Callback URLs come from clients, so the resolved address is
+		// checked when dialing; checking the URL's host would miss names
+		// that resolve to internal addresses. Deliveries go direct, since
+		// through a proxy only the proxy's address would be checked.
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: publicOnly}
+		transport.Proxy = nil
+		transport.DialContext = dialer.DialContext
+	}
+
+	return &Dispatcher{
+		client: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: transport,
+			// Callbacks must answer directly; redirects could bounce signed
+			// payloads to an unintended host
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		config: config,
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Dispatch delivers the payload to callbackURL in the background, retrying
+// failed attempts with exponential backoff
+func (d *Dispatcher) Dispatch(callbackURL string, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		d.logger.Error("Failed to encode webhook payload", "callback_url", callbackURL, "error", err)
+		return
+	}
+
+	d.pending.Add(1)
+	d.deliveries.Add(1)
+	go func() {
+		defer d.deliveries.Done()
+		defer d.pending.Add(-1)
+		d.deliverWithRetry(d.ctx, callbackURL, body)
+	}()
+}
+
+// Shutdown waits for pending deliveries, including their retries. When ctx
+// ends first, the remaining deliveries are abandoned and ctx's error is
+// returned.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	if d.Pending() == 0 {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.deliveries.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.logger.Warn("Abandoning pending webhook deliveries", "pending", d.Pending())
+		d.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Pending returns the number of deliveries not yet delivered or abandoned
+func (d *Dispatcher) Pending() int {
+	return int(d.pending.Load())
+}
+
+// deliverWithRetry attempts delivery until it succeeds, fails permanently, or
+// the configured number of attempts is exhausted
+func (d *Dispatcher) deliverWithRetry(ctx context.Context, callbackURL string, body []byte) {
+	backoff := d.config.InitialBackoff
+
+	for attempt := 1; attempt <= d.config.MaxAttempts; attempt++ {
+		retryable, err := d.deliver(ctx, callbackURL, body)
+		if err == nil {
+			d.logger.Info("Webhook delivered",
+				"callback_url", callbackURL,
+				"attempt", attempt,
+			)
+			return
+		}
+
+		if !retryable || attempt == d.config.MaxAttempts {
+			d.logger.Error("Webhook delivery failed",
+				"callback_url", callbackURL,
+				"attempt", attempt,
+				"retryable", retryable,
+				"error", err,
+			)
+			return
+		}
+
+		d.logger.Warn("Webhook delivery attempt failed, retrying",
+			"callback_url", callbackURL,
+			"attempt", attempt,
+			"backoff", backoff,
+			"error", err,
+		)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// deliver performs a single signed POST and reports whether a failure is
+// worth retrying
+func (d *Dispatcher) deliver(ctx context.Context, callbackURL string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Web-Analyzer/1.0")
+	req.Header.Set(TimestampHeader, timestamp)
+	if d.config.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(d.config.Secret, timestamp, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return !errors.Is(err, ErrPrivateAddress), err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retryable, fmt.Errorf("callback returned HTTP %d", resp.StatusCode)
+}
+
+// publicOnly is a net.Dialer Control function refusing connections to
+// addresses that are not public, such as cloud metadata endpoints
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
+
+// Sign computes the hex-encoded HMAC-SHA256 of "timestamp.body". Receivers
+// verify deliveries by recomputing it with the shared secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,174 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anjula-paulus/web-analyzer/internal/config"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// delivery is one request received by a test callback
+type delivery struct {
+	at     time.Time
+	header http.Header
+	body   []byte
+}
+
+// callback answers with statuses in turn, repeating the last one, and
+// records the deliveries it receives
+type callback struct {
+	mu         sync.Mutex
+	statuses   []int
+	deliveries []delivery
+}
+
+func (c *callback) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := c.statuses[min(len(c.deliveries), len(c.statuses)-1)]
+	c.deliveries = append(c.deliveries, delivery{at: time.Now(), header: r.Header.Clone(), body: body})
+	w.WriteHeader(status)
+}
+
+// dispatch delivers payload to a callback on the loopback interface
+// answering with statuses and returns the deliveries once the dispatcher is
+// done
+func dispatch(t *testing.T, cfg config.WebhookConfig, payload interface{}, statuses ...int) []delivery {
+	t.Helper()
+	c := &callback{statuses: statuses}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	d := New(cfg, testLogger())
+	d.Dispatch(server.URL, payload)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.deliveries
+}
+
+func TestSign(t *testing.T) {
+	// Computed with: printf '%s' '1700000000.{"url":"https://example.com"}' | openssl dgst -sha256 -hmac whsec_test
+	const want = "32f7761a1298e0c3bad3c2157922860ce73425539773673f4b5423cc72da64e8"
+	if got := Sign("whsec_test", "1700000000", []byte(`{"url":"https://example.com"}`)); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestDispatch_Retries(t *testing.T) {
+	const backoff = 20 * time.Millisecond
+	cfg := config.WebhookConfig{Timeout: time.Second, MaxAttempts: 4, InitialBackoff: backoff, AllowPrivateNetworks: true}
+
+	tests := []struct {
+		name     string
+		statuses []int
+		want     int
+	}{
+		{"success", []int{http.StatusNoContent}, 1},
+		{"server errors are retried", []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}, 3},
+		{"rate limits are retried", []int{http.StatusTooManyRequests, http.StatusOK}, 2},
+		{"retries stop after max attempts", []int{http.StatusServiceUnavailable}, 4},
+		{"client errors are not retried", []int{http.StatusBadRequest}, 1},
+		{"missing callbacks are not retried", []int{http.StatusNotFound}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries := dispatch(t, cfg, map[string]string{"url": "https://example.com"}, tt.statuses...)
+			if len(deliveries) != tt.want {
+				t.Fatalf("Expected %d deliveries, got %d", tt.want, len(deliveries))
+			}
+			// The backoff doubles after each attempt
+			wait := backoff
+			for i := 1; i < len(deliveries); i++ {
+				if gap := deliveries[i].at.Sub(deliveries[i-1].at); gap < wait {
+					t.Errorf("Expected attempt %d at least %v after the previous one, got %v", i+1, wait, gap)
+				}
+				wait *= 2
+			}
+		})
+	}
+}
+
+func TestDispatch_Headers(t *testing.T) {
+	cfg := config.WebhookConfig{Secret: "whsec_test", Timeout: time.Second, MaxAttempts: 1, AllowPrivateNetworks: true}
+	before := time.Now().Unix()
+	deliveries := dispatch(t, cfg, map[string]string{"url": "https://example.com"}, http.StatusOK)
+	if len(deliveries) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(deliveries))
+	}
+	got := deliveries[0]
+
+	if string(got.body) != `{"url":"https://example.com"}` {
+		t.Errorf("Unexpected body %s", got.body)
+	}
+	if got.header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected a JSON content type, got %q", got.header.Get("Content-Type"))
+	}
+	timestamp := got.header.Get(TimestampHeader)
+	if sent, err := strconv.ParseInt(timestamp, 10, 64); err != nil || sent < before || sent > time.Now().Unix() {
+		t.Errorf("Expected the delivery's Unix time in %s, got %q", TimestampHeader, timestamp)
+	}
+	if want := "sha256=" + Sign("whsec_test", timestamp, got.body); got.header.Get(SignatureHeader) != want {
+		t.Errorf("Expected %s %q, got %q", SignatureHeader, want, got.header.Get(SignatureHeader))
+	}
+
+	cfg.Secret = ""
+	unsigned := dispatch(t, cfg, map[string]string{"url": "https://example.com"}, http.StatusOK)
+	if len(unsigned) != 1 || unsigned[0].header.Get(SignatureHeader) != "" || unsigned[0].header.Get(TimestampHeader) == "" {
+		t.Errorf("Expected an unsigned delivery with a timestamp without a secret, got %+v", unsigned)
+	}
+}
+
+func TestDispatch_PrivateAddresses(t *testing.T) {
+	// The test callback listens on loopback, so it is refused without a
+	// retry unless private networks are allowed
+	cfg := config.WebhookConfig{Timeout: time.Second, MaxAttempts: 3, InitialBackoff: time.Second}
+	if deliveries := dispatch(t, cfg, map[string]string{}, http.StatusOK); len(deliveries) != 0 {
+		t.Errorf("Expected no deliveries to loopback, got %d", len(deliveries))
+	}
+
+	tests := []struct {
+		address string
+		public  bool
+	}{
+		{"93.184.215.14:443", true},
+		{"[2606:2800:21f:cb07:6820:80da:af6b:8b2c]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"169.254.169.254:80", false},
+		{"[fe80::1]:80", false},
+		{"10.0.0.1:80", false},
+		{"172.16.5.4:80", false},
+		{"192.168.1.1:80", false},
+		{"[fd00::1]:80", false},
+		{"[::ffff:10.0.0.1]:80", false},
+		{"0.0.0.0:80", false},
+	}
+	for _, tt := range tests {
+		err := publicOnly("tcp", tt.address, nil)
+		if public := err == nil; public != tt.public {
+			t.Errorf("%s: expected public %v, got %v", tt.address, tt.public, err)
+		}
+		if err != nil && !errors.Is(err, ErrPrivateAddress) {
+			t.Errorf("%s: expected ErrPrivateAddress, got %v", tt.address, err)
+		}
+	}
+}
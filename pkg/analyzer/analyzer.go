@@ -1,44 +1,70 @@
 package analyzer
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
-	"web-analyzer/internal/config"
-
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
 )
 
 // New func creates a new analyzer singleton instance
-func New(config config.AnalyzerConfig, logger *slog.Logger) *Analyzer {
-	return &Analyzer{
-		client: &http.Client{
-			Timeout: config.RequestTimeout,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				if len(via) >= config.MaxRedirects {
-					return fmt.Errorf("too many redirects")
-				}
-				return nil
-			},
+func New(config Config, logger *slog.Logger) *Analyzer {
+	return NewWithOptions(WithConfig(config), WithLogger(logger))
+}
+
+// newHTTPClient creates a client with the given timeout that follows at most
+// the configured number of redirects
+func (a *Analyzer) newHTTPClient(timeout time.Duration) *http.Client {
+	client := &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= a.limits().MaxRedirects {
+				return errTooManyRedirects
+			}
+			return nil
 		},
-		config: config,
-		logger: logger,
 	}
+	switch {
+	case a.transport != nil:
+		client.Transport = a.transport
+	case a.client != nil:
+		client.Transport = a.client.Transport
+	}
+	return client
 }
 
 // AnalyzeURL analyzes a web page and returns results
-func (a *Analyzer) AnalyzeURL(ctx context.Context, targetURL string) (*Result, error) {
-	start := time.Now()
+func (a *Analyzer) AnalyzeURL(ctx context.Context, targetURL string, opts ...AnalyzeOption) (result *Result, err error) {
+	o := newAnalyzeOptions(opts)
+	release, err := a.acquire(ctx, o.priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
-	a.logger.Debug("Starting URL analysis", "url", targetURL)
+	done := a.track()
+	defer func() { done(err) }()
 
-	result := &Result{
+	ctx, span := tracer.Start(ctx, "analyzer.AnalyzeURL", trace.WithAttributes(attribute.String("url.full", targetURL)))
+	defer func() { endSpan(span, err) }()
+
+	a.logger.DebugContext(ctx, "Starting URL analysis", "url", targetURL)
+
+	result = &Result{
 		URL:      targetURL,
 		Headings: make(map[string]int),
 	}
@@ -46,59 +72,445 @@ func (a *Analyzer) AnalyzeURL(ctx context.Context, targetURL string) (*Result, e
 	// Validate URL
 	parsedURL, err := url.Parse(targetURL)
 	if err != nil {
-		a.logger.Error("URL parsing failed", "url", targetURL, "error", err)
-		return nil, fmt.Errorf("invalid URL: %w", err)
+		a.logger.ErrorContext(ctx, "URL parsing failed", "url", targetURL, "error", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
 	}
 
 	if parsedURL.Scheme == "" {
 		targetURL = "http://" + targetURL
 		parsedURL, err = url.Parse(targetURL)
 		if err != nil {
-			a.logger.Error("URL normalization failed", "url", targetURL, "error", err)
-			return nil, fmt.Errorf("invalid URL: %w", err)
+			a.logger.ErrorContext(ctx, "URL normalization failed", "url", targetURL, "error", err)
+			return nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+		}
+	}
+	parsedURL = a.normalizeURL(parsedURL)
+	if normalized := parsedURL.String(); normalized != result.URL {
+		targetURL = normalized
+		a.logger.DebugContext(ctx, "URL normalized", "original", result.URL, "normalized", targetURL)
+	}
+
+	if err := o.validateExtract(); err != nil {
+		return nil, err
+	}
+	if o.device != "" {
+		if _, err := ParseDevice(string(o.device)); err != nil {
+			return nil, err
 		}
-		a.logger.Debug("URL normalized", "original", result.URL, "normalized", targetURL)
 	}
 
 	result.URL = targetURL
+	o.initSections(result, parsedURL)
+
+	// Lighthouse takes a while, so it runs alongside the page analysis and
+	// is abandoned if the analysis fails
+	var webVitals func() *WebVitals
+	if slices.Contains(o.sections, SectionWebVitals) {
+		vitalsCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		webVitals = a.startWebVitals(vitalsCtx, targetURL, o.device)
+	}
+	// Pages that redirect with a meta refresh are passed through, and the
+	// page the refresh leads to is analyzed in their place
+	o.refreshes = a.limits().MaxMetaRefreshes
+	page, pageURL := result, parsedURL
+	for {
+		o.visited = append(o.visited, pageURL.String())
+		if err := a.analyzePage(ctx, page, pageURL, o); err != nil {
+			return nil, err
+		}
+
+		next := o.refreshTarget(page)
+		if next == "" {
+			break
+		}
+		o.refreshes--
+		a.logger.DebugContext(ctx, "Following meta refresh", "url", targetURL, "from", pageURL.String(), "to", next)
+
+		if pageURL, err = url.Parse(next); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+		}
+		page = &Result{
+			URL:      targetURL,
+			Headings: make(map[string]int),
+		}
+		o.initSections(page, pageURL)
+	}
+	if page != result {
+		page.MetaRefresh = result.MetaRefresh
+		page.MetaRefresh.Followed = o.visited[1:]
+		result = page
+	}
+	if result.Frames != nil {
+		a.analyzeFrames(ctx, result, o)
+	}
+
+	if webVitals != nil {
+		result.WebVitals = webVitals()
+	}
+	result.Device = o.device
+	return result, nil
+}
+
+// analyzePage fetches and analyzes one page into result
+func (a *Analyzer) analyzePage(ctx context.Context, result *Result, pageURL *url.URL, o *analyzeOptions) error {
+	targetURL := pageURL.String()
+	o.report(Progress{URL: result.URL, Phase: PhaseFetching})
 
 	// Fetch HTML content
-	doc, err := a.fetchHTML(ctx, targetURL)
+	fetchStart := time.Now()
+	// The body is read while the document is analyzed, so the fetch budget
+	// stays in force until the analysis returns
+	fetchCtx, cancelFetch := withBudget(ctx, a.limits().Budgets.Fetch)
+	defer cancelFetch()
+	resp, err := a.fetchPage(fetchCtx, targetURL, o.userAgent(a.userAgent))
+	if err != nil {
+		a.logger.ErrorContext(ctx, "HTML fetch failed", "url", targetURL, "error", err)
+		return fmt.Errorf("failed to fetch HTML: %w", err)
+	}
+	defer resp.Body.Close()
+
+	a.logger.DebugContext(ctx, "HTML response received", "url", targetURL)
+
+	if result.Performance != nil {
+		result.Performance.ResponseMS = time.Since(fetchStart).Milliseconds()
+	}
+	inspectResponse(result, resp.Header)
+
+	if err := a.analyzeReader(ctx, resp.Body, resp.ContentLength, result, pageURL, o); err != nil {
+		a.logger.ErrorContext(ctx, "HTML fetch failed", "url", targetURL, "error", err)
+		return fmt.Errorf("failed to fetch HTML: %w", err)
+	}
+	return nil
+}
+
+// AnalyzeHTML runs the full document analysis on HTML read from r without
+// fetching a page, e.g. for stored snapshots or CI artifacts. baseURL
+// resolves relative links and decides which links are internal; when it is
+// empty, relative links count as internal and are not checked. Absolute links
+// are still checked for accessibility over the network.
+func (a *Analyzer) AnalyzeHTML(ctx context.Context, r io.Reader, baseURL string, opts ...AnalyzeOption) (result *Result, err error) {
+	o := newAnalyzeOptions(opts)
+	release, err := a.acquire(ctx, o.priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	done := a.track()
+	defer func() { done(err) }()
+
+	ctx, span := tracer.Start(ctx, "analyzer.AnalyzeHTML", trace.WithAttributes(attribute.String("url.full", baseURL)))
+	defer func() { endSpan(span, err) }()
+
+	result, parsedURL, err := a.newResult(ctx, baseURL, o)
 	if err != nil {
-		a.logger.Error("HTML fetch failed", "url", targetURL, "error", err)
-		return nil, fmt.Errorf("failed to fetch HTML: %w", err)
+		return nil, err
 	}
 
-	a.logger.Debug("HTML fetched successfully", "url", targetURL)
+	if err := a.analyzeReader(ctx, r, -1, result, parsedURL, o); err != nil {
+		a.logger.ErrorContext(ctx, "HTML parsing failed", "url", baseURL, "error", err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// AnalyzeNode is AnalyzeHTML for a document that was already parsed, e.g.
+// after headless rendering, so it is not parsed a second time. doc is only
+// read.
+func (a *Analyzer) AnalyzeNode(ctx context.Context, doc *html.Node, baseURL string, opts ...AnalyzeOption) (result *Result, err error) {
+	o := newAnalyzeOptions(opts)
+	release, err := a.acquire(ctx, o.priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	done := a.track()
+	defer func() { done(err) }()
+
+	ctx, span := tracer.Start(ctx, "analyzer.AnalyzeNode", trace.WithAttributes(attribute.String("url.full", baseURL)))
+	defer func() { endSpan(span, err) }()
+
+	return a.analyzeNode(ctx, doc, baseURL, o)
+}
+
+// AnalyzeMany analyzes urls concurrently, at most BatchConcurrency at a time,
+// and returns one result per URL in the same order. Failed analyses are
+// reported through Result.Error rather than stopping the batch. The analyses
+// run at PriorityLow unless opts say otherwise; see OnResult for streaming
+// results as they finish.
+func (a *Analyzer) AnalyzeMany(ctx context.Context, urls []string, opts ...AnalyzeOption) []*Result {
+	opts = append([]AnalyzeOption{AtPriority(PriorityLow)}, opts...)
+
+	results := make([]*Result, len(urls))
+
+	var resultMu sync.Mutex
+	onResult := newAnalyzeOptions(opts).onResult
+	finish := func(i int, result *Result) {
+		results[i] = result
+		if onResult != nil {
+			resultMu.Lock()
+			defer resultMu.Unlock()
+			onResult(i, result)
+		}
+	}
+
+	concurrency := max(a.limits().BatchConcurrency, 1)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, targetURL := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				finish(i, &Result{URL: targetURL, Error: ctx.Err().Error()})
+				return
+			}
+
+			result, err := a.AnalyzeURL(ctx, targetURL, opts...)
+			if err != nil {
+				result = &Result{URL: targetURL, Error: err.Error()}
+			}
+			finish(i, result)
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// analyzeNode analyzes doc as the page at baseURL
+func (a *Analyzer) analyzeNode(ctx context.Context, doc *html.Node, baseURL string, o *analyzeOptions) (*Result, error) {
+	result, parsedURL, err := a.newResult(ctx, baseURL, o)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.analyzeParsed(ctx, doc, result, parsedURL, o); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// newResult creates an empty result for the page at baseURL
+func (a *Analyzer) newResult(ctx context.Context, baseURL string, o *analyzeOptions) (*Result, *url.URL, error) {
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "Base URL parsing failed", "url", baseURL, "error", err)
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	if err := o.validateExtract(); err != nil {
+		return nil, nil, err
+	}
+
+	result := &Result{
+		URL:      baseURL,
+		Headings: make(map[string]int),
+	}
+	o.initSections(result, parsedURL)
+
+	return result, parsedURL, nil
+}
+
+// track records an analysis in the workload stats. The returned function
+// must be called with the analysis error once it finishes.
+func (a *Analyzer) track() func(err error) {
+	start := time.Now()
+
+	a.activeAnalyses.Add(1)
+	a.metrics.activeAnalyses.Inc()
+
+	return func(err error) {
+		a.activeAnalyses.Add(-1)
+		a.metrics.activeAnalyses.Dec()
+		a.metrics.observeAnalysis(start, err)
+	}
+}
+
+// analyzeParsed analyzes a parsed document and checks its links
+func (a *Analyzer) analyzeParsed(ctx context.Context, doc *html.Node, result *Result, baseURL *url.URL, o *analyzeOptions) error {
+	if err := checkComplexity(doc, a.limits().MaxNodes, a.limits().MaxDepth); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	o.report(Progress{URL: result.URL, Phase: PhaseAnalyzing})
 
 	// Analyze document
-	a.analyzeDocument(doc, result, parsedURL)
+	_, span := tracer.Start(ctx, "analyzer.analyze_document")
+	a.analyzeDocument(ctx, doc, result, baseURL)
+	span.End()
+
+	a.finishAnalysis(ctx, result, a.extractLinks(ctx, doc, baseURL), start, o)
+	return nil
+}
+
+// analyzeReader analyzes the HTML read from r. Documents larger than the
+// streaming threshold are analyzed token by token instead of being parsed
+// into a DOM. size is the document length, or -1 when it is not known.
+func (a *Analyzer) analyzeReader(ctx context.Context, r io.Reader, size int64, result *Result, baseURL *url.URL, o *analyzeOptions) error {
+	threshold := a.limits().StreamingThreshold
+
+	if budget := a.limits().Budgets.Parse; budget > 0 {
+		var cancel context.CancelFunc
+		r, cancel = newBudgetReader(ctx, r, budget)
+		defer cancel()
+	}
+
+	if result.Performance != nil {
+		counter := &countingReader{r: r}
+		r = counter
+		defer func() { result.Performance.PageBytes = counter.n }()
+	}
+
+	if threshold > 0 && size < 0 {
+		// Buffer up to the threshold to find out which side of it the
+		// document is on
+		head, err := io.ReadAll(io.LimitReader(r, threshold+1))
+		if err != nil {
+			return readError(err)
+		}
+		size = int64(len(head))
+		r = io.MultiReader(bytes.NewReader(head), r)
+	}
+
+	if threshold > 0 && size > threshold {
+		a.logger.DebugContext(ctx, "Using streaming analysis", "url", result.URL, "size", size, "threshold", threshold)
+
+		start := time.Now()
+		o.report(Progress{URL: result.URL, Phase: PhaseAnalyzing})
+
+		_, span := tracer.Start(ctx, "analyzer.analyze_tokens", trace.WithAttributes(attribute.Int64("page.size", size)))
+		links, err := a.analyzeTokens(ctx, r, result, baseURL)
+		if err != nil {
+			err = readError(err)
+			endSpan(span, err)
+			return err
+		}
+		span.End()
+
+		a.finishAnalysis(ctx, result, links, start, o)
+		return nil
+	}
+
+	_, span := tracer.Start(ctx, "analyzer.parse")
+	doc, err := html.Parse(r)
+	if err != nil {
+		err = readError(err)
+		endSpan(span, err)
+		return err
+	}
+	span.End()
+
+	return a.analyzeParsed(ctx, doc, result, baseURL, o)
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// readError wraps an error from reading the document
+func readError(err error) error {
+	if errors.Is(err, ErrParseTimeout) {
+		return err
+	}
+	if isTimeout(err) {
+		return fmt.Errorf("%w: %w", ErrFetchTimeout, err)
+	}
+	return fmt.Errorf("parsing HTML: %w", err)
+}
+
+// finishAnalysis checks the document's links, collects the findings and
+// reports completion
+func (a *Analyzer) finishAnalysis(ctx context.Context, result *Result, links []string, start time.Time, o *analyzeOptions) {
+	// Nothing more is done with a page whose meta refresh is followed
+	if o.refreshTarget(result) != "" {
+		return
+	}
+	result.IDN = inspectIDN(result.URL, links)
+	result.LinkDepth.finish()
 
-	// Check link accessibility
-	links := a.extractLinks(doc, parsedURL)
 	linkCount := len(links)
+	linksChecked := 0
+	contentTypes := make(map[string]string)
 
 	if linkCount > 0 {
-		a.logger.Debug("Starting link accessibility check",
-			"url", targetURL,
+		a.logger.DebugContext(ctx, "Starting link accessibility check",
+			"url", result.URL,
 			"total_links", linkCount,
-			"max_workers", a.config.MaxWorkers,
+			"max_workers", a.MaxWorkers(),
 		)
 
-		result.InaccessibleLinks = a.checkLinksAccessibility(ctx, links)
+		o.report(Progress{URL: result.URL, Phase: PhaseCheckingLinks, LinksTotal: linkCount})
+		checkStart := time.Now()
+
+		ctx, cancel := withBudget(ctx, a.limits().Budgets.Links)
+		defer cancel()
 
-		a.logger.Debug("Link accessibility check completed",
-			"url", targetURL,
+		ctx, span := tracer.Start(ctx, "analyzer.check_links", trace.WithAttributes(attribute.Int("links.total", linkCount)))
+		defer span.End()
+
+		result.InaccessibleLinks = a.checkLinksAccessibility(ctx, links, func(check linkCheck, checked int) {
+			linksChecked = checked
+			if check.contentType != "" {
+				contentTypes[check.url] = check.contentType
+			}
+			o.report(Progress{
+				URL:          result.URL,
+				Phase:        PhaseLinkChecked,
+				Link:         check.url,
+				Accessible:   check.accessible,
+				LinksChecked: checked,
+				LinksTotal:   linkCount,
+			})
+		})
+
+		if result.Performance != nil {
+			result.Performance.LinkCheckMS = time.Since(checkStart).Milliseconds()
+		}
+		if linksChecked < linkCount {
+			result.Partial = true
+			a.logger.WarnContext(ctx, "Link check cut short",
+				"url", result.URL,
+				"checked", linksChecked,
+				"total_links", linkCount,
+				"error", ctx.Err(),
+			)
+		}
+		span.SetAttributes(attribute.Int("links.inaccessible", result.InaccessibleLinks))
+
+		a.logger.DebugContext(ctx, "Link accessibility check completed",
+			"url", result.URL,
 			"total_links", linkCount,
 			"inaccessible", result.InaccessibleLinks,
 		)
 	}
 
-	duration := time.Since(start)
+	result.Downloads = findDownloads(links, contentTypes)
 
-	a.logger.Info("URL analysis completed",
-		"url", targetURL,
-		"duration", duration,
+	// The rules' findings were added with the document
+	result.Findings = append(collectFindings(result), result.Findings...)
+
+	a.logger.InfoContext(ctx, "URL analysis completed",
+		"url", result.URL,
+		"duration", time.Since(start),
 		"html_version", result.HTMLVersion,
 		"title", result.Title,
 		"headings", result.Headings,
@@ -108,52 +520,135 @@ func (a *Analyzer) AnalyzeURL(ctx context.Context, targetURL string) (*Result, e
 		"has_login_form", result.HasLoginForm,
 	)
 
-	return result, nil
+	o.report(Progress{
+		URL:          result.URL,
+		Phase:        PhaseDone,
+		LinksChecked: linksChecked,
+		LinksTotal:   linkCount,
+	})
 }
 
-// fetchHTML fetches and parses HTML from URL
-func (a *Analyzer) fetchHTML(ctx context.Context, targetURL string) (*html.Node, error) {
-	a.logger.Debug("Creating HTTP request", "url", targetURL)
+// fetchPage requests targetURL as userAgent and returns the response once it
+// is known to be an HTML page within the size limit. The caller must close
+// the body.
+func (a *Analyzer) fetchPage(ctx context.Context, targetURL, userAgent string) (resp *http.Response, err error) {
+	ctx, span := tracer.Start(ctx, "analyzer.fetch",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("url.full", targetURL)),
+	)
+	defer func() { endSpan(span, err) }()
+
+	a.logger.DebugContext(ctx, "Creating HTTP request", "url", targetURL)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("User-Agent", "Web-Analyzer/1.0")
+	req.Header.Set("User-Agent", userAgent)
 
-	a.logger.Debug("Sending HTTP request", "url", targetURL)
+	a.logger.DebugContext(ctx, "Sending HTTP request", "url", targetURL)
 
-	resp, err := a.client.Do(req)
+	resp, err = a.client.Do(req)
 	if err != nil {
+		if isTimeout(err) {
+			return nil, fmt.Errorf("%w: %w", ErrFetchTimeout, err)
+		}
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	a.logger.Debug("Received HTTP response",
+	a.logger.DebugContext(ctx, "Received HTTP response",
 		"url", targetURL,
 		"status", resp.StatusCode,
 		"content_type", resp.Header.Get("Content-Type"),
 		"content_length", resp.Header.Get("Content-Length"),
 	)
 
+	span.SetAttributes(
+		attribute.Int("http.response.status_code", resp.StatusCode),
+		attribute.Int64("http.response.body.size", resp.ContentLength),
+	)
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		resp.Body.Close()
+		return nil, &ErrHTTPStatus{Code: resp.StatusCode, Status: resp.Status}
+	}
+
+	if !isHTMLContentType(resp.Header.Get("Content-Type")) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotHTML, resp.Header.Get("Content-Type"))
 	}
 
-	doc, err := html.Parse(resp.Body)
+	maxSize := a.limits().MaxPageSize
+	if maxSize > 0 && resp.ContentLength > maxSize {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLarge, resp.ContentLength)
+	}
+
+	if maxSize > 0 {
+		resp.Body = &sizeLimitedReader{ReadCloser: resp.Body, limit: maxSize}
+	}
+
+	return resp, nil
+}
+
+// isHTMLContentType reports whether a response may be parsed as HTML. A
+// missing content type is accepted.
+func isHTMLContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
 	if err != nil {
-		return nil, fmt.Errorf("parsing HTML: %w", err)
+		return false
 	}
 
-	return doc, nil
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// sizeLimitedReader fails with ErrTooLarge once more than limit bytes are read
+type sizeLimitedReader struct {
+	io.ReadCloser
+	limit int64
+	read  int64
+}
+
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	n, err := l.ReadCloser.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, l.limit)
+	}
+	return n, err
 }
 
 // analyzeDocument analyzes the HTML document
-func (a *Analyzer) analyzeDocument(doc *html.Node, result *Result, baseURL *url.URL) {
-	a.logger.Debug("Starting document analysis", "url", baseURL.String())
-	a.traverseNode(doc, result, baseURL)
-	a.logger.Debug("Document analysis completed",
+func (a *Analyzer) analyzeDocument(ctx context.Context, doc *html.Node, result *Result, baseURL *url.URL) {
+	a.logger.DebugContext(ctx, "Starting document analysis", "url", baseURL.String())
+	a.traverseNode(ctx, doc, result, baseURL)
+
+	var page ScriptDependency
+	measureText(doc, &page)
+	result.ScriptDependency = page.detect()
+	result.Parking = detectParking(doc, baseURL)
+	if result.SEO != nil {
+		result.SEO.Breadcrumbs = findBreadcrumbs(doc, baseURL)
+	}
+	checkLanguages(doc, result.SEO, baseURL, result.URL)
+	if result.Accessibility != nil {
+		result.Accessibility.Tables = checkTables(doc)
+	}
+	if result.Performance != nil {
+		checkIcons(doc, result.Performance)
+	}
+	if rules := a.compiledRules(); len(rules) > 0 {
+		result.Findings = checkRules(doc, rules, baseURL, result.Title)
+	}
+	extract(doc, result.Extractions)
+	checkPhishing(doc, result.Phishing, baseURL, result.Title)
+	result.ThirdParty.sort()
+	a.logger.DebugContext(ctx, "Document analysis completed",
 		"url", baseURL.String(),
 		"title", result.Title,
 		"headings", result.Headings,
@@ -161,69 +656,133 @@ func (a *Analyzer) analyzeDocument(doc *html.Node, result *Result, baseURL *url.
 }
 
 // traverseNode recursively traverses HTML nodes
-func (a *Analyzer) traverseNode(n *html.Node, result *Result, baseURL *url.URL) {
+func (a *Analyzer) traverseNode(ctx context.Context, n *html.Node, result *Result, baseURL *url.URL) {
 	if n.Type == html.ElementNode {
+		a.inspectElement(strings.ToLower(n.Data), n.Attr, result, baseURL)
+
 		switch strings.ToLower(n.Data) {
 		case "title":
-			if n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
-				result.Title = strings.TrimSpace(n.FirstChild.Data)
-				a.logger.Debug("Found page title", "title", result.Title)
+			// SVG and MathML titles label graphics, not the page
+			if n.Namespace == "" {
+				a.countTitle(ctx, result, nodeText(n))
 			}
 		case "h1", "h2", "h3", "h4", "h5", "h6":
 			level := strings.ToLower(n.Data)
 			result.Headings[level]++
-			a.logger.Debug("Found heading", "level", level, "count", result.Headings[level])
+			a.logger.DebugContext(ctx, "Found heading", "level", level, "count", result.Headings[level])
+			if result.Outline != nil {
+				a.addOutlineHeading(result, level, nodeText(n))
+			}
 		case "a":
-			a.processLink(n, result, baseURL)
+			a.processLink(ctx, n, result, baseURL)
 		case "form":
-			if a.isLoginForm(n) {
+			if a.isLoginForm(ctx, n) {
 				result.HasLoginForm = true
-				a.logger.Debug("Login form detected")
+				a.logger.DebugContext(ctx, "Login form detected")
 			}
 		}
 	} else if n.Type == html.DoctypeNode {
 		result.HTMLVersion = a.detectHTMLVersion(n.Data)
-		a.logger.Debug("HTML version detected", "version", result.HTMLVersion)
+		a.logger.DebugContext(ctx, "HTML version detected", "version", result.HTMLVersion)
 	}
 
 	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		a.traverseNode(c, result, baseURL)
+		a.traverseNode(ctx, c, result, baseURL)
 	}
 }
 
 // processLink processes anchor tags
-func (a *Analyzer) processLink(n *html.Node, result *Result, baseURL *url.URL) {
-	for _, attr := range n.Attr {
-		if attr.Key == "href" {
-			linkURL, err := url.Parse(attr.Val)
-			if err != nil {
-				a.logger.Debug("Invalid link URL", "href", attr.Val, "error", err)
-				continue
-			}
+func (a *Analyzer) processLink(ctx context.Context, n *html.Node, result *Result, baseURL *url.URL) {
+	if href, ok := hrefAttr(n.Attr); ok {
+		a.countLink(ctx, href, result, baseURL)
+	}
+}
 
-			resolvedURL := baseURL.ResolveReference(linkURL)
+// countLink counts href as an internal or external link
+func (a *Analyzer) countLink(ctx context.Context, href string, result *Result, baseURL *url.URL) {
+	linkURL, err := url.Parse(href)
+	if err != nil {
+		a.logger.DebugContext(ctx, "Invalid link URL", "href", href, "error", err)
+		return
+	}
+
+	resolvedURL := baseURL.ResolveReference(linkURL)
+	if scheme := linkScheme(resolvedURL); scheme != "" {
+		if result.LinkSchemes == nil {
+			result.LinkSchemes = make(map[string]int)
+		}
+		result.LinkSchemes[scheme]++
+	}
 
-			if resolvedURL.Host == baseURL.Host {
-				result.InternalLinks++
-				a.logger.Debug("Internal link found", "href", resolvedURL.String())
-			} else {
-				result.ExternalLinks++
-				a.logger.Debug("External link found", "href", resolvedURL.String())
+	if a.isInternal(resolvedURL, baseURL) {
+		result.InternalLinks++
+		if resolvedURL.Scheme == "http" || resolvedURL.Scheme == "https" {
+			if result.LinkDepth == nil {
+				result.LinkDepth = &LinkDepth{}
 			}
-			break
+			result.LinkDepth.add(resolvedURL)
 		}
+		a.logger.DebugContext(ctx, "Internal link found", "href", resolvedURL.String())
+	} else {
+		result.ExternalLinks++
+		a.logger.DebugContext(ctx, "External link found", "href", resolvedURL.String())
+	}
+}
+
+// linkSchemes are the schemes LinkSchemes counts links by name
+var linkSchemes = []string{"mailto", "tel", "sms", "javascript", "ftp", "data"}
+
+// linkScheme returns the key LinkSchemes counts link under: its scheme when
+// it is one of linkSchemes, "other" for other schemes and "" for http(s)
+// links
+func linkScheme(link *url.URL) string {
+	switch {
+	case link.Scheme == "", link.Scheme == "http", link.Scheme == "https":
+		return ""
+	case slices.Contains(linkSchemes, link.Scheme):
+		return link.Scheme
+	default:
+		return "other"
 	}
 }
 
+// isInternal reports whether link is on the page's host or, with
+// InternalSubdomains, on another host of the same registrable domain, such
+// as blog.example.com for www.example.com
+func (a *Analyzer) isInternal(link, baseURL *url.URL) bool {
+	if link.Host == baseURL.Host {
+		return true
+	}
+	if !a.limits().InternalSubdomains {
+		return false
+	}
+	linkDomain, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(link.Hostname()))
+	if err != nil {
+		return false
+	}
+	pageDomain, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(baseURL.Hostname()))
+	return err == nil && linkDomain == pageDomain
+}
+
+// hrefAttr returns the first href attribute
+func hrefAttr(attrs []html.Attribute) (string, bool) {
+	for _, attr := range attrs {
+		if attr.Key == "href" {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
 // isLoginForm determines if a form is a login form
-func (a *Analyzer) isLoginForm(n *html.Node) bool {
+func (a *Analyzer) isLoginForm(ctx context.Context, n *html.Node) bool {
 	hasPasswordField := false
 	hasUsernameField := false
 
-	a.checkFormFields(n, &hasPasswordField, &hasUsernameField)
+	a.checkFormFields(ctx, n, &hasPasswordField, &hasUsernameField)
 
 	isLogin := hasPasswordField && hasUsernameField
-	a.logger.Debug("Form analysis",
+	a.logger.DebugContext(ctx, "Form analysis",
 		"has_password", hasPasswordField,
 		"has_username", hasUsernameField,
 		"is_login_form", isLogin,
@@ -233,36 +792,41 @@ func (a *Analyzer) isLoginForm(n *html.Node) bool {
 }
 
 // checkFormFields recursively checks form fields
-func (a *Analyzer) checkFormFields(n *html.Node, hasPassword, hasUsername *bool) {
+func (a *Analyzer) checkFormFields(ctx context.Context, n *html.Node, hasPassword, hasUsername *bool) {
 	if n.Type == html.ElementNode && n.Data == "input" {
-		inputType := ""
-		inputName := ""
+		a.checkInput(ctx, n.Attr, hasPassword, hasUsername)
+	}
 
-		for _, attr := range n.Attr {
-			if attr.Key == "type" {
-				inputType = strings.ToLower(attr.Val)
-			}
-			if attr.Key == "name" {
-				inputName = strings.ToLower(attr.Val)
-			}
-		}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		a.checkFormFields(ctx, c, hasPassword, hasUsername)
+	}
+}
 
-		if inputType == "password" {
-			*hasPassword = true
-			a.logger.Debug("Password field found", "name", inputName)
-		}
+// checkInput records whether an input is a password or username field
+func (a *Analyzer) checkInput(ctx context.Context, attrs []html.Attribute, hasPassword, hasUsername *bool) {
+	inputType := ""
+	inputName := ""
 
-		if inputType == "text" || inputType == "email" || inputType == "" {
-			if strings.Contains(inputName, "user") || strings.Contains(inputName, "email") ||
-				strings.Contains(inputName, "login") {
-				*hasUsername = true
-				a.logger.Debug("Username field found", "name", inputName, "type", inputType)
-			}
+	for _, attr := range attrs {
+		if attr.Key == "type" {
+			inputType = strings.ToLower(attr.Val)
+		}
+		if attr.Key == "name" {
+			inputName = strings.ToLower(attr.Val)
 		}
 	}
 
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		a.checkFormFields(c, hasPassword, hasUsername)
+	if inputType == "password" {
+		*hasPassword = true
+		a.logger.DebugContext(ctx, "Password field found", "name", inputName)
+	}
+
+	if inputType == "text" || inputType == "email" || inputType == "" {
+		if strings.Contains(inputName, "user") || strings.Contains(inputName, "email") ||
+			strings.Contains(inputName, "login") {
+			*hasUsername = true
+			a.logger.DebugContext(ctx, "Username field found", "name", inputName, "type", inputType)
+		}
 	}
 }
 
@@ -286,28 +850,19 @@ func (a *Analyzer) detectHTMLVersion(doctype string) string {
 }
 
 // extractLinks extracts all links from the document
-func (a *Analyzer) extractLinks(doc *html.Node, baseURL *url.URL) []string {
+func (a *Analyzer) extractLinks(ctx context.Context, doc *html.Node, baseURL *url.URL) []string {
 	var links []string
 	a.extractLinksFromNode(doc, baseURL, &links)
-	a.logger.Debug("Links extracted", "count", len(links))
+	a.logger.DebugContext(ctx, "Links extracted", "count", len(links))
 	return links
 }
 
 // extractLinksFromNode recursively extracts links
 func (a *Analyzer) extractLinksFromNode(n *html.Node, baseURL *url.URL, links *[]string) {
 	if n.Type == html.ElementNode && n.Data == "a" {
-		for _, attr := range n.Attr {
-			if attr.Key == "href" {
-				linkURL, err := url.Parse(attr.Val)
-				if err != nil {
-					continue
-				}
-
-				resolvedURL := baseURL.ResolveReference(linkURL)
-				if resolvedURL.Scheme == "http" || resolvedURL.Scheme == "https" {
-					*links = append(*links, resolvedURL.String())
-				}
-				break
+		if href, ok := hrefAttr(n.Attr); ok {
+			if link, ok := a.checkableLink(href, baseURL); ok {
+				*links = append(*links, link)
 			}
 		}
 	}
@@ -317,35 +872,79 @@ func (a *Analyzer) extractLinksFromNode(n *html.Node, baseURL *url.URL, links *[
 	}
 }
 
-// checkLinksAccessibility checks accessibility of links with configurable concurrency
-func (a *Analyzer) checkLinksAccessibility(ctx context.Context, links []string) int {
+// checkableLink resolves and normalizes href against baseURL and reports
+// whether the result is an http(s) link that can be checked for
+// accessibility
+func (a *Analyzer) checkableLink(href string, baseURL *url.URL) (string, bool) {
+	linkURL, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+
+	resolvedURL := baseURL.ResolveReference(linkURL)
+	if resolvedURL.Scheme != "http" && resolvedURL.Scheme != "https" {
+		return "", false
+	}
+	return a.normalizeURL(resolvedURL).String(), true
+}
+
+// linkCheckedFunc is called once per checked link, from a single goroutine,
+// with the number of links checked so far
+type linkCheckedFunc func(check linkCheck, checked int)
+
+// checkLinkCached checks a link through the link cache, if one is set
+func (a *Analyzer) checkLinkCached(ctx context.Context, client *http.Client, link string) (check linkCheck) {
+	ctx, span := tracer.Start(ctx, "analyzer.check_link",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("url.full", link)),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Bool("link.accessible", check.accessible))
+		span.End()
+	}()
+
+	if a.linkCache == nil {
+		return a.checkLink(ctx, client, link)
+	}
+
+	if status, ok := a.linkCache.Get(ctx, link); ok {
+		span.SetAttributes(attribute.Bool("link.cached", true))
+		return linkCheck{url: link, accessible: status.Accessible, contentType: status.ContentType}
+	}
+
+	check = a.checkLink(ctx, client, link)
+	// A check cut short by cancellation says nothing about the link
+	if ctx.Err() == nil {
+		a.linkCache.Set(ctx, link, LinkStatus{Accessible: check.accessible, ContentType: check.contentType})
+	}
+	return check
+}
+
+// checkLinksAccessibility checks accessibility of links with configurable
+// concurrency. onChecked may be nil.
+func (a *Analyzer) checkLinksAccessibility(ctx context.Context, links []string, onChecked linkCheckedFunc) int {
 	if len(links) == 0 {
 		return 0
 	}
 
-	maxWorkers := a.config.MaxWorkers
+	maxWorkers := a.MaxWorkers()
 	if maxWorkers > len(links) {
 		maxWorkers = len(links)
 	}
 
-	a.logger.Debug("Starting concurrent link checking",
+	a.logger.DebugContext(ctx, "Starting concurrent link checking",
 		"total_links", len(links),
 		"workers", maxWorkers,
-		"timeout", a.config.LinkTimeout,
+		"timeout", a.limits().LinkTimeout,
 	)
 
-	client := &http.Client{
-		Timeout: a.config.LinkTimeout,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= a.config.MaxRedirects {
-				return fmt.Errorf("too many redirects")
-			}
-			return nil
-		},
+	client := a.linkClient
+	if client == nil {
+		client = a.newHTTPClient(a.limits().LinkTimeout)
 	}
 
 	jobs := make(chan string, len(links))
-	results := make(chan bool, len(links))
+	results := make(chan linkCheck, len(links))
 	var wg sync.WaitGroup
 
 	// Start workers
@@ -353,23 +952,36 @@ func (a *Analyzer) checkLinksAccessibility(ctx context.Context, links []string)
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
-			a.logger.Debug("Link checker worker started", "worker_id", workerID)
+			a.activeWorkers.Add(1)
+			a.metrics.activeWorkers.Inc()
+			defer func() {
+				a.activeWorkers.Add(-1)
+				a.metrics.activeWorkers.Dec()
+			}()
+			a.logger.DebugContext(ctx, "Link checker worker started", "worker_id", workerID)
 
 			linksChecked := 0
 			for url := range jobs {
-				accessible := a.checkSingleLink(ctx, client, url)
-				results <- accessible
+				a.queuedLinks.Add(-1)
+				a.metrics.queuedLinks.Dec()
+
+				check := a.checkLinkCached(ctx, client, url)
+				if !check.accessible && ctx.Err() != nil {
+					// Cut short, so the link was not really checked
+					continue
+				}
+				results <- check
 				linksChecked++
 
-				a.logger.Debug("Link checked",
+				a.logger.DebugContext(ctx, "Link checked",
 					"worker_id", workerID,
 					"url", url,
-					"accessible", accessible,
+					"accessible", check.accessible,
 					"checked_count", linksChecked,
 				)
 			}
 
-			a.logger.Debug("Link checker worker finished",
+			a.logger.DebugContext(ctx, "Link checker worker finished",
 				"worker_id", workerID,
 				"links_checked", linksChecked,
 			)
@@ -380,10 +992,15 @@ func (a *Analyzer) checkLinksAccessibility(ctx context.Context, links []string)
 	go func() {
 		defer close(jobs)
 		for _, link := range links {
+			a.queuedLinks.Add(1)
+			a.metrics.queuedLinks.Inc()
+
 			select {
 			case jobs <- link:
 			case <-ctx.Done():
-				a.logger.Warn("Context cancelled while sending jobs")
+				a.queuedLinks.Add(-1)
+				a.metrics.queuedLinks.Dec()
+				a.logger.WarnContext(ctx, "Context cancelled while sending jobs")
 				return
 			}
 		}
@@ -398,14 +1015,17 @@ func (a *Analyzer) checkLinksAccessibility(ctx context.Context, links []string)
 	// Collect results
 	inaccessible := 0
 	processed := 0
-	for accessible := range results {
+	for check := range results {
 		processed++
-		if !accessible {
+		if !check.accessible {
 			inaccessible++
 		}
+		if onChecked != nil {
+			onChecked(check, processed)
+		}
 	}
 
-	a.logger.Info("Link accessibility check completed",
+	a.logger.InfoContext(ctx, "Link accessibility check completed",
 		"total_links", len(links),
 		"processed", processed,
 		"accessible", processed-inaccessible,
@@ -416,30 +1036,57 @@ func (a *Analyzer) checkLinksAccessibility(ctx context.Context, links []string)
 	return inaccessible
 }
 
+// linkCheck is the outcome of checking one link. contentType is the
+// response's Content-Type, when the link was requested.
+type linkCheck struct {
+	url         string
+	accessible  bool
+	contentType string
+}
+
 // checkSingleLink checks if a single link is accessible
 func (a *Analyzer) checkSingleLink(ctx context.Context, client *http.Client, link string) bool {
+	return a.checkLink(ctx, client, link).accessible
+}
+
+// checkLink requests a single link with HEAD
+func (a *Analyzer) checkLink(ctx context.Context, client *http.Client, link string) linkCheck {
+	a.metrics.linksChecked.Inc()
+	defer a.metrics.observeLinkCheck(time.Now())
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodHead, link, nil)
 	if err != nil {
-		a.logger.Debug("Failed to create request for link", "url", link, "error", err)
-		return false
+		a.logger.DebugContext(ctx, "Failed to create request for link", "url", link, "error", err)
+		a.metrics.linkCheckFailures.WithLabelValues(reasonInvalidURL).Inc()
+		return linkCheck{url: link}
 	}
 
-	req.Header.Set("User-Agent", "Web-Analyzer/1.0")
+	req.Header.Set("User-Agent", a.userAgent)
 
 	resp, err := client.Do(req)
 	if err != nil {
-		a.logger.Debug("Link check failed", "url", link, "error", err)
-		return false
+		a.logger.DebugContext(ctx, "Link check failed", "url", link, "error", err)
+		a.metrics.linkCheckFailures.WithLabelValues(linkFailureReason(err)).Inc()
+		trace.SpanFromContext(ctx).RecordError(err)
+		return linkCheck{url: link}
 	}
 	defer resp.Body.Close()
 
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
 	accessible := resp.StatusCode >= 200 && resp.StatusCode < 400
+	switch {
+	case resp.StatusCode >= 500:
+		a.metrics.linkCheckFailures.WithLabelValues(reasonServerError).Inc()
+	case resp.StatusCode >= 400:
+		a.metrics.linkCheckFailures.WithLabelValues(reasonClientError).Inc()
+	}
 
-	a.logger.Debug("Link checked",
+	a.logger.DebugContext(ctx, "Link checked",
 		"url", link,
 		"status", resp.StatusCode,
 		"accessible", accessible,
 	)
 
-	return accessible
+	return linkCheck{url: link, accessible: accessible, contentType: resp.Header.Get("Content-Type")}
 }
@@ -2,23 +2,34 @@ package analyzer
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
-	"web-analyzer/internal/config"
-
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/html"
 )
 
 func TestNew(t *testing.T) {
-	cfg := config.AnalyzerConfig{
+	cfg := Config{
 		RequestTimeout: 10 * time.Second,
 		LinkTimeout:    5 * time.Second,
 		MaxRedirects:   3,
@@ -45,6 +56,264 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewWithOptions(t *testing.T) {
+	var gotUserAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserAgent = r.UserAgent()
+		w.Write([]byte("<html><head><title>Options</title></head></html>"))
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	client := &http.Client{Timeout: 3 * time.Second}
+
+	analyzer := NewWithOptions(
+		WithLogger(logger),
+		WithHTTPClient(client),
+		WithMaxWorkers(7),
+		WithUserAgent("Custom-Agent/2.0"),
+	)
+
+	if analyzer.client != client {
+		t.Error("Expected the supplied HTTP client to be used")
+	}
+	if analyzer.MaxWorkers() != 7 {
+		t.Errorf("Expected 7 workers, got %d", analyzer.MaxWorkers())
+	}
+	if analyzer.config.LinkTimeout != defaultConfig.LinkTimeout {
+		t.Errorf("Expected default link timeout %v, got %v", defaultConfig.LinkTimeout, analyzer.config.LinkTimeout)
+	}
+
+	result, err := analyzer.AnalyzeURL(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	if result.Title != "Options" {
+		t.Errorf("Expected title 'Options', got '%s'", result.Title)
+	}
+	if gotUserAgent != "Custom-Agent/2.0" {
+		t.Errorf("Expected custom user agent, got '%s'", gotUserAgent)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithTransport(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		requested = append(requested, req.Method+" "+req.URL.String())
+		mu.Unlock()
+
+		body := `<html><head><title>Recorded</title></head><body><a href="/link">Link</a></body></html>`
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": []string{"text/html"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+
+	analyzer := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))),
+		WithTransport(transport),
+	)
+
+	result, err := analyzer.AnalyzeURL(context.Background(), "http://recorded.test/")
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	if result.Title != "Recorded" || result.InaccessibleLinks != 0 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	expected := []string{"GET http://recorded.test/", "HEAD http://recorded.test/link"}
+	if fmt.Sprint(requested) != fmt.Sprint(expected) {
+		t.Errorf("Expected requests %v, got %v", expected, requested)
+	}
+}
+
+func TestWithLinkCheckClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="http://unreachable.test/">Link</a></body></html>`)
+	}))
+	defer server.Close()
+
+	var checked []string
+	linkClient := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		checked = append(checked, req.URL.String())
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})}
+
+	analyzer := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))),
+		WithLinkCheckClient(linkClient),
+	)
+
+	result, err := analyzer.AnalyzeURL(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	if result.InaccessibleLinks != 0 {
+		t.Errorf("Expected the link check client to report the link accessible, got %d inaccessible", result.InaccessibleLinks)
+	}
+	if len(checked) != 1 || checked[0] != "http://unreachable.test/" {
+		t.Errorf("Expected one check through the link check client, got %v", checked)
+	}
+}
+
+// mapLinkCache is an in-process LinkCache for tests
+type mapLinkCache struct {
+	mu      sync.Mutex
+	results map[string]LinkStatus
+}
+
+func (c *mapLinkCache) Get(ctx context.Context, link string) (LinkStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status, ok := c.results[link]
+	return status, ok
+}
+
+func (c *mapLinkCache) Set(ctx context.Context, link string, status LinkStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[link] = status
+}
+
+func TestWithLinkCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="http://cached.test/">Cached</a><a href="http://fresh.test/">Fresh</a></body></html>`)
+	}))
+	defer server.Close()
+
+	var checked []string
+	linkClient := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		checked = append(checked, req.URL.String())
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})}
+
+	cache := &mapLinkCache{results: map[string]LinkStatus{"http://cached.test/": {}}}
+	analyzer := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))),
+		WithLinkCheckClient(linkClient),
+		WithLinkCache(cache),
+		WithMaxWorkers(1),
+	)
+
+	result, err := analyzer.AnalyzeURL(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	if result.InaccessibleLinks != 1 {
+		t.Errorf("Expected the cached outcome to be used, got %d inaccessible", result.InaccessibleLinks)
+	}
+	if len(checked) != 1 || checked[0] != "http://fresh.test/" {
+		t.Errorf("Expected only the uncached link to be checked, got %v", checked)
+	}
+	if status, ok := cache.results["http://fresh.test/"]; !ok || !status.Accessible {
+		t.Error("Expected the checked link's outcome to be cached")
+	}
+}
+
+func TestWithRegisterer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="/about">About</a></body></html>`)
+	}))
+	defer server.Close()
+
+	// Analyzers without a registerer register nothing, so several can be
+	// created next to one that exports its metrics
+	NewWithOptions()
+	NewWithOptions()
+	reg := prometheus.NewRegistry()
+	a := NewWithOptions(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))), WithRegisterer(reg))
+	if _, err := a.AnalyzeURL(context.Background(), server.URL); err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	values := make(map[string]float64)
+	for _, family := range families {
+		if !strings.HasPrefix(family.GetName(), "analyzer_") {
+			t.Errorf("Expected metric %s to be prefixed analyzer_", family.GetName())
+		}
+		for _, metric := range family.GetMetric() {
+			values[family.GetName()] += metric.GetCounter().GetValue()
+		}
+	}
+	if values["analyzer_analyses_total"] != 1 || values["analyzer_links_checked_total"] != 1 {
+		t.Errorf("Expected one analysis and one link check, got %v", values)
+	}
+	defaults, _ := prometheus.DefaultGatherer.Gather()
+	for _, family := range defaults {
+		if strings.HasPrefix(family.GetName(), "analyzer_") {
+			t.Errorf("Expected nothing in the default registry, found %s", family.GetName())
+		}
+	}
+}
+
+func TestAnalyzeURL_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="http://one.test/">One</a><a href="http://two.test/">Two</a></body></html>`)
+	}))
+	defer server.Close()
+
+	linkClient := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})}
+
+	analyzer := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))),
+		WithLinkCheckClient(linkClient),
+	)
+
+	if _, err := analyzer.AnalyzeURL(context.Background(), server.URL); err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+
+	spans := make(map[string]int)
+	var root trace.SpanID
+	for _, span := range recorder.Ended() {
+		spans[span.Name()]++
+		if span.Name() == "analyzer.AnalyzeURL" {
+			root = span.SpanContext().SpanID()
+		}
+	}
+
+	for name, want := range map[string]int{
+		"analyzer.AnalyzeURL":       1,
+		"analyzer.fetch":            1,
+		"analyzer.parse":            1,
+		"analyzer.analyze_document": 1,
+		"analyzer.check_links":      1,
+		"analyzer.check_link":       2,
+	} {
+		if spans[name] != want {
+			t.Errorf("Expected %d %q spans, got %d", want, name, spans[name])
+		}
+	}
+
+	for _, span := range recorder.Ended() {
+		if span.Name() == "analyzer.fetch" && span.Parent().SpanID() != root {
+			t.Error("Expected the fetch span to be a child of the analysis span")
+		}
+	}
+}
+
 func TestAnalyzeURL_CompleteAnalysis(t *testing.T) {
 	testHTML := `<!DOCTYPE html>
 <html lang="en">
@@ -132,6 +401,414 @@ func TestAnalyzeURL_CompleteAnalysis(t *testing.T) {
 	}
 }
 
+func TestAnalyzeURL_Sections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		w.Header().Set("Cf-Ray", "8a1b2c3d4e5f-AMS")
+		fmt.Fprint(w, `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta name="description" content=" A test page ">
+    <link rel="alternate canonical" href="/canonical">
+</head>
+<body>
+    <img src="a.png" alt="A">
+    <img src="b.png">
+</body>
+</html>`)
+	}))
+	defer server.Close()
+
+	analyzer := setupTestAnalyzer()
+
+	result, err := analyzer.AnalyzeURL(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	if result.SEO != nil || result.Security != nil || result.Accessibility != nil || result.Performance != nil {
+		t.Error("Expected no sections unless requested")
+	}
+
+	result, err = analyzer.AnalyzeURL(context.Background(), server.URL, IncludeSections(Sections...))
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+
+	if result.SEO == nil || result.SEO.Lang != "en" || result.SEO.MetaDescription != "A test page" || result.SEO.Canonical != server.URL+"/canonical" {
+		t.Errorf("Unexpected SEO section: %+v", result.SEO)
+	}
+	if result.Security == nil || result.Security.HTTPS || result.Security.Headers["X-Frame-Options"] != "DENY" {
+		t.Errorf("Unexpected security section: %+v", result.Security)
+	}
+	if result.Security != nil && slices.Contains(result.Security.MissingHeaders, "X-Frame-Options") {
+		t.Error("Expected X-Frame-Options not to be reported missing")
+	}
+	if result.Accessibility == nil || result.Accessibility.Images != 2 || result.Accessibility.ImagesMissingAlt != 1 {
+		t.Errorf("Unexpected accessibility section: %+v", result.Accessibility)
+	}
+	if result.Performance == nil || result.Performance.PageBytes == 0 {
+		t.Errorf("Unexpected performance section: %+v", result.Performance)
+	}
+	if perf := result.Performance; perf == nil || perf.Caching == nil || perf.Caching.Cacheability != CacheShared || perf.CDN == nil || perf.CDN.Provider != "Cloudflare" {
+		t.Errorf("Unexpected caching and CDN in the performance section: %+v", result.Performance)
+	}
+	if result.WebVitals == nil || result.WebVitals.Error != errPageSpeedNotConfigured.Error() {
+		t.Errorf("Unexpected web vitals section without an API key: %+v", result.WebVitals)
+	}
+}
+
+func TestAnalyzeURL_WebVitals(t *testing.T) {
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<!DOCTYPE html><html><head><title>Vitals</title></head><body></body></html>`)
+	}))
+	defer page.Close()
+
+	var gotQuery url.Values
+	pageSpeed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		fmt.Fprint(w, `{
+			"loadingExperience": {
+				"overall_category": "AVERAGE",
+				"metrics": {
+					"LARGEST_CONTENTFUL_PAINT_MS": {"percentile": 2900, "category": "AVERAGE"},
+					"INTERACTION_TO_NEXT_PAINT": {"percentile": 180, "category": "FAST"},
+					"CUMULATIVE_LAYOUT_SHIFT_SCORE": {"percentile": 12, "category": "AVERAGE"}
+				}
+			},
+			"lighthouseResult": {
+				"categories": {"performance": {"score": 0.874}},
+				"audits": {
+					"largest-contentful-paint": {"numericValue": 2450.5},
+					"total-blocking-time": {"numericValue": 120},
+					"cumulative-layout-shift": {"numericValue": 0.05}
+				}
+			}
+		}`)
+	}))
+	defer pageSpeed.Close()
+
+	a := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithConfig(Config{
+			RequestTimeout: 5 * time.Second,
+			MaxWorkers:     1,
+			PageSpeed:      PageSpeedConfig{APIKey: "secret", Strategy: "desktop", Endpoint: pageSpeed.URL, Timeout: 5 * time.Second},
+		}),
+	)
+
+	result, err := a.AnalyzeURL(context.Background(), page.URL, IncludeSections(SectionWebVitals))
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+
+	if gotQuery.Get("url") != page.URL || gotQuery.Get("key") != "secret" || gotQuery.Get("strategy") != "desktop" {
+		t.Errorf("Unexpected PageSpeed Insights query %v", gotQuery)
+	}
+	vitals := result.WebVitals
+	if vitals == nil || vitals.Error != "" {
+		t.Fatalf("Unexpected web vitals section: %+v", vitals)
+	}
+	if vitals.PerformanceScore == nil || *vitals.PerformanceScore != 87 || vitals.LCPMS != 2450.5 || vitals.TBTMS != 120 || vitals.CLS != 0.05 {
+		t.Errorf("Unexpected lab metrics: %+v", vitals)
+	}
+	if vitals.Field == nil || vitals.Field.Category != "AVERAGE" || vitals.Field.LCPMS != 2900 || vitals.Field.INPMS != 180 || vitals.Field.CLS != 0.12 {
+		t.Errorf("Unexpected field metrics: %+v", vitals.Field)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		raw   string
+		strip bool
+		want  string
+	}{
+		{"HTTP://Example.COM:80/Path?q=1", false, "http://example.com/Path?q=1"},
+		{"https://example.com:443/", false, "https://example.com/"},
+		{"https://example.com:8443/", false, "https://example.com:8443/"},
+		{"example.com/a", false, "http://example.com/a"},
+		{"https://example.com/?utm_source=x&id=1", false, "https://example.com/?utm_source=x&id=1"},
+		{"https://example.com/?utm_source=x&id=1&FBCLID=y&b=2&utm%5Fmedium=z", true, "https://example.com/?id=1&b=2"},
+		{"https://example.com/?gclid=1#top", true, "https://example.com/#top"},
+		{"://bad", true, "://bad"},
+	}
+	for _, tt := range tests {
+		a := NewWithOptions(WithStripTrackingParams(tt.strip))
+		if got := a.NormalizeURL(tt.raw); got != tt.want {
+			t.Errorf("NormalizeURL(%q) with strip %t = %q, want %q", tt.raw, tt.strip, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeURL_Normalized(t *testing.T) {
+	var linkQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/link" {
+			linkQuery = r.URL.RawQuery
+			return
+		}
+		fmt.Fprint(w, `<html><head><title>Page</title></head><body><a href="/link?id=2&utm_campaign=spring">Link</a></body></html>`)
+	}))
+	defer server.Close()
+
+	a := NewWithOptions(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))), WithStripTrackingParams(true))
+	target := strings.Replace(server.URL, "http://", "HTTP://", 1) + "/?utm_source=mail&fbclid=abc&page=1"
+	result, err := a.AnalyzeURL(context.Background(), target)
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	if want := server.URL + "/?page=1"; result.URL != want {
+		t.Errorf("URL = %q, want %q", result.URL, want)
+	}
+	if linkQuery != "id=2" {
+		t.Errorf("Expected the link to be checked without tracking parameters, got query %q", linkQuery)
+	}
+}
+
+func TestAnalyzeURL_Downloads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/report.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+		case "/get":
+			w.Header().Set("Content-Type", "application/zip; name=bundle.zip")
+		case "/a.tar.gz":
+			w.Header().Set("Content-Type", "application/octet-stream")
+		case "/about":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		default:
+			fmt.Fprint(w, `<html><head><title>Files</title></head><body>
+				<a href="/files/report.pdf">Report</a>
+				<a href="/get?id=1">Bundle</a>
+				<a href="/a.tar.gz">Sources</a>
+				<a href="/about">About</a>
+				<a href="/files/report.pdf">Report again</a>
+			</body></html>`)
+		}
+	}))
+	defer server.Close()
+
+	a := NewWithOptions(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	result, err := a.AnalyzeURL(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	want := []Download{
+		{URL: server.URL + "/files/report.pdf", Kind: DownloadDocument, Extension: "pdf", ContentType: "application/pdf"},
+		{URL: server.URL + "/get?id=1", Kind: DownloadArchive, ContentType: "application/zip"},
+		{URL: server.URL + "/a.tar.gz", Kind: DownloadArchive, Extension: "gz", ContentType: "application/octet-stream"},
+	}
+	if !reflect.DeepEqual(result.Downloads, want) {
+		t.Errorf("Downloads = %+v, want %+v", result.Downloads, want)
+	}
+}
+
+func TestAnalyzeURL_DownloadsWithLinkCache(t *testing.T) {
+	var checks atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/get":
+			checks.Add(1)
+			w.Header().Set("Content-Type", "application/pdf")
+		default:
+			fmt.Fprint(w, `<html><body><a href="/get?id=5">Report</a></body></html>`)
+		}
+	}))
+	defer server.Close()
+
+	a := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithLinkCache(&mapLinkCache{results: map[string]LinkStatus{}}),
+	)
+	cold, err := a.AnalyzeURL(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	warm, err := a.AnalyzeURL(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+
+	if checks.Load() != 1 {
+		t.Errorf("Expected the second analysis to use the link cache, got %d checks", checks.Load())
+	}
+	want := []Download{{URL: server.URL + "/get?id=5", Kind: DownloadDocument, ContentType: "application/pdf"}}
+	if !reflect.DeepEqual(cold.Downloads, want) || !reflect.DeepEqual(warm.Downloads, want) {
+		t.Errorf("Downloads = %+v and %+v, want %+v both times", cold.Downloads, warm.Downloads, want)
+	}
+}
+
+func TestAnalyzeURL_ContentLanguage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Language", "de, fr")
+		fmt.Fprint(w, `<html lang="en"><body><p>This is the page about the product, and it is the best one for you. The team that built it was happy with the result.</p></body></html>`)
+	}))
+	defer server.Close()
+
+	a := NewWithOptions(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	result, err := a.AnalyzeURL(context.Background(), server.URL, IncludeSections(SectionSEO))
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	want := &Languages{ContentLanguage: []string{"de", "fr"}, Detected: "en", Mismatch: true}
+	if !reflect.DeepEqual(result.SEO.Languages, want) {
+		t.Errorf("Languages = %+v, want %+v", result.SEO.Languages, want)
+	}
+	i := slices.IndexFunc(result.Findings, func(f Finding) bool { return f.ID == "language-mismatch" })
+	if i < 0 {
+		t.Fatalf("Expected a language-mismatch finding, got %+v", result.Findings)
+	}
+	if want := []string{"lang=en", "Content-Language=de, fr", "detected=en"}; !reflect.DeepEqual(result.Findings[i].Evidence, want) {
+		t.Errorf("Evidence = %q, want %q", result.Findings[i].Evidence, want)
+	}
+}
+
+func TestAnalyzeURL_Frames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><head><title>Frames</title></head><frameset cols="30%,70%">
+				<frame src="javascript:void(0)">
+				<frame src="/#top">
+				<frame src="/nav.html">
+				<frame src="/nav.html">
+				<frame src="/missing.html">
+				<frame src="/main.html">
+			</frameset></html>`)
+		case "/nav.html":
+			fmt.Fprint(w, `<html><head><title>Navigation</title></head><body><h1>Menu</h1></body></html>`)
+		case "/main.html":
+			fmt.Fprint(w, `<html><head><title>Main</title></head><body><h1>Welcome</h1></body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name   string
+		max    int
+		titles []string
+	}{
+		{name: "only reported", max: 0},
+		{name: "limited", max: 1, titles: []string{"Navigation"}},
+		{name: "all", max: 5, titles: []string{"Navigation", "", "Main"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := NewWithOptions(WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))), WithMaxFrames(tc.max))
+			result, err := a.AnalyzeURL(context.Background(), server.URL, IncludeSections(SectionSEO))
+			if err != nil {
+				t.Fatalf("AnalyzeURL failed: %v", err)
+			}
+			if result.Frames == nil || len(result.Frames.Sources) != 6 {
+				t.Fatalf("Expected 6 frame sources, got %+v", result.Frames)
+			}
+			var titles []string
+			for _, frame := range result.Frames.Documents {
+				titles = append(titles, frame.Title)
+				if frame.Title != "" && (frame.SEO == nil || frame.Headings["h1"] != 1) {
+					t.Errorf("Expected frame %s to be analyzed with the seo section, got %+v", frame.URL, frame)
+				}
+			}
+			if !reflect.DeepEqual(titles, tc.titles) {
+				t.Errorf("Analyzed frame titles = %q, want %q", titles, tc.titles)
+			}
+			if tc.max == 5 && result.Frames.Documents[1].Error == "" {
+				t.Errorf("Expected the missing frame to have an error, got %+v", result.Frames.Documents[1])
+			}
+		})
+	}
+}
+
+func TestAnalyzeURL_Device(t *testing.T) {
+	var gotUserAgent string
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserAgent = r.UserAgent()
+		title := "Desktop"
+		if strings.Contains(gotUserAgent, "Mobile") {
+			title = "Mobile"
+		}
+		fmt.Fprintf(w, `<!DOCTYPE html><html><head><title>%s</title></head><body></body></html>`, title)
+	}))
+	defer page.Close()
+
+	var gotStrategy string
+	pageSpeed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotStrategy = r.URL.Query().Get("strategy")
+		fmt.Fprint(w, `{"lighthouseResult": {"audits": {}}}`)
+	}))
+	defer pageSpeed.Close()
+
+	a := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithConfig(Config{
+			RequestTimeout: 5 * time.Second,
+			MaxWorkers:     1,
+			PageSpeed:      PageSpeedConfig{APIKey: "secret", Strategy: "desktop", Endpoint: pageSpeed.URL, Timeout: 5 * time.Second},
+		}),
+	)
+
+	result, err := a.AnalyzeURL(context.Background(), page.URL, IncludeSections(SectionWebVitals), AsDevice(DeviceTablet))
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	if result.Title != "Mobile" || result.Device != DeviceTablet {
+		t.Errorf("Expected the tablet's page, got title %q for device %q", result.Title, result.Device)
+	}
+	if !strings.Contains(gotUserAgent, "iPad") || !strings.HasSuffix(gotUserAgent, " "+DefaultUserAgent) {
+		t.Errorf("Unexpected User-Agent %q", gotUserAgent)
+	}
+	if gotStrategy != "mobile" {
+		t.Errorf("Expected the mobile strategy, got %q", gotStrategy)
+	}
+
+	result, err = a.AnalyzeURL(context.Background(), page.URL)
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	if result.Title != "Desktop" || result.Device != "" || gotUserAgent != DefaultUserAgent {
+		t.Errorf("Expected the default fetch, got title %q for device %q as %q", result.Title, result.Device, gotUserAgent)
+	}
+
+	if _, err := a.AnalyzeURL(context.Background(), page.URL, AsDevice("watch")); !errors.Is(err, ErrInvalidDevice) {
+		t.Errorf("Expected ErrInvalidDevice for an unknown device, got %v", err)
+	}
+}
+
+func TestAnalyzeURL_WebVitalsError(t *testing.T) {
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<!DOCTYPE html><html><head><title>Vitals</title></head></html>`)
+	}))
+	defer page.Close()
+
+	pageSpeed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error": {"code": 400, "message": "API key not valid."}}`)
+	}))
+	defer pageSpeed.Close()
+
+	a := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithConfig(Config{
+			RequestTimeout: 5 * time.Second,
+			MaxWorkers:     1,
+			PageSpeed:      PageSpeedConfig{APIKey: "bad", Strategy: "mobile", Endpoint: pageSpeed.URL, Timeout: 5 * time.Second},
+		}),
+	)
+
+	result, err := a.AnalyzeURL(context.Background(), page.URL, IncludeSections(SectionWebVitals))
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	if result.Title != "Vitals" {
+		t.Errorf("Title = %q, want the page analyzed despite the failure", result.Title)
+	}
+	if result.WebVitals == nil || !strings.Contains(result.WebVitals.Error, "API key not valid.") {
+		t.Errorf("Unexpected web vitals section: %+v", result.WebVitals)
+	}
+}
+
 func TestAnalyzeURL_HTTPErrors(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -159,8 +836,11 @@ func TestAnalyzeURL_HTTPErrors(t *testing.T) {
 			result, err := analyzer.AnalyzeURL(context.Background(), server.URL)
 
 			if tc.expectError {
-				if err == nil {
-					t.Errorf("Expected error for HTTP %d", tc.statusCode)
+				var statusErr *ErrHTTPStatus
+				if !errors.As(err, &statusErr) {
+					t.Errorf("Expected ErrHTTPStatus for HTTP %d, got %v", tc.statusCode, err)
+				} else if statusErr.Code != tc.statusCode {
+					t.Errorf("Expected status code %d, got %d", tc.statusCode, statusErr.Code)
 				}
 				if result != nil {
 					t.Error("Expected nil result for HTTP error")
@@ -185,7 +865,7 @@ func TestAnalyzeURL_Timeout(t *testing.T) {
 	defer server.Close()
 
 	// Create analyzer with short timeout
-	cfg := config.AnalyzerConfig{
+	cfg := Config{
 		RequestTimeout: 100 * time.Millisecond,
 		LinkTimeout:    50 * time.Millisecond,
 		MaxRedirects:   5,
@@ -196,8 +876,8 @@ func TestAnalyzeURL_Timeout(t *testing.T) {
 
 	result, err := analyzer.AnalyzeURL(context.Background(), server.URL)
 
-	if err == nil {
-		t.Error("Expected timeout error")
+	if !errors.Is(err, ErrFetchTimeout) {
+		t.Errorf("Expected ErrFetchTimeout, got %v", err)
 	}
 
 	if result != nil {
@@ -232,8 +912,252 @@ func TestAnalyzeURL_ContextCancellation(t *testing.T) {
 	}
 }
 
+func TestAnalyzeURL_Busy(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		fmt.Fprint(w, "<html><title>Slow</title></html>")
+	}))
+	defer server.Close()
+	defer close(release)
+
+	analyzer := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))),
+		WithMaxConcurrentAnalyses(1),
+		WithQueueTimeout(50*time.Millisecond),
+	)
+
+	go analyzer.AnalyzeURL(context.Background(), server.URL)
+	for analyzer.Stats().ActiveAnalyses == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := analyzer.AnalyzeURL(context.Background(), server.URL); !errors.Is(err, ErrBusy) {
+		t.Errorf("Expected ErrBusy, got %v", err)
+	}
+	if queued := analyzer.Stats().QueuedAnalyses[PriorityHigh]; queued != 0 {
+		t.Errorf("Expected no queued analyses after rejection, got %d", queued)
+	}
+}
+
+func TestAcquire_Priority(t *testing.T) {
+	analyzer := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))),
+		WithMaxConcurrentAnalyses(1),
+		WithQueueTimeout(time.Second),
+	)
+
+	release, err := analyzer.acquire(context.Background(), PriorityHigh)
+	if err != nil {
+		t.Fatalf("Expected a free slot, got %v", err)
+	}
+
+	started := make(chan Priority, 2)
+	enqueue := func(p Priority) {
+		go func() {
+			release, err := analyzer.acquire(context.Background(), p)
+			if err != nil {
+				t.Errorf("Expected a slot for %s priority, got %v", p, err)
+				return
+			}
+			started <- p
+			release()
+		}()
+		for analyzer.Stats().QueuedAnalyses[p] == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// The low priority analysis queues first but starts last
+	enqueue(PriorityLow)
+	enqueue(PriorityHigh)
+	release()
+
+	for _, expected := range []Priority{PriorityHigh, PriorityLow} {
+		if p := <-started; p != expected {
+			t.Errorf("Expected %s priority to start, got %s", expected, p)
+		}
+	}
+}
+
+func TestAnalyzeURL_RejectedPages(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		body        string
+		expectedErr error
+	}{
+		{"not html", "application/json", `{"title":"JSON"}`, ErrNotHTML},
+		{"too large", "text/html", "<html><body>" + strings.Repeat("x", 2048) + "</body></html>", ErrTooLarge},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				// Flush before writing the body so no Content-Length is sent
+				w.(http.Flusher).Flush()
+				fmt.Fprint(w, tc.body)
+			}))
+			defer server.Close()
+
+			analyzer := NewWithOptions(
+				WithLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))),
+				WithMaxPageSize(1024),
+			)
+			_, err := analyzer.AnalyzeURL(context.Background(), server.URL)
+
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("Expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestReconfigure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body>"+strings.Repeat("x", 2048)+"</body></html>")
+	}))
+	defer server.Close()
+
+	analyzer := setupTestAnalyzer()
+	if _, err := analyzer.AnalyzeURL(context.Background(), server.URL); err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+
+	cfg := analyzer.config
+	cfg.MaxPageSize = 1024
+	cfg.MaxWorkers = 7
+	analyzer.Reconfigure(cfg)
+
+	if _, err := analyzer.AnalyzeURL(context.Background(), server.URL); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected the new page size limit to apply, got %v", err)
+	}
+	if analyzer.MaxWorkers() != 7 {
+		t.Errorf("Expected 7 workers, got %d", analyzer.MaxWorkers())
+	}
+}
+
+func TestAnalyzeURL_Progress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/one">One</a><a href="/missing">Missing</a></body></html>`)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var events []Progress
+	analyzer := setupTestAnalyzer()
+	_, err := analyzer.AnalyzeURL(context.Background(), server.URL, OnProgress(func(p Progress) {
+		events = append(events, p)
+	}))
+
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+
+	var phases []Phase
+	inaccessible := 0
+	for _, event := range events {
+		phases = append(phases, event.Phase)
+		if event.Phase == PhaseLinkChecked && !event.Accessible {
+			inaccessible++
+		}
+	}
+
+	expected := []Phase{PhaseFetching, PhaseAnalyzing, PhaseCheckingLinks, PhaseLinkChecked, PhaseLinkChecked, PhaseDone}
+	if fmt.Sprint(phases) != fmt.Sprint(expected) {
+		t.Fatalf("Expected phases %v, got %v", expected, phases)
+	}
+	if inaccessible != 1 {
+		t.Errorf("Expected 1 inaccessible link event, got %d", inaccessible)
+	}
+
+	done := events[len(events)-1]
+	if done.LinksChecked != 2 || done.LinksTotal != 2 {
+		t.Errorf("Expected 2/2 links checked, got %d/%d", done.LinksChecked, done.LinksTotal)
+	}
+}
+
+func TestAnalyzeMany(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "<html><head><title>%s</title></head></html>", r.URL.Path)
+	}))
+	defer server.Close()
+
+	analyzer := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))),
+		WithBatchConcurrency(2),
+	)
+	urls := []string{server.URL + "/a", server.URL + "/missing", server.URL + "/b"}
+	results := analyzer.AnalyzeMany(context.Background(), urls)
+
+	if len(results) != len(urls) {
+		t.Fatalf("Expected %d results, got %d", len(urls), len(results))
+	}
+	for i, result := range results {
+		if result.URL != urls[i] {
+			t.Errorf("Result %d: expected URL %s, got %s", i, urls[i], result.URL)
+		}
+	}
+	if results[0].Title != "/a" || results[2].Title != "/b" {
+		t.Errorf("Expected titles /a and /b, got %q and %q", results[0].Title, results[2].Title)
+	}
+	if results[1].Error == "" {
+		t.Error("Expected error for missing page")
+	}
+}
+
+func TestAnalyzeMany_OnResult(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html><head><title>%s</title></head></html>", r.URL.Path)
+	}))
+	defer server.Close()
+
+	analyzer := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))),
+		WithBatchConcurrency(3),
+	)
+	urls := []string{server.URL + "/a", server.URL + "/b", server.URL + "/c"}
+
+	seen := make(map[int]string)
+	results := analyzer.AnalyzeMany(context.Background(), urls, OnResult(func(i int, result *Result) {
+		seen[i] = result.Title
+	}))
+
+	if len(seen) != len(urls) {
+		t.Fatalf("Expected %d callbacks, got %d", len(urls), len(seen))
+	}
+	for i, result := range results {
+		if seen[i] != result.Title {
+			t.Errorf("Callback %d: expected title %q, got %q", i, result.Title, seen[i])
+		}
+	}
+}
+
+func TestAnalyzeMany_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	analyzer := setupTestAnalyzer()
+	results := analyzer.AnalyzeMany(ctx, []string{"http://example.com/a", "http://example.com/b"})
+
+	for i, result := range results {
+		if result == nil || result.Error == "" {
+			t.Errorf("Result %d: expected an error after cancellation", i)
+		}
+	}
+}
+
 func setupTestAnalyzer() *Analyzer {
-	cfg := config.AnalyzerConfig{
+	cfg := Config{
 		RequestTimeout: 5 * time.Second,
 		LinkTimeout:    2 * time.Second,
 		MaxRedirects:   5,
@@ -243,6 +1167,259 @@ func setupTestAnalyzer() *Analyzer {
 	return New(cfg, logger)
 }
 
+func TestAnalyzeHTML(t *testing.T) {
+	testHTML := `<!DOCTYPE html>
+<html>
+<head><title>Stored Snapshot</title></head>
+<body>
+    <h1>Heading</h1>
+    <a href="/about">About</a>
+    <a href="/missing">Missing</a>
+    <form>
+        <input type="text" name="username">
+        <input type="password" name="password">
+    </form>
+</body>
+</html>`
+
+	var pageFetched bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			pageFetched = true
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	analyzer := setupTestAnalyzer()
+	result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(testHTML), server.URL)
+
+	if err != nil {
+		t.Fatalf("AnalyzeHTML failed: %v", err)
+	}
+	if pageFetched {
+		t.Error("Expected the page not to be fetched")
+	}
+	if result.URL != server.URL {
+		t.Errorf("Expected URL %s, got %s", server.URL, result.URL)
+	}
+	if result.Title != "Stored Snapshot" {
+		t.Errorf("Expected title 'Stored Snapshot', got '%s'", result.Title)
+	}
+	if result.Headings["h1"] != 1 {
+		t.Errorf("Expected 1 h1, got %d", result.Headings["h1"])
+	}
+	if result.InternalLinks != 2 {
+		t.Errorf("Expected 2 internal links, got %d", result.InternalLinks)
+	}
+	if result.InaccessibleLinks != 1 {
+		t.Errorf("Expected 1 inaccessible link, got %d", result.InaccessibleLinks)
+	}
+	if !result.HasLoginForm {
+		t.Error("Expected login form to be detected")
+	}
+}
+
+func TestAnalyzeHTML_StreamingMatchesDOM(t *testing.T) {
+	testHTML := `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN">
+<html>
+<head><title> Large Page </title></head>
+<body>
+    <h1>Main</h1>
+    <h2>One</h2>
+    <h2>Two</h2>
+    <a href="/about">About</a>
+    <a href="http://127.0.0.1:1/">External</a>
+    <a href="mailto:someone@example.com">Mail</a>
+    <form>
+        <input type="text" name="query">
+    </form>
+    <form>
+        <input type="email" name="email">
+        <input type="password" name="password">
+    </form>
+</body>
+</html>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	dom := NewWithOptions(WithLogger(logger), WithStreamingThreshold(0), WithLinkTimeout(time.Second))
+	streaming := NewWithOptions(WithLogger(logger), WithStreamingThreshold(16), WithLinkTimeout(time.Second))
+
+	domResult, err := dom.AnalyzeHTML(context.Background(), strings.NewReader(testHTML), server.URL)
+	if err != nil {
+		t.Fatalf("DOM analysis failed: %v", err)
+	}
+	streamResult, err := streaming.AnalyzeHTML(context.Background(), strings.NewReader(testHTML), server.URL)
+	if err != nil {
+		t.Fatalf("Streaming analysis failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(domResult, streamResult) {
+		t.Errorf("Expected streaming result to match DOM result\nDOM:       %+v\nStreaming: %+v", domResult, streamResult)
+	}
+	if streamResult.Title != "Large Page" || !streamResult.HasLoginForm || streamResult.Headings["h2"] != 2 {
+		t.Errorf("Unexpected streaming result: %+v", streamResult)
+	}
+}
+
+func TestAnalyzeHTML_Outline(t *testing.T) {
+	testHTML := `<html><body>
+    <h1>Main <em>title</em></h1>
+    <h2>
+        <a href="/one">One</a>
+    </h2>
+    <p>Text</p>
+    <h3>Details &amp; more</h3>
+    <h2>Two</h2>
+</body></html>`
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	want := []OutlineHeading{
+		{Level: 1, Text: "Main title", Position: 1},
+		{Level: 2, Text: "One", Position: 2},
+		{Level: 3, Text: "Details & more", Position: 3},
+	}
+
+	for _, threshold := range []int64{0, 16} {
+		analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold), WithMaxOutlineEntries(3))
+
+		result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(testHTML), "", IncludeSections(SectionOutline))
+		if err != nil {
+			t.Fatalf("AnalyzeHTML failed: %v", err)
+		}
+		if result.Outline == nil {
+			t.Fatalf("Expected the outline section with streaming threshold %d", threshold)
+		}
+		if !slices.Equal(result.Outline.Headings, want) || !result.Outline.Truncated {
+			t.Errorf("Outline with streaming threshold %d = %+v, want %+v truncated", threshold, result.Outline, want)
+		}
+	}
+}
+
+func TestAnalyzeHTML_Titles(t *testing.T) {
+	testCases := []struct {
+		name  string
+		html  string
+		title string
+		count int
+	}{
+		{"head title wins", "<html><head><title> First &amp;\n  page </title></head><body><svg><title>Icon</title></svg><title>Second</title></body></html>", "First & page", 2},
+		{"svg title ignored", "<html><head></head><body><svg><title>Icon</title></svg><p>Text</p></body></html>", "", 0},
+		{"body title fallback", "<html><head></head><body><svg><title>Icon</title></svg><title>Fallback</title></body></html>", "Fallback", 1},
+		{"empty first title", "<html><head><title></title><title>Later</title></head></html>", "", 2},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, tc := range testCases {
+		for _, threshold := range []int64{0, 16} {
+			analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+
+			result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(tc.html), "")
+			if err != nil {
+				t.Fatalf("%s: AnalyzeHTML failed: %v", tc.name, err)
+			}
+			if result.Title != tc.title || result.TitleCount != tc.count {
+				t.Errorf("%s (streaming threshold %d): title %q with count %d, want %q with count %d",
+					tc.name, threshold, result.Title, result.TitleCount, tc.title, tc.count)
+			}
+		}
+	}
+}
+
+func TestAnalyzeHTML_ScriptDependency(t *testing.T) {
+	article := strings.Repeat("Readable article text. ", 8)
+
+	testCases := []struct {
+		name         string
+		html         string
+		detected     bool
+		noscriptOnly bool
+		emptyBody    bool
+	}{
+		{
+			name:      "app shell",
+			html:      `<html><head><title>App</title><script src="/app.js"></script></head><body><noscript>You need to enable JavaScript to run this app.</noscript><div id="root"></div></body></html>`,
+			detected:  true,
+			emptyBody: true,
+		},
+		{
+			name:         "noscript content",
+			html:         `<html><body><noscript><p>` + article + `</p></noscript></body></html>`,
+			detected:     true,
+			noscriptOnly: true,
+		},
+		{
+			name:      "template content",
+			html:      `<html><body><template><p>` + article + `</p></template><script>render()</script></body></html>`,
+			detected:  true,
+			emptyBody: true,
+		},
+		{
+			name: "server rendered",
+			html: `<html><head><script src="/app.js"></script></head><body><p>` + article + `</p></body></html>`,
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, tc := range testCases {
+		var results []*ScriptDependency
+		for _, threshold := range []int64{0, 16} {
+			analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+			result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(tc.html), "")
+			if err != nil {
+				t.Fatalf("%s: AnalyzeHTML failed: %v", tc.name, err)
+			}
+			results = append(results, result.ScriptDependency)
+		}
+
+		dom := results[0]
+		if !reflect.DeepEqual(dom, results[1]) {
+			t.Errorf("%s: streaming found %+v, DOM found %+v", tc.name, results[1], dom)
+		}
+		if (dom != nil) != tc.detected {
+			t.Errorf("%s: ScriptDependency = %+v, want detected %t", tc.name, dom, tc.detected)
+			continue
+		}
+		if dom != nil && (dom.NoscriptOnly != tc.noscriptOnly || dom.EmptyBody != tc.emptyBody || dom.Advice == "") {
+			t.Errorf("%s: ScriptDependency = %+v, want noscript_only %t and empty_body %t with advice", tc.name, dom, tc.noscriptOnly, tc.emptyBody)
+		}
+	}
+}
+
+func TestAnalyzeHTML_InvalidBaseURL(t *testing.T) {
+	analyzer := setupTestAnalyzer()
+	_, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader("<html></html>"), "http://[::1")
+
+	if err == nil {
+		t.Fatal("Expected error for invalid base URL")
+	}
+}
+
+func TestAnalyzeNode(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><head><title>Rendered</title></head><body><h2>A</h2><h2>B</h2></body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	analyzer := setupTestAnalyzer()
+	result, err := analyzer.AnalyzeNode(context.Background(), doc, "https://example.com/")
+
+	if err != nil {
+		t.Fatalf("AnalyzeNode failed: %v", err)
+	}
+	if result.Title != "Rendered" {
+		t.Errorf("Expected title 'Rendered', got '%s'", result.Title)
+	}
+	if result.Headings["h2"] != 2 {
+		t.Errorf("Expected 2 h2, got %d", result.Headings["h2"])
+	}
+}
+
 func TestDetectHTMLVersion(t *testing.T) {
 	analyzer := setupTestAnalyzer()
 
@@ -319,7 +1496,7 @@ func TestIsLoginForm_ValidLoginForms(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			formNode := parseFormHTML(t, tc.html)
 
-			result := analyzer.isLoginForm(formNode)
+			result := analyzer.isLoginForm(context.Background(), formNode)
 			if !result {
 				t.Errorf("Expected login form to be detected for: %s", tc.name)
 			}
@@ -373,7 +1550,7 @@ func TestIsLoginForm_InvalidLoginForms(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			formNode := parseFormHTML(t, tc.html)
 
-			result := analyzer.isLoginForm(formNode)
+			result := analyzer.isLoginForm(context.Background(), formNode)
 			if result {
 				t.Errorf("Expected login form NOT to be detected for: %s", tc.name)
 			}
@@ -411,7 +1588,7 @@ func TestProcessLink(t *testing.T) {
 				Attr: []html.Attribute{{Key: "href", Val: tc.href}},
 			}
 
-			analyzer.processLink(linkNode, result, baseURL)
+			analyzer.processLink(context.Background(), linkNode, result, baseURL)
 
 			if result.InternalLinks != tc.expectedInternal {
 				t.Errorf("Expected %d internal links, got %d", tc.expectedInternal, result.InternalLinks)
@@ -436,7 +1613,7 @@ func TestProcessLink_InvalidHref(t *testing.T) {
 		Attr: []html.Attribute{{Key: "href", Val: "://invalid-url"}},
 	}
 
-	analyzer.processLink(linkNode, result, baseURL)
+	analyzer.processLink(context.Background(), linkNode, result, baseURL)
 
 	// Should not increment either counter for invalid URLs
 	if result.InternalLinks != 0 || result.ExternalLinks != 0 {
@@ -465,7 +1642,7 @@ func TestExtractLinks(t *testing.T) {
 		t.Fatalf("Failed to parse HTML: %v", err)
 	}
 
-	links := analyzer.extractLinks(doc, baseURL)
+	links := analyzer.extractLinks(context.Background(), doc, baseURL)
 
 	// Should only extract HTTP/HTTPS links
 	expectedCount := 4 // /internal1, /internal2, external.com, example.com/page
@@ -530,7 +1707,7 @@ func TestTraverseNode_ComplexHTML(t *testing.T) {
 	}
 
 	result := &Result{Headings: make(map[string]int)}
-	analyzer.analyzeDocument(doc, result, baseURL)
+	analyzer.analyzeDocument(context.Background(), doc, result, baseURL)
 
 	// Test title
 	if result.Title != "Complex Test Page" {
@@ -696,7 +1873,7 @@ func TestCheckLinksAccessibility_MixedResults(t *testing.T) {
 		accessibleServer.URL + "/page2",
 	}
 
-	inaccessibleCount := analyzer.checkLinksAccessibility(context.Background(), links)
+	inaccessibleCount := analyzer.checkLinksAccessibility(context.Background(), links, nil)
 
 	// Expect at least 2 inaccessible (404 server + invalid domain)
 	if inaccessibleCount < 2 {
@@ -712,7 +1889,7 @@ func TestCheckLinksAccessibility_MixedResults(t *testing.T) {
 func TestCheckLinksAccessibility_EmptyList(t *testing.T) {
 	analyzer := setupTestAnalyzer()
 
-	count := analyzer.checkLinksAccessibility(context.Background(), []string{})
+	count := analyzer.checkLinksAccessibility(context.Background(), []string{}, nil)
 
 	if count != 0 {
 		t.Errorf("Expected 0 for empty links, got %d", count)
@@ -730,7 +1907,7 @@ func TestCheckLinksAccessibility_WorkerPoolLimiting(t *testing.T) {
 	// Create fewer links than max workers to test worker limiting
 	links := []string{server.URL, server.URL + "/page1"}
 
-	count := analyzer.checkLinksAccessibility(context.Background(), links)
+	count := analyzer.checkLinksAccessibility(context.Background(), links, nil)
 
 	// All should be accessible
 	if count != 0 {
@@ -758,41 +1935,176 @@ func TestCheckLinksAccessibility_ContextCancellation(t *testing.T) {
 	}()
 
 	// Should handle cancellation gracefully without panicking
-	count := analyzer.checkLinksAccessibility(ctx, links)
+	count := analyzer.checkLinksAccessibility(ctx, links, nil)
 
 	// The exact count may vary due to timing, but it shouldn't panic
 	_ = count
 }
 
-func TestCheckLinksAccessibility_Concurrency(t *testing.T) {
-	// Create multiple servers to test concurrent access
-	servers := make([]*httptest.Server, 5)
-	links := make([]string, 5)
+func TestCheckLinksAccessibility_QueuedLinks(t *testing.T) {
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	linkClient := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		started <- struct{}{}
+		<-release
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})}
+
+	analyzer := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))),
+		WithLinkCheckClient(linkClient),
+		WithMaxWorkers(1),
+	)
+
+	done := make(chan int)
+	go func() {
+		done <- analyzer.checkLinksAccessibility(context.Background(), []string{"http://a.test/", "http://b.test/", "http://c.test/"}, nil)
+	}()
 
-	for i := 0; i < 5; i++ {
-		servers[i] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Add small delay to simulate network latency
-			time.Sleep(50 * time.Millisecond)
-			w.WriteHeader(http.StatusOK)
-		}))
-		links[i] = servers[i].URL
+	// The single worker holds the first link while the other two wait
+	<-started
+	for analyzer.Stats().QueuedLinks != 2 {
+		time.Sleep(time.Millisecond)
 	}
+	close(release)
 
-	defer func() {
-		for _, server := range servers {
-			server.Close()
-		}
-	}()
+	if inaccessible := <-done; inaccessible != 0 {
+		t.Errorf("Expected no inaccessible links, got %d", inaccessible)
+	}
+	if queued := analyzer.Stats().QueuedLinks; queued != 0 {
+		t.Errorf("QueuedLinks = %d after the check, want 0", queued)
+	}
+}
 
-	analyzer := setupTestAnalyzer()
-	start := time.Now()
+func TestAnalyzeURL_PartialOnCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="http://fast.test/">Fast</a><a href="http://slow.test/">Slow</a></body></html>`)
+	}))
+	defer server.Close()
 
-	count := analyzer.checkLinksAccessibility(context.Background(), links)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	duration := time.Since(start)
+	linkClient := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "slow.test" {
+			cancel()
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})}
 
-	// All should be accessible
-	if count != 0 {
+	analyzer := NewWithOptions(
+		WithLogger(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))),
+		WithLinkCheckClient(linkClient),
+		WithMaxWorkers(1),
+	)
+
+	result, err := analyzer.AnalyzeURL(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+	if !result.Partial {
+		t.Error("Expected the result to be marked partial")
+	}
+	if result.InaccessibleLinks != 0 {
+		t.Errorf("Expected the cancelled check not to count as inaccessible, got %d", result.InaccessibleLinks)
+	}
+}
+
+func TestAnalyzeURL_Budgets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><title>Budget</title><body><a href="http://slow.test/">Slow</a>`)
+		if r.URL.Path == "/slow" {
+			w.(http.Flusher).Flush()
+			time.Sleep(300 * time.Millisecond)
+		}
+		fmt.Fprint(w, `</body></html>`)
+	}))
+	defer server.Close()
+
+	linkClient := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	newAnalyzer := func(budgets Budgets) *Analyzer {
+		cfg := defaultConfig
+		cfg.Budgets = budgets
+		return NewWithOptions(WithConfig(cfg), WithLogger(logger), WithLinkCheckClient(linkClient))
+	}
+
+	t.Run("links", func(t *testing.T) {
+		start := time.Now()
+		result, err := newAnalyzer(Budgets{Links: 50 * time.Millisecond}).AnalyzeURL(context.Background(), server.URL)
+		if err != nil {
+			t.Fatalf("AnalyzeURL failed: %v", err)
+		}
+		if !result.Partial || result.Title != "Budget" {
+			t.Errorf("Expected a partial result with the page analyzed, got %+v", result)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Link check took %v despite a 50ms budget", elapsed)
+		}
+	})
+
+	t.Run("fetch", func(t *testing.T) {
+		_, err := newAnalyzer(Budgets{Fetch: 100 * time.Millisecond}).AnalyzeURL(context.Background(), server.URL+"/slow")
+		if !errors.Is(err, ErrFetchTimeout) {
+			t.Errorf("Expected ErrFetchTimeout, got %v", err)
+		}
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		body := slowReader{r: strings.NewReader("<html><title>Slow</title></html>"), delay: 20 * time.Millisecond}
+		_, err := newAnalyzer(Budgets{Parse: 50 * time.Millisecond}).AnalyzeHTML(context.Background(), body, "")
+		if !errors.Is(err, ErrParseTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected ErrParseTimeout, got %v", err)
+		}
+	})
+}
+
+// slowReader returns one byte per read after a delay
+type slowReader struct {
+	r     io.Reader
+	delay time.Duration
+}
+
+func (s slowReader) Read(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	return s.r.Read(p[:min(len(p), 1)])
+}
+
+func TestCheckLinksAccessibility_Concurrency(t *testing.T) {
+	// Create multiple servers to test concurrent access
+	servers := make([]*httptest.Server, 5)
+	links := make([]string, 5)
+
+	for i := 0; i < 5; i++ {
+		servers[i] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Add small delay to simulate network latency
+			time.Sleep(50 * time.Millisecond)
+			w.WriteHeader(http.StatusOK)
+		}))
+		links[i] = servers[i].URL
+	}
+
+	defer func() {
+		for _, server := range servers {
+			server.Close()
+		}
+	}()
+
+	analyzer := setupTestAnalyzer()
+	start := time.Now()
+
+	count := analyzer.checkLinksAccessibility(context.Background(), links, nil)
+
+	duration := time.Since(start)
+
+	// All should be accessible
+	if count != 0 {
 		t.Errorf("Expected 0 inaccessible links, got %d", count)
 	}
 
@@ -802,3 +2114,1280 @@ func TestCheckLinksAccessibility_Concurrency(t *testing.T) {
 		t.Errorf("Expected concurrent execution to be faster, took %v", duration)
 	}
 }
+
+func TestResult_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(&Result{URL: "https://example.com", Title: "Example"})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded["schema_version"] != SchemaVersion {
+		t.Errorf("schema_version = %v, want %s", decoded["schema_version"], SchemaVersion)
+	}
+	if decoded["title"] != "Example" {
+		t.Errorf("title = %v, want the result's fields alongside the version", decoded["title"])
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	before := &Result{
+		HTMLVersion:       "HTML5",
+		Title:             "Old Title",
+		Headings:          map[string]int{"h1": 1, "h2": 3},
+		InternalLinks:     10,
+		ExternalLinks:     2,
+		InaccessibleLinks: 0,
+		HasLoginForm:      true,
+	}
+	after := &Result{
+		HTMLVersion:       "HTML5",
+		Title:             "New Title",
+		Headings:          map[string]int{"h1": 1, "h3": 1},
+		InternalLinks:     10,
+		ExternalLinks:     4,
+		InaccessibleLinks: 3,
+		HasLoginForm:      false,
+	}
+
+	diff := DiffResults(before, after)
+
+	if diff.Identical {
+		t.Fatal("Expected results to differ")
+	}
+
+	expectedDeltas := map[string]int{
+		"headings.h2":        -3,
+		"headings.h3":        1,
+		"external_links":     2,
+		"inaccessible_links": 3,
+	}
+	for field, expected := range expectedDeltas {
+		change, ok := diff.Change(field)
+		if !ok {
+			t.Errorf("Expected change for %s", field)
+			continue
+		}
+		if change.Delta == nil || *change.Delta != expected {
+			t.Errorf("Expected delta %d for %s, got %v", expected, field, change.Delta)
+		}
+	}
+
+	for _, field := range []string{"title", "has_login_form"} {
+		if _, ok := diff.Change(field); !ok {
+			t.Errorf("Expected change for %s", field)
+		}
+	}
+
+	for _, field := range []string{"html_version", "headings.h1", "internal_links"} {
+		if _, ok := diff.Change(field); ok {
+			t.Errorf("Unexpected change for %s", field)
+		}
+	}
+
+	expectedSummary := map[string]bool{
+		`title changed from "Old Title" to "New Title"`: true,
+		"3 fewer h2 headings":                           true,
+		"1 more h3 heading":                             true,
+		"2 more external links":                         true,
+		"3 new broken links":                            true,
+		"login form removed":                            true,
+	}
+	if len(diff.Summary) != len(expectedSummary) {
+		t.Errorf("Expected %d summary lines, got %v", len(expectedSummary), diff.Summary)
+	}
+	for _, line := range diff.Summary {
+		if !expectedSummary[line] {
+			t.Errorf("Unexpected summary line: %q", line)
+		}
+	}
+}
+
+func TestDiffResults_Sections(t *testing.T) {
+	headers := func(names ...string) *Security {
+		security := &Security{Headers: map[string]string{}}
+		for _, name := range names {
+			security.Headers[name] = "value of " + name
+		}
+		return security
+	}
+
+	testCases := []struct {
+		name    string
+		before  *Result
+		after   *Result
+		summary []string
+	}{
+		{
+			name:    "meta description changed",
+			before:  &Result{SEO: &SEO{MetaDescription: "Old", Canonical: "https://example.com/"}},
+			after:   &Result{SEO: &SEO{MetaDescription: "New", Canonical: "https://example.com/"}},
+			summary: []string{`meta description changed from "Old" to "New"`},
+		},
+		{
+			name:    "meta description removed",
+			before:  &Result{SEO: &SEO{MetaDescription: "Old"}},
+			after:   &Result{SEO: &SEO{}},
+			summary: []string{"meta description removed"},
+		},
+		{
+			name:    "canonical added",
+			before:  &Result{SEO: &SEO{}},
+			after:   &Result{SEO: &SEO{Canonical: "https://example.com/"}},
+			summary: []string{"canonical URL added"},
+		},
+		{
+			name:    "canonical changed",
+			before:  &Result{SEO: &SEO{Canonical: "https://example.com/a"}},
+			after:   &Result{SEO: &SEO{Canonical: "https://example.com/b"}},
+			summary: []string{`canonical URL changed from "https://example.com/a" to "https://example.com/b"`},
+		},
+		{
+			name:    "seo section on one side only",
+			before:  &Result{},
+			after:   &Result{SEO: &SEO{MetaDescription: "New", Canonical: "https://example.com/"}},
+			summary: []string{},
+		},
+		{
+			name:    "security headers added and removed",
+			before:  &Result{Security: headers("Strict-Transport-Security", "X-Frame-Options")},
+			after:   &Result{Security: headers("X-Frame-Options", "Content-Security-Policy")},
+			summary: []string{"Strict-Transport-Security header removed", "Content-Security-Policy header added"},
+		},
+		{
+			name:    "security header changed",
+			before:  &Result{Security: &Security{Headers: map[string]string{"X-Frame-Options": "DENY"}}},
+			after:   &Result{Security: &Security{Headers: map[string]string{"X-Frame-Options": "SAMEORIGIN"}}},
+			summary: []string{`X-Frame-Options header changed from "DENY" to "SAMEORIGIN"`},
+		},
+		{
+			name:    "headers unknown on one side",
+			before:  &Result{Security: &Security{}},
+			after:   &Result{Security: headers("Strict-Transport-Security")},
+			summary: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := DiffResults(tc.before, tc.after)
+			if !reflect.DeepEqual(diff.Summary, tc.summary) {
+				t.Errorf("Summary = %q, want %q", diff.Summary, tc.summary)
+			}
+			if diff.Identical != (len(tc.summary) == 0) {
+				t.Errorf("Identical = %t with changes %v", diff.Identical, diff.Changes)
+			}
+		})
+	}
+
+	diff := DiffResults(&Result{SEO: &SEO{MetaDescription: "Old"}}, &Result{SEO: &SEO{MetaDescription: "New"}})
+	if change, ok := diff.Change("seo.meta_description"); !ok || change.Before != "Old" || change.After != "New" {
+		t.Errorf("Expected a seo.meta_description change from Old to New, got %+v", change)
+	}
+}
+
+func TestDiffResults_Identical(t *testing.T) {
+	result := &Result{Title: "Same", Headings: map[string]int{"h1": 1}, InternalLinks: 2}
+
+	diff := DiffResults(result, result)
+
+	if !diff.Identical {
+		t.Errorf("Expected identical results, got changes: %v", diff.Changes)
+	}
+}
+
+func TestDiff_Regressions(t *testing.T) {
+	before := &Result{Title: "Home", InaccessibleLinks: 1, InternalLinks: 4}
+
+	testCases := []struct {
+		name     string
+		after    *Result
+		expected []string
+	}{
+		{"unchanged", &Result{Title: "Home", InaccessibleLinks: 1, InternalLinks: 4}, nil},
+		{"improved", &Result{Title: "Home page", InternalLinks: 5}, nil},
+		{"broken links", &Result{Title: "Home", InaccessibleLinks: 3, InternalLinks: 4}, []string{"2 new broken links"}},
+		{"title removed", &Result{InaccessibleLinks: 2, InternalLinks: 4}, []string{"1 new broken link", "title removed"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := DiffResults(before, tc.after).Regressions()
+			if fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+				t.Errorf("Expected regressions %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestAnalysisOutcome(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"success", nil, outcomeSuccess},
+		{"invalid URL", fmt.Errorf("%w: %w", ErrInvalidURL, fmt.Errorf("bad")), outcomeInvalidURL},
+		{"deadline", fmt.Errorf("failed to fetch HTML: %w", context.DeadlineExceeded), outcomeTimeout},
+		{"canceled", fmt.Errorf("failed to fetch HTML: %w", context.Canceled), outcomeCanceled},
+		{"other", fmt.Errorf("failed to fetch HTML: HTTP 404"), outcomeFetchFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := analysisOutcome(tc.err); got != tc.expected {
+				t.Errorf("Expected outcome %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestLinkFailureReason(t *testing.T) {
+	redirectErr := &url.Error{Op: "Head", URL: "http://example.com", Err: errTooManyRedirects}
+	if got := linkFailureReason(redirectErr); got != reasonRedirects {
+		t.Errorf("Expected reason %s, got %s", reasonRedirects, got)
+	}
+
+	if got := linkFailureReason(fmt.Errorf("dial tcp: connection refused")); got != reasonNetwork {
+		t.Errorf("Expected reason %s, got %s", reasonNetwork, got)
+	}
+}
+
+func TestParseRefresh(t *testing.T) {
+	testCases := []struct {
+		content string
+		delay   int
+		target  string
+		ok      bool
+	}{
+		{"0; url=/next", 0, "/next", true},
+		{"5;URL='https://example.com/a b'", 5, "https://example.com/a b", true},
+		{` 3 , url = "/quoted"`, 3, "/quoted", true},
+		{"1.5; /bare", 1, "/bare", true},
+		{"10", 10, "", true},
+		{"0; urlish/path", 0, "urlish/path", true},
+		{"url=/next", 0, "", false},
+		{"", 0, "", false},
+	}
+
+	for _, tc := range testCases {
+		delay, target, ok := parseRefresh(tc.content)
+		if delay != tc.delay || target != tc.target || ok != tc.ok {
+			t.Errorf("parseRefresh(%q) = %d, %q, %t, want %d, %q, %t", tc.content, delay, target, ok, tc.delay, tc.target, tc.ok)
+		}
+	}
+}
+
+func TestAnalyzeURL_MetaRefresh(t *testing.T) {
+	var fetches []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches = append(fetches, r.URL.Path)
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><head><meta http-equiv="Refresh" content="0; url=/middle"><title>Start</title></head><body><a href="/start-link">Start</a></body></html>`)
+		case "/middle":
+			fmt.Fprint(w, `<html><head><meta http-equiv="refresh" content="2;url=/final"><title>Middle</title></head></html>`)
+		case "/final":
+			fmt.Fprint(w, `<html><head><meta http-equiv="refresh" content="0; url=/"><title>Final</title></head><body><a href="/final-link">Final</a></body></html>`)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	testCases := []struct {
+		name      string
+		max       int
+		threshold int64
+		title     string
+		followed  []string
+	}{
+		{"report only", 0, 0, "Start", nil},
+		{"bounded", 1, 0, "Middle", []string{server.URL + "/middle"}},
+		{"loop", 5, 0, "Final", []string{server.URL + "/middle", server.URL + "/final"}},
+		{"loop streaming", 5, 16, "Final", []string{server.URL + "/middle", server.URL + "/final"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fetches = nil
+			analyzer := NewWithOptions(WithLogger(logger), WithMaxMetaRefreshes(tc.max), WithStreamingThreshold(tc.threshold))
+			result, err := analyzer.AnalyzeURL(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("AnalyzeURL failed: %v", err)
+			}
+
+			if result.URL != server.URL || result.Title != tc.title {
+				t.Errorf("Result URL, title = %q, %q, want %q, %q", result.URL, result.Title, server.URL, tc.title)
+			}
+			refresh := result.MetaRefresh
+			if refresh == nil {
+				t.Fatal("Expected the meta refresh to be reported")
+			}
+			if refresh.URL != server.URL+"/middle" || refresh.DelaySeconds != 0 || !reflect.DeepEqual(refresh.Followed, tc.followed) {
+				t.Errorf("MetaRefresh = %+v, want /middle after 0s followed to %v", refresh, tc.followed)
+			}
+			// Only the analyzed page's links are checked
+			if slices.Contains(fetches, "/start-link") != (tc.max == 0) {
+				t.Errorf("Fetches = %v, want the start page's links checked only when not following", fetches)
+			}
+		})
+	}
+}
+
+func TestAnalyzeHTML_Breadcrumbs(t *testing.T) {
+	testCases := []struct {
+		name string
+		html string
+		want *Breadcrumbs
+	}{
+		{
+			name: "json-ld",
+			html: `<html><head><script type="application/ld+json">{"@context":"https://schema.org","@graph":[{"@type":"WebPage"},{"@type":"BreadcrumbList","itemListElement":[
+				{"@type":"ListItem","position":2,"name":"Docs","item":"/docs"},
+				{"@type":"ListItem","position":1,"item":{"@id":"https://example.com/","name":"Home"}},
+				{"@type":"ListItem","position":3,"name":"Install"}]}]}</script></head>
+				<body><nav aria-label="Breadcrumb"><a href="/">Ignored</a></nav></body></html>`,
+			want: &Breadcrumbs{Source: "json-ld", Items: []BreadcrumbItem{
+				{Name: "Home", URL: "https://example.com/"},
+				{Name: "Docs", URL: "https://example.com/docs"},
+				{Name: "Install"},
+			}},
+		},
+		{
+			name: "microdata",
+			html: `<html><body><ol itemscope itemtype="https://schema.org/BreadcrumbList">
+				<li itemprop="itemListElement" itemscope itemtype="https://schema.org/ListItem"><a itemprop="item" href="/"><span itemprop="name">Home</span></a> &rsaquo;<meta itemprop="position" content="1"></li>
+				<li itemprop="itemListElement" itemscope itemtype="https://schema.org/ListItem"><span itemprop="name">Current page</span><meta itemprop="position" content="2"></li>
+				</ol></body></html>`,
+			want: &Breadcrumbs{Source: "microdata", Items: []BreadcrumbItem{
+				{Name: "Home", URL: "https://example.com/"},
+				{Name: "Current page"},
+			}},
+		},
+		{
+			name: "nav",
+			html: `<html><body><div class="site-breadcrumbs">Ignored</div><nav aria-label="breadcrumb"><ol>
+				<li><a href="/">Home</a> /</li><li><a href="/docs/">Docs</a> /</li><li aria-current="page">Page</li>
+				</ol></nav></body></html>`,
+			want: &Breadcrumbs{Source: "nav", Items: []BreadcrumbItem{
+				{Name: "Home", URL: "https://example.com/"},
+				{Name: "Docs", URL: "https://example.com/docs/"},
+				{Name: "Page"},
+			}},
+		},
+		{
+			name: "class links",
+			html: `<html><body><div class="breadcrumbs"><a href="/">Home</a> &raquo; <a href="/blog">Blog</a> &raquo; Post</div></body></html>`,
+			want: &Breadcrumbs{Source: "class", Items: []BreadcrumbItem{
+				{Name: "Home", URL: "https://example.com/"},
+				{Name: "Blog", URL: "https://example.com/blog"},
+			}},
+		},
+		{
+			name: "none",
+			html: `<html><body><nav><a href="/">Home</a></nav><div class="breadcrumb"></div></body></html>`,
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, tc := range testCases {
+		for _, threshold := range []int64{0, 16} {
+			analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+			result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(tc.html), "https://example.com/docs/install", IncludeSections(SectionSEO))
+			if err != nil {
+				t.Fatalf("%s: AnalyzeHTML failed: %v", tc.name, err)
+			}
+			if got := result.SEO.Breadcrumbs; !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("%s (threshold %d): Breadcrumbs = %+v, want %+v", tc.name, threshold, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestAnalyzeHTML_Tables(t *testing.T) {
+	page := `<html><body>
+		<table role="presentation"><tr><td>Logo</td><td>Menu</td></tr></table>
+		<table>
+			<caption>Prices</caption>
+			<thead><tr><th scope="col">Plan</th><th scope="col">Price</th></tr></thead>
+			<tr><th scope="row">Basic</th><td>5</td></tr>
+			<tr><th>Pro</th><td>10</td></tr>
+		</table>
+		<table><tr><td><table><tr><td>A</td><td>B</td></tr><tr><td>C</td><td>D</td></tr></table></td><td>Side</td></tr><tr><td>Footer</td></tr></table>
+		<table><td>Implicit row</td><td>Only one</td></table>
+	</body></html>`
+	want := []AccessibilityTable{
+		{Position: 1, Rows: 1, Layout: true},
+		{Position: 2, Rows: 3, HeaderCells: 4, ScopedHeaders: 3, Caption: true},
+		{Position: 3, Rows: 2, Layout: true},
+		{Position: 4, Rows: 2},
+		{Position: 5, Rows: 1, Layout: true},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, threshold := range []int64{0, 16} {
+		analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+		result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), "", IncludeSections(SectionAccessibility))
+		if err != nil {
+			t.Fatalf("AnalyzeHTML failed: %v", err)
+		}
+		if got := result.Accessibility.Tables; !reflect.DeepEqual(got, want) {
+			t.Errorf("Threshold %d: Tables = %+v, want %+v", threshold, got, want)
+		}
+	}
+}
+
+func TestAnalyzeHTML_Icons(t *testing.T) {
+	page := `<html><head>
+		<link rel="stylesheet" href="https://cdnjs.cloudflare.com/ajax/libs/font-awesome/6.5.0/css/all.min.css">
+		<style>@FONT-FACE { font-family: "Brand Icons"; src: url(/icons.woff2) } @font-face { font-family: Inter; src: url(/inter.woff2) }</style>
+	</head><body>
+		<i class="fa-solid fa-house"></i><span class="material-icons">home</span><span class="fab"></span>
+		<svg><svg><circle r="1"/></svg><title>Logo</title></svg>
+		<svg viewBox="0 0 1 1"/>
+		<p class="fancy">Text</p>
+	</body></html>`
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, threshold := range []int64{0, 16} {
+		analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+		result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), "", IncludeSections(SectionPerformance))
+		if err != nil {
+			t.Fatalf("AnalyzeHTML failed: %v", err)
+		}
+		performance := result.Performance
+		if performance.InlineSVGs != 2 || performance.IconElements != 3 {
+			t.Errorf("Threshold %d: InlineSVGs, IconElements = %d, %d, want 2, 3", threshold, performance.InlineSVGs, performance.IconElements)
+		}
+		want := []string{"Font Awesome", "Brand Icons", "Material Icons"}
+		if !reflect.DeepEqual(performance.IconFonts, want) {
+			t.Errorf("Threshold %d: IconFonts = %v, want %v", threshold, performance.IconFonts, want)
+		}
+	}
+}
+
+func TestAnalyzeHTML_ThirdParty(t *testing.T) {
+	page := `<html><head>
+		<link rel="preconnect" href="https://fonts.gstatic.com">
+		<link rel="stylesheet" href="https://fonts.googleapis.com/css2?family=Inter">
+		<link rel="stylesheet" href="/site.css">
+		<script src="https://www.googletagmanager.com/gtag/js"></script>
+		<script src="//cdn.example.net/lib.js"></script>
+		<script>inline()</script>
+	</head><body>
+		<img src="https://cdn.example.net/a.png"><img src="https://cdn.example.net/b.png"><img src="data:image/png;base64,AAAA">
+		<iframe src="https://www.youtube.com/embed/x"></iframe>
+		<a href="https://elsewhere.example/">Links are not resources</a>
+	</body></html>`
+	want := []ThirdPartyOrigin{
+		{Origin: "https://cdn.example.net", Resources: 3, Scripts: 1, Images: 2},
+		{Origin: "https://fonts.gstatic.com", Resources: 1, Preconnects: 1},
+		{Origin: "https://fonts.googleapis.com", Resources: 1, Styles: 1},
+		{Origin: "https://www.googletagmanager.com", Resources: 1, Scripts: 1},
+		{Origin: "https://www.youtube.com", Resources: 1, Frames: 1},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, threshold := range []int64{0, 16} {
+		analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+		result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), "https://example.com/", IncludeSections(SectionThirdParty))
+		if err != nil {
+			t.Fatalf("AnalyzeHTML failed: %v", err)
+		}
+		if !reflect.DeepEqual(result.ThirdParty.Origins, want) {
+			t.Errorf("Threshold %d: Origins = %+v, want %+v", threshold, result.ThirdParty.Origins, want)
+		}
+	}
+}
+
+func TestAnalyzeHTML_InternalSubdomains(t *testing.T) {
+	page := `<html><body>
+		<a href="/about">About</a>
+		<a href="https://blog.example.co.uk/">Blog</a>
+		<a href="https://example.co.uk/">Apex</a>
+		<a href="https://example.com/">Other domain</a>
+		<a href="https://other.co.uk/">Same suffix</a>
+	</body></html>`
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, subdomains := range []bool{false, true} {
+		wantInternal, wantExternal := 1, 4
+		if subdomains {
+			wantInternal, wantExternal = 3, 2
+		}
+		for _, threshold := range []int64{0, 16} {
+			analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold), WithInternalSubdomains(subdomains))
+			result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), "https://www.example.co.uk/")
+			if err != nil {
+				t.Fatalf("AnalyzeHTML failed: %v", err)
+			}
+			if result.InternalLinks != wantInternal || result.ExternalLinks != wantExternal {
+				t.Errorf("Subdomains %t, threshold %d: %d internal and %d external links, want %d and %d",
+					subdomains, threshold, result.InternalLinks, result.ExternalLinks, wantInternal, wantExternal)
+			}
+		}
+	}
+}
+
+func TestAnalyzeHTML_LinkSchemes(t *testing.T) {
+	page := `<html><body>
+		<a href="mailto:team@example.com">Mail</a>
+		<a href="MAILTO:sales@example.com">Sales</a>
+		<a href="tel:+15551234">Call</a>
+		<a href="javascript:void(0)">Menu</a>
+		<a href="ftp://files.example.com/a.zip">Files</a>
+		<a href="data:text/plain,hi">Data</a>
+		<a href="whatsapp://send?text=hi">Share</a>
+		<a href="/about">About</a>
+		<a href="https://other.example/">Other</a>
+	</body></html>`
+	want := map[string]int{"mailto": 2, "tel": 1, "javascript": 1, "ftp": 1, "data": 1, "other": 1}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, threshold := range []int64{0, 16} {
+		analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+		result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), "https://example.com/")
+		if err != nil {
+			t.Fatalf("AnalyzeHTML failed: %v", err)
+		}
+		if !reflect.DeepEqual(result.LinkSchemes, want) {
+			t.Errorf("Threshold %d: LinkSchemes = %v, want %v", threshold, result.LinkSchemes, want)
+		}
+		if result.InternalLinks+result.ExternalLinks != 9 {
+			t.Errorf("Threshold %d: expected all 9 links to be counted, got %d internal and %d external", threshold, result.InternalLinks, result.ExternalLinks)
+		}
+	}
+
+	analyzer := NewWithOptions(WithLogger(logger))
+	result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(`<a href="/a">A</a>`), "https://example.com/")
+	if err != nil {
+		t.Fatalf("AnalyzeHTML failed: %v", err)
+	}
+	if result.LinkSchemes != nil {
+		t.Errorf("Expected no link_schemes for http links, got %v", result.LinkSchemes)
+	}
+}
+
+func TestAnalyzeHTML_LinkDepth(t *testing.T) {
+	page := `<html><body>
+		<a href="/">Home</a>
+		<a href="/blog/">Blog</a>
+		<a href="blog/2024/post.html">Post</a>
+		<a href="/docs/guides/setup/linux?v=2#top">Linux</a>
+		<a href="//example.com/a/b/c/d/e">Deep</a>
+		<a href="mailto:team@example.com">Mail</a>
+		<a href="https://other.example/a/b/c/d">Other</a>
+	</body></html>`
+	want := &LinkDepth{Counts: map[int]int{0: 1, 1: 1, 3: 1, 4: 1, 5: 1}, Max: 5, Mean: 2.6, Deep: 2}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, threshold := range []int64{0, 16} {
+		analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+		result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), "https://example.com/")
+		if err != nil {
+			t.Fatalf("AnalyzeHTML failed: %v", err)
+		}
+		if !reflect.DeepEqual(result.LinkDepth, want) {
+			t.Errorf("Threshold %d: LinkDepth = %+v, want %+v", threshold, result.LinkDepth, want)
+		}
+	}
+
+	analyzer := NewWithOptions(WithLogger(logger))
+	result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(`<a href="https://other.example/a">A</a>`), "https://example.com/")
+	if err != nil {
+		t.Fatalf("AnalyzeHTML failed: %v", err)
+	}
+	if result.LinkDepth != nil {
+		t.Errorf("Expected no link_depth without internal links, got %+v", result.LinkDepth)
+	}
+}
+
+func TestAnalyzeHTML_Languages(t *testing.T) {
+	english := "<p>This is the page about the product, and it is the best one for you. The team that built it was happy with the result of the work, and we hope that you are too.</p>"
+	german := "<p>Dies ist die Seite über das Produkt, und wir sind sehr froh, dass Sie sich für die Arbeit interessieren. Das Team hat es mit viel Liebe gebaut und es ist nicht fertig.</p>"
+	japanese := "<p>" + strings.Repeat("これは日本語のページです。私たちはこの製品をとても誇りに思っています。", 2) + "</p>"
+	testCases := []struct {
+		name     string
+		html     string
+		want     *Languages
+		evidence []string
+	}{
+		{
+			name: "agreeing",
+			html: `<html lang="en"><head>
+				<link rel="alternate" hreflang="x-default" href="https://example.com/page">
+				<link rel="alternate" hreflang="de" href="/de/page">
+				<link rel="alternate" hreflang="en-US" href="/page#top">
+				</head><body>` + english + `</body></html>`,
+			want: &Languages{Hreflang: "en-US", Detected: "en"},
+		},
+		{
+			name:     "text in another language",
+			html:     `<html lang="en"><body>` + german + `</body></html>`,
+			want:     &Languages{Detected: "de", Mismatch: true},
+			evidence: []string{"lang=en", "detected=de"},
+		},
+		{
+			name:     "hreflang disagrees with lang",
+			html:     `<html lang="en-GB"><head><link rel="alternate" hreflang="fr" href="https://EXAMPLE.com:443/page"></head><body><p>Hello</p></body></html>`,
+			want:     &Languages{Hreflang: "fr", Mismatch: true},
+			evidence: []string{"lang=en-GB", "hreflang=fr"},
+		},
+		{
+			name: "japanese",
+			html: `<html lang="ja"><body>` + japanese + `<script>var the = "and the of the to the";</script></body></html>`,
+			want: &Languages{Detected: "ja"},
+		},
+		{
+			name: "nothing known",
+			html: `<html><body><p>Hello</p></body></html>`,
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, tc := range testCases {
+		for _, threshold := range []int64{0, 16} {
+			t.Run(fmt.Sprintf("%s/threshold %d", tc.name, threshold), func(t *testing.T) {
+				analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+				result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(tc.html), "https://example.com/page", IncludeSections(SectionSEO))
+				if err != nil {
+					t.Fatalf("AnalyzeHTML failed: %v", err)
+				}
+				if !reflect.DeepEqual(result.SEO.Languages, tc.want) {
+					t.Errorf("Languages = %+v, want %+v", result.SEO.Languages, tc.want)
+				}
+				var evidence []string
+				for _, finding := range result.Findings {
+					if finding.ID == "language-mismatch" {
+						evidence = finding.Evidence
+					}
+				}
+				if !reflect.DeepEqual(evidence, tc.evidence) {
+					t.Errorf("language-mismatch evidence = %q, want %q", evidence, tc.evidence)
+				}
+			})
+		}
+	}
+}
+
+func TestAnalyzeHTML_Frames(t *testing.T) {
+	page := `<!DOCTYPE html PUBLIC "-//W3C//DTD HTML 4.01 Frameset//EN" "http://www.w3.org/TR/html4/frameset.dtd">
+<html><head><title>Frames</title></head>
+<frameset cols="20%,80%">
+	<frame src="nav.html" name="nav">
+	<frameset rows="50%,50%">
+		<frame src="/main.html">
+		<frame>
+	</frameset>
+	<noframes><p>Your browser does not support frames.</p></noframes>
+</frameset>
+</html>`
+	want := &Frames{
+		Frameset: true,
+		Sources:  []string{"https://example.com/site/nav.html", "https://example.com/main.html"},
+		Noframes: true,
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, threshold := range []int64{0, 16} {
+		analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+		result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), "https://example.com/site/")
+		if err != nil {
+			t.Fatalf("AnalyzeHTML failed: %v", err)
+		}
+		if !reflect.DeepEqual(result.Frames, want) {
+			t.Errorf("Threshold %d: Frames = %+v, want %+v", threshold, result.Frames, want)
+		}
+		i := slices.IndexFunc(result.Findings, func(f Finding) bool { return f.ID == "legacy-frames" })
+		if i < 0 {
+			t.Errorf("Threshold %d: expected a legacy-frames finding, got %+v", threshold, result.Findings)
+		} else if !reflect.DeepEqual(result.Findings[i].Evidence, want.Sources) {
+			t.Errorf("Threshold %d: evidence = %q, want %q", threshold, result.Findings[i].Evidence, want.Sources)
+		}
+	}
+
+	analyzer := NewWithOptions(WithLogger(logger))
+	result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(`<html><body><iframe src="/embed"></iframe><noframes>No frames</noframes></body></html>`), "https://example.com/")
+	if err != nil {
+		t.Fatalf("AnalyzeHTML failed: %v", err)
+	}
+	if result.Frames != nil {
+		t.Errorf("Expected no frames for a page with an iframe, got %+v", result.Frames)
+	}
+}
+
+func TestAnalyzeHTML_Phishing(t *testing.T) {
+	login := `<form action="%s"><input name="email"><input type="password" name="pass"></form>`
+	testCases := []struct {
+		name    string
+		baseURL string
+		html    string
+		score   int
+		risk    string
+		signals []string
+	}{
+		{
+			name:    "own login",
+			baseURL: "https://www.paypal.com/signin",
+			html:    `<html><head><title>Log in to your PayPal account</title></head><body>` + fmt.Sprintf(login, "/signin") + `</body></html>`,
+			risk:    PhishingLow,
+		},
+		{
+			name:    "impersonated login",
+			baseURL: "https://paypal-secure.example.net/",
+			html:    `<html><head><title>PayPal: Log in</title></head><body>` + fmt.Sprintf(login, "https://collect.example.org/p.php") + fmt.Sprintf(login, "https://other.example.org/") + `</body></html>`,
+			score:   70,
+			risk:    PhishingHigh,
+			signals: []string{SignalCrossOriginLogin, SignalBrandMismatch},
+		},
+		{
+			name:    "data form on punycode host",
+			baseURL: "https://xn--pypal-4ve.com/",
+			html:    `<html><head><title>Verify</title></head><body><form action="data:text/html;base64,PGgxPk9rPC9oMT4="><input type="text"></form></body></html>`,
+			score:   50,
+			risk:    PhishingMedium,
+			signals: []string{SignalDataURIForm, SignalPunycodeHost},
+		},
+		{
+			name:    "brand in another word",
+			baseURL: "https://blog.example.com/",
+			html:    `<html><head><title>Chasers and Appleton news</title></head><body><form><input type="password"></form></body></html>`,
+			risk:    PhishingLow,
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, tc := range testCases {
+		for _, threshold := range []int64{0, 16} {
+			analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+			result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(tc.html), tc.baseURL, IncludeSections(SectionPhishing))
+			if err != nil {
+				t.Fatalf("%s: AnalyzeHTML failed: %v", tc.name, err)
+			}
+			phishing := result.Phishing
+			var ids []string
+			for _, signal := range phishing.Signals {
+				ids = append(ids, signal.ID)
+			}
+			if phishing.Score != tc.score || phishing.Risk != tc.risk || !slices.Equal(ids, tc.signals) {
+				t.Errorf("%s, threshold %d: phishing = %+v, want score %d, risk %s, signals %v", tc.name, threshold, phishing, tc.score, tc.risk, tc.signals)
+			}
+			hasFinding := slices.ContainsFunc(result.Findings, func(f Finding) bool { return f.ID == "phishing-risk" })
+			if hasFinding != (tc.risk != PhishingLow) {
+				t.Errorf("%s, threshold %d: phishing-risk finding = %t", tc.name, threshold, hasFinding)
+			}
+		}
+	}
+
+	analyzer := NewWithOptions(WithLogger(logger))
+	result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(testCases[1].html), testCases[1].baseURL)
+	if err != nil {
+		t.Fatalf("AnalyzeHTML failed: %v", err)
+	}
+	if result.Phishing != nil {
+		t.Errorf("Expected no phishing section unless requested, got %+v", result.Phishing)
+	}
+}
+
+func TestIDNHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want IDNHost
+		ok   bool
+	}{
+		{"xn--mnchen-3ya.de", IDNHost{ASCII: "xn--mnchen-3ya.de", Unicode: "münchen.de", Scripts: []string{"Latin"}}, true},
+		{"MÜNCHEN.de.", IDNHost{ASCII: "xn--mnchen-3ya.de", Unicode: "münchen.de", Scripts: []string{"Latin"}}, true},
+		{"аpple.com", IDNHost{ASCII: "xn--pple-43d.com", Unicode: "аpple.com", Scripts: []string{"Cyrillic", "Latin"}, Homograph: true}, true},
+		{"例え.jp", IDNHost{ASCII: "xn--r8jz45g.jp", Unicode: "例え.jp", Scripts: []string{"Han", "Hiragana", "Latin"}}, true},
+		{"пример.рф", IDNHost{ASCII: "xn--e1afmkfd.xn--p1ai", Unicode: "пример.рф", Scripts: []string{"Cyrillic"}}, true},
+		{"example.com", IDNHost{}, false},
+		{"xn--zz.com", IDNHost{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := idnHost(tt.host)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("idnHost(%q) = %+v, %t, want %+v, %t", tt.host, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAnalyzeHTML_IDN(t *testing.T) {
+	page := `<html><body>
+		<a href="/home">Home</a>
+		<a href="https://münchen.example/">München</a>
+		<a href="https://xn--mnchen-3ya.example/karte">Map</a>
+		<a href="https://www.еxample.example/">Look-alike</a>
+		<a href="https://ascii.example/">Plain</a>
+	</body></html>`
+	want := &IDN{
+		Host: &IDNHost{ASCII: "xn--pple-43d.example", Unicode: "аpple.example", Scripts: []string{"Cyrillic", "Latin"}, Homograph: true},
+		Links: []IDNHost{
+			{ASCII: "xn--mnchen-3ya.example", Unicode: "münchen.example", Scripts: []string{"Latin"}},
+			{ASCII: "www.xn--xample-2of.example", Unicode: "www.еxample.example", Scripts: []string{"Latin", "Cyrillic"}, Homograph: true},
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, threshold := range []int64{0, 16} {
+		analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+		result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), "https://xn--pple-43d.example/")
+		if err != nil {
+			t.Fatalf("AnalyzeHTML failed: %v", err)
+		}
+		if !reflect.DeepEqual(result.IDN, want) {
+			t.Errorf("Threshold %d: IDN = %+v, want %+v", threshold, result.IDN, want)
+		}
+		i := slices.IndexFunc(result.Findings, func(f Finding) bool { return f.ID == "idn-homograph" })
+		if i < 0 || !slices.Equal(result.Findings[i].Evidence, []string{"аpple.example (xn--pple-43d.example)", "www.еxample.example (www.xn--xample-2of.example)"}) {
+			t.Errorf("Threshold %d: findings = %+v, want idn-homograph for both look-alikes", threshold, result.Findings)
+		}
+	}
+}
+
+func TestAnalyzeHTML_ComplexityLimits(t *testing.T) {
+	deep := strings.Repeat("<div>", 40) + "Deep" + strings.Repeat("</div>", 40)
+	wide := strings.Repeat("<p>Para</p><br>", 30)
+
+	testCases := []struct {
+		name     string
+		html     string
+		maxNodes int
+		maxDepth int
+		wantErr  bool
+	}{
+		{"within limits", deep + wide, 200, 50, false},
+		{"too deep", deep, 0, 30, true},
+		{"too many nodes", wide, 50, 0, true},
+		{"limits disabled", deep + wide, 0, 0, false},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, tc := range testCases {
+		for _, threshold := range []int64{0, 16} {
+			analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold), WithMaxNodes(tc.maxNodes), WithMaxDepth(tc.maxDepth))
+			_, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader("<html><body>"+tc.html+"</body></html>"), "")
+			if gotErr := errors.Is(err, ErrTooComplex); gotErr != tc.wantErr {
+				t.Errorf("%s (threshold %d): error = %v, want ErrTooComplex %t", tc.name, threshold, err, tc.wantErr)
+			}
+		}
+	}
+}
+
+func TestAnalyzeSite(t *testing.T) {
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp := &http.Response{Header: make(http.Header), Body: io.NopCloser(strings.NewReader("")), Request: req}
+		switch req.URL.String() {
+		case "https://example.com/", "http://example.com/":
+			resp.StatusCode = http.StatusMovedPermanently
+			resp.Header.Set("Location", "https://www.example.com/")
+		case "https://www.example.com/":
+			resp.StatusCode = http.StatusOK
+			resp.Header.Set("Content-Type", "text/html")
+			resp.Body = io.NopCloser(strings.NewReader("<html><title>Home</title></html>"))
+		default:
+			return nil, errors.New("connection refused")
+		}
+		return resp, nil
+	})
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	analyzer := NewWithOptions(WithLogger(logger), WithTransport(transport))
+
+	report, err := analyzer.AnalyzeSite(context.Background(), "https://WWW.Example.com/some/page")
+	if err != nil {
+		t.Fatalf("AnalyzeSite failed: %v", err)
+	}
+
+	if report.Domain != "example.com" || report.CanonicalOrigin != "https://www.example.com" {
+		t.Errorf("Domain, CanonicalOrigin = %q, %q, want example.com, https://www.example.com", report.Domain, report.CanonicalOrigin)
+	}
+	want := []SiteVariant{
+		{URL: "https://example.com/", Responds: true, Status: 301, Redirects: []string{"https://www.example.com/"}, FinalURL: "https://www.example.com/", FinalStatus: 200},
+		{URL: "https://www.example.com/", Responds: true, Status: 200, FinalURL: "https://www.example.com/", FinalStatus: 200},
+		{URL: "http://example.com/", Responds: true, Status: 301, Redirects: []string{"https://www.example.com/"}, FinalURL: "https://www.example.com/", FinalStatus: 200},
+		{URL: "http://www.example.com/"},
+	}
+	for i, variant := range report.Variants {
+		variant.Error = strings.TrimPrefix(variant.Error, `Get "http://www.example.com/": `)
+		if i == 3 && variant.Error != "connection refused" {
+			t.Errorf("Variant %s error = %q, want connection refused", variant.URL, variant.Error)
+		}
+		variant.Error = ""
+		if !reflect.DeepEqual(variant, want[i]) {
+			t.Errorf("Variant %d = %+v, want %+v", i, variant, want[i])
+		}
+	}
+	if report.Result == nil || report.Result.Title != "Home" || report.Result.URL != "https://www.example.com/" {
+		t.Errorf("Result = %+v, want the analysis of https://www.example.com/", report.Result)
+	}
+
+	if _, err := analyzer.AnalyzeSite(context.Background(), "https:///"); !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("Expected ErrInvalidURL for a URL without a host, got %v", err)
+	}
+}
+
+func TestParseReferrerPolicy(t *testing.T) {
+	testCases := []struct {
+		value   string
+		policy  string
+		unknown []string
+		issues  int
+	}{
+		{"no-referrer", "no-referrer", nil, 0},
+		{"Unsafe-URL", "unsafe-url", nil, 1},
+		{"no-referrer, strict-origin-when-cross-origin", "strict-origin-when-cross-origin", nil, 0},
+		{"unsafe-url, same-origin-ish", "unsafe-url", []string{"same-origin-ish"}, 1},
+		{"no-referrer-when-downgrade", "no-referrer-when-downgrade", nil, 1},
+		{"bogus", "", []string{"bogus"}, 1},
+	}
+
+	for _, tc := range testCases {
+		policy := parseReferrerPolicy(tc.value)
+		if policy.Policy != tc.policy || !slices.Equal(policy.Unknown, tc.unknown) || len(policy.Issues) != tc.issues {
+			t.Errorf("parseReferrerPolicy(%q) = %+v, want policy %q, unknown %v and %d issues", tc.value, policy, tc.policy, tc.unknown, tc.issues)
+		}
+	}
+}
+
+func TestParsePermissionsPolicy(t *testing.T) {
+	policy := parsePermissionsPolicy(`camera=(), geolocation=(self "https://maps.example.com");report-to=default, microphone=*, fullscreen=self, usb=(*), camera=(self), bad, payment=(nope)`)
+
+	want := []PermissionsFeature{
+		{Name: "camera", Allowlist: []string{"self"}},
+		{Name: "geolocation", Allowlist: []string{"self", "https://maps.example.com"}},
+		{Name: "microphone", Allowlist: []string{"*"}},
+		{Name: "fullscreen", Allowlist: []string{"self"}},
+		{Name: "usb", Allowlist: []string{"*"}},
+	}
+	if !reflect.DeepEqual(policy.Features, want) {
+		t.Errorf("Expected features %+v, got %+v", want, policy.Features)
+	}
+
+	wantIssues := []string{
+		`could not parse "bad"`,
+		`could not parse "payment=(nope)"`,
+		"microphone is allowed for every origin",
+		"usb is allowed for every origin",
+	}
+	if !slices.Equal(policy.Issues, wantIssues) {
+		t.Errorf("Expected issues %q, got %q", wantIssues, policy.Issues)
+	}
+
+	if disabled := parsePermissionsPolicy("interest-cohort=()"); len(disabled.Features) != 1 || disabled.Features[0].Allowlist == nil || len(disabled.Issues) != 0 {
+		t.Errorf("Expected a disabled feature with an empty allowlist, got %+v", disabled)
+	}
+}
+
+func TestInspectCaching(t *testing.T) {
+	testCases := []struct {
+		header       http.Header
+		cacheability string
+		maxAge       string
+		issues       int
+	}{
+		{http.Header{"Cache-Control": {"no-store, max-age=600"}}, CacheNone, "600", 0},
+		{http.Header{"Cache-Control": {"Private, max-age=60"}, "Etag": {`"v1"`}}, CachePrivate, "60", 0},
+		{http.Header{"Cache-Control": {"no-cache"}, "Last-Modified": {"Tue, 01 Oct 2024 10:00:00 GMT"}}, CacheRevalidate, "<nil>", 0},
+		{http.Header{"Cache-Control": {"max-age=0, s-maxage=300"}, "Etag": {`"v1"`}}, CacheShared, "0", 0},
+		{http.Header{"Cache-Control": {"max-age=300, s-maxage=0"}, "Etag": {`"v1"`}}, CacheRevalidate, "300", 0},
+		{http.Header{"Cache-Control": {`max-age="bogus"`}, "Etag": {`"v1"`}}, CacheRevalidate, "0", 0},
+		{http.Header{"Cache-Control": {"public"}, "Vary": {"Accept-Encoding", "*"}}, CacheShared, "<nil>", 2},
+		{http.Header{"Expires": {"Tue, 01 Oct 2024 10:00:00 GMT"}, "Etag": {`"v1"`}}, CacheShared, "<nil>", 0},
+		{http.Header{}, CacheHeuristic, "<nil>", 1},
+	}
+
+	for _, tc := range testCases {
+		caching := inspectCaching(tc.header)
+		maxAge := "<nil>"
+		if caching.MaxAge != nil {
+			maxAge = strconv.FormatInt(*caching.MaxAge, 10)
+		}
+		if caching.Cacheability != tc.cacheability || maxAge != tc.maxAge || len(caching.Issues) != tc.issues {
+			t.Errorf("inspectCaching(%v) = %+v with max-age %s, want %s, max-age %s and %d issues", tc.header, caching, maxAge, tc.cacheability, tc.maxAge, tc.issues)
+		}
+	}
+}
+
+func TestDetectCDN(t *testing.T) {
+	testCases := []struct {
+		header http.Header
+		want   *CDN
+	}{
+		{http.Header{"Cf-Ray": {"8a1b2c3d4e5f-AMS"}, "Server": {"cloudflare"}, "Cf-Cache-Status": {"HIT"}}, &CDN{Provider: "Cloudflare", Cache: "HIT", Evidence: []string{"Cf-Ray", "Server"}}},
+		{http.Header{"X-Served-By": {"cache-ams21042-AMS"}, "Via": {"1.1 varnish"}, "X-Cache": {"MISS"}}, &CDN{Provider: "Fastly", Cache: "MISS", Evidence: []string{"X-Served-By"}}},
+		{http.Header{"Via": {"1.1 abc.cloudfront.net (CloudFront)"}, "X-Cache": {"Hit from cloudfront"}}, &CDN{Provider: "Amazon CloudFront", Cache: "Hit from cloudfront", Evidence: []string{"Via"}}},
+		{http.Header{"X-Served-By": {"web-01"}, "Server": {"nginx"}}, nil},
+	}
+
+	for _, tc := range testCases {
+		if got := detectCDN(tc.header); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("detectCDN(%v) = %+v, want %+v", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestAnalyzeURL_SecurityPolicies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Referrer-Policy", "unsafe-url")
+		w.Header().Add("Permissions-Policy", "camera=()")
+		w.Header().Add("Permissions-Policy", "geolocation=*")
+		fmt.Fprint(w, `<html><head><title>Policies</title></head></html>`)
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	analyzer := NewWithOptions(WithLogger(logger))
+
+	result, err := analyzer.AnalyzeURL(context.Background(), server.URL, IncludeSections(SectionSecurity))
+	if err != nil {
+		t.Fatalf("AnalyzeURL failed: %v", err)
+	}
+
+	security := result.Security
+	if security.ReferrerPolicy == nil || security.ReferrerPolicy.Policy != "unsafe-url" || len(security.ReferrerPolicy.Issues) != 1 {
+		t.Errorf("Expected a flagged unsafe-url referrer policy, got %+v", security.ReferrerPolicy)
+	}
+	if security.PermissionsPolicy == nil || len(security.PermissionsPolicy.Features) != 2 || !slices.Equal(security.PermissionsPolicy.Issues, []string{"geolocation is allowed for every origin"}) {
+		t.Errorf("Expected both Permissions-Policy headers with geolocation flagged, got %+v", security.PermissionsPolicy)
+	}
+}
+
+func TestAnalyzeHTML_OpenRedirects(t *testing.T) {
+	page := `<html><body>
+		<a href="/login?next=https%3A%2F%2Fevil.example%2F&amp;lang=en">Log in</a>
+		<a href="/login?next=https%3A%2F%2Fevil.example%2F&amp;lang=en">Again</a>
+		<a href="https://example.com/out?to=//cdn.example.net/x&amp;back=HTTP://example.com/">Out</a>
+		<a href="/search?q=http">Not a URL</a>
+		<a href="https://share.example/?u=https://example.com/">Other host</a>
+		<map><area href="/go?url=https://partner.example/"></map>
+		<form action="/redirect?return_to=https://example.com/done" method="post"></form>
+	</body></html>`
+	want := []RedirectCandidate{
+		{Element: "a", URL: "https://example.com/login?next=https%3A%2F%2Fevil.example%2F&lang=en", Parameter: "next", Target: "https://evil.example/"},
+		{Element: "a", URL: "https://example.com/out?to=//cdn.example.net/x&back=HTTP://example.com/", Parameter: "back", Target: "HTTP://example.com/"},
+		{Element: "a", URL: "https://example.com/out?to=//cdn.example.net/x&back=HTTP://example.com/", Parameter: "to", Target: "//cdn.example.net/x"},
+		{Element: "area", URL: "https://example.com/go?url=https://partner.example/", Parameter: "url", Target: "https://partner.example/"},
+		{Element: "form", URL: "https://example.com/redirect?return_to=https://example.com/done", Parameter: "return_to", Target: "https://example.com/done"},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, threshold := range []int64{0, 16} {
+		analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+		result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), "https://example.com/", IncludeSections(SectionSecurity))
+		if err != nil {
+			t.Fatalf("AnalyzeHTML failed: %v", err)
+		}
+		if !reflect.DeepEqual(result.Security.OpenRedirectCandidates, want) {
+			t.Errorf("Threshold %d: OpenRedirectCandidates = %+v, want %+v", threshold, result.Security.OpenRedirectCandidates, want)
+		}
+	}
+}
+
+func TestAnalyzeHTML_Findings(t *testing.T) {
+	page := `<html><head><title>One</title><title>Two</title></head><body>
+		<img src="/a.png"><img src="/b.png" alt="">
+		<table><tr><td>a</td><td>b</td></tr><tr><td>c</td><td>d</td></tr></table>
+		<a href="/login?next=https://evil.example/">Log in</a>
+		<a href="/missing">Gone</a>
+	</body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	want := []Finding{
+		{ID: "multiple-titles", Severity: SeverityWarning, Category: CategorySEO, Message: "page has 2 title elements"},
+		{ID: "h1-count", Severity: SeverityWarning, Category: CategorySEO, Message: "expected exactly one h1 heading, found 0"},
+		{ID: "missing-lang", Severity: SeverityWarning, Category: CategorySEO, Message: "html element has no lang attribute"},
+		{ID: "missing-meta-description", Severity: SeverityWarning, Category: CategorySEO, Message: "page has no meta description"},
+		{ID: "missing-canonical", Severity: SeverityInfo, Category: CategorySEO, Message: "page has no canonical link"},
+		{ID: "broken-links", Severity: SeverityError, Category: CategoryLinks, Message: "1 inaccessible link"},
+		{ID: "images-missing-alt", Severity: SeverityError, Category: CategoryAccessibility, Message: "1 of 2 images have no alt attribute"},
+		{ID: "table-missing-headers", Severity: SeverityWarning, Category: CategoryAccessibility, Message: "data tables have no header cells", Evidence: []string{"table 1"}},
+		{ID: "open-redirect-candidates", Severity: SeverityWarning, Category: CategorySecurity, Message: "links pass absolute URLs in their query", Evidence: []string{server.URL + "/login?next=https://evil.example/"}},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, threshold := range []int64{0, 16} {
+		analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+		result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), server.URL+"/",
+			IncludeSections(SectionSEO, SectionAccessibility, SectionSecurity))
+		if err != nil {
+			t.Fatalf("AnalyzeHTML failed: %v", err)
+		}
+		if !reflect.DeepEqual(result.Findings, want) {
+			t.Errorf("Threshold %d: Findings = %+v, want %+v", threshold, result.Findings, want)
+		}
+	}
+}
+
+func TestAnalyzeHTML_Rules(t *testing.T) {
+	page := `<html><head><title>Welcome - Acme</title></head><body>
+		<header><nav class="main top"><ul><li><a href="/">Home</a></li></ul></nav></header>
+		<main><p>Read the <a href="https://staging.acme.example/docs">docs</a>,
+		<a href="https://STAGING.acme.example/docs">again</a> and
+		<a href="https://acme.example/blog">blog</a>.<img src="/x.png"></p></main>
+	</body></html>`
+	rules := []Rule{
+		{ID: "home-link", Selector: "header nav.main a[href='/']"},
+		{ID: "direct-child", Selector: "nav > a"},
+		{ID: "footer", Selector: "footer, div#footer", Severity: SeverityError, Message: "page has no footer"},
+		{ID: "void-element", Selector: "p > img[src$='.png']"},
+		{ID: "brand-title", TitleMatches: `\| Acme$`},
+		{ID: "any-title", TitleMatches: `\S`},
+		{ID: "no-staging-links", NoLinksTo: "acme.example."},
+		{ID: "no-other-links", NoLinksTo: "other.example"},
+	}
+	want := []Finding{
+		{ID: "direct-child", Severity: SeverityWarning, Category: CategoryCustom, Message: "no element matches nav > a"},
+		{ID: "footer", Severity: SeverityError, Category: CategoryCustom, Message: "page has no footer"},
+		{ID: "brand-title", Severity: SeverityWarning, Category: CategoryCustom, Message: `title does not match \| Acme$`, Evidence: []string{"Welcome - Acme"}},
+		{ID: "no-staging-links", Severity: SeverityWarning, Category: CategoryCustom, Message: "page links to acme.example", Evidence: []string{
+			"https://staging.acme.example/docs", "https://STAGING.acme.example/docs", "https://acme.example/blog",
+		}},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, threshold := range []int64{0, 16} {
+		cfg := defaultConfig
+		cfg.StreamingThreshold = threshold
+		cfg.Rules = rules
+		analyzer := NewWithOptions(WithLogger(logger), WithConfig(cfg))
+		result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), "")
+		if err != nil {
+			t.Fatalf("AnalyzeHTML failed: %v", err)
+		}
+
+		var custom []Finding
+		for _, finding := range result.Findings {
+			if finding.Category == CategoryCustom {
+				custom = append(custom, finding)
+			}
+		}
+		if !reflect.DeepEqual(custom, want) {
+			t.Errorf("Threshold %d: custom findings = %+v, want %+v", threshold, custom, want)
+		}
+	}
+}
+
+func TestAnalyzeHTML_Extract(t *testing.T) {
+	page := `<html><head><title>Prices</title></head><body>
+		<div class="card"><h2>Basic</h2> <span class="price">$5</span>
+			<div class="card"><h2>Nested</h2></div>
+		</div>
+		<div class="card"><h2>Pro</h2>  <span class="price">$20
+			/ month</span><img src="/pro.png" alt="Pro"></div>
+		<ul>` + strings.Repeat("<li>item</li>", MaxExtractMatches+1) + `</ul>
+	</body></html>`
+	want := []Extraction{
+		{Selector: ".card", Count: 3, Matches: []string{"Basic $5 Nested", "Nested", "Pro $20 / month"}},
+		{Selector: "div.card > h2, title", Count: 4, Matches: []string{"Prices", "Basic", "Nested", "Pro"}},
+		{Selector: "img[alt]", Count: 1, Matches: []string{""}},
+		{Selector: "table", Count: 0, Matches: []string{}},
+		{Selector: "li", Count: MaxExtractMatches + 1, Matches: slices.Repeat([]string{"item"}, MaxExtractMatches), Truncated: true},
+	}
+	selectors := make([]string, len(want))
+	for i, extraction := range want {
+		selectors[i] = extraction.Selector
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, threshold := range []int64{0, 16} {
+		analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+		result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), "", Extract(selectors...))
+		if err != nil {
+			t.Fatalf("AnalyzeHTML failed: %v", err)
+		}
+
+		for i := range result.Extractions {
+			result.Extractions[i].selector = nil
+		}
+		if !reflect.DeepEqual(result.Extractions, want) {
+			t.Errorf("Threshold %d: extractions = %+v, want %+v", threshold, result.Extractions, want)
+		}
+	}
+
+	analyzer := NewWithOptions(WithLogger(logger))
+	_, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(page), "", Extract("a::before"))
+	if !errors.Is(err, ErrInvalidSelector) {
+		t.Errorf("Expected ErrInvalidSelector, got %v", err)
+	}
+}
+
+func TestAnalyzeHTML_Parking(t *testing.T) {
+	testCases := []struct {
+		name string
+		html string
+		want *Parking
+	}{
+		{
+			name: "parking service",
+			html: `<html><head><title>example.com</title><script src="//img.sedoparking.com/js/park.js"></script></head><body><a href="/buy">Buy this domain</a><iframe src="https://www.sedoparking.com/frmpark/example.com"></iframe></body></html>`,
+			want: &Parking{Kind: ParkingParked, Signals: []string{"points to sedoparking.com", `says "buy this domain"`}},
+		},
+		{
+			name: "for sale",
+			html: `<html><body><h1>This domain name is  for sale!</h1><p>Make an offer on this domain today.</p></body></html>`,
+			want: &Parking{Kind: ParkingParked, Signals: []string{`says "domain name is for sale"`, `says "make an offer on this domain"`}},
+		},
+		{
+			name: "coming soon",
+			html: `<html><head><style>.coming-soon { color: red }</style></head><body><h1>Coming Soon</h1><p>Our new website is on its way.</p></body></html>`,
+			want: &Parking{Kind: ParkingComingSoon, Signals: []string{`says "coming soon"`, `says "site is on its way"`}},
+		},
+		{
+			name: "hidden text",
+			html: `<html><body><p>Welcome</p><script>var banner = "coming soon";</script><noscript>Under construction</noscript></body></html>`,
+		},
+		{
+			name: "long page",
+			html: `<html><body><h1>Coming soon</h1><p>` + strings.Repeat("Plenty of real content here. ", 100) + `</p></body></html>`,
+		},
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	for _, tc := range testCases {
+		for _, threshold := range []int64{0, 16} {
+			analyzer := NewWithOptions(WithLogger(logger), WithStreamingThreshold(threshold))
+			result, err := analyzer.AnalyzeHTML(context.Background(), strings.NewReader(tc.html), "https://example.com/")
+			if err != nil {
+				t.Fatalf("%s: AnalyzeHTML failed: %v", tc.name, err)
+			}
+			if !reflect.DeepEqual(result.Parking, tc.want) {
+				t.Errorf("%s, threshold %d: parking = %+v, want %+v", tc.name, threshold, result.Parking, tc.want)
+			}
+		}
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		rule Rule
+		want string
+	}{
+		{Rule{ID: "ok", Selector: "div.a > span[lang|=en]"}, `rule "ok": selector "div.a > span[lang|=en]": unsupported attribute selector [lang|=en]`},
+		{Rule{Selector: "div"}, "rule 1: id is required"},
+		{Rule{ID: "pseudo", Selector: "a:hover"}, `rule "pseudo": selector "a:hover": unsupported ":hover"`},
+		{Rule{ID: "combinator", Selector: "div >"}, `rule "combinator": selector "div >" ends with >`},
+		{Rule{ID: "regexp", TitleMatches: "("}, `rule "regexp": title_matches: error parsing regexp: missing closing ): ` + "`(`"},
+		{Rule{ID: "url", NoLinksTo: "https://example.com"}, `rule "url": no_links_to must be a domain, got "https://example.com"`},
+		{Rule{ID: "two", Selector: "div", NoLinksTo: "example.com"}, `rule "two": exactly one of selector, title_matches and no_links_to must be set`},
+		{Rule{ID: "severity", Selector: "div", Severity: "fatal"}, `rule "severity": unknown severity "fatal"`},
+	}
+	for _, tt := range tests {
+		err := ValidateRules([]Rule{tt.rule})
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ValidateRules(%+v) = %v, want %s", tt.rule, err, tt.want)
+		}
+	}
+
+	if err := ValidateRules([]Rule{{ID: "ok", Selector: "*[data-x] .a, #b"}}); err != nil {
+		t.Errorf("ValidateRules of a valid rule = %v", err)
+	}
+}
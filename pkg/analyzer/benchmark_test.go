@@ -126,7 +126,7 @@ func BenchmarkCheckLinksAccessibility_SmallSet(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		analyzer.checkLinksAccessibility(ctx, links)
+		analyzer.checkLinksAccessibility(ctx, links, nil)
 	}
 }
 
@@ -148,7 +148,7 @@ func BenchmarkCheckLinksAccessibility_LargeSet(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		analyzer.checkLinksAccessibility(ctx, links)
+		analyzer.checkLinksAccessibility(ctx, links, nil)
 	}
 }
 
@@ -187,6 +187,6 @@ func BenchmarkExtractLinks(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		analyzer.extractLinks(doc, baseURL)
+		analyzer.extractLinks(context.Background(), doc, baseURL)
 	}
 }
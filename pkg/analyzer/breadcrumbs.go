@@ -0,0 +1,410 @@
+package analyzer
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Breadcrumb trail sources, in order of preference
+const (
+	breadcrumbJSONLD    = "json-ld"
+	breadcrumbMicrodata = "microdata"
+	breadcrumbNav       = "nav"
+	breadcrumbClass     = "class"
+)
+
+var breadcrumbSources = []string{breadcrumbJSONLD, breadcrumbMicrodata, breadcrumbNav, breadcrumbClass}
+
+// Breadcrumbs is the page's breadcrumb trail. Source tells how it was
+// found: "json-ld" or "microdata" for a schema.org BreadcrumbList, "nav" for
+// a nav labelled as breadcrumbs and "class" for an element with a
+// breadcrumb class. Structured data wins when a page has several trails.
+type Breadcrumbs struct {
+	Source string           `json:"source"`
+	Items  []BreadcrumbItem `json:"items"`
+}
+
+// BreadcrumbItem is a step of a breadcrumb trail. URL is resolved against
+// the page and empty for steps without a link, usually the current page.
+type BreadcrumbItem struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// voidElements have no end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// crumbRole is what an open element inside a markup trail is collecting
+type crumbRole int
+
+const (
+	crumbNone crumbRole = iota
+	crumbRoot
+	crumbItem
+	crumbLink
+	crumbItemName
+)
+
+type crumbFrame struct {
+	tag  string
+	role crumbRole
+}
+
+// markupTrail is a microdata, nav or class trail being read. Its items are
+// its li elements, or itemListElement items for microdata, and links its a
+// elements, which are the steps of trails without list items.
+type markupTrail struct {
+	source       string
+	items, links []BreadcrumbItem
+	item, link   BreadcrumbItem
+	// Text is collected while the matching flag is set
+	inItem, inLink, inName       bool
+	itemText, linkText, nameText strings.Builder
+}
+
+// breadcrumbFinder finds breadcrumb trails in a sequence of start tags,
+// text and end tags, so the DOM and streaming analyses share it. Its
+// methods do nothing on a nil finder.
+type breadcrumbFinder struct {
+	baseURL *url.URL
+	found   map[string]*Breadcrumbs
+	trail   *markupTrail
+	// stack holds the elements open inside trail
+	stack []crumbFrame
+	// jsonLD collects the text of an open JSON-LD script
+	jsonLD *strings.Builder
+}
+
+func newBreadcrumbFinder(baseURL *url.URL) *breadcrumbFinder {
+	return &breadcrumbFinder{baseURL: baseURL, found: make(map[string]*Breadcrumbs)}
+}
+
+// findBreadcrumbs returns the breadcrumb trail of a parsed document
+func findBreadcrumbs(doc *html.Node, baseURL *url.URL) *Breadcrumbs {
+	f := newBreadcrumbFinder(baseURL)
+	walkTags(doc, f.start, f.text, f.end)
+	return f.result()
+}
+
+// start handles a start tag; tag is lower case
+func (f *breadcrumbFinder) start(tag string, attrs []html.Attribute) {
+	if f == nil {
+		return
+	}
+	if tag == "script" {
+		if strings.EqualFold(strings.TrimSpace(attrValue(attrs, "type")), "application/ld+json") {
+			f.jsonLD = &strings.Builder{}
+		}
+		return
+	}
+
+	t := f.trail
+	if t == nil {
+		if source := breadcrumbSource(tag, attrs); source != "" && f.found[source] == nil && !voidElements[tag] {
+			f.trail = &markupTrail{source: source}
+			f.stack = []crumbFrame{{tag: tag, role: crumbRoot}}
+		}
+		return
+	}
+
+	frame := crumbFrame{tag: tag}
+	props := strings.Fields(attrValue(attrs, "itemprop"))
+	isItem := tag == "li"
+	if t.source == breadcrumbMicrodata {
+		isItem = slices.Contains(props, "itemListElement")
+	}
+
+	switch {
+	case isItem && !t.inItem:
+		t.inItem = true
+		t.item = BreadcrumbItem{}
+		t.itemText.Reset()
+		t.nameText.Reset()
+		frame.role = crumbItem
+	case tag == "a" && !t.inLink:
+		t.inLink = true
+		t.link = BreadcrumbItem{URL: f.resolve(attrValue(attrs, "href"))}
+		t.linkText.Reset()
+		frame.role = crumbLink
+	case t.inItem && !t.inName && slices.Contains(props, "name"):
+		if content, ok := attrContent(attrs); ok {
+			t.nameText.WriteString(content)
+		} else {
+			t.inName = true
+			frame.role = crumbItemName
+		}
+	}
+
+	if t.inItem && t.item.URL == "" {
+		if href := cmp.Or(attrValue(attrs, "href"), attrValue(attrs, "itemid")); href != "" {
+			t.item.URL = f.resolve(href)
+		}
+	}
+
+	if !voidElements[tag] {
+		f.stack = append(f.stack, frame)
+	}
+}
+
+// text handles a text token
+func (f *breadcrumbFinder) text(s string) {
+	if f == nil {
+		return
+	}
+	if f.jsonLD != nil {
+		f.jsonLD.WriteString(s)
+		return
+	}
+	if t := f.trail; t != nil {
+		if t.inItem {
+			t.itemText.WriteString(s)
+		}
+		if t.inLink {
+			t.linkText.WriteString(s)
+		}
+		if t.inName {
+			t.nameText.WriteString(s)
+		}
+	}
+}
+
+// end handles an end tag; tag is lower case
+func (f *breadcrumbFinder) end(tag string) {
+	if f == nil {
+		return
+	}
+	if tag == "script" && f.jsonLD != nil {
+		f.addJSONLD(f.jsonLD.String())
+		f.jsonLD = nil
+		return
+	}
+	if f.trail == nil || voidElements[tag] {
+		return
+	}
+
+	// Like the HTML parser, an end tag closes the elements opened after
+	// the matching start tag
+	for i := len(f.stack) - 1; i >= 0; i-- {
+		if f.stack[i].tag == tag {
+			f.close(len(f.stack) - i)
+			return
+		}
+	}
+}
+
+// close pops n elements off the stack, finishing what they collected
+func (f *breadcrumbFinder) close(n int) {
+	t := f.trail
+	for range n {
+		frame := f.stack[len(f.stack)-1]
+		f.stack = f.stack[:len(f.stack)-1]
+
+		switch frame.role {
+		case crumbItem:
+			t.inItem = false
+			text := t.itemText.String()
+			if t.source == breadcrumbMicrodata {
+				text = t.nameText.String()
+			}
+			if t.item.Name = crumbName(text); t.item.Name != "" || t.item.URL != "" {
+				t.items = append(t.items, t.item)
+			}
+		case crumbLink:
+			t.inLink = false
+			if t.link.Name = crumbName(t.linkText.String()); t.link.Name != "" {
+				t.links = append(t.links, t.link)
+			}
+		case crumbItemName:
+			t.inName = false
+		case crumbRoot:
+			items := t.items
+			if len(items) == 0 {
+				items = t.links
+			}
+			if len(items) > 0 {
+				f.found[t.source] = &Breadcrumbs{Source: t.source, Items: items}
+			}
+			f.trail = nil
+		}
+	}
+}
+
+// result returns the preferred trail found, or nil when there is none
+func (f *breadcrumbFinder) result() *Breadcrumbs {
+	if f == nil {
+		return nil
+	}
+	if f.trail != nil {
+		// The document ended inside a trail
+		f.close(len(f.stack))
+	}
+	for _, source := range breadcrumbSources {
+		if crumbs := f.found[source]; crumbs != nil {
+			return crumbs
+		}
+	}
+	return nil
+}
+
+// addJSONLD records the first BreadcrumbList in a JSON-LD script
+func (f *breadcrumbFinder) addJSONLD(data string) {
+	if f.found[breadcrumbJSONLD] != nil {
+		return
+	}
+	var doc any
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		return
+	}
+	list := findBreadcrumbList(doc)
+	if list == nil {
+		return
+	}
+
+	elements, ok := list["itemListElement"].([]any)
+	if !ok {
+		elements = []any{list["itemListElement"]}
+	}
+	type step struct {
+		position float64
+		item     BreadcrumbItem
+	}
+	var steps []step
+	for _, element := range elements {
+		e, ok := element.(map[string]any)
+		if !ok {
+			continue
+		}
+		s := step{position: jsonNumber(e["position"])}
+		s.item.Name, _ = e["name"].(string)
+		switch item := e["item"].(type) {
+		case string:
+			s.item.URL = f.resolve(item)
+		case map[string]any:
+			if s.item.Name == "" {
+				s.item.Name, _ = item["name"].(string)
+			}
+			id, _ := item["@id"].(string)
+			link, _ := item["url"].(string)
+			s.item.URL = f.resolve(cmp.Or(id, link))
+		}
+		s.item.Name = crumbName(s.item.Name)
+		if s.item.Name != "" || s.item.URL != "" {
+			steps = append(steps, s)
+		}
+	}
+	if len(steps) == 0 {
+		return
+	}
+
+	slices.SortStableFunc(steps, func(a, b step) int { return cmp.Compare(a.position, b.position) })
+	crumbs := &Breadcrumbs{Source: breadcrumbJSONLD}
+	for _, s := range steps {
+		crumbs.Items = append(crumbs.Items, s.item)
+	}
+	f.found[breadcrumbJSONLD] = crumbs
+}
+
+// resolve resolves href against the page, returning "" when it is empty or
+// invalid
+func (f *breadcrumbFinder) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return ""
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return f.baseURL.ResolveReference(ref).String()
+}
+
+// breadcrumbSource returns the source of the trail an element starts, or ""
+// when it does not start one
+func breadcrumbSource(tag string, attrs []html.Attribute) string {
+	for _, itemType := range strings.Fields(attrValue(attrs, "itemtype")) {
+		if isBreadcrumbList(itemType) {
+			return breadcrumbMicrodata
+		}
+	}
+	if tag == "nav" && strings.Contains(strings.ToLower(attrValue(attrs, "aria-label")), "breadcrumb") {
+		return breadcrumbNav
+	}
+	for _, class := range strings.Fields(attrValue(attrs, "class")) {
+		if strings.Contains(strings.ToLower(class), "breadcrumb") {
+			return breadcrumbClass
+		}
+	}
+	return ""
+}
+
+// findBreadcrumbList returns the first schema.org BreadcrumbList in a
+// decoded JSON-LD document, looking through arrays and @graph
+func findBreadcrumbList(v any) map[string]any {
+	switch v := v.(type) {
+	case []any:
+		for _, e := range v {
+			if list := findBreadcrumbList(e); list != nil {
+				return list
+			}
+		}
+	case map[string]any:
+		switch t := v["@type"].(type) {
+		case string:
+			if isBreadcrumbList(t) {
+				return v
+			}
+		case []any:
+			for _, e := range t {
+				if s, ok := e.(string); ok && isBreadcrumbList(s) {
+					return v
+				}
+			}
+		}
+		return findBreadcrumbList(v["@graph"])
+	}
+	return nil
+}
+
+// isBreadcrumbList reports whether a schema.org type names BreadcrumbList
+func isBreadcrumbList(t string) bool {
+	return t == "BreadcrumbList" || strings.HasSuffix(t, "schema.org/BreadcrumbList")
+}
+
+// jsonNumber returns a JSON-LD number, which may be written as a string
+func jsonNumber(v any) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case string:
+		var n float64
+		if err := json.Unmarshal([]byte(v), &n); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// attrContent returns the content attribute, which microdata uses for
+// values that are not shown
+func attrContent(attrs []html.Attribute) (string, bool) {
+	for _, attr := range attrs {
+		if attr.Key == "content" {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// crumbName collapses whitespace in a step's text and trims the separators
+// that trails put between steps
+func crumbName(text string) string {
+	return strings.Trim(strings.Join(strings.Fields(text), " "), " /|>›»→·")
+}
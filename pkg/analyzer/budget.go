@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// withBudget derives a context that ends once budget has passed, or returns
+// ctx unchanged when budget is zero
+func withBudget(ctx context.Context, budget time.Duration) (context.Context, context.CancelFunc) {
+	if budget <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, budget)
+}
+
+// parseBudgetExceeded is the cause of a parse context whose budget ran out.
+// It still matches context.DeadlineExceeded, like other timeouts.
+var parseBudgetExceeded = fmt.Errorf("%w: %w", ErrParseTimeout, context.DeadlineExceeded)
+
+// budgetReader fails reads once ctx ends, with ctx's cause. Parsing only
+// stops to read, so this bounds the parse as well as the reads.
+type budgetReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// newBudgetReader returns r limited to budget from now, and the function
+// releasing its timer
+func newBudgetReader(ctx context.Context, r io.Reader, budget time.Duration) (io.Reader, context.CancelFunc) {
+	ctx, cancel := context.WithTimeoutCause(ctx, budget, parseBudgetExceeded)
+	return &budgetReader{ctx: ctx, r: r}, cancel
+}
+
+func (b *budgetReader) Read(p []byte) (int, error) {
+	if b.ctx.Err() != nil {
+		return 0, context.Cause(b.ctx)
+	}
+	return b.r.Read(p)
+}
@@ -0,0 +1,191 @@
+package analyzer
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Cacheability values, from least to most cacheable
+const (
+	// CacheNone means caches may not store the response (no-store)
+	CacheNone = "none"
+	// CachePrivate means only the browser may store it (private)
+	CachePrivate = "private"
+	// CacheRevalidate means caches must check with the server before each
+	// reuse (no-cache or a max-age of 0)
+	CacheRevalidate = "revalidate"
+	// CacheHeuristic means the response has no freshness information, so
+	// caches may guess a lifetime, e.g. from Last-Modified
+	CacheHeuristic = "heuristic"
+	// CacheShared means shared caches such as CDNs may store and reuse it
+	CacheShared = "shared"
+)
+
+// Caching describes how the page's response may be cached, from its
+// Cache-Control, Expires, ETag, Last-Modified, Vary and Age headers. MaxAge,
+// SMaxAge and Age are in seconds. Only fetched pages have one.
+type Caching struct {
+	Cacheability string   `json:"cacheability"`
+	CacheControl string   `json:"cache_control,omitempty"`
+	MaxAge       *int64   `json:"max_age,omitempty"`
+	SMaxAge      *int64   `json:"s_maxage,omitempty"`
+	ETag         string   `json:"etag,omitempty"`
+	LastModified string   `json:"last_modified,omitempty"`
+	Vary         []string `json:"vary,omitempty"`
+	// Age is how long the response had been in a cache, when one says so
+	Age    *int64   `json:"age,omitempty"`
+	Issues []string `json:"issues,omitempty"`
+}
+
+// CDN is the edge provider that served the page, detected from headers it
+// adds. Cache is its cache status, such as HIT or MISS, when it reports
+// one, and Evidence names the headers the provider was recognized by.
+type CDN struct {
+	Provider string   `json:"provider"`
+	Cache    string   `json:"cache,omitempty"`
+	Evidence []string `json:"evidence"`
+}
+
+// cdnSignature recognizes a provider by a header, and optionally a
+// case-insensitive substring of its value
+type cdnSignature struct {
+	provider, header, contains string
+}
+
+// cdnSignatures are checked in order, so providers that run on another's
+// software, like Fastly on Varnish, come first
+var cdnSignatures = []cdnSignature{
+	{"Cloudflare", "Cf-Ray", ""},
+	{"Cloudflare", "Server", "cloudflare"},
+	{"Fastly", "X-Fastly-Request-Id", ""},
+	{"Fastly", "X-Served-By", "cache-"},
+	{"Amazon CloudFront", "X-Amz-Cf-Id", ""},
+	{"Amazon CloudFront", "Via", "cloudfront"},
+	{"Akamai", "Akamai-Grn", ""},
+	{"Akamai", "X-Akamai-Transformed", ""},
+	{"Akamai", "Server", "akamaighost"},
+	{"Vercel", "X-Vercel-Id", ""},
+	{"Netlify", "X-Nf-Request-Id", ""},
+	{"Azure Front Door", "X-Azure-Ref", ""},
+	{"Bunny CDN", "Server", "bunnycdn"},
+	{"KeyCDN", "Server", "keycdn"},
+	{"Google Cloud CDN", "Via", "google"},
+	{"Varnish", "X-Varnish", ""},
+	{"Varnish", "Via", "varnish"},
+}
+
+// cdnCacheHeaders report an edge cache's status, in order of preference
+var cdnCacheHeaders = []string{"Cf-Cache-Status", "X-Vercel-Cache", "Cdn-Cache", "X-Cache-Status", "X-Cache"}
+
+// inspectCaching parses the response's caching headers
+func inspectCaching(header http.Header) *Caching {
+	caching := &Caching{
+		CacheControl: strings.Join(header.Values("Cache-Control"), ", "),
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+	for _, value := range header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				caching.Vary = append(caching.Vary, field)
+			}
+		}
+	}
+	if age, err := strconv.ParseInt(strings.TrimSpace(header.Get("Age")), 10, 64); err == nil {
+		caching.Age = &age
+	}
+
+	directives := parseCacheControl(caching.CacheControl)
+	seconds := func(name string) *int64 {
+		value, ok := directives[name]
+		if !ok {
+			return nil
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			// Invalid lifetimes make the response stale
+			n = 0
+		}
+		return &n
+	}
+	caching.MaxAge = seconds("max-age")
+	caching.SMaxAge = seconds("s-maxage")
+
+	_, noStore := directives["no-store"]
+	_, private := directives["private"]
+	_, noCache := directives["no-cache"]
+	_, public := directives["public"]
+	lifetime := caching.SMaxAge
+	if lifetime == nil {
+		lifetime = caching.MaxAge
+	}
+	switch {
+	case noStore:
+		caching.Cacheability = CacheNone
+	case private:
+		caching.Cacheability = CachePrivate
+	case noCache, lifetime != nil && *lifetime == 0:
+		caching.Cacheability = CacheRevalidate
+	case lifetime != nil, public, header.Get("Expires") != "":
+		caching.Cacheability = CacheShared
+	default:
+		caching.Cacheability = CacheHeuristic
+	}
+
+	if slices.Contains(caching.Vary, "*") {
+		caching.Issues = append(caching.Issues, "Vary: * keeps caches from reusing the response")
+	}
+	if caching.Cacheability != CacheNone && caching.ETag == "" && caching.LastModified == "" {
+		caching.Issues = append(caching.Issues, "no ETag or Last-Modified, so caches cannot revalidate the response")
+	}
+	return caching
+}
+
+// parseCacheControl returns the Cache-Control directives by lower case
+// name, with quotes removed from their values
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, directive := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if name = strings.ToLower(strings.TrimSpace(name)); name == "" {
+			continue
+		}
+		if _, seen := directives[name]; !seen {
+			directives[name] = strings.Trim(strings.TrimSpace(arg), `"`)
+		}
+	}
+	return directives
+}
+
+// detectCDN returns the edge provider that served the response, or nil
+// when no provider's headers are present
+func detectCDN(header http.Header) *CDN {
+	var cdn *CDN
+	for _, signature := range cdnSignatures {
+		if cdn != nil && signature.provider != cdn.Provider {
+			continue
+		}
+		value := header.Get(signature.header)
+		if value == "" || !strings.Contains(strings.ToLower(value), signature.contains) {
+			continue
+		}
+		if cdn == nil {
+			cdn = &CDN{Provider: signature.provider}
+		}
+		if !slices.Contains(cdn.Evidence, signature.header) {
+			cdn.Evidence = append(cdn.Evidence, signature.header)
+		}
+	}
+	if cdn == nil {
+		return nil
+	}
+	for _, name := range cdnCacheHeaders {
+		if value := header.Get(name); value != "" {
+			cdn.Cache = value
+			break
+		}
+	}
+	return cdn
+}
@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"golang.org/x/net/html"
+)
+
+// complexityCounter enforces MaxNodes and MaxDepth while a document is
+// streamed. Depth counts the start tags not closed yet, so unclosed
+// elements that the HTML parser would close implicitly count as nested.
+type complexityCounter struct {
+	maxNodes, maxDepth int
+	nodes, depth       int
+}
+
+// start counts an element; selfClosing elements do not nest
+func (c *complexityCounter) start(tag string, selfClosing bool) error {
+	c.nodes++
+	if c.maxNodes > 0 && c.nodes > c.maxNodes {
+		return tooManyNodes(c.maxNodes)
+	}
+	if selfClosing || voidElements[tag] {
+		return nil
+	}
+	c.depth++
+	if c.maxDepth > 0 && c.depth > c.maxDepth {
+		return tooDeep(c.maxDepth)
+	}
+	return nil
+}
+
+// end closes an element
+func (c *complexityCounter) end(tag string) {
+	if !voidElements[tag] {
+		c.depth = max(c.depth-1, 0)
+	}
+}
+
+// checkComplexity fails with ErrTooComplex when doc has more than maxNodes
+// elements or nests them more than maxDepth deep; 0 disables either limit.
+// It walks the tree without recursion, so deep documents cannot exhaust the
+// stack.
+func checkComplexity(doc *html.Node, maxNodes, maxDepth int) error {
+	nodes, depth := 0, 0
+	n := doc
+	for {
+		if n.Type == html.ElementNode {
+			nodes++
+			if maxNodes > 0 && nodes > maxNodes {
+				return tooManyNodes(maxNodes)
+			}
+			if maxDepth > 0 && depth > maxDepth {
+				return tooDeep(maxDepth)
+			}
+		}
+
+		if n.FirstChild != nil {
+			n = n.FirstChild
+			depth++
+			continue
+		}
+		for n != doc && n.NextSibling == nil {
+			n = n.Parent
+			depth--
+		}
+		if n == doc {
+			return nil
+		}
+		n = n.NextSibling
+	}
+}
+
+func tooManyNodes(limit int) error {
+	return fmt.Errorf("%w: more than %d elements", ErrTooComplex, limit)
+}
+
+func tooDeep(limit int) error {
+	return fmt.Errorf("%w: elements nested more than %d deep", ErrTooComplex, limit)
+}
@@ -0,0 +1,82 @@
+package analyzer
+
+import "time"
+
+// Config holds the analyzer's settings. The yaml tags let programs embed it
+// in their own configuration files.
+type Config struct {
+	MaxWorkers     int           `yaml:"max_workers"`
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	LinkTimeout    time.Duration `yaml:"link_timeout"`
+	MaxRedirects   int           `yaml:"max_redirects"`
+	// MaxPageSize limits fetched page bodies in bytes; 0 disables the limit
+	MaxPageSize int64 `yaml:"max_page_size"`
+	// MaxNodes and MaxDepth fail analyses of documents with more elements
+	// or deeper element nesting with ErrTooComplex, to guard against HTML
+	// bombs; 0 disables the limit
+	MaxNodes int `yaml:"max_nodes"`
+	MaxDepth int `yaml:"max_depth"`
+	// StreamingThreshold is the page size in bytes above which pages are
+	// analyzed token by token instead of as a DOM; 0 always builds a DOM
+	StreamingThreshold int64 `yaml:"streaming_threshold"`
+	// BatchConcurrency limits how many pages AnalyzeMany analyzes at once
+	BatchConcurrency int `yaml:"batch_concurrency"`
+	// MaxConcurrentAnalyses limits how many analyses run at once across the
+	// service; 0 disables the limit
+	MaxConcurrentAnalyses int `yaml:"max_concurrent_analyses"`
+	// QueueTimeout is how long a high priority analysis waits for a free
+	// slot before it is rejected; 0 rejects it at once. Low priority
+	// analyses wait until their context ends.
+	QueueTimeout time.Duration `yaml:"queue_timeout"`
+	// MaxOutlineEntries limits the headings listed by the outline section;
+	// 0 lists them all
+	MaxOutlineEntries int `yaml:"max_outline_entries"`
+	// MaxMetaRefreshes is how many meta refresh redirects AnalyzeURL
+	// follows to analyze the page they lead to; 0 only reports them
+	MaxMetaRefreshes int `yaml:"max_meta_refreshes"`
+	// MaxFrames is how many frame sources of a frameset page AnalyzeURL
+	// fetches and analyzes as documents of their own; 0 only reports them
+	MaxFrames int `yaml:"max_frames"`
+	// InternalSubdomains counts links to other hosts of the page's
+	// registrable domain, like blog.example.com from www.example.com, as
+	// internal rather than external
+	InternalSubdomains bool `yaml:"internal_subdomains"`
+	// StripTrackingParams removes utm_ parameters and click IDs such as
+	// fbclid from analyzed and checked URLs, so links that only differ in
+	// tracking are analyzed, checked and cached as one
+	StripTrackingParams bool `yaml:"strip_tracking_params"`
+	// PageSpeed configures the web_vitals section
+	PageSpeed PageSpeedConfig `yaml:"pagespeed"`
+	// Budgets limits how long each phase of an analysis may take
+	Budgets Budgets `yaml:"budgets"`
+	// Rules are checks of the site's own conventions, evaluated on every
+	// analyzed page
+	Rules []Rule `yaml:"rules"`
+}
+
+// Budgets holds per-phase time limits, so one slow phase cannot use up the
+// whole request. A zero budget leaves the phase limited only by the
+// caller's context.
+type Budgets struct {
+	// Fetch covers requesting the page and downloading its body
+	Fetch time.Duration `yaml:"fetch"`
+	// Parse covers reading and analyzing the document; it fails the
+	// analysis with ErrParseTimeout
+	Parse time.Duration `yaml:"parse"`
+	// Links covers the link accessibility check; links not checked in time
+	// leave the result Partial
+	Links time.Duration `yaml:"links"`
+}
+
+// PageSpeedConfig holds the PageSpeed Insights API settings used to fill in
+// the web_vitals section
+type PageSpeedConfig struct {
+	// APIKey authenticates with the API; without it the section reports
+	// that PageSpeed Insights is not configured
+	APIKey string `yaml:"api_key"`
+	// Strategy is "mobile" or "desktop"
+	Strategy string `yaml:"strategy"`
+	// Endpoint overrides the API's runPagespeed URL
+	Endpoint string        `yaml:"endpoint"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Device is a device preset to analyze a page as. The page is fetched with
+// the device's User-Agent, so servers that send phones different HTML are
+// analyzed as a phone would see them. The web_vitals section's Lighthouse
+// run emulates a desktop or phone screen; PageSpeed Insights has no tablet
+// strategy, so tablets are measured as phones.
+type Device string
+
+// Device presets
+const (
+	DeviceDesktop Device = "desktop"
+	DeviceMobile  Device = "mobile"
+	DeviceTablet  Device = "tablet"
+)
+
+// Devices lists every device preset
+var Devices = []Device{DeviceDesktop, DeviceMobile, DeviceTablet}
+
+// deviceUserAgents are the browser User-Agents the presets fetch pages with
+var deviceUserAgents = map[Device]string{
+	DeviceDesktop: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+	DeviceMobile:  "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36",
+	DeviceTablet:  "Mozilla/5.0 (iPad; CPU OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1",
+}
+
+// ErrInvalidDevice is returned for device names that are not a preset
+var ErrInvalidDevice = errors.New("unknown device")
+
+// ParseDevice returns the device preset named s
+func ParseDevice(s string) (Device, error) {
+	device := Device(s)
+	if _, ok := deviceUserAgents[device]; !ok {
+		return "", fmt.Errorf("%w %q", ErrInvalidDevice, s)
+	}
+	return device, nil
+}
+
+// AsDevice analyzes the page as device would see it. It only applies to
+// AnalyzeURL, which reports the device in Result.Device.
+func AsDevice(device Device) AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.device = device
+	}
+}
+
+// strategy returns the PageSpeed Insights strategy that emulates the device.
+// There are only desktop and mobile strategies, so tablets get mobile.
+func (d Device) strategy() string {
+	if d == DeviceDesktop {
+		return "desktop"
+	}
+	return "mobile"
+}
+
+// userAgent returns the User-Agent to fetch pages with. With a device, the
+// analyzer's own User-Agent follows the device's so it stays identifiable.
+func (o *analyzeOptions) userAgent(own string) string {
+	if browser, ok := deviceUserAgents[o.device]; ok {
+		return browser + " " + own
+	}
+	return own
+}
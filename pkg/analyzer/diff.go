@@ -0,0 +1,206 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldChange describes a single metric that differs between two results
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+	Delta  *int        `json:"delta,omitempty"`
+}
+
+// Diff describes the differences between two analysis results
+type Diff struct {
+	Identical bool          `json:"identical"`
+	Changes   []FieldChange `json:"changes"`
+	Summary   []string      `json:"summary"`
+}
+
+// DiffResults compares two analysis results field by field. Changes are
+// reported from before to after, so deltas are after minus before. The seo
+// section's meta description and canonical URL, and the security headers,
+// are compared when both results have them.
+func DiffResults(before, after *Result) *Diff {
+	diff := &Diff{Changes: []FieldChange{}}
+
+	diff.addString("html_version", before.HTMLVersion, after.HTMLVersion)
+	diff.addString("title", before.Title, after.Title)
+
+	for _, level := range headingLevels(before.Headings, after.Headings) {
+		diff.addInt("headings."+level, before.Headings[level], after.Headings[level])
+	}
+
+	diff.addInt("internal_links", before.InternalLinks, after.InternalLinks)
+	diff.addInt("external_links", before.ExternalLinks, after.ExternalLinks)
+	diff.addInt("inaccessible_links", before.InaccessibleLinks, after.InaccessibleLinks)
+	diff.addBool("has_login_form", before.HasLoginForm, after.HasLoginForm)
+
+	if before.SEO != nil && after.SEO != nil {
+		diff.addString("seo.meta_description", before.SEO.MetaDescription, after.SEO.MetaDescription)
+		diff.addString("seo.canonical", before.SEO.Canonical, after.SEO.Canonical)
+	}
+	// Headers are only known for fetched pages, not archived snapshots
+	if before.Security != nil && after.Security != nil && before.Security.Headers != nil && after.Security.Headers != nil {
+		for _, name := range securityHeaders {
+			diff.addString("security.headers."+name, before.Security.Headers[name], after.Security.Headers[name])
+		}
+	}
+
+	diff.Identical = len(diff.Changes) == 0
+	diff.Summary = diff.summarize()
+	return diff
+}
+
+// summarize describes each change in plain words, e.g. "3 new broken links"
+func (d *Diff) summarize() []string {
+	summary := make([]string, 0, len(d.Changes))
+
+	for _, change := range d.Changes {
+		switch {
+		case change.Field == "title":
+			summary = append(summary, fmt.Sprintf("title changed from %q to %q", change.Before, change.After))
+		case change.Field == "html_version":
+			summary = append(summary, fmt.Sprintf("HTML version changed from %s to %s", change.Before, change.After))
+		case change.Field == "seo.meta_description":
+			summary = append(summary, describeTextChange("meta description", change))
+		case change.Field == "seo.canonical":
+			summary = append(summary, describeTextChange("canonical URL", change))
+		case strings.HasPrefix(change.Field, "security.headers."):
+			summary = append(summary, describeTextChange(strings.TrimPrefix(change.Field, "security.headers.")+" header", change))
+		case change.Field == "has_login_form":
+			if change.After == true {
+				summary = append(summary, "login form added")
+			} else {
+				summary = append(summary, "login form removed")
+			}
+		case change.Field == "inaccessible_links":
+			if *change.Delta > 0 {
+				summary = append(summary, fmt.Sprintf("%d new broken %s", *change.Delta, plural(*change.Delta, "link")))
+			} else {
+				summary = append(summary, fmt.Sprintf("%d broken %s fixed", -*change.Delta, plural(-*change.Delta, "link")))
+			}
+		case change.Delta != nil:
+			summary = append(summary, describeCountChange(change.Field, *change.Delta))
+		default:
+			summary = append(summary, change.String())
+		}
+	}
+
+	return summary
+}
+
+// describeTextChange renders a change to an optional text field such as
+// "meta description added"
+func describeTextChange(name string, change FieldChange) string {
+	switch {
+	case change.Before == "":
+		return name + " added"
+	case change.After == "":
+		return name + " removed"
+	default:
+		return fmt.Sprintf("%s changed from %q to %q", name, change.Before, change.After)
+	}
+}
+
+// describeCountChange renders a count delta such as "2 more h2 headings"
+func describeCountChange(field string, delta int) string {
+	var noun string
+	switch field {
+	case "internal_links":
+		noun = "internal link"
+	case "external_links":
+		noun = "external link"
+	default:
+		// headings.hN
+		noun = strings.TrimPrefix(field, "headings.") + " heading"
+	}
+
+	if delta > 0 {
+		return fmt.Sprintf("%d more %s", delta, plural(delta, noun))
+	}
+	return fmt.Sprintf("%d fewer %s", -delta, plural(-delta, noun))
+}
+
+// plural appends an "s" to noun unless count is one
+func plural(count int, noun string) string {
+	if count == 1 {
+		return noun
+	}
+	return noun + "s"
+}
+
+// addString records a change between two string values
+func (d *Diff) addString(field, before, after string) {
+	if before != after {
+		d.Changes = append(d.Changes, FieldChange{Field: field, Before: before, After: after})
+	}
+}
+
+// addInt records a change between two counts along with its delta
+func (d *Diff) addInt(field string, before, after int) {
+	if before != after {
+		delta := after - before
+		d.Changes = append(d.Changes, FieldChange{Field: field, Before: before, After: after, Delta: &delta})
+	}
+}
+
+// addBool records a change between two flags
+func (d *Diff) addBool(field string, before, after bool) {
+	if before != after {
+		d.Changes = append(d.Changes, FieldChange{Field: field, Before: before, After: after})
+	}
+}
+
+// Change returns the change recorded for a field, if any
+func (d *Diff) Change(field string) (FieldChange, bool) {
+	for _, change := range d.Changes {
+		if change.Field == field {
+			return change, true
+		}
+	}
+	return FieldChange{}, false
+}
+
+// Regressions describes the changes that made the page worse: new broken
+// links and a title that disappeared. It is empty when nothing regressed.
+func (d *Diff) Regressions() []string {
+	var regressions []string
+	if change, ok := d.Change("inaccessible_links"); ok && *change.Delta > 0 {
+		regressions = append(regressions, fmt.Sprintf("%d new broken %s", *change.Delta, plural(*change.Delta, "link")))
+	}
+	if change, ok := d.Change("title"); ok && change.After == "" {
+		regressions = append(regressions, "title removed")
+	}
+	return regressions
+}
+
+// headingLevels returns the sorted union of heading levels in both maps
+func headingLevels(a, b map[string]int) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	for level := range a {
+		seen[level] = true
+	}
+	for level := range b {
+		seen[level] = true
+	}
+
+	levels := make([]string, 0, len(seen))
+	for level := range seen {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+	return levels
+}
+
+// String renders a change as a short human-readable sentence
+func (c FieldChange) String() string {
+	if c.Delta != nil {
+		return fmt.Sprintf("%s changed from %v to %v (%+d)", c.Field, c.Before, c.After, *c.Delta)
+	}
+	return fmt.Sprintf("%s changed from %q to %q", c.Field, fmt.Sprint(c.Before), fmt.Sprint(c.After))
+}
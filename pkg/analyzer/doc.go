@@ -0,0 +1,12 @@
+// Package analyzer fetches web pages and reports their structure: HTML
+// version, title, headings, links and whether they are reachable, and login
+// forms, plus optional SEO, security, accessibility and performance
+// sections and Core Web Vitals from PageSpeed Insights.
+//
+// The package has no dependencies on the web-analyzer service and can be
+// used on its own:
+//
+//	a := analyzer.NewWithOptions(analyzer.WithMaxWorkers(20))
+//	result, err := a.AnalyzeURL(ctx, "https://example.com",
+//		analyzer.IncludeSections(analyzer.SectionSEO))
+package analyzer
@@ -0,0 +1,109 @@
+package analyzer
+
+import (
+	"mime"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Download kinds
+const (
+	DownloadDocument     = "document"
+	DownloadSpreadsheet  = "spreadsheet"
+	DownloadPresentation = "presentation"
+	DownloadArchive      = "archive"
+	DownloadInstaller    = "installer"
+)
+
+// downloadExtensions maps lower case file extensions to download kinds
+var downloadExtensions = map[string]string{
+	"pdf": DownloadDocument, "doc": DownloadDocument, "docx": DownloadDocument,
+	"odt": DownloadDocument, "rtf": DownloadDocument, "epub": DownloadDocument,
+	"xls": DownloadSpreadsheet, "xlsx": DownloadSpreadsheet, "ods": DownloadSpreadsheet,
+	"csv": DownloadSpreadsheet,
+	"ppt": DownloadPresentation, "pptx": DownloadPresentation, "odp": DownloadPresentation,
+	"key": DownloadPresentation,
+	"zip": DownloadArchive, "rar": DownloadArchive, "7z": DownloadArchive,
+	"tar": DownloadArchive, "gz": DownloadArchive, "tgz": DownloadArchive,
+	"bz2": DownloadArchive, "xz": DownloadArchive,
+	"exe": DownloadInstaller, "msi": DownloadInstaller, "dmg": DownloadInstaller,
+	"pkg": DownloadInstaller, "apk": DownloadInstaller, "deb": DownloadInstaller,
+	"rpm": DownloadInstaller,
+}
+
+// downloadContentTypes maps media types to download kinds
+var downloadContentTypes = map[string]string{
+	"application/pdf":                         DownloadDocument,
+	"application/msword":                      DownloadDocument,
+	"application/rtf":                         DownloadDocument,
+	"application/epub+zip":                    DownloadDocument,
+	"application/vnd.oasis.opendocument.text": DownloadDocument,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": DownloadDocument,
+
+	"text/csv":                 DownloadSpreadsheet,
+	"application/vnd.ms-excel": DownloadSpreadsheet,
+	"application/vnd.oasis.opendocument.spreadsheet":                    DownloadSpreadsheet,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": DownloadSpreadsheet,
+
+	"application/vnd.ms-powerpoint":                                             DownloadPresentation,
+	"application/vnd.oasis.opendocument.presentation":                           DownloadPresentation,
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": DownloadPresentation,
+
+	"application/zip":              DownloadArchive,
+	"application/x-zip-compressed": DownloadArchive,
+	"application/vnd.rar":          DownloadArchive,
+	"application/x-rar-compressed": DownloadArchive,
+	"application/x-7z-compressed":  DownloadArchive,
+	"application/x-tar":            DownloadArchive,
+	"application/gzip":             DownloadArchive,
+	"application/x-gzip":           DownloadArchive,
+	"application/x-bzip2":          DownloadArchive,
+	"application/x-xz":             DownloadArchive,
+
+	"application/x-msdownload":                DownloadInstaller,
+	"application/x-msi":                       DownloadInstaller,
+	"application/x-apple-diskimage":           DownloadInstaller,
+	"application/vnd.android.package-archive": DownloadInstaller,
+}
+
+// Download is a link to a document, archive or installer rather than a
+// page. Kind comes from the Content-Type the link check received, when it
+// names a download, and otherwise from the path's file extension.
+type Download struct {
+	URL         string `json:"url"`
+	Kind        string `json:"kind"`
+	Extension   string `json:"extension,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// findDownloads returns the distinct downloads among links, in document
+// order. contentTypes holds the Content-Type of the checked links.
+func findDownloads(links []string, contentTypes map[string]string) []Download {
+	var downloads []Download
+	for _, link := range links {
+		if slices.ContainsFunc(downloads, func(d Download) bool { return d.URL == link }) {
+			continue
+		}
+		parsed, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		download := Download{URL: link}
+		if ext := strings.TrimPrefix(path.Ext(strings.ToLower(parsed.Path)), "."); downloadExtensions[ext] != "" {
+			download.Extension = ext
+			download.Kind = downloadExtensions[ext]
+		}
+		if mediaType, _, err := mime.ParseMediaType(contentTypes[link]); err == nil {
+			download.ContentType = mediaType
+			if kind := downloadContentTypes[mediaType]; kind != "" {
+				download.Kind = kind
+			}
+		}
+		if download.Kind != "" {
+			downloads = append(downloads, download)
+		}
+	}
+	return downloads
+}
@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidURL is returned when the target or base URL cannot be parsed
+	ErrInvalidURL = errors.New("invalid URL")
+
+	// ErrFetchTimeout is returned when the page fetch times out. The wrapped
+	// error is still matched by context.DeadlineExceeded where it applies.
+	ErrFetchTimeout = errors.New("page fetch timed out")
+
+	// ErrParseTimeout is returned when reading and analyzing the document
+	// exceeds the parse budget
+	ErrParseTimeout = errors.New("document parse timed out")
+
+	// ErrNotHTML is returned when the page is served with a content type
+	// other than HTML
+	ErrNotHTML = errors.New("page is not HTML")
+
+	// ErrTooLarge is returned when the page body exceeds MaxPageSize
+	ErrTooLarge = errors.New("page exceeds maximum size")
+
+	// ErrTooComplex is returned when the document has more elements than
+	// MaxNodes or nests them deeper than MaxDepth
+	ErrTooComplex = errors.New("document exceeds complexity limits")
+
+	// ErrBusy is returned when MaxConcurrentAnalyses analyses are already
+	// running and none finished within QueueTimeout
+	ErrBusy = errors.New("too many concurrent analyses")
+
+	// errTooManyRedirects is returned by redirect policies once the
+	// configured limit is reached
+	errTooManyRedirects = errors.New("too many redirects")
+)
+
+// ErrHTTPStatus is returned when the page is served with a status other than
+// 200 OK. Match it with errors.As.
+type ErrHTTPStatus struct {
+	Code   int
+	Status string
+}
+
+// Error returns the error message
+func (e *ErrHTTPStatus) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.Code, e.Status)
+}
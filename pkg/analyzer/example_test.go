@@ -0,0 +1,27 @@
+package analyzer_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/anjula-paulus/web-analyzer/pkg/analyzer"
+)
+
+func ExampleAnalyzer_AnalyzeURL() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<!DOCTYPE html><html lang="en"><head><title>Example</title></head><body><h1>Hello</h1></body></html>`)
+	}))
+	defer server.Close()
+
+	a := analyzer.NewWithOptions(analyzer.WithMaxWorkers(2))
+	result, err := a.AnalyzeURL(context.Background(), server.URL, analyzer.IncludeSections(analyzer.SectionSEO))
+	if err != nil {
+		fmt.Println("analysis failed:", err)
+		return
+	}
+
+	fmt.Println(result.HTMLVersion, result.Title, result.Headings["h1"], result.SEO.Lang)
+	// Output: HTML5 Example 1 en
+}
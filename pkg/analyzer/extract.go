@@ -0,0 +1,183 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// MaxExtractMatches limits the texts an extraction lists. Count still
+// counts every match.
+const MaxExtractMatches = 100
+
+// ErrInvalidSelector is returned when a selector passed to Extract cannot
+// be parsed
+var ErrInvalidSelector = errors.New("invalid selector")
+
+// Extraction holds what a selector passed to Extract matched: the number of
+// matching elements and, in document order, the text content of the first
+// MaxExtractMatches of them with whitespace collapsed. Truncated is set when
+// there were more.
+type Extraction struct {
+	Selector  string   `json:"selector"`
+	Count     int      `json:"count"`
+	Matches   []string `json:"matches"`
+	Truncated bool     `json:"truncated,omitempty"`
+
+	selector selector
+}
+
+// Extract evaluates CSS selectors against the document and reports their
+// matches in Result.Extractions, in the order given. The selectors support
+// what configured Rules do; see ValidateSelector.
+func Extract(selectors ...string) AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.extract = append(o.extract, selectors...)
+	}
+}
+
+// ValidateSelector reports whether s can be used with Extract. Type,
+// universal, id, class and attribute selectors joined by descendant and
+// child combinators are supported, in comma-separated lists.
+func ValidateSelector(s string) error {
+	if _, err := parseSelector(s); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSelector, err)
+	}
+	return nil
+}
+
+// validateExtract checks the selectors passed to Extract before an analysis
+// starts
+func (o *analyzeOptions) validateExtract() error {
+	for _, s := range o.extract {
+		if err := ValidateSelector(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initExtractions creates an extraction on result for each selector passed
+// to Extract, which must have been validated
+func (o *analyzeOptions) initExtractions(result *Result) {
+	if len(o.extract) == 0 {
+		return
+	}
+	result.Extractions = make([]Extraction, len(o.extract))
+	for i, s := range o.extract {
+		sel, _ := parseSelector(s)
+		result.Extractions[i] = Extraction{Selector: s, Matches: []string{}, selector: sel}
+	}
+}
+
+// extractor fills in extractions from start tags, text and end tags, for
+// both the DOM and streaming analyses. Its methods do nothing on a nil
+// extractor.
+type extractor struct {
+	extractions []Extraction
+	// open holds the open elements, outermost first, for selectors
+	open []element
+	// captures collect the text of the open matched elements
+	captures []*capture
+}
+
+// capture is the text of a matched element, for the match at index in the
+// extraction at extraction, collected while depth elements are open
+type capture struct {
+	extraction, index, depth int
+	text                     strings.Builder
+}
+
+// newExtractor returns an extractor for extractions, or nil when there are
+// none
+func newExtractor(extractions []Extraction) *extractor {
+	if len(extractions) == 0 {
+		return nil
+	}
+	return &extractor{extractions: extractions}
+}
+
+// extract fills in extractions from a parsed document
+func extract(doc *html.Node, extractions []Extraction) {
+	e := newExtractor(extractions)
+	if e == nil {
+		return
+	}
+	walkTags(doc, e.start, e.text, e.end)
+	e.finish()
+}
+
+// start handles a start tag; tag is lower case
+func (e *extractor) start(tag string, attrs []html.Attribute) {
+	if e == nil {
+		return
+	}
+	e.open = append(e.open, element{tag: tag, attrs: attrs})
+	for i := range e.extractions {
+		extraction := &e.extractions[i]
+		if !extraction.selector.match(e.open) {
+			continue
+		}
+		extraction.Count++
+		if len(extraction.Matches) >= MaxExtractMatches {
+			extraction.Truncated = true
+			continue
+		}
+		// The slot keeps matches in document order when nested matches
+		// end first
+		extraction.Matches = append(extraction.Matches, "")
+		e.captures = append(e.captures, &capture{extraction: i, index: len(extraction.Matches) - 1, depth: len(e.open)})
+	}
+	if voidElements[tag] {
+		e.closeTo(len(e.open) - 1)
+	}
+}
+
+// text handles text content
+func (e *extractor) text(text string) {
+	if e == nil {
+		return
+	}
+	for _, c := range e.captures {
+		c.text.WriteString(text)
+	}
+}
+
+// end handles an end tag, closing the elements opened since the matching
+// start tag. Stray end tags are ignored.
+func (e *extractor) end(tag string) {
+	if e == nil {
+		return
+	}
+	for i := len(e.open) - 1; i >= 0; i-- {
+		if e.open[i].tag == tag {
+			e.closeTo(i)
+			return
+		}
+	}
+}
+
+// finish closes the elements still open at the end of the document
+func (e *extractor) finish() {
+	if e == nil {
+		return
+	}
+	e.closeTo(0)
+}
+
+// closeTo closes the open elements past the first n, storing the text of
+// the matches among them
+func (e *extractor) closeTo(n int) {
+	e.open = e.open[:n]
+	kept := e.captures[:0]
+	for _, c := range e.captures {
+		if c.depth <= n {
+			kept = append(kept, c)
+			continue
+		}
+		e.extractions[c.extraction].Matches[c.index] = strings.Join(strings.Fields(c.text.String()), " ")
+	}
+	e.captures = kept
+}
@@ -0,0 +1,216 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// Severity ranks a finding. Errors are problems that should be fixed,
+// warnings are likely problems and info findings are worth a look.
+type Severity string
+
+// Finding severities
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// AtLeast reports whether s is as severe as threshold or more
+func (s Severity) AtLeast(threshold Severity) bool {
+	return s.rank() >= threshold.rank()
+}
+
+// rank orders severities from least to most severe
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityInfo:
+		return 1
+	}
+	return 0
+}
+
+// Category groups findings by the kind of check that reported them
+type Category string
+
+// Finding categories
+const (
+	CategorySEO           Category = "seo"
+	CategoryLinks         Category = "links"
+	CategoryContent       Category = "content"
+	CategoryAccessibility Category = "accessibility"
+	CategorySecurity      Category = "security"
+	// CategoryCustom holds the findings of configured Rules
+	CategoryCustom Category = "custom"
+)
+
+// Categories lists every category in the order reports group them
+var Categories = []Category{CategorySEO, CategoryLinks, CategoryContent, CategoryAccessibility, CategorySecurity, CategoryCustom}
+
+// Finding is a problem reported by one of the checks. ID names the check
+// and stays stable across releases, so clients can match on it; Evidence
+// holds what the check found, such as URLs or header names.
+type Finding struct {
+	ID       string   `json:"id"`
+	Severity Severity `json:"severity"`
+	Category Category `json:"category"`
+	Message  string   `json:"message"`
+	Evidence []string `json:"evidence,omitempty"`
+}
+
+// Verdict is the outcome of a result under a failure policy: it fails when
+// the analysis failed or a finding is at least as severe as FailOn.
+// Failures counts those findings.
+type Verdict struct {
+	FailOn   Severity `json:"fail_on"`
+	Passed   bool     `json:"passed"`
+	Failures int      `json:"failures"`
+}
+
+// Judge returns the verdict on result when findings of severity failOn or
+// worse fail it, e.g. SeverityWarning to fail on warnings and errors
+func Judge(result *Result, failOn Severity) *Verdict {
+	verdict := &Verdict{FailOn: failOn}
+	for _, finding := range result.Findings {
+		if finding.Severity.AtLeast(failOn) {
+			verdict.Failures++
+		}
+	}
+	verdict.Passed = result.Error == "" && verdict.Failures == 0
+	return verdict
+}
+
+// collectFindings derives the built-in findings of a finished analysis from
+// the core fields and the requested sections, grouped by category
+func collectFindings(result *Result) []Finding {
+	findings := []Finding{}
+	add := func(id string, severity Severity, category Category, message string, evidence ...string) {
+		findings = append(findings, Finding{ID: id, Severity: severity, Category: category, Message: message, Evidence: evidence})
+	}
+
+	if result.Title == "" {
+		add("missing-title", SeverityError, CategorySEO, "page has no <title>")
+	}
+	if result.TitleCount > 1 {
+		add("multiple-titles", SeverityWarning, CategorySEO, fmt.Sprintf("page has %d title elements", result.TitleCount))
+	}
+	if h1 := result.Headings["h1"]; h1 != 1 {
+		add("h1-count", SeverityWarning, CategorySEO, fmt.Sprintf("expected exactly one h1 heading, found %d", h1))
+	}
+	if seo := result.SEO; seo != nil {
+		if seo.Lang == "" {
+			add("missing-lang", SeverityWarning, CategorySEO, "html element has no lang attribute")
+		}
+		if seo.MetaDescription == "" {
+			add("missing-meta-description", SeverityWarning, CategorySEO, "page has no meta description")
+		}
+		if seo.Canonical == "" {
+			add("missing-canonical", SeverityInfo, CategorySEO, "page has no canonical link")
+		}
+		if seo.Languages != nil && seo.Languages.Mismatch {
+			var evidence []string
+			for _, source := range languageSources(seo.Lang, seo.Languages) {
+				evidence = append(evidence, source.evidence)
+			}
+			add("language-mismatch", SeverityWarning, CategorySEO, "declared and detected page languages disagree", evidence...)
+		}
+	}
+
+	switch result.InaccessibleLinks {
+	case 0:
+	case 1:
+		add("broken-links", SeverityError, CategoryLinks, "1 inaccessible link")
+	default:
+		add("broken-links", SeverityError, CategoryLinks, fmt.Sprintf("%d inaccessible links", result.InaccessibleLinks))
+	}
+
+	if result.ScriptDependency != nil {
+		add("script-dependency", SeverityWarning, CategoryContent, result.ScriptDependency.Advice)
+	}
+	if parking := result.Parking; parking != nil {
+		if parking.Kind == ParkingParked {
+			add("parked-domain", SeverityWarning, CategoryContent, "page looks like a parked domain", parking.Signals...)
+		} else {
+			add("coming-soon", SeverityInfo, CategoryContent, "page looks like a coming soon placeholder", parking.Signals...)
+		}
+	}
+	if frames := result.Frames; frames != nil {
+		add("legacy-frames", SeverityWarning, CategoryContent, "page is built from obsolete frames", frames.Sources...)
+	}
+
+	if a11y := result.Accessibility; a11y != nil {
+		if a11y.ImagesMissingAlt > 0 {
+			add("images-missing-alt", SeverityError, CategoryAccessibility, fmt.Sprintf("%d of %d images have no alt attribute", a11y.ImagesMissingAlt, a11y.Images))
+		}
+		var tables []string
+		for _, table := range a11y.Tables {
+			if !table.Layout && table.HeaderCells == 0 {
+				tables = append(tables, "table "+strconv.Itoa(table.Position))
+			}
+		}
+		if len(tables) > 0 {
+			add("table-missing-headers", SeverityWarning, CategoryAccessibility, "data tables have no header cells", tables...)
+		}
+	}
+
+	if security := result.Security; security != nil {
+		// Only fetched pages have a transport to judge
+		if !security.HTTPS && security.Headers != nil {
+			add("not-https", SeverityError, CategorySecurity, "page is not served over https")
+		}
+		if len(security.MissingHeaders) > 0 {
+			add("missing-security-headers", SeverityWarning, CategorySecurity, "response lacks security headers", security.MissingHeaders...)
+		}
+		if policy := security.ReferrerPolicy; policy != nil {
+			for _, issue := range policy.Issues {
+				add("referrer-policy", SeverityWarning, CategorySecurity, issue)
+			}
+		}
+		if policy := security.PermissionsPolicy; policy != nil {
+			for _, issue := range policy.Issues {
+				add("permissions-policy", SeverityWarning, CategorySecurity, issue)
+			}
+		}
+		if len(security.OpenRedirectCandidates) > 0 {
+			var urls []string
+			for _, candidate := range security.OpenRedirectCandidates {
+				if !slices.Contains(urls, candidate.URL) {
+					urls = append(urls, candidate.URL)
+				}
+			}
+			add("open-redirect-candidates", SeverityWarning, CategorySecurity, "links pass absolute URLs in their query", urls...)
+		}
+	}
+	if idn := result.IDN; idn != nil {
+		hosts := idn.Links
+		if idn.Host != nil {
+			hosts = append([]IDNHost{*idn.Host}, hosts...)
+		}
+		var homographs []string
+		for _, host := range hosts {
+			if host.Homograph {
+				homographs = append(homographs, host.Unicode+" ("+host.ASCII+")")
+			}
+		}
+		if len(homographs) > 0 {
+			add("idn-homograph", SeverityWarning, CategorySecurity, "host names mix scripts like look-alike domains do", homographs...)
+		}
+	}
+	if phishing := result.Phishing; phishing != nil && phishing.Risk != PhishingLow {
+		severity := SeverityWarning
+		if phishing.Risk == PhishingHigh {
+			severity = SeverityError
+		}
+		var details []string
+		for _, signal := range phishing.Signals {
+			details = append(details, signal.Detail)
+		}
+		add("phishing-risk", severity, CategorySecurity, fmt.Sprintf("page has a %s phishing risk score of %d", phishing.Risk, phishing.Score), details...)
+	}
+	return findings
+}
@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Frames is set on results for pages built with the legacy <frameset> and
+// <frame> elements, which HTML5 made obsolete and which search engines and
+// screen readers handle poorly. Sources lists the resolved frame URLs in
+// document order, and Noframes is set when the frameset has a <noframes>
+// fallback. Documents holds the analyses of the frame sources AnalyzeURL
+// fetched, with MaxFrames; a frame that could not be analyzed has only its
+// URL and Error.
+type Frames struct {
+	Frameset  bool      `json:"frameset"`
+	Sources   []string  `json:"sources"`
+	Noframes  bool      `json:"noframes"`
+	Documents []*Result `json:"documents,omitempty"`
+}
+
+// inspectFrames records the frameset elements of the page
+func inspectFrames(tag string, attrs []html.Attribute, result *Result, baseURL *url.URL) {
+	if tag == "noframes" {
+		// Fallbacks outside a frameset have nothing to fall back from
+		if result.Frames != nil {
+			result.Frames.Noframes = true
+		}
+		return
+	}
+	if result.Frames == nil {
+		result.Frames = &Frames{Sources: []string{}}
+	}
+	if tag == "frameset" {
+		result.Frames.Frameset = true
+		return
+	}
+	src := strings.TrimSpace(attrValue(attrs, "src"))
+	if src == "" {
+		return
+	}
+	if ref, err := url.Parse(src); err == nil {
+		result.Frames.Sources = append(result.Frames.Sources, baseURL.ResolveReference(ref).String())
+	}
+}
+
+// analyzeFrames fetches and analyzes up to MaxFrames of the frame sources
+// of result's page as documents of their own. Frames are analyzed with the
+// same sections, without following their meta refreshes or their own
+// frames. Sources that are not http(s), repeat or load the page itself are
+// skipped.
+func (a *Analyzer) analyzeFrames(ctx context.Context, result *Result, o *analyzeOptions) {
+	frames := result.Frames
+	limit := a.limits().MaxFrames
+	for _, src := range frames.Sources {
+		if len(frames.Documents) >= limit {
+			return
+		}
+		frameURL, err := url.Parse(src)
+		if err != nil || (frameURL.Scheme != "http" && frameURL.Scheme != "https") {
+			continue
+		}
+		if slices.ContainsFunc(o.visited, func(visited string) bool { return samePage(visited, frameURL) }) ||
+			slices.ContainsFunc(frames.Documents, func(d *Result) bool { return d.URL == src }) {
+			continue
+		}
+
+		a.logger.DebugContext(ctx, "Analyzing frame", "url", result.URL, "frame", src)
+		frame := &Result{
+			URL:      src,
+			Headings: make(map[string]int),
+		}
+		frameOptions := &analyzeOptions{sections: o.sections, extract: o.extract, device: o.device, priority: o.priority}
+		frameOptions.initSections(frame, frameURL)
+		if err := a.analyzePage(ctx, frame, frameURL, frameOptions); err != nil {
+			frame = &Result{URL: src, Error: err.Error()}
+		}
+		frames.Documents = append(frames.Documents, frame)
+	}
+}
@@ -0,0 +1,167 @@
+package analyzer
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// iconFonts are popular icon fonts with the classes of their icon elements
+// and what the URLs of their stylesheets contain
+var iconFonts = []struct {
+	name     string
+	classes  []string
+	prefixes []string
+	hrefs    []string
+}{
+	{"Font Awesome", []string{"fa", "fas", "far", "fal", "fad", "fab"}, []string{"fa-"}, []string{"fontawesome", "font-awesome"}},
+	{"Material Icons", []string{"material-icons"}, []string{"material-icons-"}, []string{"family=material+icons"}},
+	{"Material Symbols", nil, []string{"material-symbols-"}, []string{"family=material+symbols"}},
+	{"Bootstrap Icons", nil, []string{"bi-"}, []string{"bootstrap-icons"}},
+	{"Glyphicons", []string{"glyphicon"}, []string{"glyphicon-"}, nil},
+}
+
+// iconFamilyWords mark an @font-face family as an icon font
+var iconFamilyWords = []string{"icon", "awesome", "glyph", "symbols"}
+
+// iconChecker counts inline SVGs and finds icon fonts for the performance
+// section, from the same tags and text in the DOM and streaming analyses.
+// Its methods do nothing on a nil checker.
+type iconChecker struct {
+	svgs     int
+	elements int
+	fonts    []string
+	// svgDepth counts the open svg elements, so nested ones are not
+	// counted again
+	svgDepth int
+	// style collects the text of an open style element
+	style *strings.Builder
+}
+
+// checkIcons fills in the icon usage of a parsed document
+func checkIcons(doc *html.Node, performance *Performance) {
+	c := &iconChecker{}
+	walkTags(doc, c.start, c.text, c.end)
+	c.result(performance)
+}
+
+// start handles a start tag; tag is lower case
+func (c *iconChecker) start(tag string, attrs []html.Attribute) {
+	if c == nil {
+		return
+	}
+	switch tag {
+	case "svg":
+		if c.svgDepth == 0 {
+			c.svgs++
+		}
+		c.svgDepth++
+	case "style":
+		c.style = &strings.Builder{}
+	case "link":
+		if hasToken(attrValue(attrs, "rel"), "stylesheet") {
+			href := strings.ToLower(attrValue(attrs, "href"))
+			for _, font := range iconFonts {
+				if slices.ContainsFunc(font.hrefs, func(s string) bool { return strings.Contains(href, s) }) {
+					c.addFont(font.name)
+				}
+			}
+		}
+	}
+
+	icon := false
+	for _, class := range strings.Fields(attrValue(attrs, "class")) {
+		for _, font := range iconFonts {
+			if slices.Contains(font.classes, class) || slices.ContainsFunc(font.prefixes, func(p string) bool { return strings.HasPrefix(class, p) }) {
+				c.addFont(font.name)
+				icon = true
+			}
+		}
+	}
+	if icon {
+		c.elements++
+	}
+}
+
+// text handles a text token
+func (c *iconChecker) text(s string) {
+	if c != nil && c.style != nil {
+		c.style.WriteString(s)
+	}
+}
+
+// end handles an end tag; tag is lower case
+func (c *iconChecker) end(tag string) {
+	if c == nil {
+		return
+	}
+	switch tag {
+	case "svg":
+		c.svgDepth = max(c.svgDepth-1, 0)
+	case "style":
+		if c.style != nil {
+			for _, family := range fontFaceFamilies(c.style.String()) {
+				lower := strings.ToLower(family)
+				if slices.ContainsFunc(iconFamilyWords, func(w string) bool { return strings.Contains(lower, w) }) {
+					c.addFont(family)
+				}
+			}
+			c.style = nil
+		}
+	}
+}
+
+// result copies the findings to the performance section
+func (c *iconChecker) result(performance *Performance) {
+	if c == nil {
+		return
+	}
+	performance.InlineSVGs = c.svgs
+	performance.IconElements = c.elements
+	performance.IconFonts = c.fonts
+}
+
+func (c *iconChecker) addFont(name string) {
+	if !slices.Contains(c.fonts, name) {
+		c.fonts = append(c.fonts, name)
+	}
+}
+
+// fontFaceFamilies returns the font families declared by the @font-face
+// rules of a stylesheet
+func fontFaceFamilies(css string) []string {
+	var families []string
+	// Lower ASCII only, so offsets in lower are offsets in css
+	b := []byte(css)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	lower := string(b)
+	for {
+		i := strings.Index(lower, "@font-face")
+		if i < 0 {
+			return families
+		}
+		css, lower = css[i:], lower[i:]
+
+		open := strings.IndexByte(lower, '{')
+		end := strings.IndexByte(lower, '}')
+		if open < 0 || end < open {
+			return families
+		}
+		rule := css[open+1 : end]
+		css, lower = css[end+1:], lower[end+1:]
+
+		for _, declaration := range strings.Split(rule, ";") {
+			name, value, ok := strings.Cut(declaration, ":")
+			if ok && strings.TrimSpace(strings.ToLower(name)) == "font-family" {
+				if family := strings.Trim(strings.TrimSpace(value), `"'`); family != "" {
+					families = append(families, family)
+				}
+			}
+		}
+	}
+}
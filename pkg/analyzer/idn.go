@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
+// IDN lists the internationalized domain names of the page and its links,
+// in both forms. Host is the page's own host, when it is one, and Links the
+// other hosts links point to that are, in document order.
+type IDN struct {
+	Host  *IDNHost  `json:"host,omitempty"`
+	Links []IDNHost `json:"links,omitempty"`
+}
+
+// IDNHost is an internationalized host name: ASCII is its punycode form, as
+// sent in DNS and HTTP, and Unicode the form people read. Scripts names the
+// Unicode scripts of its letters, such as Latin and Cyrillic. Homograph is
+// set when a label mixes scripts that are not written together, as
+// look-alikes of other domains do.
+type IDNHost struct {
+	ASCII     string   `json:"ascii"`
+	Unicode   string   `json:"unicode"`
+	Scripts   []string `json:"scripts"`
+	Homograph bool     `json:"homograph,omitempty"`
+}
+
+// compatibleScripts are the scripts a label may mix, from the "highly
+// restrictive" level of Unicode TS #39: Latin with Han and Japanese kana,
+// with Han and Bopomofo, or with Han and Hangul
+var compatibleScripts = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"},
+	{"Latin", "Han", "Bopomofo"},
+	{"Latin", "Han", "Hangul"},
+}
+
+// inspectIDN returns the internationalized hosts of the page at pageURL and
+// of links, or nil when there are none
+func inspectIDN(pageURL string, links []string) *IDN {
+	idn := &IDN{}
+	if parsed, err := url.Parse(pageURL); err == nil {
+		if host, ok := idnHost(parsed.Hostname()); ok {
+			idn.Host = &host
+		}
+	}
+	for _, link := range links {
+		parsed, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		host, ok := idnHost(parsed.Hostname())
+		if !ok || idn.Host != nil && host.ASCII == idn.Host.ASCII {
+			continue
+		}
+		if !slices.ContainsFunc(idn.Links, func(h IDNHost) bool { return h.ASCII == host.ASCII }) {
+			idn.Links = append(idn.Links, host)
+		}
+	}
+	if idn.Host == nil && len(idn.Links) == 0 {
+		return nil
+	}
+	return idn
+}
+
+// idnHost describes host, which may be in either form, and reports whether
+// it is an internationalized name that can be decoded
+func idnHost(host string) (IDNHost, bool) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	ascii, err := idna.Punycode.ToASCII(host)
+	if err != nil {
+		return IDNHost{}, false
+	}
+	unicodeHost, err := idna.Punycode.ToUnicode(ascii)
+	if err != nil || unicodeHost == ascii {
+		return IDNHost{}, false
+	}
+
+	h := IDNHost{ASCII: ascii, Unicode: unicodeHost, Scripts: []string{}}
+	for _, label := range strings.Split(unicodeHost, ".") {
+		scripts := labelScripts(label)
+		for _, script := range scripts {
+			if !slices.Contains(h.Scripts, script) {
+				h.Scripts = append(h.Scripts, script)
+			}
+		}
+		if len(scripts) > 1 && !slices.ContainsFunc(compatibleScripts, func(allowed []string) bool {
+			return !slices.ContainsFunc(scripts, func(s string) bool { return !slices.Contains(allowed, s) })
+		}) {
+			h.Homograph = true
+		}
+	}
+	return h, true
+}
+
+// labelScripts returns the scripts of the letters in label, in order of
+// first use. Digits, hyphens and combining marks belong to no script.
+func labelScripts(label string) []string {
+	var scripts []string
+	for _, r := range label {
+		for name, table := range unicode.Scripts {
+			if name == "Common" || name == "Inherited" || !unicode.Is(table, r) {
+				continue
+			}
+			if !slices.Contains(scripts, name) {
+				scripts = append(scripts, name)
+			}
+			break
+		}
+	}
+	return scripts
+}
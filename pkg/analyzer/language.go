@@ -0,0 +1,304 @@
+package analyzer
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// maxLanguageLetters is the number of letters of text read to detect the
+// page's language, which is plenty to tell the languages apart
+const maxLanguageLetters = 20000
+
+// minLanguageLetters is the number of letters below which a page has too
+// little text for its language to be detected
+const minLanguageLetters = 50
+
+// languageStopwords are frequent words of the languages detected from
+// Latin text, in lower case. No word is in two lists.
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "that", "it", "with", "for", "are", "was", "this", "you", "have", "be", "on", "from", "by", "at", "or"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "mit", "sich", "auf", "für", "den", "von", "zu", "dem", "auch", "wir", "sie", "werden"},
+	"fr": {"le", "les", "et", "est", "des", "une", "pour", "dans", "qui", "pas", "sur", "avec", "du", "au", "nous", "vous", "ce", "sont", "être", "cette"},
+	"es": {"el", "los", "las", "y", "más", "pero", "está", "son", "muy", "también", "sus", "hay", "cuando", "fue", "desde", "tiene", "puede", "ellos", "usted", "nosotros"},
+	"it": {"il", "di", "che", "è", "per", "non", "sono", "gli", "della", "anche", "più", "questo", "nel", "alla", "essere", "ma", "dei", "delle", "loro", "molto"},
+	"pt": {"não", "uma", "os", "com", "em", "mais", "também", "são", "foi", "ao", "seu", "sua", "pelo", "muito", "ele", "dos", "das", "isso", "você", "pela"},
+	"nl": {"het", "een", "van", "niet", "op", "met", "voor", "zijn", "ook", "maar", "aan", "wij", "dit", "wordt", "naar", "bij", "uit", "heeft", "dat", "kunnen"},
+}
+
+// stopwordLanguages maps each stopword to its language
+var stopwordLanguages = func() map[string]string {
+	languages := make(map[string]string)
+	for language, words := range languageStopwords {
+		for _, word := range words {
+			languages[word] = language
+		}
+	}
+	return languages
+}()
+
+// scriptLanguages are the languages told apart by script alone. Cyrillic
+// and Arabic are written in too many languages to be among them.
+var scriptLanguages = map[string]string{
+	"Hangul": "ko",
+	"Hebrew": "he",
+	"Greek":  "el",
+	"Thai":   "th",
+}
+
+// languageScripts are the scripts whose letters are counted, with Hiragana
+// and Katakana counted together as Kana
+var languageScripts = []string{"Latin", "Han", "Hiragana", "Katakana", "Hangul", "Hebrew", "Greek", "Thai", "Cyrillic", "Arabic"}
+
+// Languages cross-checks the languages the page declares with the one its
+// text is written in. ContentLanguage lists the Content-Language header's
+// languages, for fetched pages, Hreflang is the hreflang of the page's
+// alternate link to itself and Detected the language of its text, when
+// there is enough of it in a language that can be detected. Mismatch is
+// set when two of them, or the html lang attribute, name different
+// languages; only primary subtags are compared, so en-US agrees with en.
+type Languages struct {
+	ContentLanguage []string `json:"content_language,omitempty"`
+	Hreflang        string   `json:"hreflang,omitempty"`
+	Detected        string   `json:"detected,omitempty"`
+	Mismatch        bool     `json:"mismatch"`
+}
+
+// inspectContentLanguage records the response's Content-Language for the
+// seo section
+func inspectContentLanguage(seo *SEO, header http.Header) {
+	var languages []string
+	for _, value := range header.Values("Content-Language") {
+		for _, language := range strings.Split(value, ",") {
+			if language = strings.TrimSpace(language); language != "" {
+				languages = append(languages, language)
+			}
+		}
+	}
+	if len(languages) > 0 {
+		seo.Languages = &Languages{ContentLanguage: languages}
+	}
+}
+
+// languageChecker finds the page's hreflang self-reference and detects the
+// language of its text, from the same tags and text in the DOM and
+// streaming analyses. Its methods do nothing on a nil checker.
+type languageChecker struct {
+	seo      *SEO
+	baseURL  *url.URL
+	hreflang string
+	// page is the normalized page URL the hreflang links are compared with
+	page string
+	// hidden counts the open elements whose text is not shown
+	hidden int
+	// letters counts the letters read by script and stopwords the
+	// stopwords by language
+	letters   map[string]int
+	stopwords map[string]int
+	total     int
+}
+
+// newLanguageChecker returns a checker for the page at pageURL that fills
+// in seo, or nil when the section was not requested
+func newLanguageChecker(seo *SEO, baseURL *url.URL, pageURL string) *languageChecker {
+	if seo == nil {
+		return nil
+	}
+	c := &languageChecker{
+		seo:       seo,
+		baseURL:   baseURL,
+		letters:   make(map[string]int),
+		stopwords: make(map[string]int),
+	}
+	if parsed, err := url.Parse(pageURL); err == nil {
+		c.page = pageKey(parsed)
+	}
+	return c
+}
+
+// checkLanguages fills in seo's Languages for a parsed document
+func checkLanguages(doc *html.Node, seo *SEO, baseURL *url.URL, pageURL string) {
+	c := newLanguageChecker(seo, baseURL, pageURL)
+	if c == nil {
+		return
+	}
+	walkTags(doc, c.start, c.text, c.end)
+	c.finish()
+}
+
+// start handles a start tag; tag is lower case
+func (c *languageChecker) start(tag string, attrs []html.Attribute) {
+	if c == nil {
+		return
+	}
+	switch tag {
+	case "script", "style", "template", "noscript":
+		c.hidden++
+	case "link":
+		// x-default marks the page for other languages and names none
+		hreflang := strings.TrimSpace(attrValue(attrs, "hreflang"))
+		if c.hreflang != "" || hreflang == "" || strings.EqualFold(hreflang, "x-default") || !hasToken(attrValue(attrs, "rel"), "alternate") {
+			return
+		}
+		if href, err := url.Parse(strings.TrimSpace(attrValue(attrs, "href"))); err == nil && pageKey(c.baseURL.ResolveReference(href)) == c.page {
+			c.hreflang = hreflang
+		}
+	}
+}
+
+// text handles text content
+func (c *languageChecker) text(text string) {
+	if c == nil || c.hidden > 0 || c.total >= maxLanguageLetters {
+		return
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, word := range words {
+		if language := stopwordLanguages[word]; language != "" {
+			c.stopwords[language]++
+		}
+		for _, r := range word {
+			c.total++
+			for _, script := range languageScripts {
+				if unicode.Is(unicode.Scripts[script], r) {
+					c.letters[script]++
+					break
+				}
+			}
+		}
+	}
+}
+
+// end handles an end tag; tag is lower case
+func (c *languageChecker) end(tag string) {
+	if c == nil {
+		return
+	}
+	switch tag {
+	case "script", "style", "template", "noscript":
+		c.hidden = max(c.hidden-1, 0)
+	}
+}
+
+// finish sets seo's Languages, keeping the Content-Language recorded from
+// the response. It stays nil when no language is known at all.
+func (c *languageChecker) finish() {
+	if c == nil {
+		return
+	}
+	languages := c.seo.Languages
+	if languages == nil {
+		languages = &Languages{}
+	}
+	languages.Hreflang = c.hreflang
+	languages.Detected = c.detect()
+
+	sources := languageSources(c.seo.Lang, languages)
+	for i, a := range sources {
+		for _, b := range sources[i+1:] {
+			if !slices.ContainsFunc(a.primary, func(p string) bool { return slices.Contains(b.primary, p) }) {
+				languages.Mismatch = true
+			}
+		}
+	}
+	if len(sources) > 0 {
+		c.seo.Languages = languages
+	}
+}
+
+// detect returns the language of the text read, or "" when it is unknown
+func (c *languageChecker) detect() string {
+	if c.total < minLanguageLetters {
+		return ""
+	}
+	kana := c.letters["Hiragana"] + c.letters["Katakana"]
+	dominant, count := "", 0
+	for _, script := range languageScripts {
+		letters := c.letters[script]
+		if script == "Hiragana" || script == "Katakana" {
+			script, letters = "Kana", kana
+		}
+		if letters > count {
+			dominant, count = script, letters
+		}
+	}
+
+	switch dominant {
+	case "Latin":
+		// The language with the most stopwords wins when it has twice as
+		// many as the next
+		best, first, second := "", 0, 0
+		for language := range languageStopwords {
+			switch hits := c.stopwords[language]; {
+			case hits > first:
+				best, first, second = language, hits, first
+			case hits > second:
+				second = hits
+			}
+		}
+		if first >= 5 && first >= 2*second {
+			return best
+		}
+		return ""
+	case "Han", "Kana":
+		// Japanese mixes kana with Han, which Chinese is written in alone
+		if kana*10 >= kana+c.letters["Han"] {
+			return "ja"
+		}
+		return "zh"
+	default:
+		return scriptLanguages[dominant]
+	}
+}
+
+// languageSource is one of the languages a page declares or is detected
+// in: evidence names it for findings and primary holds the primary
+// subtags of its languages
+type languageSource struct {
+	evidence string
+	primary  []string
+}
+
+// languageSources returns the known languages of a page whose html lang
+// attribute is lang, in a fixed order
+func languageSources(lang string, languages *Languages) []languageSource {
+	var sources []languageSource
+	add := func(name string, tags ...string) {
+		var primary []string
+		for _, tag := range tags {
+			if p := primaryLanguage(tag); p != "" && p != "*" {
+				primary = append(primary, p)
+			}
+		}
+		if len(primary) > 0 {
+			sources = append(sources, languageSource{evidence: name + "=" + strings.Join(tags, ", "), primary: primary})
+		}
+	}
+	add("lang", lang)
+	if languages != nil {
+		add("Content-Language", languages.ContentLanguage...)
+		add("hreflang", languages.Hreflang)
+		add("detected", languages.Detected)
+	}
+	return sources
+}
+
+// primaryLanguage returns the lower case primary subtag of a language tag,
+// e.g. "en" for "en-US"
+func primaryLanguage(tag string) string {
+	primary, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+	primary, _, _ = strings.Cut(primary, "_")
+	return strings.ToLower(primary)
+}
+
+// pageKey returns the form of a page URL that hreflang links are compared
+// in: normalized and without its fragment
+func pageKey(u *url.URL) string {
+	key := normalizeURL(u, false)
+	key.Fragment = ""
+	key.RawFragment = ""
+	return key.String()
+}
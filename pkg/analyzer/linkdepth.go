@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"math"
+	"net/url"
+	"strings"
+)
+
+// deepLinkDepth is the path depth from which an internal link counts as
+// deep, e.g. /docs/guides/setup/linux
+const deepLinkDepth = 4
+
+// LinkDepth describes how deep in the site's path hierarchy the internal
+// links point, telling flat sites from deeply nested ones. A link's depth
+// is the number of segments in its path, so / is 0, /blog/ is 1 and
+// /blog/2024/post.html is 3. Counts holds the number of links at each
+// depth, Deep the number at deepLinkDepth or more, and Mean the average
+// depth, to two decimals.
+type LinkDepth struct {
+	Counts map[int]int `json:"counts"`
+	Max    int         `json:"max"`
+	Mean   float64     `json:"mean"`
+	Deep   int         `json:"deep"`
+}
+
+// add counts an internal link
+func (d *LinkDepth) add(link *url.URL) {
+	depth := pathDepth(link.Path)
+	if d.Counts == nil {
+		d.Counts = make(map[int]int)
+	}
+	d.Counts[depth]++
+	d.Max = max(d.Max, depth)
+	if depth >= deepLinkDepth {
+		d.Deep++
+	}
+}
+
+// finish computes the mean depth. It does nothing on a nil LinkDepth.
+func (d *LinkDepth) finish() {
+	if d == nil {
+		return
+	}
+	links, total := 0, 0
+	for depth, count := range d.Counts {
+		links += count
+		total += depth * count
+	}
+	if links > 0 {
+		d.Mean = math.Round(float64(total)/float64(links)*100) / 100
+	}
+}
+
+// pathDepth returns the number of non-empty segments in an URL path
+func pathDepth(path string) int {
+	depth := 0
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			depth++
+		}
+	}
+	return depth
+}
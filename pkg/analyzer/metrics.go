@@ -0,0 +1,206 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricsNamespace prefixes the analyzer's metric names
+const metricsNamespace = "analyzer"
+
+// metrics are an analyzer's Prometheus collectors. Each analyzer has its
+// own, exported only when WithRegisterer is given, so importing the package
+// registers nothing.
+type metrics struct {
+	analyses          *prometheus.CounterVec
+	analysisDuration  *prometheus.HistogramVec
+	linksChecked      prometheus.Counter
+	linkCheckFailures *prometheus.CounterVec
+	activeAnalyses    prometheus.Gauge
+	queuedAnalyses    *prometheus.GaugeVec
+	activeWorkers     prometheus.Gauge
+	maxWorkers        prometheus.Gauge
+	queuedLinks       prometheus.Gauge
+	linkCheckDuration prometheus.Histogram
+}
+
+// newMetrics func creates a new, unregistered set of analyzer metrics
+func newMetrics() *metrics {
+	return &metrics{
+		analyses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: metricsNamespace,
+				Name:      "analyses_total",
+				Help:      "Total number of page analyses by outcome",
+			},
+			[]string{"outcome"},
+		),
+		analysisDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: metricsNamespace,
+				Name:      "analysis_duration_seconds",
+				Help:      "Duration of page analyses in seconds, including link checks",
+				Buckets:   []float64{0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0, 20.0, 30.0, 60.0},
+			},
+			[]string{"outcome"},
+		),
+		linksChecked: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: metricsNamespace,
+				Name:      "links_checked_total",
+				Help:      "Total number of links checked for accessibility",
+			},
+		),
+		linkCheckFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: metricsNamespace,
+				Name:      "link_check_failures_total",
+				Help:      "Total number of inaccessible links by reason",
+			},
+			[]string{"reason"},
+		),
+		activeAnalyses: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: metricsNamespace,
+				Name:      "active_analyses",
+				Help:      "Number of page analyses in progress",
+			},
+		),
+		queuedAnalyses: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: metricsNamespace,
+				Name:      "queued_analyses",
+				Help:      "Number of page analyses waiting for a slot by priority",
+			},
+			[]string{"priority"},
+		),
+		activeWorkers: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: metricsNamespace,
+				Name:      "active_workers",
+				Help:      "Number of link checker workers running",
+			},
+		),
+		maxWorkers: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: metricsNamespace,
+				Name:      "max_workers",
+				Help:      "Number of link checker workers each analysis may use",
+			},
+		),
+		queuedLinks: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: metricsNamespace,
+				Name:      "queued_links",
+				Help:      "Number of links waiting for a link checker worker",
+			},
+		),
+		linkCheckDuration: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: metricsNamespace,
+				Name:      "link_check_duration_seconds",
+				Help:      "Duration of link accessibility checks in seconds, excluding cached results",
+				Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0, 30.0},
+			},
+		),
+	}
+}
+
+// register registers the metrics with reg, stopping at the first error
+func (m *metrics) register(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{
+		m.analyses,
+		m.analysisDuration,
+		m.linksChecked,
+		m.linkCheckFailures,
+		m.activeAnalyses,
+		m.queuedAnalyses,
+		m.activeWorkers,
+		m.maxWorkers,
+		m.queuedLinks,
+		m.linkCheckDuration,
+	} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Analysis outcomes
+const (
+	outcomeSuccess     = "success"
+	outcomeInvalidURL  = "invalid_url"
+	outcomeTimeout     = "timeout"
+	outcomeCanceled    = "canceled"
+	outcomeFetchFailed = "fetch_failed"
+	outcomeRejected    = "rejected"
+)
+
+// Link check failure reasons
+const (
+	reasonInvalidURL  = "invalid_url"
+	reasonTimeout     = "timeout"
+	reasonCanceled    = "canceled"
+	reasonRedirects   = "too_many_redirects"
+	reasonNetwork     = "network"
+	reasonClientError = "http_4xx"
+	reasonServerError = "http_5xx"
+)
+
+// observeAnalysis records a finished analysis
+func (m *metrics) observeAnalysis(start time.Time, err error) {
+	outcome := analysisOutcome(err)
+	m.analyses.WithLabelValues(outcome).Inc()
+	m.analysisDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+}
+
+// observeLinkCheck records a finished link check
+func (m *metrics) observeLinkCheck(start time.Time) {
+	m.linkCheckDuration.Observe(time.Since(start).Seconds())
+}
+
+// analysisOutcome classifies an analysis error
+func analysisOutcome(err error) string {
+	switch {
+	case err == nil:
+		return outcomeSuccess
+	case errors.Is(err, ErrInvalidURL):
+		return outcomeInvalidURL
+	case errors.Is(err, ErrBusy):
+		return outcomeRejected
+	case isTimeout(err):
+		return outcomeTimeout
+	case errors.Is(err, context.Canceled):
+		return outcomeCanceled
+	default:
+		return outcomeFetchFailed
+	}
+}
+
+// linkFailureReason classifies a failed link request
+func linkFailureReason(err error) string {
+	switch {
+	case errors.Is(err, errTooManyRedirects):
+		return reasonRedirects
+	case isTimeout(err):
+		return reasonTimeout
+	case errors.Is(err, context.Canceled):
+		return reasonCanceled
+	default:
+		return reasonNetwork
+	}
+}
+
+// isTimeout reports whether err is a deadline or network timeout
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
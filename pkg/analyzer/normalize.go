@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// trackingParams are the click ID parameters StripTrackingParams removes,
+// besides the utm_ campaign parameters
+var trackingParams = []string{
+	"fbclid", "gclid", "gclsrc", "dclid", "gbraid", "wbraid", "msclkid",
+	"yclid", "twclid", "ttclid", "igshid", "li_fat_id", "mc_cid", "mc_eid",
+	"_ga", "_gl",
+}
+
+// NormalizeURL returns rawURL the way AnalyzeURL records it, so callers can
+// key caches and history by it: http:// is assumed when there is no
+// scheme, the host is lower-cased, default ports are removed and, with
+// StripTrackingParams, so are tracking parameters. URLs that cannot be
+// parsed are returned unchanged.
+func (a *Analyzer) NormalizeURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err == nil && parsed.Scheme == "" {
+		parsed, err = url.Parse("http://" + rawURL)
+	}
+	if err != nil {
+		return rawURL
+	}
+	return a.normalizeURL(parsed).String()
+}
+
+// normalizeURL returns a normalized copy of u with the current settings
+func (a *Analyzer) normalizeURL(u *url.URL) *url.URL {
+	return normalizeURL(u, a.limits().StripTrackingParams)
+}
+
+// normalizeURL returns a copy of u with a lower case host and no default
+// port, and without tracking parameters when stripTracking is set. The
+// remaining query parameters keep their order.
+func normalizeURL(u *url.URL, stripTracking bool) *url.URL {
+	normalized := *u
+	normalized.Host = strings.ToLower(normalized.Host)
+	if port := normalized.Port(); port == "80" && normalized.Scheme == "http" || port == "443" && normalized.Scheme == "https" {
+		normalized.Host = strings.TrimSuffix(normalized.Host, ":"+port)
+	}
+	if stripTracking && normalized.RawQuery != "" {
+		var kept []string
+		for _, param := range strings.Split(normalized.RawQuery, "&") {
+			if !isTrackingParam(param) {
+				kept = append(kept, param)
+			}
+		}
+		normalized.RawQuery = strings.Join(kept, "&")
+	}
+	return &normalized
+}
+
+// isTrackingParam reports whether a raw name=value query parameter is a
+// tracking parameter
+func isTrackingParam(param string) bool {
+	name, _, _ := strings.Cut(param, "=")
+	if unescaped, err := url.QueryUnescape(name); err == nil {
+		name = unescaped
+	}
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, "utm_") || slices.Contains(trackingParams, name)
+}
@@ -0,0 +1,260 @@
+package analyzer
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultUserAgent is sent with page fetches and link checks unless
+// overridden with WithUserAgent
+const DefaultUserAgent = "Web-Analyzer/1.0"
+
+// defaultConfig matches the service's configuration defaults
+var defaultConfig = Config{
+	MaxWorkers:         10,
+	RequestTimeout:     30 * time.Second,
+	LinkTimeout:        10 * time.Second,
+	MaxRedirects:       5,
+	MaxPageSize:        10 << 20,
+	MaxNodes:           500_000,
+	MaxDepth:           512,
+	StreamingThreshold: 2 << 20,
+	BatchConcurrency:   4,
+	QueueTimeout:       2 * time.Second,
+	MaxOutlineEntries:  100,
+	PageSpeed: PageSpeedConfig{
+		Strategy: "mobile",
+		Endpoint: DefaultPageSpeedEndpoint,
+		Timeout:  time.Minute,
+	},
+}
+
+// Option configures an Analyzer built by NewWithOptions. Later options
+// override earlier ones.
+type Option func(*Analyzer)
+
+// NewWithOptions func creates a new analyzer singleton instance configured by
+// options, starting from the service defaults and slog.Default()
+func NewWithOptions(opts ...Option) *Analyzer {
+	a := &Analyzer{
+		config:    defaultConfig,
+		logger:    slog.Default(),
+		userAgent: DefaultUserAgent,
+		metrics:   newMetrics(),
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.client == nil {
+		a.client = a.newHTTPClient(a.config.RequestTimeout)
+	}
+	settings := a.config
+	a.settings.Store(&settings)
+	a.setRules(a.config.Rules)
+	a.maxWorkers.Store(int64(a.config.MaxWorkers))
+	a.metrics.maxWorkers.Set(float64(a.config.MaxWorkers))
+	if a.config.MaxConcurrentAnalyses > 0 {
+		a.queue = newQueue(a.config.MaxConcurrentAnalyses, a.metrics.queuedAnalyses)
+	}
+	if a.registerer != nil {
+		if err := a.metrics.register(a.registerer); err != nil {
+			a.logger.Error("Failed to register analyzer metrics", "error", err)
+		}
+	}
+
+	return a
+}
+
+// WithConfig replaces all analyzer settings
+func WithConfig(cfg Config) Option {
+	return func(a *Analyzer) {
+		a.config = cfg
+	}
+}
+
+// WithHTTPClient sets the client used to fetch pages. Its transport is also
+// used for link checks, which keep their own timeout and redirect limit,
+// unless WithTransport or WithLinkCheckClient is given.
+func WithHTTPClient(client *http.Client) Option {
+	return func(a *Analyzer) {
+		a.client = client
+	}
+}
+
+// WithLinkCheckClient sets the client used as-is for link checks, e.g. a
+// test double or a client with its own redirect policy. Link checks then use
+// the client's timeout instead of the configured link timeout.
+func WithLinkCheckClient(client *http.Client) Option {
+	return func(a *Analyzer) {
+		a.linkClient = client
+	}
+}
+
+// WithRegisterer registers the analyzer's metrics with reg, such as
+// prometheus.DefaultRegisterer. Without it the metrics are kept but not
+// exported. Analyzers sharing a registerer must not both register.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(a *Analyzer) {
+		a.registerer = reg
+	}
+}
+
+// WithLinkCache consults cache before checking a link and stores the
+// outcome afterwards
+func WithLinkCache(cache LinkCache) Option {
+	return func(a *Analyzer) {
+		a.linkCache = cache
+	}
+}
+
+// WithTransport sets the transport for page fetches and link checks while
+// keeping the configured timeouts and redirect limit, e.g. for recording
+// transports or caching proxies. Clients supplied with WithHTTPClient or
+// WithLinkCheckClient keep their own transports.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(a *Analyzer) {
+		a.transport = transport
+	}
+}
+
+// WithLogger sets the analyzer's logger
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *Analyzer) {
+		a.logger = logger
+	}
+}
+
+// WithMaxWorkers sets the number of concurrent link checkers per analysis
+func WithMaxWorkers(n int) Option {
+	return func(a *Analyzer) {
+		a.config.MaxWorkers = n
+	}
+}
+
+// WithRequestTimeout sets the page fetch timeout. It has no effect on a
+// client supplied with WithHTTPClient.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(a *Analyzer) {
+		a.config.RequestTimeout = d
+	}
+}
+
+// WithLinkTimeout sets the timeout for each link check
+func WithLinkTimeout(d time.Duration) Option {
+	return func(a *Analyzer) {
+		a.config.LinkTimeout = d
+	}
+}
+
+// WithMaxRedirects sets how many redirects page fetches and link checks follow
+func WithMaxRedirects(n int) Option {
+	return func(a *Analyzer) {
+		a.config.MaxRedirects = n
+	}
+}
+
+// WithMaxPageSize sets the largest page body, in bytes, that is fetched for
+// analysis. Zero disables the limit.
+func WithMaxPageSize(n int64) Option {
+	return func(a *Analyzer) {
+		a.config.MaxPageSize = n
+	}
+}
+
+// WithMaxNodes limits the elements a document may have; 0 disables the
+// limit
+func WithMaxNodes(n int) Option {
+	return func(a *Analyzer) {
+		a.config.MaxNodes = n
+	}
+}
+
+// WithMaxDepth limits how deep a document may nest elements; 0 disables the
+// limit
+func WithMaxDepth(n int) Option {
+	return func(a *Analyzer) {
+		a.config.MaxDepth = n
+	}
+}
+
+// WithStreamingThreshold sets the page size, in bytes, above which pages are
+// analyzed token by token to save memory. Zero always builds a DOM.
+func WithStreamingThreshold(n int64) Option {
+	return func(a *Analyzer) {
+		a.config.StreamingThreshold = n
+	}
+}
+
+// WithBatchConcurrency sets how many pages AnalyzeMany analyzes at once
+func WithBatchConcurrency(n int) Option {
+	return func(a *Analyzer) {
+		a.config.BatchConcurrency = n
+	}
+}
+
+// WithMaxConcurrentAnalyses limits how many analyses run at once; 0
+// disables the limit
+func WithMaxConcurrentAnalyses(n int) Option {
+	return func(a *Analyzer) {
+		a.config.MaxConcurrentAnalyses = n
+	}
+}
+
+// WithQueueTimeout sets how long an analysis waits for a free slot
+func WithQueueTimeout(d time.Duration) Option {
+	return func(a *Analyzer) {
+		a.config.QueueTimeout = d
+	}
+}
+
+// WithMaxOutlineEntries limits the headings listed by the outline section;
+// 0 lists them all
+func WithMaxOutlineEntries(n int) Option {
+	return func(a *Analyzer) {
+		a.config.MaxOutlineEntries = n
+	}
+}
+
+// WithMaxMetaRefreshes sets how many meta refresh redirects AnalyzeURL
+// follows; 0 only reports them
+func WithMaxMetaRefreshes(n int) Option {
+	return func(a *Analyzer) {
+		a.config.MaxMetaRefreshes = n
+	}
+}
+
+// WithMaxFrames sets how many frame sources of a frameset page AnalyzeURL
+// analyzes; 0 only reports them
+func WithMaxFrames(n int) Option {
+	return func(a *Analyzer) {
+		a.config.MaxFrames = n
+	}
+}
+
+// WithInternalSubdomains sets whether links to other subdomains of the
+// page's registrable domain count as internal
+func WithInternalSubdomains(internal bool) Option {
+	return func(a *Analyzer) {
+		a.config.InternalSubdomains = internal
+	}
+}
+
+// WithStripTrackingParams sets whether tracking parameters are removed from
+// analyzed and checked URLs
+func WithStripTrackingParams(strip bool) Option {
+	return func(a *Analyzer) {
+		a.config.StripTrackingParams = strip
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(a *Analyzer) {
+		a.userAgent = userAgent
+	}
+}
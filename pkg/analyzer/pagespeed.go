@@ -0,0 +1,152 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultPageSpeedEndpoint is the PageSpeed Insights v5 runPagespeed URL
+const DefaultPageSpeedEndpoint = "https://www.googleapis.com/pagespeedonline/v5/runPagespeed"
+
+// errPageSpeedNotConfigured is reported in the web_vitals section when no
+// API key is set
+var errPageSpeedNotConfigured = errors.New("PageSpeed Insights is not configured")
+
+// pageSpeedResponse is the part of a runPagespeed response the analyzer uses
+type pageSpeedResponse struct {
+	LoadingExperience struct {
+		Metrics         map[string]pageSpeedFieldMetric `json:"metrics"`
+		OverallCategory string                          `json:"overall_category"`
+		// OriginFallback is set when the field data is for the whole origin
+		// because the page itself has too little traffic
+		OriginFallback bool `json:"origin_fallback"`
+	} `json:"loadingExperience"`
+	LighthouseResult struct {
+		Categories struct {
+			Performance struct {
+				Score *float64 `json:"score"`
+			} `json:"performance"`
+		} `json:"categories"`
+		Audits map[string]struct {
+			NumericValue float64 `json:"numericValue"`
+		} `json:"audits"`
+	} `json:"lighthouseResult"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// pageSpeedFieldMetric is a Chrome UX Report metric
+type pageSpeedFieldMetric struct {
+	Percentile float64 `json:"percentile"`
+}
+
+// startWebVitals runs the PageSpeed Insights call for targetURL in the
+// background and returns a function waiting for its outcome, so Lighthouse
+// runs while the page is analyzed. A device overrides the configured
+// strategy.
+func (a *Analyzer) startWebVitals(ctx context.Context, targetURL string, device Device) func() *WebVitals {
+	done := make(chan *WebVitals, 1)
+	go func() {
+		done <- a.fetchWebVitals(ctx, targetURL, device)
+	}()
+	return func() *WebVitals {
+		return <-done
+	}
+}
+
+// fetchWebVitals asks PageSpeed Insights for targetURL's Core Web Vitals.
+// Failures are reported in the returned section's Error.
+func (a *Analyzer) fetchWebVitals(ctx context.Context, targetURL string, device Device) *WebVitals {
+	cfg := a.limits().PageSpeed
+	if device != "" {
+		cfg.Strategy = device.strategy()
+	}
+	vitals := &WebVitals{Strategy: cfg.Strategy}
+
+	ctx, span := tracer.Start(ctx, "analyzer.pagespeed", trace.WithAttributes(attribute.String("pagespeed.strategy", cfg.Strategy)))
+	defer span.End()
+
+	resp, err := a.runPageSpeed(ctx, cfg, targetURL)
+	if err != nil {
+		a.logger.WarnContext(ctx, "PageSpeed Insights request failed", "url", targetURL, "error", err)
+		span.RecordError(err)
+		vitals.Error = err.Error()
+		return vitals
+	}
+
+	lab := resp.LighthouseResult
+	if score := lab.Categories.Performance.Score; score != nil {
+		percent := int(math.Round(*score * 100))
+		vitals.PerformanceScore = &percent
+	}
+	vitals.LCPMS = lab.Audits["largest-contentful-paint"].NumericValue
+	vitals.FCPMS = lab.Audits["first-contentful-paint"].NumericValue
+	vitals.TBTMS = lab.Audits["total-blocking-time"].NumericValue
+	vitals.SpeedIndexMS = lab.Audits["speed-index"].NumericValue
+	vitals.CLS = lab.Audits["cumulative-layout-shift"].NumericValue
+
+	field := resp.LoadingExperience
+	if len(field.Metrics) > 0 && !field.OriginFallback {
+		vitals.Field = &FieldVitals{
+			Category: field.OverallCategory,
+			LCPMS:    int(field.Metrics["LARGEST_CONTENTFUL_PAINT_MS"].Percentile),
+			INPMS:    int(field.Metrics["INTERACTION_TO_NEXT_PAINT"].Percentile),
+			// The API reports layout shift multiplied by 100
+			CLS: field.Metrics["CUMULATIVE_LAYOUT_SHIFT_SCORE"].Percentile / 100,
+		}
+	}
+
+	a.logger.DebugContext(ctx, "PageSpeed Insights completed", "url", targetURL, "lcp_ms", vitals.LCPMS, "cls", vitals.CLS)
+	return vitals
+}
+
+// runPageSpeed calls the runPagespeed endpoint for targetURL
+func (a *Analyzer) runPageSpeed(ctx context.Context, cfg PageSpeedConfig, targetURL string) (*pageSpeedResponse, error) {
+	if cfg.APIKey == "" {
+		return nil, errPageSpeedNotConfigured
+	}
+
+	query := url.Values{}
+	query.Set("url", targetURL)
+	query.Set("key", cfg.APIKey)
+	query.Set("strategy", cfg.Strategy)
+	query.Set("category", "performance")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.Endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.newHTTPClient(cfg.Timeout).Do(req)
+	if err != nil {
+		// The request URL holds the API key, so report the cause only
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("calling PageSpeed Insights: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body pageSpeedResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding PageSpeed Insights response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if body.Error != nil && body.Error.Message != "" {
+			return nil, fmt.Errorf("PageSpeed Insights returned HTTP %d: %s", resp.StatusCode, body.Error.Message)
+		}
+		return nil, fmt.Errorf("PageSpeed Insights returned HTTP %d", resp.StatusCode)
+	}
+	return &body, nil
+}
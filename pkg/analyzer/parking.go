@@ -0,0 +1,182 @@
+package analyzer
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Parking kinds
+const (
+	// ParkingParked is a domain-parking page, typically with ads or an
+	// offer to buy the domain
+	ParkingParked = "parked"
+	// ParkingComingSoon is a "coming soon" or "under construction"
+	// placeholder
+	ParkingComingSoon = "coming_soon"
+)
+
+// maxPlaceholderText is the number of non-space characters of text above
+// which a page has content of its own and is never taken for a placeholder
+const maxPlaceholderText = 2000
+
+// parkingServices are the parking and domain sale services whose links,
+// scripts or frames mark a page as parked, as a host and an optional path
+// prefix
+var parkingServices = []string{
+	"sedoparking.com",
+	"sedo.com",
+	"bodis.com",
+	"parkingcrew.net",
+	"above.com",
+	"afternic.com",
+	"dan.com",
+	"hugedomains.com",
+	"parklogic.com",
+	"undeveloped.com",
+	"domainmarket.com",
+	"parkingpage.namecheap.com",
+	"wsimg.com/parking-lander",
+}
+
+// parkedPhrases and comingSoonPhrases are what the text of those pages
+// says, in lower case
+var (
+	parkedPhrases = []string{
+		"domain is for sale",
+		"domain may be for sale",
+		"domain name is for sale",
+		"buy this domain",
+		"make an offer on this domain",
+		"this domain is parked",
+		"parked free",
+		"domain has been registered",
+		"related searches",
+	}
+	comingSoonPhrases = []string{
+		"coming soon",
+		"under construction",
+		"launching soon",
+		"site is on its way",
+	}
+)
+
+// Parking is set on results for domain-parking and "coming soon" pages, so
+// pipelines can set them aside. Kind is ParkingParked or ParkingComingSoon,
+// and Signals lists what gave the page away, such as a parking service it
+// links to or loads, or a phrase in its text.
+type Parking struct {
+	Kind    string   `json:"kind"`
+	Signals []string `json:"signals"`
+}
+
+// parkingChecker looks for parking services and placeholder phrases, from
+// the same tags and text in the DOM and streaming analyses
+type parkingChecker struct {
+	baseURL  *url.URL
+	services []string
+	// content collects the page's text and length counts its non-space
+	// characters
+	content strings.Builder
+	length  int
+	// hidden counts the open elements whose text is not shown
+	hidden int
+}
+
+// detectParking returns the parsed document's Parking, if it is a
+// placeholder
+func detectParking(doc *html.Node, baseURL *url.URL) *Parking {
+	c := &parkingChecker{baseURL: baseURL}
+	walkTags(doc, c.start, c.text, c.end)
+	return c.result()
+}
+
+// start handles a start tag; tag is lower case
+func (c *parkingChecker) start(tag string, attrs []html.Attribute) {
+	switch tag {
+	case "script", "style", "template", "noscript":
+		c.hidden++
+	}
+	for _, attr := range attrs {
+		if attr.Key != "href" && attr.Key != "src" && attr.Key != "action" {
+			continue
+		}
+		link, err := url.Parse(strings.TrimSpace(attr.Val))
+		if err != nil {
+			continue
+		}
+		if service := parkingService(c.baseURL.ResolveReference(link)); service != "" && !slices.Contains(c.services, service) {
+			c.services = append(c.services, service)
+		}
+	}
+}
+
+// text handles text content
+func (c *parkingChecker) text(text string) {
+	if c.hidden > 0 {
+		return
+	}
+	// Longer pages are not placeholders, so their text is not needed
+	if c.length += textLength(text); c.length <= maxPlaceholderText {
+		c.content.WriteString(text)
+		c.content.WriteByte(' ')
+	}
+}
+
+// end handles an end tag; tag is lower case
+func (c *parkingChecker) end(tag string) {
+	switch tag {
+	case "script", "style", "template", "noscript":
+		c.hidden = max(c.hidden-1, 0)
+	}
+}
+
+// result returns the page's Parking, or nil when it has content of its own
+// or no placeholder signals
+func (c *parkingChecker) result() *Parking {
+	if c.length > maxPlaceholderText {
+		return nil
+	}
+	text := strings.ToLower(strings.Join(strings.Fields(c.content.String()), " "))
+
+	var signals []string
+	for _, service := range c.services {
+		signals = append(signals, "points to "+service)
+	}
+	for _, phrase := range parkedPhrases {
+		if strings.Contains(text, phrase) {
+			signals = append(signals, `says "`+phrase+`"`)
+		}
+	}
+	if len(signals) > 0 {
+		return &Parking{Kind: ParkingParked, Signals: signals}
+	}
+
+	for _, phrase := range comingSoonPhrases {
+		if strings.Contains(text, phrase) {
+			signals = append(signals, `says "`+phrase+`"`)
+		}
+	}
+	if len(signals) > 0 {
+		return &Parking{Kind: ParkingComingSoon, Signals: signals}
+	}
+	return nil
+}
+
+// parkingService returns the entry of parkingServices that link points to,
+// or ""
+func parkingService(link *url.URL) string {
+	host := strings.ToLower(link.Hostname())
+	if host == "" {
+		return ""
+	}
+	for _, service := range parkingServices {
+		serviceHost, path, _ := strings.Cut(service, "/")
+		if (host == serviceHost || strings.HasSuffix(host, "."+serviceHost)) && (path == "" || strings.HasPrefix(link.Path, "/"+path)) {
+			return service
+		}
+	}
+	return ""
+}
@@ -0,0 +1,228 @@
+package analyzer
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Phishing risk levels, from the score
+const (
+	PhishingLow    = "low"
+	PhishingMedium = "medium"
+	PhishingHigh   = "high"
+)
+
+// Phishing signal IDs
+const (
+	// SignalCrossOriginLogin is a form with a password field that posts
+	// to another host
+	SignalCrossOriginLogin = "cross-origin-login"
+	// SignalBrandMismatch is a well-known brand in the title of a page
+	// that is not on one of the brand's domains
+	SignalBrandMismatch = "brand-mismatch"
+	// SignalDataURIForm is a form that posts to a data: URI
+	SignalDataURIForm = "data-uri-form"
+	// SignalPunycodeHost is a page whose host has punycode labels, as
+	// look-alike domains do
+	SignalPunycodeHost = "punycode-host"
+)
+
+// phishingWeights are what each signal adds to the score
+var phishingWeights = map[string]int{
+	SignalCrossOriginLogin: 40,
+	SignalBrandMismatch:    30,
+	SignalDataURIForm:      30,
+	SignalPunycodeHost:     20,
+}
+
+// phishingBrand is a brand often impersonated, as it appears in titles in
+// lower case, and the names of the domains it serves pages from, without
+// their public suffix
+type phishingBrand struct {
+	name    string
+	domains []string
+}
+
+// phishingBrands are the brands looked for in titles, in order
+var phishingBrands = []phishingBrand{
+	{"paypal", []string{"paypal"}},
+	{"microsoft", []string{"microsoft", "microsoftonline", "live", "office", "office365", "outlook", "bing"}},
+	{"office 365", []string{"microsoft", "microsoftonline", "live", "office", "office365"}},
+	{"outlook", []string{"microsoft", "microsoftonline", "live", "office", "outlook"}},
+	{"apple", []string{"apple", "icloud"}},
+	{"icloud", []string{"apple", "icloud"}},
+	{"google", []string{"google", "gmail", "youtube"}},
+	{"gmail", []string{"google", "gmail"}},
+	{"amazon", []string{"amazon", "aws"}},
+	{"facebook", []string{"facebook", "fb", "meta"}},
+	{"instagram", []string{"instagram"}},
+	{"netflix", []string{"netflix"}},
+	{"linkedin", []string{"linkedin"}},
+	{"dropbox", []string{"dropbox"}},
+	{"docusign", []string{"docusign"}},
+	{"adobe", []string{"adobe"}},
+	{"dhl", []string{"dhl"}},
+	{"fedex", []string{"fedex"}},
+	{"chase", []string{"chase"}},
+	{"wells fargo", []string{"wellsfargo"}},
+	{"bank of america", []string{"bankofamerica"}},
+	{"coinbase", []string{"coinbase"}},
+	{"binance", []string{"binance"}},
+}
+
+// Phishing scores how much the page looks like a phishing page, for
+// triaging URLs. Score adds up the weights of the signals found, up to 100;
+// Risk is PhishingHigh from 60 and PhishingMedium from 30. A high score
+// calls for a closer look and is no verdict.
+type Phishing struct {
+	Score   int              `json:"score"`
+	Risk    string           `json:"risk"`
+	Signals []PhishingSignal `json:"signals"`
+}
+
+// PhishingSignal is one reason for the score, such as SignalBrandMismatch,
+// with what set it off. Each signal counts once.
+type PhishingSignal struct {
+	ID     string `json:"id"`
+	Weight int    `json:"weight"`
+	Detail string `json:"detail"`
+}
+
+// phishingChecker looks for the signals in forms, from the same tags in
+// the DOM and streaming analyses. Its methods do nothing on a nil checker.
+type phishingChecker struct {
+	phishing *Phishing
+	baseURL  *url.URL
+	// forms holds the resolved actions of the open forms, innermost last
+	forms []*url.URL
+}
+
+// newPhishingChecker returns a checker that fills in phishing, or nil when
+// the section was not requested
+func newPhishingChecker(phishing *Phishing, baseURL *url.URL) *phishingChecker {
+	if phishing == nil {
+		return nil
+	}
+	return &phishingChecker{phishing: phishing, baseURL: baseURL}
+}
+
+// checkPhishing fills in phishing for a parsed document whose title is
+// title
+func checkPhishing(doc *html.Node, phishing *Phishing, baseURL *url.URL, title string) {
+	c := newPhishingChecker(phishing, baseURL)
+	if c == nil {
+		return
+	}
+	walkTags(doc, c.start, nil, c.end)
+	c.finish(title)
+}
+
+// start handles a start tag; tag is lower case
+func (c *phishingChecker) start(tag string, attrs []html.Attribute) {
+	if c == nil {
+		return
+	}
+	switch tag {
+	case "form":
+		// A form without an action posts to the page itself
+		action := c.baseURL
+		if parsed, err := url.Parse(strings.TrimSpace(attrValue(attrs, "action"))); err == nil {
+			action = c.baseURL.ResolveReference(parsed)
+		}
+		c.forms = append(c.forms, action)
+		if action.Scheme == "data" {
+			c.add(SignalDataURIForm, "a form posts to a data: URI")
+		}
+	case "input":
+		if len(c.forms) == 0 || !strings.EqualFold(strings.TrimSpace(attrValue(attrs, "type")), "password") {
+			return
+		}
+		action := c.forms[len(c.forms)-1]
+		if (action.Scheme == "http" || action.Scheme == "https") && action.Host != c.baseURL.Host {
+			c.add(SignalCrossOriginLogin, "a password form posts to "+action.Host)
+		}
+	}
+}
+
+// end handles an end tag
+func (c *phishingChecker) end(tag string) {
+	if c == nil || tag != "form" || len(c.forms) == 0 {
+		return
+	}
+	c.forms = c.forms[:len(c.forms)-1]
+}
+
+// finish adds the signals of the page's title and host, and scores them
+func (c *phishingChecker) finish(title string) {
+	if c == nil {
+		return
+	}
+	host := strings.TrimSuffix(strings.ToLower(c.baseURL.Hostname()), ".")
+	if host != "" {
+		if brand := impersonatedBrand(title, host); brand != "" {
+			c.add(SignalBrandMismatch, fmt.Sprintf("title mentions %s but the page is on %s", brand, host))
+		}
+		for _, label := range strings.Split(host, ".") {
+			if !strings.HasPrefix(label, "xn--") {
+				continue
+			}
+			detail := fmt.Sprintf("host %s uses punycode", host)
+			if idn, ok := idnHost(host); ok {
+				detail = fmt.Sprintf("host %s (%s) uses punycode", host, idn.Unicode)
+			}
+			c.add(SignalPunycodeHost, detail)
+			break
+		}
+	}
+
+	c.phishing.Score = 0
+	for _, signal := range c.phishing.Signals {
+		c.phishing.Score += signal.Weight
+	}
+	c.phishing.Score = min(c.phishing.Score, 100)
+	switch {
+	case c.phishing.Score >= 60:
+		c.phishing.Risk = PhishingHigh
+	case c.phishing.Score >= 30:
+		c.phishing.Risk = PhishingMedium
+	default:
+		c.phishing.Risk = PhishingLow
+	}
+}
+
+// add records a signal, unless it was already found
+func (c *phishingChecker) add(id, detail string) {
+	if slices.ContainsFunc(c.phishing.Signals, func(s PhishingSignal) bool { return s.ID == id }) {
+		return
+	}
+	c.phishing.Signals = append(c.phishing.Signals, PhishingSignal{ID: id, Weight: phishingWeights[id], Detail: detail})
+}
+
+// impersonatedBrand returns the first brand the title names as a whole
+// word when host is not on one of its domains, or ""
+func impersonatedBrand(title, host string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	text := " " + strings.Join(words, " ") + " "
+
+	// The domain's name is what is left of the registrable domain once
+	// its public suffix is removed, e.g. "paypal" for www.paypal.co.uk
+	name := host
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		suffix, _ := publicsuffix.PublicSuffix(host)
+		name = strings.TrimSuffix(domain, "."+suffix)
+	}
+	for _, brand := range phishingBrands {
+		if strings.Contains(text, " "+brand.name+" ") && !slices.Contains(brand.domains, name) {
+			return brand.name
+		}
+	}
+	return ""
+}
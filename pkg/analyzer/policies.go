@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// referrerPolicies are the Referrer-Policy values browsers recognize
+var referrerPolicies = []string{
+	"no-referrer",
+	"no-referrer-when-downgrade",
+	"same-origin",
+	"origin",
+	"strict-origin",
+	"origin-when-cross-origin",
+	"strict-origin-when-cross-origin",
+	"unsafe-url",
+}
+
+// ReferrerPolicy is the parsed Referrer-Policy header. Policy is the value
+// browsers apply: the last one they recognize, since the header may list
+// fallbacks. Unknown lists the values they ignore.
+type ReferrerPolicy struct {
+	Policy  string   `json:"policy,omitempty"`
+	Unknown []string `json:"unknown,omitempty"`
+	Issues  []string `json:"issues,omitempty"`
+}
+
+// PermissionsPolicy is the parsed Permissions-Policy header. Issues flags
+// features delegated to every origin and members that could not be parsed.
+type PermissionsPolicy struct {
+	Features []PermissionsFeature `json:"features"`
+	Issues   []string             `json:"issues,omitempty"`
+}
+
+// PermissionsFeature is a feature and the origins allowed to use it. The
+// allowlist holds *, self, src and quoted origins without their quotes; an
+// empty one disables the feature.
+type PermissionsFeature struct {
+	Name      string   `json:"name"`
+	Allowlist []string `json:"allowlist"`
+}
+
+// parseReferrerPolicy parses a Referrer-Policy header value
+func parseReferrerPolicy(value string) *ReferrerPolicy {
+	policy := &ReferrerPolicy{}
+	for _, token := range strings.Split(value, ",") {
+		token = strings.ToLower(strings.TrimSpace(token))
+		switch {
+		case token == "":
+		case slices.Contains(referrerPolicies, token):
+			policy.Policy = token
+		default:
+			policy.Unknown = append(policy.Unknown, token)
+		}
+	}
+
+	switch policy.Policy {
+	case "":
+		policy.Issues = append(policy.Issues, "no recognized policy; browsers use strict-origin-when-cross-origin")
+	case "unsafe-url":
+		policy.Issues = append(policy.Issues, "unsafe-url sends the full URL, including path and query, to every origin, also over plain http")
+	case "no-referrer-when-downgrade":
+		policy.Issues = append(policy.Issues, "no-referrer-when-downgrade sends the full URL, including path and query, to other https origins")
+	}
+	return policy
+}
+
+// parsePermissionsPolicy parses a Permissions-Policy header value, a
+// structured field dictionary such as
+// `camera=(), geolocation=(self "https://maps.example")`
+func parsePermissionsPolicy(value string) *PermissionsPolicy {
+	policy := &PermissionsPolicy{Features: []PermissionsFeature{}}
+	for _, member := range splitOutside(value, ',') {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		name, list, ok := strings.Cut(member, "=")
+		name = strings.TrimSpace(name)
+		allowlist, valid := parseAllowlist(list)
+		if !ok || name == "" || !valid {
+			policy.Issues = append(policy.Issues, fmt.Sprintf("could not parse %q", member))
+			continue
+		}
+
+		feature := PermissionsFeature{Name: name, Allowlist: allowlist}
+		// A later member for the same feature replaces the earlier one
+		if i := slices.IndexFunc(policy.Features, func(f PermissionsFeature) bool { return f.Name == name }); i >= 0 {
+			policy.Features[i] = feature
+		} else {
+			policy.Features = append(policy.Features, feature)
+		}
+	}
+
+	for _, feature := range policy.Features {
+		if slices.Contains(feature.Allowlist, "*") {
+			policy.Issues = append(policy.Issues, feature.Name+" is allowed for every origin")
+		}
+	}
+	return policy
+}
+
+// parseAllowlist parses a member's value: an inner list in parentheses or
+// a single item. Parameters after ';' are ignored.
+func parseAllowlist(value string) ([]string, bool) {
+	value = strings.TrimSpace(value)
+	items := []string{}
+	if !strings.HasPrefix(value, "(") {
+		item, _, _ := strings.Cut(value, ";")
+		item, ok := allowlistItem(strings.TrimSpace(item))
+		if !ok {
+			return nil, false
+		}
+		return append(items, item), true
+	}
+
+	end := strings.IndexByte(value, ')')
+	if end < 0 {
+		return nil, false
+	}
+	for _, item := range splitOutside(value[1:end], ' ') {
+		if item == "" {
+			continue
+		}
+		item, _, _ = strings.Cut(item, ";")
+		item, ok := allowlistItem(item)
+		if !ok {
+			return nil, false
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+// allowlistItem unquotes an origin and accepts the *, self and src tokens
+func allowlistItem(item string) (string, bool) {
+	if len(item) >= 2 && item[0] == '"' && item[len(item)-1] == '"' {
+		return item[1 : len(item)-1], true
+	}
+	switch item {
+	case "*", "self", "src":
+		return item, true
+	}
+	return "", false
+}
+
+// splitOutside splits s at sep, except inside quotes and parentheses
+func splitOutside(s string, sep byte) []string {
+	var parts []string
+	quoted, depth, start := false, 0, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth = max(depth-1, 0)
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,82 @@
+package analyzer
+
+// Phase identifies a step of an analysis
+type Phase string
+
+// Analysis phases reported to a ProgressFunc, in order
+const (
+	PhaseFetching      Phase = "fetching"
+	PhaseAnalyzing     Phase = "analyzing"
+	PhaseCheckingLinks Phase = "checking_links"
+	PhaseLinkChecked   Phase = "link_checked"
+	PhaseDone          Phase = "done"
+)
+
+// Progress is a single progress event. Link and Accessible are set for
+// PhaseLinkChecked only; the link counts are set from PhaseCheckingLinks on.
+type Progress struct {
+	URL          string `json:"url"`
+	Phase        Phase  `json:"phase"`
+	Link         string `json:"link,omitempty"`
+	Accessible   bool   `json:"accessible"`
+	LinksChecked int    `json:"links_checked"`
+	LinksTotal   int    `json:"links_total"`
+}
+
+// ProgressFunc receives progress events for one analysis. Calls are not
+// concurrent and block the analysis, so slow consumers should hand events
+// off rather than process them inline.
+type ProgressFunc func(Progress)
+
+// ResultFunc receives each of AnalyzeMany's results as soon as it is
+// ready, with the index of its URL. Calls are not concurrent.
+type ResultFunc func(index int, result *Result)
+
+// AnalyzeOption configures a single analysis
+type AnalyzeOption func(*analyzeOptions)
+
+// analyzeOptions holds per-call settings
+type analyzeOptions struct {
+	progress ProgressFunc
+	onResult ResultFunc
+	sections []Section
+	extract  []string
+	device   Device
+	priority Priority
+
+	// refreshes is how many more meta refreshes AnalyzeURL may follow, and
+	// visited the pages it has fetched so far
+	refreshes int
+	visited   []string
+}
+
+// OnProgress reports the analysis's progress to fn
+func OnProgress(fn ProgressFunc) AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.progress = fn
+	}
+}
+
+// OnResult reports each result of AnalyzeMany to fn as it finishes, so
+// callers can stream a large batch. Single analyses ignore it.
+func OnResult(fn ResultFunc) AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.onResult = fn
+	}
+}
+
+// newAnalyzeOptions applies opts to the defaults
+func newAnalyzeOptions(opts []AnalyzeOption) *analyzeOptions {
+	o := &analyzeOptions{priority: PriorityHigh}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// report sends p to the progress callback, if any
+func (o *analyzeOptions) report(p Progress) {
+	if o.progress != nil {
+		o.progress(p)
+	}
+}
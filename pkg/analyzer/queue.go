@@ -0,0 +1,148 @@
+package analyzer
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Priority orders analyses waiting for a slot when MaxConcurrentAnalyses is
+// reached
+type Priority string
+
+// Analysis priorities. Waiting high priority analyses are always started
+// before low priority ones.
+const (
+	// PriorityHigh is for analyses a caller is waiting on, and is the
+	// default
+	PriorityHigh Priority = "high"
+	// PriorityLow is for batch work such as AnalyzeMany
+	PriorityLow Priority = "low"
+)
+
+// Priorities lists every priority, highest first
+var Priorities = []Priority{PriorityHigh, PriorityLow}
+
+// AtPriority sets the analysis's priority
+func AtPriority(p Priority) AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.priority = p
+	}
+}
+
+// queue hands out a fixed number of analysis slots. Analyses that find every
+// slot taken wait in a FIFO per priority, and a freed slot passes straight
+// to the first waiter of the highest priority.
+type queue struct {
+	mu      sync.Mutex
+	slots   int
+	running int
+	waiting map[Priority]*list.List
+	// queued counts the waiting analyses by priority
+	queued *prometheus.GaugeVec
+}
+
+// waiter is an analysis waiting for a slot. ready is closed once the slot
+// is handed over.
+type waiter struct {
+	ready chan struct{}
+}
+
+// newQueue func creates a new queue with the given number of slots that
+// reports its waiting analyses to queued
+func newQueue(slots int, queued *prometheus.GaugeVec) *queue {
+	q := &queue{
+		slots:   slots,
+		waiting: make(map[Priority]*list.List, len(Priorities)),
+		queued:  queued,
+	}
+	for _, p := range Priorities {
+		q.waiting[p] = list.New()
+	}
+	return q
+}
+
+// depth returns the number of analyses waiting at priority p
+func (q *queue) depth(p Priority) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.waiting[p].Len()
+}
+
+// release gives a slot back, or hands it to the next waiter
+func (q *queue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, p := range Priorities {
+		if front := q.waiting[p].Front(); front != nil {
+			q.waiting[p].Remove(front)
+			q.queued.WithLabelValues(string(p)).Dec()
+			close(front.Value.(*waiter).ready)
+			return
+		}
+	}
+	q.running--
+}
+
+// acquire takes one of the MaxConcurrentAnalyses slots for an analysis at
+// priority p. High priority analyses wait up to QueueTimeout for a slot and
+// then fail with ErrBusy; low priority ones wait until ctx ends. The returned
+// function gives the slot back. Without a limit it returns at once.
+func (a *Analyzer) acquire(ctx context.Context, p Priority) (func(), error) {
+	q := a.queue
+	if q == nil {
+		return func() {}, nil
+	}
+
+	q.mu.Lock()
+	if q.running < q.slots {
+		q.running++
+		q.mu.Unlock()
+		return q.release, nil
+	}
+	w := &waiter{ready: make(chan struct{})}
+	elem := q.waiting[p].PushBack(w)
+	q.queued.WithLabelValues(string(p)).Inc()
+	q.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if p == PriorityHigh {
+		timer := time.NewTimer(a.config.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case <-w.ready:
+		return q.release, nil
+	case <-timeout:
+		err = ErrBusy
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case <-w.ready:
+		// The slot was handed over while giving up, so keep it
+		return q.release, nil
+	default:
+	}
+	q.waiting[p].Remove(elem)
+	q.queued.WithLabelValues(string(p)).Dec()
+
+	if err == ErrBusy {
+		a.logger.WarnContext(ctx, "Analysis rejected, too many concurrent analyses",
+			"max_concurrent_analyses", q.slots,
+			"queue_timeout", a.config.QueueTimeout,
+		)
+		a.metrics.observeAnalysis(time.Now(), ErrBusy)
+	}
+	return nil, err
+}
@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// RedirectCandidate is a link or form action on the page's own host whose
+// query passes an absolute URL, e.g. ?next=https://example.com. Handlers
+// that redirect to such parameters without checking them are open
+// redirects, so each candidate is worth reviewing by hand.
+type RedirectCandidate struct {
+	// Element is a, area or form
+	Element   string `json:"element"`
+	URL       string `json:"url"`
+	Parameter string `json:"parameter"`
+	Target    string `json:"target"`
+}
+
+// inspectRedirect records the query parameters of a link or form action
+// that hold absolute URLs
+func (s *Security) inspectRedirect(tag string, attrs []html.Attribute, baseURL *url.URL) {
+	var ref string
+	switch tag {
+	case "a", "area":
+		ref = attrValue(attrs, "href")
+	case "form":
+		ref = attrValue(attrs, "action")
+	default:
+		return
+	}
+
+	parsed, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil || parsed.RawQuery == "" {
+		return
+	}
+	resolved := baseURL.ResolveReference(parsed)
+	if resolved.Host != baseURL.Host {
+		return
+	}
+	query, err := url.ParseQuery(resolved.RawQuery)
+	if err != nil && len(query) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range query[name] {
+			if !isAbsoluteURL(value) {
+				continue
+			}
+			candidate := RedirectCandidate{Element: tag, URL: resolved.String(), Parameter: name, Target: value}
+			if !slices.Contains(s.OpenRedirectCandidates, candidate) {
+				s.OpenRedirectCandidates = append(s.OpenRedirectCandidates, candidate)
+			}
+		}
+	}
+}
+
+// isAbsoluteURL reports whether value is an http(s) or scheme-relative URL
+// with a host
+func isAbsoluteURL(value string) bool {
+	value = strings.TrimSpace(value)
+	lower := strings.ToLower(value)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(value, "//") {
+		return false
+	}
+	parsed, err := url.Parse(value)
+	return err == nil && parsed.Host != ""
+}
@@ -0,0 +1,111 @@
+package analyzer
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MetaRefresh is a redirect made with <meta http-equiv="refresh"> on the
+// requested page
+type MetaRefresh struct {
+	// URL is the resolved redirect target; it is empty for refreshes that
+	// only reload the page
+	URL          string `json:"url,omitempty"`
+	DelaySeconds int    `json:"delay_seconds"`
+	// Followed lists the pages fetched by following meta refreshes, in
+	// order. The result describes the last of them.
+	Followed []string `json:"followed,omitempty"`
+}
+
+// inspectRefresh records the page's first meta refresh
+func inspectRefresh(content string, result *Result, baseURL *url.URL) {
+	if result.MetaRefresh != nil {
+		return
+	}
+	delay, target, ok := parseRefresh(content)
+	if !ok {
+		return
+	}
+
+	refresh := &MetaRefresh{DelaySeconds: delay}
+	if target != "" {
+		if ref, err := url.Parse(target); err == nil {
+			refresh.URL = baseURL.ResolveReference(ref).String()
+		}
+	}
+	result.MetaRefresh = refresh
+}
+
+// parseRefresh splits a meta refresh content attribute, such as
+// "5; url='/next'", into its delay and target. ok is false when the content
+// does not start with a delay, which browsers ignore.
+func parseRefresh(content string) (delay int, target string, ok bool) {
+	s := strings.TrimLeft(content, " \t\n\f\r")
+	digits := len(s) - len(strings.TrimLeft(s, "0123456789"))
+	if digits == 0 {
+		return 0, "", false
+	}
+	delay, err := strconv.Atoi(s[:digits])
+	if err != nil {
+		// Only overflows, which are as good as never
+		delay = int(^uint(0) >> 1)
+	}
+
+	// Browsers accept and ignore a fraction
+	s = strings.TrimLeft(s[digits:], "0123456789.")
+	s = strings.TrimLeft(s, " \t\n\f\r")
+	if s == "" || (s[0] != ';' && s[0] != ',') {
+		return delay, "", true
+	}
+	s = strings.TrimLeft(s[1:], " \t\n\f\r")
+
+	if len(s) >= 3 && strings.EqualFold(s[:3], "url") {
+		if rest := strings.TrimLeft(s[3:], " \t\n\f\r"); strings.HasPrefix(rest, "=") {
+			s = strings.TrimLeft(rest[1:], " \t\n\f\r")
+		}
+	}
+	if s != "" && (s[0] == '\'' || s[0] == '"') {
+		if end := strings.IndexByte(s[1:], s[0]); end >= 0 {
+			s = s[1 : end+1]
+		} else {
+			s = s[1:]
+		}
+	}
+	return delay, strings.TrimSpace(s), true
+}
+
+// refreshTarget returns the page AnalyzeURL should fetch next by following
+// result's meta refresh, or "" when the refresh is not followed
+func (o *analyzeOptions) refreshTarget(result *Result) string {
+	refresh := result.MetaRefresh
+	if refresh == nil || refresh.URL == "" || o.refreshes <= 0 {
+		return ""
+	}
+	target, err := url.Parse(refresh.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		return ""
+	}
+	if slices.ContainsFunc(o.visited, func(visited string) bool { return samePage(visited, target) }) {
+		return ""
+	}
+	return refresh.URL
+}
+
+// samePage reports whether visited and target fetch the same page, which
+// they do when they differ only in the fragment or an empty path
+func samePage(visited string, target *url.URL) bool {
+	page, err := url.Parse(visited)
+	if err != nil {
+		return false
+	}
+	normalize := func(u url.URL) string {
+		u.Fragment, u.RawFragment = "", ""
+		if u.Path == "" {
+			u.Path = "/"
+		}
+		return u.String()
+	}
+	return normalize(*page) == normalize(*target)
+}
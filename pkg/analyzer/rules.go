@@ -0,0 +1,240 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Rule is a check defined in configuration and reported as a finding in
+// CategoryCustom, with the rule's ID, when the page breaks it. Each rule
+// sets exactly one of Selector, TitleMatches and NoLinksTo:
+//
+//   - Selector: the page must have an element matching this CSS selector.
+//     Type, id, class and attribute selectors with descendant (space) and
+//     child (>) combinators are supported.
+//   - TitleMatches: the page title must match this regular expression.
+//   - NoLinksTo: no link may point to this domain or its subdomains.
+//
+// Severity defaults to SeverityWarning and Message to a description of the
+// rule.
+type Rule struct {
+	ID           string   `yaml:"id"`
+	Severity     Severity `yaml:"severity"`
+	Message      string   `yaml:"message"`
+	Selector     string   `yaml:"selector"`
+	TitleMatches string   `yaml:"title_matches"`
+	NoLinksTo    string   `yaml:"no_links_to"`
+}
+
+// compiledRule is a Rule ready to be evaluated
+type compiledRule struct {
+	Rule
+	selector selector
+	title    *regexp.Regexp
+	domain   string
+}
+
+// ValidateRules reports the first rule that cannot be evaluated, so that
+// configuration mistakes are found at startup rather than skipped
+func ValidateRules(rules []Rule) error {
+	_, err := compileRules(rules)
+	return err
+}
+
+// compileRules compiles rules, failing on the first invalid one
+func compileRules(rules []Rule) ([]*compiledRule, error) {
+	compiled := make([]*compiledRule, 0, len(rules))
+	for i, rule := range rules {
+		c, err := compileRule(rule)
+		if err != nil {
+			if rule.ID != "" {
+				return nil, fmt.Errorf("rule %q: %w", rule.ID, err)
+			}
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// compileRule validates a rule and fills in its defaults
+func compileRule(rule Rule) (*compiledRule, error) {
+	if rule.ID == "" {
+		return nil, errors.New("id is required")
+	}
+	switch rule.Severity {
+	case "":
+		rule.Severity = SeverityWarning
+	case SeverityError, SeverityWarning, SeverityInfo:
+	default:
+		return nil, fmt.Errorf("unknown severity %q", rule.Severity)
+	}
+
+	c := &compiledRule{Rule: rule}
+	set := 0
+	if rule.Selector != "" {
+		set++
+		sel, err := parseSelector(rule.Selector)
+		if err != nil {
+			return nil, err
+		}
+		c.selector = sel
+		if c.Message == "" {
+			c.Message = fmt.Sprintf("no element matches %s", rule.Selector)
+		}
+	}
+	if rule.TitleMatches != "" {
+		set++
+		title, err := regexp.Compile(rule.TitleMatches)
+		if err != nil {
+			return nil, fmt.Errorf("title_matches: %w", err)
+		}
+		c.title = title
+		if c.Message == "" {
+			c.Message = fmt.Sprintf("title does not match %s", rule.TitleMatches)
+		}
+	}
+	if rule.NoLinksTo != "" {
+		set++
+		c.domain = strings.Trim(strings.ToLower(strings.TrimSpace(rule.NoLinksTo)), ".")
+		if c.domain == "" || strings.ContainsAny(c.domain, "/:") {
+			return nil, fmt.Errorf("no_links_to must be a domain, got %q", rule.NoLinksTo)
+		}
+		if c.Message == "" {
+			c.Message = fmt.Sprintf("page links to %s", c.domain)
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("exactly one of selector, title_matches and no_links_to must be set")
+	}
+	return c, nil
+}
+
+// ruleChecker evaluates the configured rules from start and end tags, for
+// both the DOM and streaming analyses. Its methods do nothing on a nil
+// checker.
+type ruleChecker struct {
+	rules   []*compiledRule
+	baseURL *url.URL
+	// open holds the open elements, outermost first, for selectors
+	open []element
+	// matched is set for selector rules once an element matches, and
+	// links holds the links each domain rule forbids, in document order
+	matched []bool
+	links   [][]string
+}
+
+// newRuleChecker returns a checker for rules, or nil when there are none
+func newRuleChecker(rules []*compiledRule, baseURL *url.URL) *ruleChecker {
+	if len(rules) == 0 {
+		return nil
+	}
+	return &ruleChecker{
+		rules:   rules,
+		baseURL: baseURL,
+		matched: make([]bool, len(rules)),
+		links:   make([][]string, len(rules)),
+	}
+}
+
+// checkRules evaluates rules on a parsed document whose title is title
+func checkRules(doc *html.Node, rules []*compiledRule, baseURL *url.URL, title string) []Finding {
+	c := newRuleChecker(rules, baseURL)
+	walkTags(doc, c.start, nil, c.end)
+	return c.findings(title)
+}
+
+// start handles a start tag; tag is lower case
+func (c *ruleChecker) start(tag string, attrs []html.Attribute) {
+	if c == nil {
+		return
+	}
+	c.open = append(c.open, element{tag: tag, attrs: attrs})
+
+	var link string
+	if tag == "a" || tag == "area" {
+		if href, ok := hrefAttr(attrs); ok {
+			if parsed, err := url.Parse(strings.TrimSpace(href)); err == nil {
+				link = c.baseURL.ResolveReference(parsed).String()
+			}
+		}
+	}
+	for i, rule := range c.rules {
+		switch {
+		case rule.selector != nil && !c.matched[i]:
+			c.matched[i] = rule.selector.match(c.open)
+		case rule.domain != "" && link != "":
+			if linksTo(link, rule.domain) && !slices.Contains(c.links[i], link) {
+				c.links[i] = append(c.links[i], link)
+			}
+		}
+	}
+
+	if voidElements[tag] {
+		c.open = c.open[:len(c.open)-1]
+	}
+}
+
+// end handles an end tag, closing the elements opened since the matching
+// start tag. Stray end tags are ignored.
+func (c *ruleChecker) end(tag string) {
+	if c == nil {
+		return
+	}
+	for i := len(c.open) - 1; i >= 0; i-- {
+		if c.open[i].tag == tag {
+			c.open = c.open[:i]
+			return
+		}
+	}
+}
+
+// findings returns a finding for each broken rule
+func (c *ruleChecker) findings(title string) []Finding {
+	if c == nil {
+		return nil
+	}
+	var findings []Finding
+	for i, rule := range c.rules {
+		broken := false
+		var evidence []string
+		switch {
+		case rule.selector != nil:
+			broken = !c.matched[i]
+		case rule.title != nil:
+			broken = !rule.title.MatchString(title)
+			if broken && title != "" {
+				evidence = []string{title}
+			}
+		case rule.domain != "":
+			broken = len(c.links[i]) > 0
+			evidence = c.links[i]
+		}
+		if broken {
+			findings = append(findings, Finding{
+				ID:       rule.ID,
+				Severity: rule.Severity,
+				Category: CategoryCustom,
+				Message:  rule.Message,
+				Evidence: evidence,
+			})
+		}
+	}
+	return findings
+}
+
+// linksTo reports whether link points to domain or one of its subdomains
+func linksTo(link, domain string) bool {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,95 @@
+package analyzer
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// minPageText is the number of non-space characters of text below which a
+// page counts as having no content of its own
+const minPageText = 100
+
+// scriptDependencyAdvice is the advice given with a ScriptDependency
+const scriptDependencyAdvice = "The page appears to build its content with JavaScript, so this analysis " +
+	"describes the HTML before scripts run. Re-run it on the rendered page for accurate results."
+
+// ScriptDependency is set on results for pages that appear to need
+// JavaScript to show their content, such as single-page app shells. Text
+// lengths count non-space characters.
+type ScriptDependency struct {
+	// NoscriptOnly is set when the page's text is inside noscript elements
+	NoscriptOnly bool `json:"noscript_only,omitempty"`
+	// EmptyBody is set when the page has scripts but hardly any text
+	EmptyBody          bool   `json:"empty_body,omitempty"`
+	TextLength         int    `json:"text_length"`
+	NoscriptTextLength int    `json:"noscript_text_length"`
+	Scripts            int    `json:"scripts"`
+	Advice             string `json:"advice"`
+}
+
+// detect returns the measured page as a ScriptDependency, or nil when the
+// page shows its content without JavaScript
+func (d ScriptDependency) detect() *ScriptDependency {
+	d.NoscriptOnly = d.TextLength < minPageText && d.NoscriptTextLength >= minPageText
+	d.EmptyBody = d.TextLength < minPageText && d.Scripts > 0
+	if !d.NoscriptOnly && !d.EmptyBody {
+		return nil
+	}
+
+	d.Advice = scriptDependencyAdvice
+	return &d
+}
+
+// measureText adds the text and scripts of n and its descendants to page.
+// Script, style, template and title contents are not page text, and
+// noscript contents are counted separately.
+func measureText(n *html.Node, page *ScriptDependency) {
+	switch n.Type {
+	case html.TextNode:
+		page.TextLength += textLength(n.Data)
+	case html.ElementNode:
+		switch strings.ToLower(n.Data) {
+		case "script":
+			page.Scripts++
+			return
+		case "style", "template", "title":
+			return
+		case "noscript":
+			// With scripting on, noscript holds its markup as text
+			page.NoscriptTextLength += markupTextLength(nodeText(n))
+			return
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		measureText(c, page)
+	}
+}
+
+// textLength counts the non-space characters of text
+func textLength(text string) int {
+	n := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// markupTextLength counts the non-space characters of the text in an HTML
+// fragment
+func markupTextLength(markup string) int {
+	z := html.NewTokenizerFragment(strings.NewReader(markup), "div")
+	n := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return n
+		case html.TextToken:
+			n += textLength(string(z.Text()))
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Section names an optional part of a Result. Sections are only populated
+// when requested with IncludeSections, so results stay small by default and
+// new checks can be added without changing the core fields.
+type Section string
+
+// Optional result sections
+const (
+	SectionSEO           Section = "seo"
+	SectionSecurity      Section = "security"
+	SectionAccessibility Section = "accessibility"
+	SectionPerformance   Section = "performance"
+	SectionWebVitals     Section = "web_vitals"
+	SectionOutline       Section = "outline"
+	SectionThirdParty    Section = "third_party"
+	SectionPhishing      Section = "phishing"
+)
+
+// Sections lists every optional section
+var Sections = []Section{SectionSEO, SectionSecurity, SectionAccessibility, SectionPerformance, SectionWebVitals, SectionOutline, SectionThirdParty, SectionPhishing}
+
+// SEO holds search engine related findings
+type SEO struct {
+	Lang            string `json:"lang,omitempty"`
+	MetaDescription string `json:"meta_description,omitempty"`
+	Canonical       string `json:"canonical,omitempty"`
+	// Breadcrumbs is set when the page has a breadcrumb trail
+	Breadcrumbs *Breadcrumbs `json:"breadcrumbs,omitempty"`
+	// Languages is set when the page declares a language or its text is
+	// in one that can be detected
+	Languages *Languages `json:"languages,omitempty"`
+}
+
+// Security holds transport and response header findings. Headers are only
+// known for fetched pages.
+type Security struct {
+	HTTPS          bool              `json:"https"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	MissingHeaders []string          `json:"missing_headers,omitempty"`
+	// ReferrerPolicy and PermissionsPolicy parse those headers when the
+	// response has them
+	ReferrerPolicy    *ReferrerPolicy    `json:"referrer_policy,omitempty"`
+	PermissionsPolicy *PermissionsPolicy `json:"permissions_policy,omitempty"`
+	// OpenRedirectCandidates are links and forms whose query passes an
+	// absolute URL, in document order
+	OpenRedirectCandidates []RedirectCandidate `json:"open_redirect_candidates,omitempty"`
+}
+
+// Accessibility holds accessibility findings
+type Accessibility struct {
+	Images           int                  `json:"images"`
+	ImagesMissingAlt int                  `json:"images_missing_alt"`
+	Tables           []AccessibilityTable `json:"tables,omitempty"`
+}
+
+// Performance holds timing and size measurements. ResponseMS is only set
+// for fetched pages and PageBytes only for pages that were read, not for
+// AnalyzeNode.
+type Performance struct {
+	ResponseMS  int64 `json:"response_ms,omitempty"`
+	PageBytes   int64 `json:"page_bytes,omitempty"`
+	LinkCheckMS int64 `json:"link_check_ms"`
+	// InlineSVGs counts svg elements that are not inside another svg
+	InlineSVGs int `json:"inline_svgs"`
+	// IconFonts names the icon fonts the page uses, by library or by
+	// @font-face family, and IconElements counts elements with their
+	// icon classes
+	IconFonts    []string `json:"icon_fonts,omitempty"`
+	IconElements int      `json:"icon_elements"`
+	// Caching and CDN describe the response's cacheability and the edge
+	// provider that served it, for fetched pages
+	Caching *Caching `json:"caching,omitempty"`
+	CDN     *CDN     `json:"cdn,omitempty"`
+}
+
+// WebVitals holds Core Web Vitals from the PageSpeed Insights API. The lab
+// metrics come from a Lighthouse run for this analysis; Field holds what
+// real Chrome users experienced over the last 28 days, when Google has
+// enough data for the page. Error is set instead when the API call failed,
+// which does not fail the analysis. Only AnalyzeURL fills it in.
+type WebVitals struct {
+	Strategy string `json:"strategy"`
+	// PerformanceScore is Lighthouse's performance score from 0 to 100
+	PerformanceScore *int         `json:"performance_score,omitempty"`
+	LCPMS            float64      `json:"lcp_ms,omitempty"`
+	FCPMS            float64      `json:"fcp_ms,omitempty"`
+	TBTMS            float64      `json:"tbt_ms,omitempty"`
+	SpeedIndexMS     float64      `json:"speed_index_ms,omitempty"`
+	CLS              float64      `json:"cls"`
+	Field            *FieldVitals `json:"field,omitempty"`
+	Error            string       `json:"error,omitempty"`
+}
+
+// FieldVitals holds 75th percentile Core Web Vitals of real users. Category
+// is Google's overall assessment: FAST, AVERAGE or SLOW.
+type FieldVitals struct {
+	Category string  `json:"category,omitempty"`
+	LCPMS    int     `json:"lcp_ms,omitempty"`
+	INPMS    int     `json:"inp_ms,omitempty"`
+	CLS      float64 `json:"cls"`
+}
+
+// Outline holds the page's headings in document order, up to
+// MaxOutlineEntries of them. Truncated is set when the page has more.
+type Outline struct {
+	Headings  []OutlineHeading `json:"headings"`
+	Truncated bool             `json:"truncated,omitempty"`
+}
+
+// OutlineHeading is a heading in the outline. Level is 1 for h1 through 6
+// for h6, and Position counts the page's headings from 1 in document order.
+type OutlineHeading struct {
+	Level    int    `json:"level"`
+	Text     string `json:"text"`
+	Position int    `json:"position"`
+}
+
+// securityHeaders are the response headers reported in the security section
+var securityHeaders = []string{
+	"Strict-Transport-Security",
+	"Content-Security-Policy",
+	"X-Content-Type-Options",
+	"X-Frame-Options",
+	"Referrer-Policy",
+	"Permissions-Policy",
+}
+
+// IncludeSections populates the given optional sections
+func IncludeSections(sections ...Section) AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.sections = append(o.sections, sections...)
+	}
+}
+
+// initSections creates the requested sections and extractions on result,
+// so the checks that fill them in know to run
+func (o *analyzeOptions) initSections(result *Result, baseURL *url.URL) {
+	for _, section := range o.sections {
+		switch section {
+		case SectionSEO:
+			result.SEO = &SEO{}
+		case SectionSecurity:
+			result.Security = &Security{HTTPS: baseURL.Scheme == "https"}
+		case SectionAccessibility:
+			result.Accessibility = &Accessibility{}
+		case SectionPerformance:
+			result.Performance = &Performance{}
+		case SectionOutline:
+			result.Outline = &Outline{Headings: []OutlineHeading{}}
+		case SectionThirdParty:
+			result.ThirdParty = &ThirdParty{Origins: []ThirdPartyOrigin{}}
+		case SectionPhishing:
+			result.Phishing = &Phishing{Signals: []PhishingSignal{}}
+		}
+	}
+	o.initExtractions(result)
+}
+
+// inspectElement runs the section checks for a single element
+func (a *Analyzer) inspectElement(tag string, attrs []html.Attribute, result *Result, baseURL *url.URL) {
+	if result.ThirdParty != nil {
+		result.ThirdParty.inspect(tag, attrs, baseURL)
+	}
+	if result.Security != nil {
+		result.Security.inspectRedirect(tag, attrs, baseURL)
+	}
+
+	switch tag {
+	case "html":
+		if result.SEO != nil {
+			result.SEO.Lang = attrValue(attrs, "lang")
+		}
+	case "meta":
+		if result.SEO != nil && strings.EqualFold(attrValue(attrs, "name"), "description") {
+			result.SEO.MetaDescription = strings.TrimSpace(attrValue(attrs, "content"))
+		}
+		if strings.EqualFold(attrValue(attrs, "http-equiv"), "refresh") {
+			inspectRefresh(attrValue(attrs, "content"), result, baseURL)
+		}
+	case "frameset", "frame", "noframes":
+		inspectFrames(tag, attrs, result, baseURL)
+	case "link":
+		if result.SEO != nil && hasToken(attrValue(attrs, "rel"), "canonical") {
+			if canonical, err := url.Parse(attrValue(attrs, "href")); err == nil {
+				result.SEO.Canonical = baseURL.ResolveReference(canonical).String()
+			}
+		}
+	case "img":
+		if result.Accessibility != nil {
+			result.Accessibility.Images++
+			if !hasAttr(attrs, "alt") {
+				result.Accessibility.ImagesMissingAlt++
+			}
+		}
+	}
+}
+
+// addOutlineHeading adds a heading to the outline section, if requested.
+// tag is the heading's lower case tag name and text its text content.
+func (a *Analyzer) addOutlineHeading(result *Result, tag, text string) {
+	outline := result.Outline
+	if outline == nil || outline.Truncated {
+		return
+	}
+
+	if limit := a.limits().MaxOutlineEntries; limit > 0 && len(outline.Headings) >= limit {
+		outline.Truncated = true
+		return
+	}
+
+	outline.Headings = append(outline.Headings, OutlineHeading{
+		Level:    int(tag[1] - '0'),
+		Text:     strings.Join(strings.Fields(text), " "),
+		Position: len(outline.Headings) + 1,
+	})
+}
+
+// inspectResponse runs the section checks that need the page's response
+func inspectResponse(result *Result, header http.Header) {
+	if result.Performance != nil {
+		result.Performance.Caching = inspectCaching(header)
+		result.Performance.CDN = detectCDN(header)
+	}
+	if result.SEO != nil {
+		inspectContentLanguage(result.SEO, header)
+	}
+	if result.Security == nil {
+		return
+	}
+
+	result.Security.Headers = make(map[string]string)
+	for _, name := range securityHeaders {
+		if value := header.Get(name); value != "" {
+			result.Security.Headers[name] = value
+		} else {
+			result.Security.MissingHeaders = append(result.Security.MissingHeaders, name)
+		}
+	}
+	// Repeated headers combine into one list
+	if values := header.Values("Referrer-Policy"); len(values) > 0 {
+		result.Security.ReferrerPolicy = parseReferrerPolicy(strings.Join(values, ","))
+	}
+	if values := header.Values("Permissions-Policy"); len(values) > 0 {
+		result.Security.PermissionsPolicy = parsePermissionsPolicy(strings.Join(values, ","))
+	}
+}
+
+// attrValue returns the value of the named attribute, or "" when it is absent
+func attrValue(attrs []html.Attribute, key string) string {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// countTitle counts a title element. Like browsers, the first title in the
+// document names the page, which is the head's title whenever it has one.
+func (a *Analyzer) countTitle(ctx context.Context, result *Result, text string) {
+	result.TitleCount++
+	if result.TitleCount == 1 {
+		result.Title = strings.Join(strings.Fields(text), " ")
+		a.logger.DebugContext(ctx, "Found page title", "title", result.Title)
+	}
+}
+
+// nodeText returns the text content of n and its descendants
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// walkTags replays a parsed document as the start tags, text and end tags
+// the streaming analysis sees, so checks written against tokens also run on
+// a DOM. Tags are lower case, void elements have no end tag and text may be
+// nil.
+func walkTags(n *html.Node, start func(tag string, attrs []html.Attribute), text func(string), end func(tag string)) {
+	switch n.Type {
+	case html.TextNode:
+		if text != nil {
+			text(n.Data)
+		}
+	case html.ElementNode:
+		tag := strings.ToLower(n.Data)
+		start(tag, n.Attr)
+		if !voidElements[tag] {
+			defer end(tag)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkTags(c, start, text, end)
+	}
+}
+
+// hasAttr reports whether the named attribute is present
+func hasAttr(attrs []html.Attribute, key string) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// hasToken reports whether a space-separated attribute value contains token
+func hasToken(value, token string) bool {
+	for _, field := range strings.Fields(value) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,273 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// selector is a parsed CSS selector list. It supports type, universal, id,
+// class and attribute selectors (=, ~=, ^=, $=, *=) joined by descendant
+// and child combinators, which covers what rules need without pseudo
+// classes.
+type selector [][]compoundSelector
+
+// compoundSelector is one step of a complex selector. child is set when it
+// must be the child of the step before it rather than a descendant.
+type compoundSelector struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrSelector
+	child   bool
+}
+
+// attrSelector matches an attribute by name and, unless op is empty, value
+type attrSelector struct {
+	name, op, value string
+}
+
+// element is an open element, as seen by a selector
+type element struct {
+	tag   string
+	attrs []html.Attribute
+}
+
+// parseSelector parses a comma-separated selector list
+func parseSelector(s string) (selector, error) {
+	var sel selector
+	for _, part := range splitOutside(s, ',') {
+		complex, err := parseComplexSelector(part)
+		if err != nil {
+			return nil, err
+		}
+		sel = append(sel, complex)
+	}
+	return sel, nil
+}
+
+// parseComplexSelector parses compound selectors joined by combinators
+func parseComplexSelector(s string) ([]compoundSelector, error) {
+	var steps []compoundSelector
+	child := false
+	p := selectorParser{s: strings.TrimSpace(s)}
+	if p.s == "" {
+		return nil, fmt.Errorf("empty selector in %q", s)
+	}
+	for !p.done() {
+		if p.skipSpace() && p.done() {
+			break
+		}
+		if p.peek() == '>' {
+			if len(steps) == 0 || child {
+				return nil, fmt.Errorf("misplaced > in selector %q", s)
+			}
+			p.pos++
+			child = true
+			continue
+		}
+		step, err := p.compound()
+		if err != nil {
+			return nil, fmt.Errorf("selector %q: %w", s, err)
+		}
+		step.child = child
+		child = false
+		steps = append(steps, step)
+	}
+	if child {
+		return nil, fmt.Errorf("selector %q ends with >", s)
+	}
+	return steps, nil
+}
+
+// selectorParser reads a selector one character at a time
+type selectorParser struct {
+	s   string
+	pos int
+}
+
+func (p *selectorParser) done() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *selectorParser) peek() byte {
+	return p.s[p.pos]
+}
+
+// skipSpace skips whitespace and reports whether there was any
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for !p.done() && strings.IndexByte(" \t\n\r\f", p.peek()) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// ident reads a name made of letters, digits, - and _
+func (p *selectorParser) ident() string {
+	start := p.pos
+	for !p.done() {
+		c := p.peek()
+		if c != '-' && c != '_' && (c < '0' || c > '9') && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && c < 0x80 {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// compound reads a compound selector, up to whitespace or a combinator
+func (p *selectorParser) compound() (compoundSelector, error) {
+	var step compoundSelector
+	if p.peek() == '*' {
+		p.pos++
+	} else {
+		step.tag = strings.ToLower(p.ident())
+	}
+	for !p.done() {
+		switch c := p.peek(); c {
+		case '#', '.':
+			p.pos++
+			name := p.ident()
+			if name == "" {
+				return step, fmt.Errorf("missing name after %c", c)
+			}
+			if c == '#' {
+				step.id = name
+			} else {
+				step.classes = append(step.classes, name)
+			}
+		case '[':
+			attr, err := p.attr()
+			if err != nil {
+				return step, err
+			}
+			step.attrs = append(step.attrs, attr)
+		case ' ', '\t', '\n', '\r', '\f', '>':
+			return step, nil
+		default:
+			return step, fmt.Errorf("unsupported %q", p.s[p.pos:])
+		}
+	}
+	return step, nil
+}
+
+// attr reads an attribute selector such as [type="email"]
+func (p *selectorParser) attr() (attrSelector, error) {
+	end := strings.IndexByte(p.s[p.pos:], ']')
+	if end < 0 {
+		return attrSelector{}, fmt.Errorf("unclosed [")
+	}
+	body := strings.TrimSpace(p.s[p.pos+1 : p.pos+end])
+	p.pos += end + 1
+	name, value, hasValue := strings.Cut(body, "=")
+	op := ""
+	if hasValue {
+		op = "="
+		if n := len(name); n > 0 && strings.IndexByte("~^$*", name[n-1]) >= 0 {
+			op = name[n-1:] + "="
+			name = name[:n-1]
+		}
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return attrSelector{}, fmt.Errorf("missing attribute name in [%s]", body)
+	}
+	if ident := (&selectorParser{s: name}).ident(); ident != name {
+		return attrSelector{}, fmt.Errorf("unsupported attribute selector [%s]", body)
+	}
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return attrSelector{name: strings.ToLower(name), op: op, value: value}, nil
+}
+
+// match reports whether the last of open, whose ancestors are the others,
+// matches the selector
+func (sel selector) match(open []element) bool {
+	for _, complex := range sel {
+		if matchSteps(complex, open) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSteps matches the last step against the last open element and the
+// steps before it against its ancestors
+func matchSteps(steps []compoundSelector, open []element) bool {
+	last := steps[len(steps)-1]
+	if len(open) == 0 || !last.match(open[len(open)-1]) {
+		return false
+	}
+	if len(steps) == 1 {
+		return true
+	}
+	ancestors := open[:len(open)-1]
+	if last.child {
+		return matchSteps(steps[:len(steps)-1], ancestors)
+	}
+	for i := len(ancestors); i > 0; i-- {
+		if matchSteps(steps[:len(steps)-1], ancestors[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether e matches the compound selector
+func (c compoundSelector) match(e element) bool {
+	if c.tag != "" && c.tag != e.tag {
+		return false
+	}
+	if c.id != "" && attrValue(e.attrs, "id") != c.id {
+		return false
+	}
+	for _, class := range c.classes {
+		if !hasClass(attrValue(e.attrs, "class"), class) {
+			return false
+		}
+	}
+	for _, attr := range c.attrs {
+		if !attr.match(e.attrs) {
+			return false
+		}
+	}
+	return true
+}
+
+// match reports whether attrs has the attribute with a matching value
+func (a attrSelector) match(attrs []html.Attribute) bool {
+	if !hasAttr(attrs, a.name) {
+		return false
+	}
+	value := attrValue(attrs, a.name)
+	switch a.op {
+	case "":
+		return true
+	case "=":
+		return value == a.value
+	case "~=":
+		return hasClass(value, a.value)
+	case "^=":
+		return a.value != "" && strings.HasPrefix(value, a.value)
+	case "$=":
+		return a.value != "" && strings.HasSuffix(value, a.value)
+	case "*=":
+		return a.value != "" && strings.Contains(value, a.value)
+	}
+	return false
+}
+
+// hasClass reports whether a space-separated list holds token, case
+// sensitively as class names are
+func hasClass(list, token string) bool {
+	for _, field := range strings.Fields(list) {
+		if field == token {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// SiteReport is the outcome of AnalyzeSite: how the four http/https and
+// www/non-www variants of a domain respond, and the analysis of the page
+// they lead to
+type SiteReport struct {
+	Domain   string        `json:"domain"`
+	Variants []SiteVariant `json:"variants"`
+	// CanonicalOrigin is the origin that every variant which responds
+	// without a probe error ends up on, e.g. https://www.example.com. It is
+	// empty when they end up on different origins or none responds.
+	CanonicalOrigin string `json:"canonical_origin,omitempty"`
+	// Result analyzes the final page of the first variant, in the order of
+	// Variants, that responds without a probe error. It is nil when there is
+	// none, and reports a failed analysis in its Error field.
+	Result *Result `json:"result,omitempty"`
+}
+
+// SiteVariant is one probed variant of a domain. Status is the variant's
+// own response status and Redirects the Location of each redirect
+// followed; FinalURL and FinalStatus describe the last response. Error is
+// set when the variant or a redirect target did not respond, e.g. for a
+// missing certificate, or when there were more than MaxRedirects redirects.
+type SiteVariant struct {
+	URL         string   `json:"url"`
+	Responds    bool     `json:"responds"`
+	Status      int      `json:"status,omitempty"`
+	Redirects   []string `json:"redirects,omitempty"`
+	FinalURL    string   `json:"final_url,omitempty"`
+	FinalStatus int      `json:"final_status,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// AnalyzeSite probes https and http, with and without www, for domain,
+// reports which respond and whether they redirect to a single canonical
+// origin, and analyzes the page the first responding one leads to. domain
+// may also be given as a URL, whose path is ignored. Failed probes and
+// analyses are reported in the SiteReport; the error is only set for an
+// invalid domain or when the analysis could not start.
+func (a *Analyzer) AnalyzeSite(ctx context.Context, domain string, opts ...AnalyzeOption) (*SiteReport, error) {
+	host, err := siteHost(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SiteReport{Domain: host}
+	for _, prefix := range []string{"https://", "https://www.", "http://", "http://www."} {
+		report.Variants = append(report.Variants, SiteVariant{URL: prefix + host + "/"})
+	}
+
+	var wg sync.WaitGroup
+	for i := range report.Variants {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.probeVariant(ctx, &report.Variants[i])
+		}()
+	}
+	wg.Wait()
+
+	origins := make(map[string]bool)
+	target := ""
+	for _, variant := range report.Variants {
+		if !variant.Responds || variant.Error != "" {
+			continue
+		}
+		if final, err := url.Parse(variant.FinalURL); err == nil {
+			origins[final.Scheme+"://"+final.Host] = true
+		}
+		if target == "" {
+			target = variant.FinalURL
+		}
+	}
+	if len(origins) == 1 {
+		for origin := range origins {
+			report.CanonicalOrigin = origin
+		}
+	}
+
+	a.logger.DebugContext(ctx, "Site variants probed", "domain", host, "canonical_origin", report.CanonicalOrigin, "origins", len(origins))
+	if target == "" {
+		return report, nil
+	}
+
+	report.Result, err = a.AnalyzeURL(ctx, target, opts...)
+	if err != nil && (errors.Is(err, ErrBusy) || ctx.Err() != nil) {
+		return nil, err
+	}
+	if err != nil {
+		report.Result = &Result{URL: target, Error: err.Error()}
+	}
+	return report, nil
+}
+
+// probeVariant requests variant.URL, following redirects one at a time to
+// record them, and fills in the outcome
+func (a *Analyzer) probeVariant(ctx context.Context, variant *SiteVariant) {
+	client := *a.client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	current := variant.URL
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, current, nil)
+		if err != nil {
+			variant.Error = err.Error()
+			return
+		}
+		req.Header.Set("User-Agent", a.userAgent)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			// After a redirect, the final fields keep the last response
+			variant.Error = err.Error()
+			return
+		}
+		resp.Body.Close()
+
+		if !variant.Responds {
+			variant.Responds = true
+			variant.Status = resp.StatusCode
+		}
+		variant.FinalURL, variant.FinalStatus = current, resp.StatusCode
+
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+			return
+		}
+		location, err := resp.Location()
+		if err != nil {
+			// A redirect without a Location ends here
+			return
+		}
+		if len(variant.Redirects) >= a.limits().MaxRedirects {
+			variant.Error = errTooManyRedirects.Error()
+			return
+		}
+		current = location.String()
+		variant.Redirects = append(variant.Redirects, current)
+	}
+}
+
+// siteHost returns the host of domain without a www. prefix
+func siteHost(domain string) (string, error) {
+	raw := strings.TrimSpace(domain)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	if parsed.Hostname() == "" {
+		return "", fmt.Errorf("%w: no domain in %q", ErrInvalidURL, domain)
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Host), "www."), nil
+}
@@ -0,0 +1,103 @@
+package analyzer
+
+import "reflect"
+
+// Stats is a snapshot of the analyzer's current workload
+type Stats struct {
+	ActiveAnalyses int `json:"active_analyses"`
+	ActiveWorkers  int `json:"active_workers"`
+	MaxWorkers     int `json:"max_workers"`
+	// QueuedLinks counts links waiting for a link checker worker
+	QueuedLinks int `json:"queued_links"`
+	// QueuedAnalyses counts analyses waiting for a slot by priority
+	QueuedAnalyses map[Priority]int `json:"queued_analyses"`
+}
+
+// Stats returns the analyzer's current workload
+func (a *Analyzer) Stats() Stats {
+	stats := Stats{
+		ActiveAnalyses: int(a.activeAnalyses.Load()),
+		ActiveWorkers:  int(a.activeWorkers.Load()),
+		MaxWorkers:     a.MaxWorkers(),
+		QueuedLinks:    int(a.queuedLinks.Load()),
+		QueuedAnalyses: make(map[Priority]int, len(Priorities)),
+	}
+	for _, p := range Priorities {
+		depth := 0
+		if a.queue != nil {
+			depth = a.queue.depth(p)
+		}
+		stats.QueuedAnalyses[p] = depth
+	}
+	return stats
+}
+
+// MaxWorkers returns the number of concurrent link checkers used per analysis
+func (a *Analyzer) MaxWorkers() int {
+	return int(a.maxWorkers.Load())
+}
+
+// SetMaxWorkers changes the number of concurrent link checkers used per
+// analysis. Analyses already checking links keep their current workers.
+// Values below one are ignored.
+func (a *Analyzer) SetMaxWorkers(n int) {
+	if n < 1 {
+		return
+	}
+
+	previous := a.maxWorkers.Swap(int64(n))
+	a.metrics.maxWorkers.Set(float64(n))
+	a.logger.Info("Analyzer worker limit changed",
+		"previous", previous,
+		"max_workers", n,
+	)
+}
+
+// Reconfigure replaces the analyzer's limits at runtime. Analyses already
+// running may still use some of the previous limits. RequestTimeout,
+// MaxConcurrentAnalyses and QueueTimeout are fixed when the analyzer is
+// created.
+func (a *Analyzer) Reconfigure(cfg Config) {
+	previous := a.settings.Swap(&cfg)
+	a.setRules(cfg.Rules)
+	if previous != nil && !reflect.DeepEqual(*previous, cfg) {
+		a.logger.Info("Analyzer limits changed",
+			"link_timeout", cfg.LinkTimeout,
+			"max_redirects", cfg.MaxRedirects,
+			"max_page_size", cfg.MaxPageSize,
+			"max_nodes", cfg.MaxNodes,
+			"max_depth", cfg.MaxDepth,
+			"streaming_threshold", cfg.StreamingThreshold,
+			"batch_concurrency", cfg.BatchConcurrency,
+		)
+	}
+
+	if cfg.MaxWorkers != a.MaxWorkers() {
+		a.SetMaxWorkers(cfg.MaxWorkers)
+	}
+}
+
+// limits returns the limits in effect
+func (a *Analyzer) limits() *Config {
+	return a.settings.Load()
+}
+
+// compiledRules returns the rules in effect
+func (a *Analyzer) compiledRules() []*compiledRule {
+	return *a.rules.Load()
+}
+
+// setRules compiles the rules analyses evaluate. Invalid rules are logged
+// and skipped; ValidateRules finds them up front.
+func (a *Analyzer) setRules(rules []Rule) {
+	compiled := make([]*compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		c, err := compileRule(rule)
+		if err != nil {
+			a.logger.Error("Skipping invalid rule", "rule", rule.ID, "error", err)
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+	a.rules.Store(&compiled)
+}
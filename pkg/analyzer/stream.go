@@ -0,0 +1,261 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// analyzeTokens fills in result from a token stream without building a DOM,
+// so memory use does not grow with the page. It returns the links to check
+// for accessibility. Counts match analyzeDocument for well-formed pages.
+func (a *Analyzer) analyzeTokens(ctx context.Context, r io.Reader, result *Result, baseURL *url.URL) ([]string, error) {
+	a.logger.DebugContext(ctx, "Starting streaming document analysis", "url", baseURL.String())
+
+	z := html.NewTokenizer(r)
+
+	var links []string
+	inTitle := false
+	// foreign counts the open svg and math elements, whose titles are not
+	// the page's
+	foreign := 0
+	// page measures the text for ScriptDependency. raw is the element whose
+	// contents the next text token holds, when they are not parsed, and
+	// template counts the open template elements, whose text is not shown.
+	var page ScriptDependency
+	raw := ""
+	template := 0
+	// crumbs finds the breadcrumb trail for the seo section
+	var crumbs *breadcrumbFinder
+	if result.SEO != nil {
+		crumbs = newBreadcrumbFinder(baseURL)
+	}
+	// tables checks tables for the accessibility section
+	var tables *tableChecker
+	if result.Accessibility != nil {
+		tables = &tableChecker{}
+	}
+	// icons counts inline SVGs and icon fonts for the performance section
+	var icons *iconChecker
+	if result.Performance != nil {
+		icons = &iconChecker{}
+	}
+	// rules evaluates the configured rules
+	rules := newRuleChecker(a.compiledRules(), baseURL)
+	// parking looks for parked and coming soon pages
+	parking := &parkingChecker{baseURL: baseURL}
+	// phishing looks for phishing signals for the phishing section
+	phishing := newPhishingChecker(result.Phishing, baseURL)
+	// languages cross-checks the page's languages for the seo section
+	languages := newLanguageChecker(result.SEO, baseURL, result.URL)
+	// extracts fills in the extractions asked for
+	extracts := newExtractor(result.Extractions)
+	complexity := complexityCounter{maxNodes: a.limits().MaxNodes, maxDepth: a.limits().MaxDepth}
+	inForm := false
+	hasPassword, hasUsername := false, false
+
+	endForm := func() {
+		if inForm && hasPassword && hasUsername {
+			result.HasLoginForm = true
+			a.logger.DebugContext(ctx, "Login form detected")
+		}
+		inForm = false
+	}
+
+	// heading is the open heading's tag while its text is collected for
+	// the outline section
+	heading := ""
+	var headingText strings.Builder
+	endHeading := func() {
+		if heading != "" {
+			a.addOutlineHeading(result, heading, headingText.String())
+			heading = ""
+			headingText.Reset()
+		}
+	}
+
+	for {
+		tt := z.Next()
+		parent := raw
+		raw = ""
+
+		switch tt {
+		case html.ErrorToken:
+			endForm()
+			endHeading()
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			result.ScriptDependency = page.detect()
+			result.Parking = parking.result()
+			if result.SEO != nil {
+				result.SEO.Breadcrumbs = crumbs.result()
+			}
+			if result.Accessibility != nil {
+				result.Accessibility.Tables = tables.result()
+			}
+			if result.Performance != nil {
+				icons.result(result.Performance)
+			}
+			result.Findings = rules.findings(result.Title)
+			extracts.finish()
+			phishing.finish(result.Title)
+			languages.finish()
+			result.ThirdParty.sort()
+			a.logger.DebugContext(ctx, "Streaming document analysis completed",
+				"url", baseURL.String(),
+				"title", result.Title,
+				"headings", result.Headings,
+			)
+			return links, nil
+
+		case html.DoctypeToken:
+			result.HTMLVersion = a.detectHTMLVersion(doctypeName(string(z.Text())))
+			a.logger.DebugContext(ctx, "HTML version detected", "version", result.HTMLVersion)
+
+		case html.TextToken:
+			text := z.Text()
+			if inTitle {
+				a.countTitle(ctx, result, string(text))
+			}
+			if heading != "" {
+				headingText.Write(text)
+			}
+			crumbs.text(string(text))
+			icons.text(string(text))
+			extracts.text(string(text))
+			parking.text(string(text))
+			languages.text(string(text))
+
+			switch {
+			case template > 0, parent == "script", parent == "style", parent == "title":
+			case parent == "noscript":
+				page.NoscriptTextLength += markupTextLength(string(text))
+			default:
+				page.TextLength += textLength(string(text))
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			if err := complexity.start(token.Data, tt == html.SelfClosingTagToken); err != nil {
+				return nil, err
+			}
+			a.inspectElement(token.Data, token.Attr, result, baseURL)
+			crumbs.start(token.Data, token.Attr)
+			tables.start(token.Data, token.Attr)
+			icons.start(token.Data, token.Attr)
+			rules.start(token.Data, token.Attr)
+			extracts.start(token.Data, token.Attr)
+			parking.start(token.Data, token.Attr)
+			phishing.start(token.Data, token.Attr)
+			languages.start(token.Data, token.Attr)
+			if tt == html.SelfClosingTagToken {
+				// Self-closing tags have no end tag
+				parking.end(token.Data)
+				phishing.end(token.Data)
+				languages.end(token.Data)
+				if token.Data == "svg" {
+					icons.end(token.Data)
+				}
+			}
+
+			if tt == html.StartTagToken {
+				switch token.Data {
+				case "script", "style", "title", "noscript":
+					raw = token.Data
+				case "template":
+					template++
+				}
+			}
+
+			switch token.Data {
+			case "script":
+				if template == 0 {
+					page.Scripts++
+				}
+			case "title":
+				if foreign > 0 {
+					break
+				}
+				if tt == html.StartTagToken {
+					// The title is counted along with its text
+					inTitle = true
+					continue
+				}
+				a.countTitle(ctx, result, "")
+			case "svg", "math":
+				if tt == html.StartTagToken {
+					foreign++
+				}
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				result.Headings[token.Data]++
+				a.logger.DebugContext(ctx, "Found heading", "level", token.Data, "count", result.Headings[token.Data])
+				if result.Outline != nil {
+					// Like the HTML parser, a heading ends an open one
+					endHeading()
+					heading = token.Data
+				}
+			case "a":
+				if href, ok := hrefAttr(token.Attr); ok {
+					a.countLink(ctx, href, result, baseURL)
+					if link, ok := a.checkableLink(href, baseURL); ok {
+						links = append(links, link)
+					}
+				}
+			case "form":
+				// Like the HTML parser, ignore forms nested in a form
+				if !inForm {
+					inForm = true
+					hasPassword, hasUsername = false, false
+				}
+			case "input":
+				if inForm {
+					a.checkInput(ctx, token.Attr, &hasPassword, &hasUsername)
+				}
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			complexity.end(string(name))
+			crumbs.end(string(name))
+			tables.end(string(name))
+			icons.end(string(name))
+			rules.end(string(name))
+			extracts.end(string(name))
+			parking.end(string(name))
+			phishing.end(string(name))
+			languages.end(string(name))
+			switch string(name) {
+			case "title":
+				if inTitle {
+					// An empty title has no text token
+					a.countTitle(ctx, result, "")
+				}
+			case "svg", "math":
+				foreign = max(foreign-1, 0)
+			case "template":
+				template = max(template-1, 0)
+			case "form":
+				endForm()
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				endHeading()
+			}
+		}
+
+		inTitle = false
+	}
+}
+
+// doctypeName returns the DOCTYPE name, which is all the HTML parser keeps
+// of a DOCTYPE in the DOM
+func doctypeName(doctype string) string {
+	fields := strings.Fields(doctype)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[0])
+}
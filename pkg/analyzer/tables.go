@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// AccessibilityTable describes a table for the accessibility section.
+// Position counts the page's tables from 1 in document order. A table is
+// taken for a layout table when its role is presentation or none, or when
+// it has no header cells, caption, thead or summary and either holds
+// another table or has a single row or column.
+type AccessibilityTable struct {
+	Position    int `json:"position"`
+	Rows        int `json:"rows"`
+	HeaderCells int `json:"header_cells"`
+	// ScopedHeaders counts the header cells with a scope attribute
+	ScopedHeaders int  `json:"scoped_headers"`
+	Caption       bool `json:"caption"`
+	Layout        bool `json:"layout"`
+}
+
+// openTable is a table whose end tag has not been seen yet
+type openTable struct {
+	table *AccessibilityTable
+	// cells counts the current row's cells and columns the most in a row
+	cells, columns int
+	// marked is set by a thead or summary, which only data tables have
+	marked bool
+	// nested is set when the table holds another table
+	nested       bool
+	presentation bool
+}
+
+// tableChecker fills in the accessibility section's tables from start and
+// end tags, for both the DOM and streaming analyses. Its methods do
+// nothing on a nil checker.
+type tableChecker struct {
+	tables []*AccessibilityTable
+	open   []*openTable
+}
+
+// checkTables returns the tables of a parsed document
+func checkTables(doc *html.Node) []AccessibilityTable {
+	c := &tableChecker{}
+	walkTags(doc, c.start, nil, c.end)
+	return c.result()
+}
+
+// start handles a start tag; tag is lower case
+func (c *tableChecker) start(tag string, attrs []html.Attribute) {
+	if c == nil {
+		return
+	}
+	if tag == "table" {
+		if len(c.open) > 0 {
+			c.open[len(c.open)-1].nested = true
+		}
+		table := &AccessibilityTable{Position: len(c.tables) + 1}
+		role := strings.ToLower(strings.TrimSpace(attrValue(attrs, "role")))
+		c.tables = append(c.tables, table)
+		c.open = append(c.open, &openTable{
+			table:        table,
+			marked:       hasAttr(attrs, "summary"),
+			presentation: role == "presentation" || role == "none",
+		})
+		return
+	}
+	if len(c.open) == 0 {
+		return
+	}
+
+	t := c.open[len(c.open)-1]
+	switch tag {
+	case "caption":
+		t.table.Caption = true
+	case "thead":
+		t.marked = true
+	case "tr":
+		t.endRow()
+		t.table.Rows++
+	case "th", "td":
+		// Cells outside a tr are in a row the HTML parser adds
+		if t.table.Rows == 0 {
+			t.table.Rows++
+		}
+		t.cells++
+		if tag == "th" {
+			t.table.HeaderCells++
+			if hasAttr(attrs, "scope") {
+				t.table.ScopedHeaders++
+			}
+		}
+	}
+}
+
+// end handles an end tag; tag is lower case
+func (c *tableChecker) end(tag string) {
+	if c == nil || len(c.open) == 0 {
+		return
+	}
+	switch tag {
+	case "tr":
+		c.open[len(c.open)-1].endRow()
+	case "table":
+		c.closeTable()
+	}
+}
+
+// result returns the tables in document order, closing any left open
+func (c *tableChecker) result() []AccessibilityTable {
+	if c == nil {
+		return nil
+	}
+	for len(c.open) > 0 {
+		c.closeTable()
+	}
+	tables := make([]AccessibilityTable, len(c.tables))
+	for i, table := range c.tables {
+		tables[i] = *table
+	}
+	return tables
+}
+
+// closeTable finishes the innermost open table
+func (c *tableChecker) closeTable() {
+	t := c.open[len(c.open)-1]
+	c.open = c.open[:len(c.open)-1]
+
+	t.endRow()
+	table := t.table
+	dataMarkup := table.HeaderCells > 0 || table.Caption || t.marked
+	table.Layout = t.presentation || (!dataMarkup && (t.nested || table.Rows <= 1 || t.columns <= 1))
+}
+
+// endRow finishes the current row's cell count
+func (t *openTable) endRow() {
+	t.columns = max(t.columns, t.cells)
+	t.cells = 0
+}
@@ -0,0 +1,94 @@
+package analyzer
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ThirdParty lists the other origins the page loads resources from, most
+// used first
+type ThirdParty struct {
+	Origins []ThirdPartyOrigin `json:"origins"`
+}
+
+// ThirdPartyOrigin counts the resources a page references on one origin.
+// Resources is the total; Preconnects counts preconnect and dns-prefetch
+// hints.
+type ThirdPartyOrigin struct {
+	Origin      string `json:"origin"`
+	Resources   int    `json:"resources"`
+	Scripts     int    `json:"scripts,omitempty"`
+	Styles      int    `json:"styles,omitempty"`
+	Images      int    `json:"images,omitempty"`
+	Frames      int    `json:"frames,omitempty"`
+	Preconnects int    `json:"preconnects,omitempty"`
+}
+
+// inspect counts the resource an element references, if it is on another
+// origin
+func (t *ThirdParty) inspect(tag string, attrs []html.Attribute, baseURL *url.URL) {
+	kind, ref := tag, attrValue(attrs, "src")
+	switch tag {
+	case "script", "img", "iframe":
+	case "link":
+		rel := attrValue(attrs, "rel")
+		switch {
+		case hasToken(rel, "stylesheet"):
+			kind = "stylesheet"
+		case hasToken(rel, "preconnect"), hasToken(rel, "dns-prefetch"):
+			kind = "preconnect"
+		default:
+			return
+		}
+		ref = attrValue(attrs, "href")
+	default:
+		return
+	}
+
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return
+	}
+	resolved := baseURL.ResolveReference(parsed)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" || resolved.Host == "" || resolved.Host == baseURL.Host {
+		return
+	}
+
+	origin := resolved.Scheme + "://" + resolved.Host
+	i := slices.IndexFunc(t.Origins, func(o ThirdPartyOrigin) bool { return o.Origin == origin })
+	if i < 0 {
+		t.Origins = append(t.Origins, ThirdPartyOrigin{Origin: origin})
+		i = len(t.Origins) - 1
+	}
+	o := &t.Origins[i]
+	o.Resources++
+	switch kind {
+	case "script":
+		o.Scripts++
+	case "stylesheet":
+		o.Styles++
+	case "img":
+		o.Images++
+	case "iframe":
+		o.Frames++
+	case "preconnect":
+		o.Preconnects++
+	}
+}
+
+// sort orders the origins by resource count, keeping document order for
+// ties
+func (t *ThirdParty) sort() {
+	if t == nil {
+		return
+	}
+	slices.SortStableFunc(t.Origins, func(a, b ThirdPartyOrigin) int { return cmp.Compare(b.Resources, a.Resources) })
+}
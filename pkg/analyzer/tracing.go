@@ -0,0 +1,20 @@
+package analyzer
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records analysis spans with the global tracer provider, so they
+// are dropped unless the embedding program installs one
+var tracer = otel.Tracer("github.com/anjula-paulus/web-analyzer/pkg/analyzer")
+
+// endSpan ends span, marking it failed when err is set
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
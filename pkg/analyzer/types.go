@@ -1,32 +1,191 @@
 package analyzer
 
 import (
+	"context"
+	"encoding/json"
+	"io"
 	"log/slog"
 	"net/http"
-	"web-analyzer/internal/config"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/html"
 )
 
+// PageAnalyzer analyzes web pages. *Analyzer implements it; consumers such as
+// the HTTP handlers depend on the interface so tests can substitute fakes.
+type PageAnalyzer interface {
+	AnalyzeURL(ctx context.Context, targetURL string, opts ...AnalyzeOption) (*Result, error)
+	AnalyzeHTML(ctx context.Context, r io.Reader, baseURL string, opts ...AnalyzeOption) (*Result, error)
+	AnalyzeNode(ctx context.Context, doc *html.Node, baseURL string, opts ...AnalyzeOption) (*Result, error)
+	AnalyzeMany(ctx context.Context, urls []string, opts ...AnalyzeOption) []*Result
+	AnalyzeSite(ctx context.Context, domain string, opts ...AnalyzeOption) (*SiteReport, error)
+	NormalizeURL(rawURL string) string
+}
+
+var _ PageAnalyzer = (*Analyzer)(nil)
+
+// LinkCache stores link check outcomes so repeated analyses, possibly on
+// other replicas, can skip the request. Implementations handle their own
+// errors; a failed lookup is reported as a miss.
+type LinkCache interface {
+	Get(ctx context.Context, link string) (status LinkStatus, ok bool)
+	Set(ctx context.Context, link string, status LinkStatus)
+}
+
+// LinkStatus is the outcome of a link check kept in a LinkCache.
+// ContentType is the Content-Type the link answered with, which classifies
+// downloads without a file extension.
+type LinkStatus struct {
+	Accessible  bool
+	ContentType string
+}
+
 // Analyzer provides web page analysis functionality
 type Analyzer struct {
-	client *http.Client
-	config config.AnalyzerConfig
-	logger *slog.Logger
+	client     *http.Client
+	linkClient *http.Client
+	linkCache  LinkCache
+	transport  http.RoundTripper
+	config     Config
+	logger     *slog.Logger
+	userAgent  string
+
+	// settings starts as config and is replaced by Reconfigure; analyses
+	// read their limits from it
+	settings atomic.Pointer[Config]
+	// rules are the valid rules of settings, compiled
+	rules atomic.Pointer[[]*compiledRule]
+
+	// maxWorkers starts at config.MaxWorkers and may be changed at runtime
+	maxWorkers     atomic.Int64
+	activeAnalyses atomic.Int64
+	activeWorkers  atomic.Int64
+	queuedLinks    atomic.Int64
+
+	// queue bounds running analyses when MaxConcurrentAnalyses is set, and
+	// is nil otherwise
+	queue *queue
+
+	metrics *metrics
+	// registerer exports metrics when set with WithRegisterer
+	registerer prometheus.Registerer
 }
 
-// Result represents the analysis result
+// SchemaVersion is the version of the Result JSON schema, written to every
+// encoded result as schema_version. Adding optional fields bumps the minor
+// version; removing, renaming or retyping a field bumps the major version.
+const SchemaVersion = "1.24"
+
+// Result represents the analysis result. The top-level fields are always
+// set; the optional sections are nil unless requested with IncludeSections.
+// TitleCount counts title elements outside SVG and MathML; more than one
+// usually means a templating mistake.
 type Result struct {
+	// ID and Permalink are set by the service once the result is stored
+	ID        string `json:"id,omitempty"`
+	Permalink string `json:"permalink,omitempty"`
+
 	URL               string         `json:"url"`
 	HTMLVersion       string         `json:"html_version"`
 	Title             string         `json:"title"`
+	TitleCount        int            `json:"title_count"`
 	Headings          map[string]int `json:"headings"`
 	InternalLinks     int            `json:"internal_links"`
 	ExternalLinks     int            `json:"external_links"`
 	InaccessibleLinks int            `json:"inaccessible_links"`
 	HasLoginForm      bool           `json:"has_login_form"`
 	Error             string         `json:"error,omitempty"`
+	PreviousDiff      *Diff          `json:"previous_diff,omitempty"`
+
+	// LinkSchemes counts the links that are not http(s) by scheme: mailto,
+	// tel, sms, javascript, ftp, data and other. They are also counted as
+	// internal or external links, but never checked.
+	LinkSchemes map[string]int `json:"link_schemes,omitempty"`
+
+	// LinkDepth is the path depth distribution of the http(s) internal
+	// links, if there are any
+	LinkDepth *LinkDepth `json:"link_depth,omitempty"`
+
+	// Downloads lists the links to documents, spreadsheets, presentations,
+	// archives and installers, classified by the Content-Type of their
+	// link check or by file extension
+	Downloads []Download `json:"downloads,omitempty"`
+
+	// Device is the preset the page was analyzed as, if any
+	Device Device `json:"device,omitempty"`
+
+	// Cached is set when the result was served from the result cache, and
+	// AnalyzedAt then holds when the page was actually analyzed
+	Cached     bool       `json:"cached,omitempty"`
+	AnalyzedAt *time.Time `json:"analyzed_at,omitempty"`
+
+	// Partial is set when the context ended before every link was checked,
+	// e.g. on shutdown. InaccessibleLinks then only counts finished checks.
+	Partial bool `json:"partial,omitempty"`
+
+	// ScriptDependency is set when the page seems to need JavaScript to
+	// show its content
+	ScriptDependency *ScriptDependency `json:"script_dependency,omitempty"`
+
+	// IDN is set when the page or its links are on internationalized
+	// domain names
+	IDN *IDN `json:"idn,omitempty"`
+
+	// Parking is set when the page is a parked domain or a "coming soon"
+	// placeholder
+	Parking *Parking `json:"parking,omitempty"`
+
+	// MetaRefresh is set when the requested page redirects with a meta
+	// refresh
+	MetaRefresh *MetaRefresh `json:"meta_refresh,omitempty"`
+
+	// Frames is set when the page is built from legacy frames
+	Frames *Frames `json:"frames,omitempty"`
+
+	// Findings lists the problems the checks found, from the core fields
+	// and the requested sections, grouped by category, followed by the
+	// configured rules the page breaks
+	Findings []Finding `json:"findings,omitempty"`
+	// Verdict is set when the caller asked for a pass/fail outcome, e.g.
+	// with Request.FailOn
+	Verdict *Verdict `json:"verdict,omitempty"`
+	// Extractions holds the matches of the selectors asked for with
+	// Extract, in the order they were given
+	Extractions []Extraction `json:"extractions,omitempty"`
+
+	SEO           *SEO           `json:"seo,omitempty"`
+	Security      *Security      `json:"security,omitempty"`
+	Accessibility *Accessibility `json:"accessibility,omitempty"`
+	Performance   *Performance   `json:"performance,omitempty"`
+	WebVitals     *WebVitals     `json:"web_vitals,omitempty"`
+	Outline       *Outline       `json:"outline,omitempty"`
+	ThirdParty    *ThirdParty    `json:"third_party,omitempty"`
+	Phishing      *Phishing      `json:"phishing,omitempty"`
+}
+
+// MarshalJSON encodes the result with its schema_version. A decoded result
+// is re-encoded in the current schema, so the version is not stored.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	return json.Marshal(struct {
+		SchemaVersion string `json:"schema_version"`
+		plain
+	}{SchemaVersion, plain(r)})
 }
 
 // Request represents the analysis request
 type Request struct {
-	URL string `json:"url"`
+	URL         string    `json:"url"`
+	CallbackURL string    `json:"callback_url,omitempty"`
+	Sections    []Section `json:"sections,omitempty"`
+	// FailOn asks for a Verdict that fails on findings of this severity
+	// or worse
+	FailOn Severity `json:"fail_on,omitempty"`
+	// Extract lists CSS selectors whose matches are reported in
+	// Result.Extractions
+	Extract []string `json:"extract,omitempty"`
+	// Device analyzes the page as a device preset would see it
+	Device Device `json:"device,omitempty"`
 }